@@ -0,0 +1,54 @@
+package cbsgo
+
+import "math"
+
+// Alert reports a changepoint confirmed while streaming segments, such as a
+// regression-style level shift of a monitored metric.
+type Alert struct {
+	Chrom string
+	// Bin and Position locate the changepoint like those of a Breakpoint.
+	Bin      int
+	Position int
+
+	// From and To are the means of the segments before and after the
+	// changepoint, and Magnitude the difference To - From.
+	From      float64
+	To        float64
+	Magnitude float64
+	// PValue is the p-value of the split that introduced the changepoint,
+	// and Confidence 1 - PValue.
+	PValue     float64
+	Confidence float64
+}
+
+// WithAlerts calls f for every changepoint as soon as the segment after it is
+// final, when segments are streamed with WithWriter by Run or Track.Segment,
+// so that a monitoring system can page on level shifts without waiting for
+// the end of the run. f is called after the segment is written, from the
+// goroutine of the run, which it blocks; it may hand the alert to a channel.
+// Alerts are not fired when the segments are not streamed, such as with
+// WithUndoSD or by SegmentGenome.
+func WithAlerts(f func(Alert)) Option {
+	return func(c *config) {
+		c.alert = f
+	}
+}
+
+// newAlert returns the alert of the changepoint between the consecutive
+// segments prev and s.
+func (sg *segmenter) newAlert(prev, s Segment) Alert {
+	a := Alert{
+		Chrom:     sg.cfg.chrom,
+		Bin:       s.BinStart,
+		Position:  sg.position(s.BinStart),
+		From:      prev.Mean,
+		To:        s.Mean,
+		Magnitude: s.Mean - prev.Mean,
+		PValue:    math.NaN(),
+	}
+	if p, ok := sg.pvalues[s.BinStart]; ok {
+		a.PValue = p
+	}
+	a.Confidence = 1 - a.PValue
+	return a
+}
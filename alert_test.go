@@ -0,0 +1,72 @@
+package cbsgo_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// alertLog is a SegmentWriter logging the number of segments written when
+// every alert is fired.
+type alertLog struct {
+	collector
+	alerts  []cbsgo.Alert
+	written []int
+}
+
+func (l *alertLog) alert(a cbsgo.Alert) {
+	l.alerts = append(l.alerts, a)
+	l.written = append(l.written, len(l.segments))
+}
+
+func TestWithAlerts(t *testing.T) {
+	x := []float64{1, 1, 1, 3, 3, 2, 1, 2, 3, 300, 310, 321, 310, 299, 1, 2, 1, 2, 1, 1}
+	expected, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithBreakpoints(0.95))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if len(expected.Breakpoints) == 0 {
+		t.Fatal("Expected breakpoints")
+	}
+
+	var l alertLog
+	if _, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithWriter(&l), cbsgo.WithAlerts(l.alert)); err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if len(l.alerts) != len(expected.Breakpoints) {
+		t.Fatalf("Unexpected result.\nExpected: %v\nGot: %v", expected.Breakpoints, l.alerts)
+	}
+	for i, a := range l.alerts {
+		b := expected.Breakpoints[i]
+		if a.Bin != b.Bin || a.Position != b.Position || math.Abs(a.Magnitude-b.Delta) > 1e-9 || a.PValue != b.PValue {
+			t.Errorf("Unexpected alert %d.\nExpected: %+v\nGot: %+v", i, b, a)
+		}
+		if a.From != l.segments[i].Mean || a.To != l.segments[i+1].Mean || a.Confidence != 1-a.PValue {
+			t.Errorf("Unexpected alert %d: %+v", i, a)
+		}
+		// Every alert is fired once the segment after the changepoint is
+		// written.
+		if l.written[i] != i+2 {
+			t.Errorf("Alert %d fired after %d segments", i, l.written[i])
+		}
+	}
+}
+
+func TestTrackWithAlerts(t *testing.T) {
+	track := &cbsgo.Track{
+		Chrom:  "chr3",
+		Values: []float64{1, 1, 1, 3, 99, 3, 2, 1, 2, 3, 300, 310, 321, 310, 299},
+		Mask:   []bool{4: true, 14: false},
+	}
+	var l alertLog
+	if _, err := track.Segment(cbsgo.WithSeed(42), cbsgo.WithWriter(&l), cbsgo.WithAlerts(l.alert)); err != nil {
+		t.Fatalf("Segment returned an unexpected error: %v", err)
+	}
+	if len(l.alerts) != 1 {
+		t.Fatalf("Expected a single alert, got %v", l.alerts)
+	}
+	if a := l.alerts[0]; a.Chrom != "chr3" || a.Bin != 9 || a.Position != 9 || !(a.Magnitude > 250) {
+		t.Errorf("Unexpected alert %+v", a)
+	}
+}
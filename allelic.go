@@ -0,0 +1,121 @@
+package cbsgo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// AllelicState is the allelic state of a segment called from B-allele
+// frequencies (BAF) of heterozygous SNPs.
+type AllelicState int
+
+const (
+	// AllelicUnknown is reported for segments with too few SNPs to call.
+	AllelicUnknown AllelicState = iota
+	// Balanced is reported when both alleles are equally represented.
+	Balanced
+	// AllelicImbalance is reported when one allele is over-represented.
+	AllelicImbalance
+	// LOH is reported when one allele is (almost) absent: loss of heterozygosity.
+	LOH
+)
+
+// String returns a short name of the state.
+func (s AllelicState) String() string {
+	switch s {
+	case Balanced:
+		return "balanced"
+	case AllelicImbalance:
+		return "imbalance"
+	case LOH:
+		return "LOH"
+	}
+	return "unknown"
+}
+
+// AllelicThresholds configures the allelic state calls of CallAllelic.
+//
+// Calls are based on the median mirrored BAF of the SNPs in a segment, that is
+// the median of |BAF - 0.5| + 0.5, which ranges from 0.5 for balanced alleles
+// to 1 for a complete loss of one allele.
+type AllelicThresholds struct {
+	// Imbalance is the median mirrored BAF from which a segment is called
+	// allelically imbalanced.
+	Imbalance float64
+	// LOH is the median mirrored BAF from which a segment is called LOH.
+	LOH float64
+	// MinSNPs is the number of SNPs a segment needs to be called at all.
+	MinSNPs int
+}
+
+// DefaultAllelicThresholds returns the thresholds used by WithBAF, suited to
+// samples of high purity.
+func DefaultAllelicThresholds() AllelicThresholds {
+	return AllelicThresholds{
+		Imbalance: 0.6,
+		LOH:       0.9,
+		MinSNPs:   5,
+	}
+}
+
+// CallAllelic assigns SNPs to segments and calls the allelic state of each
+// segment from the SNPs it contains.
+//
+// pos[i] is the coordinate of SNP i, in the same coordinate system as the
+// segments, and baf[i] its B-allele frequency. Only SNPs heterozygous in the
+// germline should be supplied, for instance as determined from a matched
+// normal sample, as homozygous SNPs are indistinguishable from LOH.
+//
+// The returned segments are copies of segments with the SNPs, BAF, and Allelic
+// fields set; the other fields are preserved.
+func CallAllelic(segments []Segment, pos []int, baf []float64, t AllelicThresholds) ([]Segment, error) {
+	if len(pos) != len(baf) {
+		return nil, fmt.Errorf("cbsgo: got %d SNP positions for %d BAF values", len(pos), len(baf))
+	}
+
+	mirrored := make([][]float64, len(segments))
+	for i, p := range pos {
+		if math.IsNaN(baf[i]) {
+			continue
+		}
+		j := sort.Search(len(segments), func(j int) bool { return segments[j].End > p })
+		if j == len(segments) || segments[j].Start > p {
+			continue
+		}
+		mirrored[j] = append(mirrored[j], math.Abs(baf[i]-0.5)+0.5)
+	}
+
+	res := make([]Segment, len(segments))
+	for i, s := range segments {
+		m := mirrored[i]
+		s.SNPs = len(m)
+		s.BAF = math.NaN()
+		s.Allelic = AllelicUnknown
+		if len(m) > 0 {
+			sort.Float64s(m)
+			s.BAF = median(m)
+		}
+		if len(m) >= t.MinSNPs && len(m) > 0 {
+			switch {
+			case s.BAF >= t.LOH:
+				s.Allelic = LOH
+			case s.BAF >= t.Imbalance:
+				s.Allelic = AllelicImbalance
+			default:
+				s.Allelic = Balanced
+			}
+		}
+		res[i] = s
+	}
+	return res, nil
+}
+
+// median returns the median of the sorted, non-empty slice x.
+func median(x []float64) float64 {
+	n := len(x)
+	if n%2 == 1 {
+		return x[n/2]
+	}
+	return (x[n/2-1] + x[n/2]) / 2
+}
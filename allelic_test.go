@@ -0,0 +1,77 @@
+package cbsgo_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestCallAllelic(t *testing.T) {
+	segments := []cbsgo.Segment{
+		{Start: 0, End: 100},
+		{Start: 100, End: 200},
+		{Start: 200, End: 300},
+		{Start: 300, End: 400},
+	}
+
+	var pos []int
+	var baf []float64
+	add := func(start int, values ...float64) {
+		for i, v := range values {
+			pos = append(pos, start+10*i)
+			baf = append(baf, v)
+		}
+	}
+	add(0, 0.48, 0.52, 0.45, 0.55, 0.5, 0.47)          // balanced
+	add(100, 0.33, 0.67, 0.30, 0.70, 0.35, math.NaN()) // allelic imbalance
+	add(200, 0.02, 0.97, 0.05, 0.99, 0.01)             // LOH
+	add(300, 0.01, 0.99)                               // too few SNPs
+
+	res, err := cbsgo.CallAllelic(segments, pos, baf, cbsgo.DefaultAllelicThresholds())
+	if err != nil {
+		t.Fatalf("CallAllelic returned an unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		state cbsgo.AllelicState
+		snps  int
+	}{
+		{cbsgo.Balanced, 6},
+		{cbsgo.AllelicImbalance, 5},
+		{cbsgo.LOH, 5},
+		{cbsgo.AllelicUnknown, 2},
+	}
+	for i, e := range expected {
+		if s := res[i]; s.Allelic != e.state || s.SNPs != e.snps {
+			t.Errorf("Segment %d: expected %v with %d SNPs, got %v with %d SNPs", i, e.state, e.snps, s.Allelic, s.SNPs)
+		}
+	}
+	if b := res[2].BAF; b != 0.98 {
+		t.Errorf("Expected a median mirrored BAF of 0.98, got %v", b)
+	}
+}
+
+func TestRunBAF(t *testing.T) {
+	steps := []float64{1, 1, 1, 3, 3, 2, 1, 2, 3, 300, 310, 321, 310, 299}
+	pos := []int{0, 1, 2, 3, 4, 5, 6, 9, 10, 11, 12, 13}
+	baf := []float64{0.5, 0.45, 0.55, 0.5, 0.52, 0.48, 0.5, 0.01, 0.99, 0.02, 0.98, 0.03}
+
+	res, err := cbsgo.Run(steps, cbsgo.WithSeed(42), cbsgo.WithBAF(pos, baf))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if len(res.Segments) != 2 {
+		t.Fatalf("Expected 2 segments, got %v", res.Segments)
+	}
+	if s := res.Segments[0]; s.Allelic != cbsgo.Balanced || s.SNPs != 7 {
+		t.Errorf("Expected a balanced first segment with 7 SNPs, got %v with %d", s.Allelic, s.SNPs)
+	}
+	if s := res.Segments[1]; s.Allelic != cbsgo.LOH || s.SNPs != 5 {
+		t.Errorf("Expected LOH in the second segment with 5 SNPs, got %v with %d", s.Allelic, s.SNPs)
+	}
+
+	if _, err := cbsgo.Run(steps, cbsgo.WithBAF(pos, baf[1:])); err == nil {
+		t.Errorf("Expected an error for mismatched SNP positions and BAF values")
+	}
+}
@@ -0,0 +1,35 @@
+package cbsgo
+
+// AlphaAdjustment is a method adjusting the significance level of the tests
+// of a run. See WithAlphaAdjustment.
+//
+// The adjustments spend the significance level over the tests of a run: the
+// levels of all the tests a run may make sum to at most the significance
+// level, which so bounds, by the union bound, the probability of any false
+// split in the run.
+type AlphaAdjustment int
+
+const (
+	// NoAdjustment tests every split at the significance level.
+	NoAdjustment AlphaAdjustment = iota
+	// BonferroniAdjustment tests the k-th test of a run at the significance
+	// level times 6/(π²k²), which sums to the significance level over k, so
+	// that later tests, which are more numerous, need stronger evidence.
+	BonferroniAdjustment
+	// DepthAdjustment spends half of the significance level at recursion
+	// depth 0, a quarter at depth 1, and so on, split evenly across the at
+	// most 2^d intervals tested at depth d: every test at depth d is at the
+	// significance level times 2^-(2d+1).
+	DepthAdjustment
+)
+
+// String returns the name of the adjustment.
+func (a AlphaAdjustment) String() string {
+	switch a {
+	case BonferroniAdjustment:
+		return "bonferroni"
+	case DepthAdjustment:
+		return "depth"
+	}
+	return "none"
+}
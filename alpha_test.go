@@ -0,0 +1,81 @@
+package cbsgo_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestWithAlphaAdjustment(t *testing.T) {
+	// Pure noise, where every split is false.
+	rng := rand.New(rand.NewSource(3))
+	noise := make([]float64, 2000)
+	for i := range noise {
+		noise[i] = rng.NormFloat64()
+	}
+	// Clear events at both ends.
+	events := make([]float64, 300)
+	for i := range events {
+		events[i] = rng.NormFloat64() / 5
+		if i >= 100 && i < 200 {
+			events[i] += 2
+		}
+	}
+
+	count := func(x []float64, a cbsgo.AlphaAdjustment) int {
+		res, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithShuffles(200), cbsgo.WithAlpha(0.2), cbsgo.WithAlphaAdjustment(a))
+		if err != nil {
+			t.Fatalf("%v: Run returned an unexpected error: %v", a, err)
+		}
+		return len(res.Segments)
+	}
+	unadjusted := count(noise, cbsgo.NoAdjustment)
+	for _, a := range []cbsgo.AlphaAdjustment{cbsgo.BonferroniAdjustment, cbsgo.DepthAdjustment} {
+		if got := count(noise, a); got > unadjusted {
+			t.Errorf("%v: expected at most %d segments in noise, got %d", a, unadjusted, got)
+		}
+		if got := count(events, a); got != 3 {
+			t.Errorf("%v: expected 3 segments, got %d", a, got)
+		}
+	}
+}
+
+func TestWithAlphaAdjustmentFalseSplits(t *testing.T) {
+	// Profiles with a true step at bin 100, where any other split is false:
+	// the adjustments bound the rate of runs with a false split by alpha.
+	const alpha, runs = 0.2, 200
+	rng := rand.New(rand.NewSource(5))
+	profiles := make([][]float64, runs)
+	for i := range profiles {
+		x := make([]float64, 200)
+		for j := range x {
+			x[j] = rng.NormFloat64()
+			if j >= 100 {
+				x[j] += 3
+			}
+		}
+		profiles[i] = x
+	}
+	for _, a := range []cbsgo.AlphaAdjustment{cbsgo.NoAdjustment, cbsgo.BonferroniAdjustment, cbsgo.DepthAdjustment} {
+		falseRuns := 0
+		for i, x := range profiles {
+			res, err := cbsgo.Run(x, cbsgo.WithSeed(int64(i+1)), cbsgo.WithShuffles(200), cbsgo.WithAlpha(alpha), cbsgo.WithAlphaAdjustment(a))
+			if err != nil {
+				t.Fatalf("%v: Run returned an unexpected error: %v", a, err)
+			}
+			for _, s := range res.Segments[1:] {
+				// Splits may be placed a bin early.
+				if s.BinStart < 98 || s.BinStart > 101 {
+					falseRuns++
+					break
+				}
+			}
+		}
+		rate := float64(falseRuns) / runs
+		t.Logf("%v: false split rate %v", a, rate)
+		if a != cbsgo.NoAdjustment && rate > alpha {
+			t.Errorf("%v: expected a false split rate of at most %v, got %v", a, alpha, rate)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package cbsgo
+
+// AlterationSummary summarizes the copy number alterations of a sample, as
+// returned by SummarizeAlterations.
+type AlterationSummary struct {
+	// Gained and Lost are the fractions of the segmented length called as a
+	// gain or amplification, and as a loss or deep deletion.
+	Gained, Lost float64
+	// Breakpoints is the number of boundaries between adjacent segments of a
+	// chromosome.
+	Breakpoints int
+	// WGII is the weighted genome instability index: the fraction of the
+	// length of every chromosome called as altered, averaged over the
+	// chromosomes so that each counts equally regardless of its length.
+	WGII float64
+}
+
+// Altered returns the fraction of the segmented length called as altered.
+func (a AlterationSummary) Altered() float64 {
+	return a.Gained + a.Lost
+}
+
+// SummarizeAlterations summarizes the alterations of the segments of a
+// sample, in chromosome order, whose states are called with WithCalls or
+// CallStates. Segments without a call count as neutral.
+func SummarizeAlterations(segments []Segment) AlterationSummary {
+	var (
+		a                     AlterationSummary
+		total, gained, lost   float64
+		chroms                int
+		chromLen, chromAltLen float64
+	)
+	endChrom := func() {
+		if chromLen > 0 {
+			a.WGII += chromAltLen / chromLen
+			chroms++
+		}
+		chromLen, chromAltLen = 0, 0
+	}
+	for i, s := range segments {
+		if i > 0 && s.Chrom == segments[i-1].Chrom {
+			a.Breakpoints++
+		} else if i > 0 {
+			endChrom()
+		}
+		n := float64(s.Len())
+		total += n
+		chromLen += n
+		switch s.State {
+		case Gain, Amplification:
+			gained += n
+			chromAltLen += n
+		case Loss, DeepDeletion:
+			lost += n
+			chromAltLen += n
+		}
+	}
+	endChrom()
+	if total > 0 {
+		a.Gained, a.Lost = gained/total, lost/total
+	}
+	if chroms > 0 {
+		a.WGII /= float64(chroms)
+	}
+	return a
+}
@@ -0,0 +1,31 @@
+package cbsgo_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestSummarizeAlterations(t *testing.T) {
+	segments := []cbsgo.Segment{
+		{Chrom: "chr1", Start: 0, End: 600, State: cbsgo.Neutral},
+		{Chrom: "chr1", Start: 600, End: 800, State: cbsgo.Gain},
+		{Chrom: "chr1", Start: 800, End: 1000, State: cbsgo.Neutral},
+		{Chrom: "chr2", Start: 0, End: 100, State: cbsgo.DeepDeletion},
+		{Chrom: "chr2", Start: 100, End: 200, State: cbsgo.Loss},
+		{Chrom: "chr3", Start: 0, End: 200},
+	}
+	expected := cbsgo.AlterationSummary{Gained: 200.0 / 1400, Lost: 200.0 / 1400, Breakpoints: 3, WGII: (0.2 + 1 + 0) / 3}
+	got := cbsgo.SummarizeAlterations(segments)
+	if math.Abs(got.Gained-expected.Gained) > 1e-12 || math.Abs(got.Lost-expected.Lost) > 1e-12 || got.Breakpoints != expected.Breakpoints || math.Abs(got.WGII-expected.WGII) > 1e-12 {
+		t.Errorf("Unexpected result.\nExpected: %+v\nGot: %+v", expected, got)
+	}
+	if a := got.Altered(); math.Abs(a-400.0/1400) > 1e-12 {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", 400.0/1400, a)
+	}
+
+	if got := cbsgo.SummarizeAlterations(nil); got != (cbsgo.AlterationSummary{}) {
+		t.Errorf("Unexpected result.\nExpected: %+v\nGot: %+v", cbsgo.AlterationSummary{}, got)
+	}
+}
@@ -0,0 +1,63 @@
+package cbsgo
+
+// Float64Array is the subset of the Apache Arrow float64 array API used by
+// CBSArrow. Arrays of type *array.Float64 from github.com/apache/arrow-go
+// satisfy it without any conversion, so data read from Parquet files or Flight
+// streams can be segmented without depending on Arrow here.
+type Float64Array interface {
+	Len() int
+	NullN() int
+	IsNull(i int) bool
+	Float64Values() []float64
+}
+
+// CBSArrow performs Circular Binary Segmentation on an Arrow float64 array.
+//
+// Null entries, as marked by the validity bitmap, are treated as missing values:
+// they are excluded from the statistic, and every segment starts and ends on a
+// valid element, so runs of nulls between two segments are covered by neither.
+// Arrays without nulls are segmented in place without copying; otherwise the
+// valid values are gathered into a temporary slice first.
+//
+// Segment bounds refer to positions in the array. See CBS for the meaning of
+// the remaining parameters.
+func CBSArrow(a Float64Array, shuffles int, significanceLevel float64, seed int64) ([][2]int, error) {
+	values := a.Float64Values()[:a.Len()]
+	if a.NullN() == 0 {
+		return CBS(values, shuffles, significanceLevel, seed)
+	}
+
+	x, idx := compact(values, a.IsNull)
+	if len(x) == 0 {
+		return nil, nil
+	}
+	segments, err := CBS(x, shuffles, significanceLevel, seed)
+	if err != nil {
+		return nil, err
+	}
+	return expand(segments, idx), nil
+}
+
+// compact returns the values that are not missing together with their
+// original positions.
+func compact(values []float64, missing func(i int) bool) ([]float64, []int) {
+	x := make([]float64, 0, len(values))
+	idx := make([]int, 0, len(values))
+	for i, v := range values {
+		if missing(i) {
+			continue
+		}
+		x = append(x, v)
+		idx = append(idx, i)
+	}
+	return x, idx
+}
+
+// expand maps segments over compacted values back to the original positions.
+func expand(segments [][2]int, idx []int) [][2]int {
+	res := make([][2]int, len(segments))
+	for i, s := range segments {
+		res[i] = [2]int{idx[s[0]], idx[s[1]-1] + 1}
+	}
+	return res
+}
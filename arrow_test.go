@@ -0,0 +1,76 @@
+package cbsgo_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// float64Array mimics an Arrow float64 array with a validity bitmap.
+type float64Array struct {
+	values []float64
+	valid  []bool
+}
+
+func (a float64Array) Len() int { return len(a.values) }
+
+func (a float64Array) NullN() int {
+	n := 0
+	for _, ok := range a.valid {
+		if !ok {
+			n++
+		}
+	}
+	return n
+}
+
+func (a float64Array) IsNull(i int) bool { return a.valid != nil && !a.valid[i] }
+
+func (a float64Array) Float64Values() []float64 { return a.values }
+
+func TestCBSArrow(t *testing.T) {
+	steps := []float64{1, 1, 1, 3, 3, 2, 1, 2, 3, 300, 310, 321, 310, 299}
+
+	expected, err := cbsgo.CBS(steps, 1000, 0.05, 42)
+	if err != nil {
+		t.Fatalf("CBS function returned an unexpected error: %v", err)
+	}
+	res, err := cbsgo.CBSArrow(float64Array{values: steps}, 1000, 0.05, 42)
+	if err != nil {
+		t.Fatalf("CBSArrow returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, res)
+	}
+}
+
+func TestCBSArrowNulls(t *testing.T) {
+	// The same profile as above with nulls interleaved; null slots hold garbage.
+	values := []float64{-1e9, 1, 1, 1, 3, 3, 2, 1, 2, 3, 1e9, 300, 310, 321, 310, 299, 1e9}
+	valid := make([]bool, len(values))
+	for i := range valid {
+		valid[i] = values[i] > -1e9 && values[i] < 1e9
+	}
+
+	res, err := cbsgo.CBSArrow(float64Array{values: values, valid: valid}, 1000, 0.05, 42)
+	if err != nil {
+		t.Fatalf("CBSArrow returned an unexpected error: %v", err)
+	}
+
+	expected := [][2]int{{1, 9}, {9, 16}}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, res)
+	}
+}
+
+func TestCBSArrowAllNull(t *testing.T) {
+	a := float64Array{values: []float64{1, 2, 3}, valid: []bool{false, false, false}}
+	res, err := cbsgo.CBSArrow(a, 1000, 0.05, 42)
+	if err != nil {
+		t.Fatalf("CBSArrow returned an unexpected error: %v", err)
+	}
+	if len(res) != 0 {
+		t.Errorf("Expected no segments, got %v", res)
+	}
+}
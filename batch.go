@@ -0,0 +1,109 @@
+package cbsgo
+
+import (
+	"math"
+	"math/rand"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// batchBytes bounds the size of a batch of permutations.
+const batchBytes = 1 << 20
+
+// permutationBatch computes the statistics of successive permutations of an
+// interval of a single sample with bins of equal width, a batch of shuffled
+// copies at a time. The copies are held in a contiguous matrix, whose rows are
+// reduced to their statistics in a single fused pass each: centering, the
+// cumulative sum and its extremes. The statistics equal those computed by
+// cbsStatInto for a two-sided test with leftmost ties.
+//
+// The rows are not reduced with BLAS (gonum's blas64): the cumulative sum and
+// its extremes have no BLAS routine, and centering with one would take a pass
+// of its own over every row, where the fused kernel takes one pass in all.
+type permutationBatch struct {
+	data *singleInterval
+	// buf holds the shuffled copies, row after row, in buf.rows, and the
+	// statistics of the rows not consumed yet, in reverse order, in
+	// buf.stats.
+	buf *scratch
+	// max is the largest number of rows of a batch.
+	max int
+}
+
+// newBatch returns a batch for the permutations of data, or nil if the
+// statistic of data cannot be computed by batches.
+func (sg *segmenter) newBatch(data interval) *permutationBatch {
+	d, ok := data.(*singleInterval)
+	if !ok || d.w != nil || d.dir != TwoSided || d.tie != Leftmost {
+		return nil
+	}
+	rows := sg.cfg.batchRows(d.len(), len(sg.x))
+	if rows == 0 {
+		return nil
+	}
+	return &permutationBatch{data: d, buf: sg.buf, max: rows}
+}
+
+// fill shuffles the interval rows times, at most the batch size, and computes
+// the statistics of the copies.
+func (b *permutationBatch) fill(rng *rand.Rand, rows int) {
+	rows = min(rows, b.max)
+	n := b.data.len()
+	m := grow(&b.buf.rows, rows*n)
+	for r := range rows {
+		b.data.shuffle(rng)
+		copy(m[r*n:(r+1)*n], b.data.x)
+	}
+	b.buf.stats = grow(&b.buf.stats, rows)
+	for r := range rows {
+		b.buf.stats[rows-1-r] = centeredStat(m[r*n : (r+1)*n])
+	}
+}
+
+// empty reports whether the statistics of the batch are all consumed.
+func (b *permutationBatch) empty() bool {
+	return len(b.buf.stats) == 0
+}
+
+// next returns the statistic of the next permutation, which must have been
+// computed by fill.
+func (b *permutationBatch) next() float64 {
+	k := len(b.buf.stats) - 1
+	t := b.buf.stats[k]
+	b.buf.stats = b.buf.stats[:k]
+	return t
+}
+
+// centeredStat computes the statistic of cbsStatInto for a two-sided test of
+// x in a single pass.
+func centeredStat(x []float64) float64 {
+	hi, lo, i0, i1 := centeredExtremes(x, stat.Mean(x, nil))
+	if i1 < i0 {
+		i0, i1 = i1, i0
+	}
+	length := float64(len(x))
+	denominator := (float64(i1-i0) + 1.0) * (length - float64(i1-i0))
+	if denominator == 0 {
+		return 0
+	}
+	return math.Pow(lo-hi, 2) * length / denominator
+}
+
+// centeredExtremesGeneric returns the maximum hi and the minimum lo of the
+// cumulative sums of x-mean, and their first indices i0 and i1. Assembly
+// versions of centeredExtremes perform the same operations in the same order,
+// so that their results are identical.
+func centeredExtremesGeneric(x []float64, mean float64) (hi, lo float64, i0, i1 int) {
+	sum := 0.0
+	hi, lo = math.Inf(-1), math.Inf(1)
+	for i, v := range x {
+		sum += v - mean
+		if sum > hi {
+			hi, i0 = sum, i
+		}
+		if sum < lo {
+			lo, i1 = sum, i
+		}
+	}
+	return hi, lo, i0, i1
+}
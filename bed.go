@@ -0,0 +1,54 @@
+package cbsgo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ReadBED reads the intervals of a BED file as segments, in file order. The
+// name column, when numeric, is read as the segment mean, as written by
+// BEDWriter; other columns are ignored. A name starting like a number, with a
+// digit, a sign or a point, must be a valid number, except for the placeholder
+// ".". Track, browser and comment lines are skipped. The bins of the segments
+// equal their positions.
+func ReadBED(r io.Reader) (SegmentSet, error) {
+	var segments SegmentSet
+	dr, err := Decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	defer dr.Close()
+	sc := bufio.NewScanner(dr)
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		if skipHeader(text) {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("cbsgo: BED line %d: expected at least 3 fields, got %d", line, len(fields))
+		}
+		start, err1 := strconv.Atoi(fields[1])
+		end, err2 := strconv.Atoi(fields[2])
+		if err := firstError(err1, err2); err != nil {
+			return nil, fmt.Errorf("cbsgo: BED line %d: %w", line, err)
+		}
+		if end < start {
+			return nil, fmt.Errorf("cbsgo: BED line %d: end %d before start %d", line, end, start)
+		}
+		s := Segment{Chrom: fields[0], Start: start, End: end, BinStart: start, BinEnd: end}
+		if len(fields) > 3 && fields[3] != "." && strings.IndexByte("0123456789+-.", fields[3][0]) >= 0 {
+			if s.Mean, err = strconv.ParseFloat(fields[3], 64); err != nil {
+				return nil, fmt.Errorf("cbsgo: BED line %d: %w", line, err)
+			}
+		}
+		segments = append(segments, s)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return segments, nil
+}
@@ -0,0 +1,45 @@
+package cbsgo_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestReadBED(t *testing.T) {
+	input := "track name=x\nchr1\t0\t100\t0.5\nchr1\t100\t250\tgain\n# comment\nchr2\t10\t20\t.\n"
+	got, err := cbsgo.ReadBED(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadBED returned an unexpected error: %v", err)
+	}
+	expected := cbsgo.SegmentSet{
+		{Chrom: "chr1", Start: 0, End: 100, BinStart: 0, BinEnd: 100, Mean: 0.5},
+		{Chrom: "chr1", Start: 100, End: 250, BinStart: 100, BinEnd: 250},
+		{Chrom: "chr2", Start: 10, End: 20, BinStart: 10, BinEnd: 20},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+	}
+
+	// Round trip through BEDWriter.
+	var buf bytes.Buffer
+	w := cbsgo.NewBEDWriter(&buf)
+	for _, s := range expected {
+		if err := w.Write(s); err != nil {
+			t.Fatalf("Write returned an unexpected error: %v", err)
+		}
+	}
+	w.Close()
+	if got, err := cbsgo.ReadBED(&buf); err != nil || !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v, %v", expected, got, err)
+	}
+
+	for _, input := range []string{"chr1\t0\n", "chr1\tx\t10\n", "chr1\t10\t5\n", "chr1\t0\t10\t0.5x\n", "chr1\t0\t10\t-\n"} {
+		if _, err := cbsgo.ReadBED(strings.NewReader(input)); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package cbsgo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ReadBedGraph reads the intervals of a bedGraph file into one track per
+// chromosome, in order of first appearance. Track, browser and comment lines
+// are skipped. Coordinates are zero-based and half-open, as in the file.
+func ReadBedGraph(r io.Reader) ([]*Track, error) {
+	var tb trackBuilder
+	dr, err := Decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	defer dr.Close()
+	sc := bufio.NewScanner(dr)
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		if skipHeader(text) {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("cbsgo: bedGraph line %d: expected 4 fields, got %d", line, len(fields))
+		}
+		start, err1 := strconv.Atoi(fields[1])
+		end, err2 := strconv.Atoi(fields[2])
+		value, err3 := strconv.ParseFloat(fields[3], 64)
+		if err := firstError(err1, err2, err3); err != nil {
+			return nil, fmt.Errorf("cbsgo: bedGraph line %d: %w", line, err)
+		}
+		tb.add(fields[0], start, end, value)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return tb.tracks, nil
+}
+
+// WriteBedGraph writes the segment means in bedGraph format.
+func WriteBedGraph(w io.Writer, segments []Segment) error {
+	bw := NewBedGraphWriter(w)
+	for _, s := range segments {
+		if err := bw.Write(s); err != nil {
+			return err
+		}
+	}
+	return bw.Close()
+}
+
+// BedGraphWriter writes the segment means in bedGraph format.
+type BedGraphWriter struct {
+	w      *bufio.Writer
+	coords Coordinates
+}
+
+// SetManifest writes m as a comment line before the segments. Call it before
+// the first Write.
+func (b *BedGraphWriter) SetManifest(m Manifest) error {
+	_, err := fmt.Fprintf(b.w, "# cbsgo %s\n", m)
+	return err
+}
+
+// SetCoordinates sets the coordinates of the segments written, which are
+// zero-based as required by bedGraph. Call it before the first Write.
+func (b *BedGraphWriter) SetCoordinates(c Coordinates) error {
+	if err := c.check("bedGraph", ZeroBased); err != nil {
+		return err
+	}
+	b.coords = c
+	return nil
+}
+
+// NewBedGraphWriter returns a BedGraphWriter writing to w.
+func NewBedGraphWriter(w io.Writer) *BedGraphWriter {
+	return &BedGraphWriter{w: bufio.NewWriter(w)}
+}
+
+func (b *BedGraphWriter) Write(s Segment) error {
+	start, end := b.coords.interval(s.Start, s.End, s.BinStart, s.BinEnd, ZeroBased)
+	_, err := fmt.Fprintf(b.w, "%s\t%d\t%d\t%g\n", s.Chrom, start, end, s.Mean)
+	return err
+}
+
+func (b *BedGraphWriter) Close() error {
+	return b.w.Flush()
+}
+
+// trackBuilder collects intervals into one track per chromosome.
+type trackBuilder struct {
+	tracks  []*Track
+	byChrom map[string]*Track
+}
+
+func (tb *trackBuilder) add(chrom string, start, end int, value float64) {
+	t := tb.byChrom[chrom]
+	if t == nil {
+		if tb.byChrom == nil {
+			tb.byChrom = make(map[string]*Track)
+		}
+		t = &Track{Chrom: chrom, Starts: []int{}, Ends: []int{}}
+		tb.byChrom[chrom] = t
+		tb.tracks = append(tb.tracks, t)
+	}
+	t.Starts = append(t.Starts, start)
+	t.Ends = append(t.Ends, end)
+	t.Values = append(t.Values, value)
+}
+
+// skipHeader reports whether a line of a UCSC track file holds no data.
+func skipHeader(line string) bool {
+	line = strings.TrimSpace(line)
+	return line == "" || line[0] == '#' || strings.HasPrefix(line, "track") || strings.HasPrefix(line, "browser")
+}
+
+// firstError returns the first non-nil error of errs.
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package cbsgo_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestReadBedGraph(t *testing.T) {
+	input := `track type=bedGraph name=sample
+# comment
+chr1	0	100	0.5
+chr1	100	200	-0.25
+chr2	50	150	1
+`
+	tracks, err := cbsgo.ReadBedGraph(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadBedGraph returned an unexpected error: %v", err)
+	}
+	expected := []*cbsgo.Track{
+		{Chrom: "chr1", Starts: []int{0, 100}, Ends: []int{100, 200}, Values: []float64{0.5, -0.25}},
+		{Chrom: "chr2", Starts: []int{50}, Ends: []int{150}, Values: []float64{1}},
+	}
+	if !reflect.DeepEqual(tracks, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, tracks)
+	}
+
+	if _, err := cbsgo.ReadBedGraph(strings.NewReader("chr1\t0\tx\t1\n")); err == nil {
+		t.Errorf("Expected an error for an invalid end")
+	}
+}
+
+func TestWriteBedGraph(t *testing.T) {
+	segments := []cbsgo.Segment{
+		{Chrom: "chr1", Start: 0, End: 200, Mean: 0.125},
+		{Chrom: "chr2", Start: 50, End: 150, Mean: -1},
+	}
+	var buf bytes.Buffer
+	if err := cbsgo.WriteBedGraph(&buf, segments); err != nil {
+		t.Fatalf("WriteBedGraph returned an unexpected error: %v", err)
+	}
+	expected := "chr1\t0\t200\t0.125\nchr2\t50\t150\t-1\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected result.\nExpected: %q\nGot: %q", expected, buf.String())
+	}
+}
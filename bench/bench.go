@@ -0,0 +1,101 @@
+// Package bench provides reproducible genome-scale datasets for benchmarking
+// cbsgo, and the benchmarks themselves.
+//
+// Run the benchmarks with
+//
+//	go test -bench . -benchtime 1x ./bench
+//
+// The largest datasets are skipped with -short.
+package bench
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// Sizes lists the dataset sizes of the benchmarks, in bins.
+var Sizes = []int{100_000, 1_000_000, 10_000_000}
+
+// Synthetic returns a profile of n log2 ratios with Gaussian noise of
+// standard deviation 0.2 around a piecewise constant signal, with events of
+// lengths spread over four orders of magnitude. The same n and seed always
+// give the same profile.
+func Synthetic(n int, seed int64) []float64 {
+	rng := rand.New(rand.NewSource(seed))
+	x := make([]float64, n)
+	addEvents(rng, x)
+	for i := range x {
+		x[i] += 0.2 * rng.NormFloat64()
+	}
+	return x
+}
+
+// RealLike returns a profile of n log2 ratios resembling those of a tumor
+// sample binned at high resolution, with the same events as Synthetic
+// blurred by the artifacts of real data: autocorrelated noise, a GC wave,
+// heavy-tailed outliers and compressed log2 ratios as from an impure
+// sample.
+func RealLike(n int, seed int64) []float64 {
+	rng := rand.New(rand.NewSource(seed))
+	x := make([]float64, n)
+	addEvents(rng, x)
+	noise := 0.0
+	for i := range x {
+		// Compression by 30% normal contamination.
+		x[i] = math.Log2(0.7*math.Exp2(x[i]) + 0.3)
+		noise = 0.5*noise + 0.15*rng.NormFloat64()
+		x[i] += noise + 0.05*math.Sin(float64(i)/500)
+		if rng.Intn(1000) == 0 {
+			x[i] += 2 * rng.NormFloat64()
+		}
+	}
+	return x
+}
+
+// addEvents adds gains and losses to x, about one per 2000 bins, with lengths
+// drawn log-uniformly between 10 bins and a tenth of x.
+func addEvents(rng *rand.Rand, x []float64) {
+	n := len(x)
+	levels := []float64{-1, -0.4, 0.3, 0.6, 1.2}
+	for range n / 2000 {
+		length := int(math.Exp(rng.Float64()*math.Log(float64(max(n/10, 11))/10)) * 10)
+		start := rng.Intn(max(n-length, 1))
+		level := levels[rng.Intn(len(levels))]
+		for i := start; i < min(start+length, n); i++ {
+			x[i] = level
+		}
+	}
+}
+
+// Genome returns the RealLike profile of n bins split into 22 autosome tracks
+// with lengths proportional to those of the human autosomes, with positions
+// of bins of equal size.
+func Genome(n int, seed int64) []*cbsgo.Track {
+	lengths := []float64{
+		248.9, 242.2, 198.3, 190.2, 181.5, 170.8, 159.3, 145.1, 138.4, 133.8, 135.1,
+		133.3, 114.4, 107.0, 102.0, 90.3, 83.3, 80.4, 58.6, 64.4, 46.7, 50.8,
+	}
+	total := 0.0
+	for _, l := range lengths {
+		total += l
+	}
+	x := RealLike(n, seed)
+	binSize := int(math.Ceil(total * 1e6 / float64(n)))
+	var tracks []*cbsgo.Track
+	start, cum := 0, 0.0
+	for i, l := range lengths {
+		cum += l
+		end := int(math.Round(cum / total * float64(n)))
+		t := &cbsgo.Track{Chrom: fmt.Sprintf("chr%d", i+1), Values: x[start:end]}
+		for j := range t.Values {
+			t.Starts = append(t.Starts, j*binSize)
+			t.Ends = append(t.Ends, (j+1)*binSize)
+		}
+		tracks = append(tracks, t)
+		start = end
+	}
+	return tracks
+}
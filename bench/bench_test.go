@@ -0,0 +1,102 @@
+package bench_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/bench"
+)
+
+func TestDatasets(t *testing.T) {
+	if !reflect.DeepEqual(bench.Synthetic(10_000, 1), bench.Synthetic(10_000, 1)) {
+		t.Errorf("Synthetic is not reproducible")
+	}
+	if !reflect.DeepEqual(bench.RealLike(10_000, 1), bench.RealLike(10_000, 1)) {
+		t.Errorf("RealLike is not reproducible")
+	}
+	n := 0
+	for _, track := range bench.Genome(10_000, 1) {
+		if err := track.Validate(); err != nil {
+			t.Errorf("Genome returned an invalid track: %v", err)
+		}
+		n += track.Len()
+	}
+	if n != 10_000 {
+		t.Errorf("Expected 10000 bins, got %d", n)
+	}
+}
+
+// sizes returns the dataset sizes to benchmark.
+func sizes() []int {
+	if testing.Short() {
+		return bench.Sizes[:1]
+	}
+	return bench.Sizes
+}
+
+// BenchmarkSerial segments whole profiles with Run.
+func BenchmarkSerial(b *testing.B) {
+	for _, n := range sizes() {
+		for _, data := range []struct {
+			name string
+			x    []float64
+		}{{"synthetic", bench.Synthetic(n, 1)}, {"reallike", bench.RealLike(n, 1)}} {
+			x := data.x
+			b.Run(fmt.Sprintf("%s/n=%d", data.name, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := cbsgo.Run(x, cbsgo.WithSeed(1), cbsgo.WithShuffles(100)); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkGenome segments the chromosomes of a genome concurrently with
+// SegmentGenome.
+func BenchmarkGenome(b *testing.B) {
+	for _, n := range sizes() {
+		tracks := bench.Genome(n, 1)
+		for _, workers := range []int{1, 4, 0} {
+			b.Run(fmt.Sprintf("n=%d/workers=%d", n, workers), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := cbsgo.SegmentGenome(context.Background(), tracks, cbsgo.WithSeed(1),
+						cbsgo.WithShuffles(100), cbsgo.WithWorkers(workers)); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkSegmenter segments many profiles in parallel with a shared
+// Segmenter, as a service would.
+func BenchmarkSegmenter(b *testing.B) {
+	x := bench.RealLike(bench.Sizes[0], 1)
+	s := cbsgo.NewSegmenter(cbsgo.WithSeed(1), cbsgo.WithShuffles(100))
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := s.Segment(x); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkNullCache segments profiles against a shared null cache, which
+// replaces the batches of permutations.
+func BenchmarkNullCache(b *testing.B) {
+	x := bench.RealLike(bench.Sizes[0], 1)
+	cache := cbsgo.NewNullCache(100, 1)
+	for i := 0; i < b.N; i++ {
+		if _, err := cbsgo.Run(x, cbsgo.WithSeed(1), cbsgo.WithNullCache(cache)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
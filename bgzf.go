@@ -0,0 +1,198 @@
+package cbsgo
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// bgzfReader reads a BGZF file, the blocked gzip format written by bgzip, at
+// virtual offsets: the offset of a block in the file shifted left by 16 bits,
+// plus an offset in the decompressed block.
+type bgzfReader struct {
+	r io.ReadSeeker
+
+	// block holds the decompressed block at offset coff of the file, which
+	// is followed by the block at offset next. pos is the read offset in block.
+	block      []byte
+	coff, next int64
+	pos        int
+}
+
+// seek moves to the virtual offset voff.
+func (b *bgzfReader) seek(voff uint64) error {
+	coff := int64(voff >> 16)
+	if b.block == nil || coff != b.coff {
+		if err := b.load(coff); err != nil {
+			return err
+		}
+	}
+	b.pos = int(voff & 0xffff)
+	if b.pos > len(b.block) {
+		return fmt.Errorf("cbsgo: invalid BGZF offset %d", voff)
+	}
+	return nil
+}
+
+// offset returns the current virtual offset.
+func (b *bgzfReader) offset() uint64 {
+	if b.pos == len(b.block) {
+		return uint64(b.next) << 16
+	}
+	return uint64(b.coff)<<16 | uint64(b.pos)
+}
+
+// load reads and decompresses the block at offset coff of the file.
+func (b *bgzfReader) load(coff int64) error {
+	if _, err := b.r.Seek(coff, io.SeekStart); err != nil {
+		return err
+	}
+	var header [18]byte
+	if _, err := io.ReadFull(b.r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return err
+	}
+	if header[0] != 0x1f || header[1] != 0x8b || header[3]&4 == 0 ||
+		binary.LittleEndian.Uint16(header[10:]) != 6 || header[12] != 'B' || header[13] != 'C' {
+		return errors.New("cbsgo: not a BGZF file")
+	}
+	size := int(binary.LittleEndian.Uint16(header[16:])) + 1
+	data := make([]byte, size-len(header))
+	if _, err := io.ReadFull(b.r, data); err != nil {
+		return err
+	}
+	block, err := io.ReadAll(flate.NewReader(bytes.NewReader(data[:len(data)-8])))
+	if err != nil {
+		return err
+	}
+	b.block, b.coff, b.next, b.pos = block, coff, coff+int64(size), 0
+	return nil
+}
+
+// readLine returns the next line without its line terminator.
+func (b *bgzfReader) readLine() (string, error) {
+	var line []byte
+	for {
+		for b.pos == len(b.block) {
+			if err := b.load(b.next); err != nil {
+				if err == io.EOF && len(line) > 0 {
+					return string(line), nil
+				}
+				return "", err
+			}
+		}
+		rest := b.block[b.pos:]
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			b.pos += i + 1
+			return string(bytes.TrimSuffix(append(line, rest[:i]...), []byte{'\r'})), nil
+		}
+		line = append(line, rest...)
+		b.pos = len(b.block)
+	}
+}
+
+// bgzfBlockSize is the amount of data compressed into one BGZF block, which
+// leaves room for incompressible data within the 64 KiB block size limit.
+const bgzfBlockSize = 0xff00
+
+// bgzfEOF is the empty block marking the end of a BGZF file.
+var bgzfEOF = []byte{
+	0x1f, 0x8b, 0x08, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0x06, 0x00, 0x42, 0x43,
+	0x02, 0x00, 0x1b, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+}
+
+// bgzfWriter writes a BGZF file, keeping track of the virtual offset.
+type bgzfWriter struct {
+	w io.Writer
+	// buf holds the data of the current block, and written the number of
+	// bytes of the file written so far.
+	buf     []byte
+	written int64
+	zw      *flate.Writer
+	block   bytes.Buffer
+}
+
+// offset returns the current virtual offset.
+func (b *bgzfWriter) offset() uint64 {
+	return uint64(b.written)<<16 | uint64(len(b.buf))
+}
+
+// Write buffers p, compressing full blocks.
+func (b *bgzfWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		k := min(len(p), bgzfBlockSize-len(b.buf))
+		b.buf = append(b.buf, p[:k]...)
+		p = p[k:]
+		if len(b.buf) == bgzfBlockSize {
+			if err := b.flush(); err != nil {
+				return n - len(p), err
+			}
+		}
+	}
+	return n, nil
+}
+
+// reserve starts a new block unless n more bytes fit in the current one, so
+// that records shorter than a block are not split across blocks.
+func (b *bgzfWriter) reserve(n int) error {
+	if len(b.buf)+n > bgzfBlockSize && len(b.buf) > 0 {
+		return b.flush()
+	}
+	return nil
+}
+
+// flush compresses and writes the current block.
+func (b *bgzfWriter) flush() error {
+	if len(b.buf) == 0 {
+		return nil
+	}
+	b.block.Reset()
+	if b.zw == nil {
+		zw, err := flate.NewWriter(&b.block, flate.DefaultCompression)
+		if err != nil {
+			return err
+		}
+		b.zw = zw
+	} else {
+		b.zw.Reset(&b.block)
+	}
+	if _, err := b.zw.Write(b.buf); err != nil {
+		return err
+	}
+	if err := b.zw.Close(); err != nil {
+		return err
+	}
+
+	header := []byte{0x1f, 0x8b, 0x08, 0x04, 0, 0, 0, 0, 0, 0xff, 0x06, 0x00, 'B', 'C', 0x02, 0x00, 0, 0}
+	binary.LittleEndian.PutUint16(header[16:], uint16(len(header)+b.block.Len()+8-1))
+	var trailer [8]byte
+	binary.LittleEndian.PutUint32(trailer[:], crc32.ChecksumIEEE(b.buf))
+	binary.LittleEndian.PutUint32(trailer[4:], uint32(len(b.buf)))
+	for _, p := range [][]byte{header, b.block.Bytes(), trailer[:]} {
+		n, err := b.w.Write(p)
+		b.written += int64(n)
+		if err != nil {
+			return err
+		}
+	}
+	b.buf = b.buf[:0]
+	return nil
+}
+
+// Close flushes the last block and writes the end-of-file marker. It does
+// not close the underlying writer.
+func (b *bgzfWriter) Close() error {
+	if err := b.flush(); err != nil {
+		return err
+	}
+	n, err := b.w.Write(bgzfEOF)
+	b.written += int64(n)
+	return err
+}
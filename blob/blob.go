@@ -0,0 +1,112 @@
+// Package blob opens the input files of cbsgo by URL, so that coverage and
+// track files can be streamed from object stores without staging them
+// locally first.
+//
+// Plain paths and file:// URLs name local files. Backends for http://,
+// https://, s3:// and gs:// URLs are built in, and Register adds backends for
+// other schemes or replaces the built-in ones, for example with backends
+// based on the SDKs of the cloud providers.
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+)
+
+// Backend opens blobs of a URL scheme.
+type Backend interface {
+	// Open returns a reader of the blob at u. The caller closes it.
+	Open(ctx context.Context, u *url.URL) (io.ReadCloser, error)
+}
+
+// BackendFunc adapts a function to a Backend.
+type BackendFunc func(ctx context.Context, u *url.URL) (io.ReadCloser, error)
+
+// Open calls f.
+func (f BackendFunc) Open(ctx context.Context, u *url.URL) (io.ReadCloser, error) {
+	return f(ctx, u)
+}
+
+var (
+	mu       sync.RWMutex
+	backends = map[string]Backend{
+		"file":  BackendFunc(openFile),
+		"http":  &HTTP{},
+		"https": &HTTP{},
+		"s3":    &S3{},
+		"gs":    &GCS{},
+	}
+)
+
+// Register sets the backend of URLs with the given scheme, such as "s3".
+func Register(scheme string, b Backend) {
+	mu.Lock()
+	defer mu.Unlock()
+	backends[scheme] = b
+}
+
+// Open returns a reader of the named blob: a local file for a path, or the
+// blob at a URL opened by the backend of its scheme.
+func Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	u, err := url.Parse(name)
+	if err != nil || len(u.Scheme) < 2 {
+		// Paths, including Windows paths with a drive letter.
+		return os.Open(name)
+	}
+	mu.RLock()
+	b := backends[u.Scheme]
+	mu.RUnlock()
+	if b == nil {
+		return nil, fmt.Errorf("cbsgo: no blob backend for %s:// URLs", u.Scheme)
+	}
+	r, err := b.Open(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("cbsgo: opening %s: %w", name, err)
+	}
+	return r, nil
+}
+
+// IsURL reports whether name is a URL rather than a local path.
+func IsURL(name string) bool {
+	u, err := url.Parse(name)
+	return err == nil && len(u.Scheme) >= 2
+}
+
+func openFile(_ context.Context, u *url.URL) (io.ReadCloser, error) {
+	return os.Open(u.Path)
+}
+
+// HTTP opens http:// and https:// URLs with GET requests.
+type HTTP struct {
+	// Client is the client of the requests, or nil for http.DefaultClient.
+	Client *http.Client
+}
+
+func (h *HTTP) Open(ctx context.Context, u *url.URL) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return get(h.Client, req)
+}
+
+// get sends req and returns the body of a successful response.
+func get(client *http.Client, req *http.Request) (io.ReadCloser, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.Body, nil
+}
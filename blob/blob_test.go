@@ -0,0 +1,121 @@
+package blob_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo/blob"
+)
+
+// read returns the content of the named blob.
+func read(t *testing.T, name string) (string, error) {
+	t.Helper()
+	r, err := blob.Open(context.Background(), name)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	return string(data), err
+}
+
+func TestOpenFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "a.bed")
+	if err := os.WriteFile(name, []byte("chr1\t0\t10\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []string{name, "file://" + name} {
+		if got, err := read(t, n); err != nil || got != "chr1\t0\t10\n" {
+			t.Errorf("%s: unexpected result %q, %v", n, got, err)
+		}
+	}
+	if blob.IsURL(name) || !blob.IsURL("s3://bucket/key") {
+		t.Errorf("IsURL does not tell paths from URLs")
+	}
+	if _, err := read(t, "ftp://host/a.bed"); err == nil {
+		t.Errorf("Expected an error for an unknown scheme")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	blob.Register("mem", blob.BackendFunc(func(_ context.Context, u *url.URL) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(u.Host + u.Path)), nil
+	}))
+	if got, err := read(t, "mem://bucket/key"); err != nil || got != "bucket/key" {
+		t.Errorf("Unexpected result %q, %v", got, err)
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/data.bed" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, "data")
+	}))
+	defer srv.Close()
+
+	if got, err := read(t, srv.URL+"/data.bed"); err != nil || got != "data" {
+		t.Errorf("Unexpected result %q, %v", got, err)
+	}
+	if _, err := read(t, srv.URL+"/missing.bed"); err == nil {
+		t.Errorf("Expected an error for a missing blob")
+	}
+}
+
+func TestS3(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if r.URL.EscapedPath() != "/bucket/dir/sample%201.bed" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, "data")
+	}))
+	defer srv.Close()
+
+	blob.Register("s3", &blob.S3{Endpoint: srv.URL, AccessKeyID: "id", SecretAccessKey: "secret", Region: "eu-west-1"})
+	defer blob.Register("s3", &blob.S3{})
+	if got, err := read(t, "s3://bucket/dir/sample 1.bed"); err != nil || got != "data" {
+		t.Fatalf("Unexpected result %q, %v", got, err)
+	}
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=id/") || !strings.Contains(auth, "/eu-west-1/s3/aws4_request") {
+		t.Errorf("Unexpected authorization %q", auth)
+	}
+
+	// Without credentials, requests are anonymous.
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	blob.Register("s3", &blob.S3{Endpoint: srv.URL})
+	if _, err := read(t, "s3://bucket/dir/sample 1.bed"); err != nil || auth != "" {
+		t.Errorf("Expected an anonymous request, got %q, %v", auth, err)
+	}
+}
+
+func TestGCS(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if r.URL.EscapedPath() != "/storage/v1/b/bucket/o/dir%2Fa.bed" || r.URL.Query().Get("alt") != "media" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, "data")
+	}))
+	defer srv.Close()
+
+	blob.Register("gs", &blob.GCS{Endpoint: srv.URL, Token: "token"})
+	defer blob.Register("gs", &blob.GCS{})
+	if got, err := read(t, "gs://bucket/dir/a.bed"); err != nil || got != "data" || auth != "Bearer token" {
+		t.Errorf("Unexpected result %q, %v with authorization %q", got, err, auth)
+	}
+}
@@ -0,0 +1,166 @@
+package blob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// emptySHA256 is the SHA-256 hash of an empty request body.
+var emptySHA256 = hashHex("")
+
+// S3 opens s3://bucket/key URLs with the S3 REST API. Requests are signed
+// with AWS Signature Version 4 when credentials are available, and anonymous
+// otherwise, which suffices for public buckets.
+//
+// Fields left empty are taken from the standard AWS environment variables:
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, AWS_REGION (or
+// AWS_DEFAULT_REGION, defaulting to us-east-1) and AWS_ENDPOINT_URL.
+type S3 struct {
+	Client *http.Client
+
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Region          string
+	// Endpoint is the base URL of an S3-compatible service, such as MinIO,
+	// addressed with path-style URLs. Without it, the virtual-hosted style
+	// endpoint of AWS for the region is used.
+	Endpoint string
+}
+
+func (s *S3) Open(ctx context.Context, u *url.URL) (io.ReadCloser, error) {
+	region := firstNonEmpty(s.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1")
+	key := strings.TrimPrefix(u.Path, "/")
+
+	var target string
+	if endpoint := firstNonEmpty(s.Endpoint, os.Getenv("AWS_ENDPOINT_URL")); endpoint != "" {
+		target = strings.TrimSuffix(endpoint, "/") + "/" + u.Host + "/" + escapePath(key)
+	} else {
+		target = "https://" + u.Host + ".s3." + region + ".amazonaws.com/" + escapePath(key)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	id := firstNonEmpty(s.AccessKeyID, os.Getenv("AWS_ACCESS_KEY_ID"))
+	secret := firstNonEmpty(s.SecretAccessKey, os.Getenv("AWS_SECRET_ACCESS_KEY"))
+	if id != "" && secret != "" {
+		if token := firstNonEmpty(s.SessionToken, os.Getenv("AWS_SESSION_TOKEN")); token != "" {
+			req.Header.Set("X-Amz-Security-Token", token)
+		}
+		signV4(req, id, secret, region, time.Now().UTC())
+	}
+	return get(s.Client, req)
+}
+
+// signV4 signs a request without body for the S3 service with AWS Signature
+// Version 4, signing the host, the Range header if any and all x-amz-*
+// headers.
+func signV4(req *http.Request, id, secret, region string, t time.Time) {
+	date := t.Format("20060102T150405Z")
+	day := date[:8]
+	req.Header.Set("X-Amz-Date", date)
+	req.Header.Set("X-Amz-Content-Sha256", emptySHA256)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-amz-") || name == "range" {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptySHA256,
+	}, "\n")
+	scope := day + "/" + region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + date + "\n" + scope + "\n" + hashHex(canonical)
+
+	key := hmacSHA256([]byte("AWS4"+secret), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+id+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// canonicalQuery returns the query parameters sorted and escaped as required
+// by Signature Version 4.
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		values := q[k]
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, escape(k)+"="+escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escapePath escapes the segments of an object key, keeping the slashes.
+func escapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = escape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// escape escapes s as required by Signature Version 4, which leaves only
+// unreserved characters unescaped.
+func escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hashHex(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
+
+func firstNonEmpty(s ...string) string {
+	for _, v := range s {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
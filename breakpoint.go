@@ -0,0 +1,121 @@
+package cbsgo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// record keeps the p-value of the split introducing a breakpoint before bin,
+// if the breakpoint details or alerts are enabled.
+func (sg *segmenter) record(bin int, sp split) {
+	if sg.cfg.breakpointLevel == 0 && sg.cfg.alert == nil {
+		return
+	}
+	if sg.pvalues == nil {
+		sg.pvalues = make(map[int]float64)
+	}
+	sg.pvalues[bin] = sp.pvalue
+}
+
+// describe fills in the details of the breakpoints bps, sorted by bin, each
+// delimited by its neighbors or the ends of the profile.
+func (sg *segmenter) describe(bps []Breakpoint) {
+	level := sg.cfg.breakpointLevel
+	crit := distuv.ChiSquared{K: 1}.Quantile(level)
+
+	// Prefix sums of the weights and of the weighted values and squares.
+	x := sg.values()
+	n := len(x)
+	cw := make([]float64, n+1)
+	cx := make([]float64, n+1)
+	cxx := make([]float64, n+1)
+	for i, v := range x {
+		w := 1.0
+		if sg.w != nil {
+			w = sg.w[i]
+		}
+		cw[i+1], cx[i+1], cxx[i+1] = cw[i]+w, cx[i]+w*v, cxx[i]+w*v*v
+	}
+	mean := func(a, b int) float64 { return (cx[b] - cx[a]) / (cw[b] - cw[a]) }
+	sse := func(a, b int) float64 {
+		s := cx[b] - cx[a]
+		return math.Max(cxx[b]-cxx[a]-s*s/(cw[b]-cw[a]), 0)
+	}
+
+	for i := range bps {
+		b := &bps[i]
+		lo, hi := 0, n
+		if i > 0 {
+			lo = bps[i-1].Bin
+		}
+		if i < len(bps)-1 {
+			hi = bps[i+1].Bin
+		}
+		b.Delta = mean(b.Bin, hi) - mean(lo, b.Bin)
+		b.PValue = math.NaN()
+		if p, ok := sg.pvalues[b.Bin]; ok {
+			b.PValue = p
+		}
+
+		// Profile the fit of the two segments over the breakpoint location.
+		best := sse(lo, b.Bin) + sse(b.Bin, hi)
+		lower, upper := b.Bin, b.Bin
+		if variance := best / float64(hi-lo-2); hi-lo > 2 && variance > 0 {
+			for k := lo + 1; k < hi; k++ {
+				if (sse(lo, k)+sse(k, hi)-best)/variance <= crit {
+					lower, upper = min(lower, k), max(upper, k)
+				}
+			}
+		}
+		b.Lower, b.Upper = sg.position(lower), sg.position(upper)
+	}
+}
+
+// breakpointsOf returns the breakpoints between the segments of bounds.
+func (sg *segmenter) breakpointsOf(bounds [][2]int) []Breakpoint {
+	var bps []Breakpoint
+	for _, b := range bounds[min(1, len(bounds)):] {
+		bps = append(bps, Breakpoint{Bin: b[0], Position: sg.position(b[0]), Support: 1})
+	}
+	sg.describe(bps)
+	return bps
+}
+
+// WriteBreakpoints writes the breakpoints as tab-separated values with a
+// header line: the chromosome, position, confidence interval, change in mean,
+// p-value and support of every breakpoint.
+func WriteBreakpoints(w io.Writer, bps []Breakpoint) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "chrom\tposition\tlower\tupper\tdelta\tpvalue\tsupport")
+	for _, b := range bps {
+		fmt.Fprintf(bw, "%s\t%d\t%d\t%d\t%g\t%g\t%g\n", b.Chrom, b.Position, b.Lower, b.Upper, b.Delta, b.PValue, b.Support)
+	}
+	return bw.Flush()
+}
+
+// WriteBEDPE writes, in BEDPE format, every segment delimited by two
+// consecutive breakpoints on the same chromosome as a pair of breakpoint
+// confidence intervals, for comparison with structural variant callsets. The
+// name column holds DUP or DEL as the segment mean is higher or lower than
+// that of the segment before it, and the score the larger of the p-values of
+// the two breakpoints.
+func WriteBEDPE(w io.Writer, bps []Breakpoint) error {
+	bw := bufio.NewWriter(w)
+	for i := 1; i < len(bps); i++ {
+		a, b := bps[i-1], bps[i]
+		if a.Chrom != b.Chrom {
+			continue
+		}
+		name := "DUP"
+		if a.Delta < 0 {
+			name = "DEL"
+		}
+		fmt.Fprintf(bw, "%s\t%d\t%d\t%s\t%d\t%d\t%s\t%g\t.\t.\n",
+			a.Chrom, a.Lower, a.Upper+1, b.Chrom, b.Lower, b.Upper+1, name, math.Max(a.PValue, b.PValue))
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,99 @@
+package cbsgo_test
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestRunWithBreakpoints(t *testing.T) {
+	x := make([]float64, 300)
+	for i := range x {
+		x[i] = math.Sin(float64(i)) / 4
+		if i >= 100 && i < 200 {
+			x[i] -= 2
+		}
+	}
+
+	res, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithBreakpoints(0.95))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if len(res.Breakpoints) != len(res.Segments)-1 || len(res.Breakpoints) != 2 {
+		t.Fatalf("Unexpected breakpoints %+v for segments %v", res.Breakpoints, res.Segments)
+	}
+	for i, b := range res.Breakpoints {
+		s := res.Segments[i+1]
+		if b.Bin != s.BinStart || b.Position != s.Start || b.Support != 1 {
+			t.Errorf("Breakpoint %d: unexpected location %+v", i, b)
+		}
+		if b.Lower > b.Position || b.Upper < b.Position || b.Upper-b.Lower > 3 {
+			t.Errorf("Breakpoint %d: unexpected confidence interval %+v", i, b)
+		}
+		if delta := s.Mean - res.Segments[i].Mean; math.Abs(b.Delta-delta) > 1e-12 {
+			t.Errorf("Breakpoint %d: expected a change of %v, got %v", i, delta, b.Delta)
+		}
+		if !(b.PValue > 0 && b.PValue <= 0.05) {
+			t.Errorf("Breakpoint %d: unexpected p-value %v", i, b.PValue)
+		}
+	}
+
+	// Wider intervals for a smaller shift.
+	for i := range x {
+		if i >= 100 && i < 200 {
+			x[i] += 1.8
+		}
+	}
+	weak, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithBreakpoints(0.95))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	for i, b := range weak.Breakpoints {
+		if b.Upper-b.Lower <= res.Breakpoints[i].Upper-res.Breakpoints[i].Lower {
+			t.Errorf("Breakpoint %d: expected a wider interval than %+v, got %+v", i, res.Breakpoints[i], b)
+		}
+	}
+
+	// Consensus breakpoints get intervals but no p-values.
+	cons, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithConsensus(3), cbsgo.WithBreakpoints(0.95))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	for i, b := range cons.Breakpoints {
+		if !math.IsNaN(b.PValue) || b.Lower > b.Position || b.Upper < b.Position {
+			t.Errorf("Consensus breakpoint %d: unexpected details %+v", i, b)
+		}
+	}
+
+	if _, err := cbsgo.Run(x, cbsgo.WithBreakpoints(1)); err == nil {
+		t.Errorf("Expected an error for an invalid confidence level")
+	}
+}
+
+func TestWriteBEDPE(t *testing.T) {
+	bps := []cbsgo.Breakpoint{
+		{Chrom: "chr1", Position: 1000, Lower: 900, Upper: 1100, Delta: -1, PValue: 0.001},
+		{Chrom: "chr1", Position: 5000, Lower: 5000, Upper: 5000, Delta: 1, PValue: 0.01},
+		{Chrom: "chr2", Position: 300, Lower: 200, Upper: 400, Delta: 0.5, PValue: 0.02},
+	}
+	var buf bytes.Buffer
+	if err := cbsgo.WriteBEDPE(&buf, bps); err != nil {
+		t.Fatalf("WriteBEDPE returned an unexpected error: %v", err)
+	}
+	expected := "chr1\t900\t1101\tchr1\t5000\t5001\tDEL\t0.01\t.\t.\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected result.\nExpected: %q\nGot: %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := cbsgo.WriteBreakpoints(&buf, bps[:1]); err != nil {
+		t.Fatalf("WriteBreakpoints returned an unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || lines[1] != "chr1\t1000\t900\t1100\t-1\t0.001\t0" {
+		t.Errorf("Unexpected result %q", buf.String())
+	}
+}
@@ -0,0 +1,128 @@
+package cbsgo
+
+import (
+	"fmt"
+	"math"
+)
+
+// CopyNumberState is the direction of the copy number change of a segment.
+type CopyNumberState int
+
+const (
+	// StateUnknown is reported for segments that were not called.
+	StateUnknown CopyNumberState = iota
+	// DeepDeletion is reported for a (near) complete loss of all copies.
+	DeepDeletion
+	// Loss is reported for the loss of some copies.
+	Loss
+	// Neutral is reported for segments at the baseline copy number.
+	Neutral
+	// Gain is reported for the gain of some copies.
+	Gain
+	// Amplification is reported for a high-level gain.
+	Amplification
+)
+
+// String returns a short name of the state.
+func (s CopyNumberState) String() string {
+	switch s {
+	case DeepDeletion:
+		return "deep-del"
+	case Loss:
+		return "loss"
+	case Neutral:
+		return "neutral"
+	case Gain:
+		return "gain"
+	case Amplification:
+		return "amp"
+	}
+	return "unknown"
+}
+
+// CallThresholds configures the copy number state calls of CallStates. Each
+// threshold is the log2 ratio from which a segment is called in the state:
+// segment means at or below DeepDeletion or Loss are called deep deletions or
+// losses, and those at or above Gain or Amplification gains or amplifications.
+type CallThresholds struct {
+	DeepDeletion  float64
+	Loss          float64
+	Gain          float64
+	Amplification float64
+}
+
+// DefaultCallThresholds returns the thresholds of CNVkit, suited to germline
+// samples and tumor samples of high purity.
+func DefaultCallThresholds() CallThresholds {
+	return CallThresholds{
+		DeepDeletion:  -1.1,
+		Loss:          -0.25,
+		Gain:          0.2,
+		Amplification: 0.7,
+	}
+}
+
+// Call returns the state of a segment with the given mean log2 ratio.
+func (t CallThresholds) Call(mean float64) CopyNumberState {
+	switch {
+	case mean <= t.DeepDeletion:
+		return DeepDeletion
+	case mean <= t.Loss:
+		return Loss
+	case mean >= t.Amplification:
+		return Amplification
+	case mean >= t.Gain:
+		return Gain
+	case !math.IsNaN(mean):
+		return Neutral
+	}
+	return StateUnknown
+}
+
+// validate checks that the thresholds are ordered.
+func (t CallThresholds) validate() error {
+	if !(t.DeepDeletion <= t.Loss && t.Loss < t.Gain && t.Gain <= t.Amplification) {
+		return fmt.Errorf("cbsgo: unordered call thresholds %+v", t)
+	}
+	return nil
+}
+
+// CallStates calls the copy number state of every segment from its mean,
+// which must be a log2 ratio. Segments with a NaN mean are left unknown. The
+// returned segments are copies of segments with the State field set.
+func CallStates(segments []Segment, t CallThresholds) ([]Segment, error) {
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	res := make([]Segment, len(segments))
+	for i, s := range segments {
+		s.State = t.Call(s.Mean)
+		res[i] = s
+	}
+	return res, nil
+}
+
+// Thresholds returns the log2 ratio thresholds corresponding to the copy
+// numbers of the model, for use with CallStates: a deep deletion below 0.5
+// copies, a loss or gain half a copy away from the rounded ploidy, and an
+// amplification from twice the rounded ploidy.
+func (m SubclonalModel) Thresholds() (CallThresholds, error) {
+	if m.Purity <= 0 || m.Purity > 1 {
+		return CallThresholds{}, fmt.Errorf("cbsgo: invalid purity %v", m.Purity)
+	}
+	if m.Ploidy <= 0 {
+		return CallThresholds{}, fmt.Errorf("cbsgo: invalid ploidy %v", m.Ploidy)
+	}
+	p := m.Purity
+	normal := 2 * (1 - p)
+	ratio := func(n float64) float64 {
+		return math.Log2((p*n + normal) / (p*m.Ploidy + normal))
+	}
+	baseline := math.Max(1, math.Round(m.Ploidy))
+	return CallThresholds{
+		DeepDeletion:  ratio(0.5),
+		Loss:          ratio(baseline - 0.5),
+		Gain:          ratio(baseline + 0.5),
+		Amplification: ratio(2*baseline - 0.5),
+	}, nil
+}
@@ -0,0 +1,73 @@
+package cbsgo_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestCallStates(t *testing.T) {
+	means := []float64{-2, -0.5, 0, 0.3, 1, math.NaN()}
+	expected := []cbsgo.CopyNumberState{cbsgo.DeepDeletion, cbsgo.Loss, cbsgo.Neutral, cbsgo.Gain, cbsgo.Amplification, cbsgo.StateUnknown}
+
+	segments := make([]cbsgo.Segment, len(means))
+	for i, m := range means {
+		segments[i].Mean = m
+	}
+	res, err := cbsgo.CallStates(segments, cbsgo.DefaultCallThresholds())
+	if err != nil {
+		t.Fatalf("CallStates returned an unexpected error: %v", err)
+	}
+	for i, s := range res {
+		if s.State != expected[i] {
+			t.Errorf("Mean %v: expected %v, got %v", means[i], expected[i], s.State)
+		}
+	}
+
+	if _, err := cbsgo.CallStates(segments, cbsgo.CallThresholds{Loss: 1, Gain: 0}); err == nil {
+		t.Errorf("Expected an error for unordered thresholds")
+	}
+}
+
+func TestModelThresholds(t *testing.T) {
+	// A pure diploid sample: half a copy away from 2 copies.
+	th, err := cbsgo.SubclonalModel{Purity: 1, Ploidy: 2}.Thresholds()
+	if err != nil {
+		t.Fatalf("Thresholds returned an unexpected error: %v", err)
+	}
+	want := cbsgo.CallThresholds{
+		DeepDeletion:  math.Log2(0.25),
+		Loss:          math.Log2(0.75),
+		Gain:          math.Log2(1.25),
+		Amplification: math.Log2(1.75),
+	}
+	if th != want {
+		t.Errorf("Unexpected result.\nExpected: %+v\nGot: %+v", want, th)
+	}
+
+	// Lower purity brings the thresholds closer to zero.
+	impure, err := cbsgo.SubclonalModel{Purity: 0.5, Ploidy: 2}.Thresholds()
+	if err != nil {
+		t.Fatalf("Thresholds returned an unexpected error: %v", err)
+	}
+	if !(impure.Loss > th.Loss && impure.Gain < th.Gain) {
+		t.Errorf("Unexpected thresholds %+v", impure)
+	}
+}
+
+func TestRunWithCalls(t *testing.T) {
+	x := []float64{0, 0.1, -0.1, 0, 0, 0.1, -1, -1.1, -0.9, -1, -1.05, -1}
+	res, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithCalls(cbsgo.DefaultCallThresholds()))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	for _, s := range res.Segments {
+		if want := cbsgo.DefaultCallThresholds().Call(s.Mean); s.State != want {
+			t.Errorf("Segment %+v: expected %v", s, want)
+		}
+	}
+	if res.Segments[len(res.Segments)-1].State != cbsgo.Loss {
+		t.Errorf("Expected a loss at the end, got %v", res.Segments)
+	}
+}
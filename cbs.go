@@ -1,9 +1,12 @@
 package cbsgo
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"math"
 	"math/rand"
-	"time"
+	"runtime/pprof"
 
 	"gonum.org/v1/gonum/floats"
 	"gonum.org/v1/gonum/stat"
@@ -16,59 +19,87 @@ import (
 //   - x: A slice of float64 data.
 //   - shuffles: The number of permutations to perform to determine significance (1000 is recommended).
 //   - significanceLevel: The p-value significance level (0.05 is recommended).
+//   - seed: 0 for reproducible permutations; any other seed selects a time-based seed.
 //
 // Returns:
 //   - A slice of [2]int arrays, where each array represents a [start, end] interval of a segment.
 //   - An error if something goes wrong during the calculation.
+//
+// Run offers the same algorithm with additional options.
 func CBS(x []float64, shuffles int, significanceLevel float64, seed int64) ([][2]int, error) {
-	// Use a seeded random source for reproducible shuffles.
-	// For true randomness, use a different seed, e.g., time.Now().UnixNano().
-	var rng *rand.Rand
+	// Run takes 0 for a time-based seed. A math/rand source seeded with 0
+	// is the one seeded with 89482311.
 	if seed == 0 {
-		rng = rand.New(rand.NewSource(seed))
+		seed = 89482311
 	} else {
-		rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+		seed = 0
 	}
-
-	var segments [][2]int
-	err := rsegment(x, 0, len(x), &segments, shuffles, significanceLevel, rng)
+	res, err := Run(x, WithShuffles(shuffles), WithAlpha(significanceLevel), WithSeed(seed))
 	if err != nil {
 		return nil, err
 	}
+	segments := make([][2]int, len(res.Segments))
+	for i, s := range res.Segments {
+		segments[i] = [2]int{s.BinStart, s.BinEnd}
+	}
 	return segments, nil
 }
 
 // rsegment is the recursive function that performs the segmentation.
-func rsegment(x []float64, start, end int, l *[][2]int, shuffles int, p float64, rng *rand.Rand) error {
+func (sg *segmenter) rsegment(start, end int) error {
 	if start >= end {
 		return nil
 	}
+	if err := sg.ctx.Err(); err != nil {
+		sg.interrupted = err
+		return sg.addUnexplored(start, end)
+	}
+
+	if sg.screened(start, end) {
+		sg.explain(start, end, split{pvalue: 1, end: end - start}, SplitScreened)
+		return sg.add(start, end)
+	}
 
-	isChange, _, s, e, err := cbsInner(x[start:end], shuffles, p, rng)
+	sp, err := sg.test(start, end)
+	if err == errInterrupted {
+		return sg.addUnexplored(start, end)
+	}
 	if err != nil {
 		return err
 	}
+	s, e := sp.start, sp.end
 
 	// Add segment if there is no significant changepoint or if the segment is too small.
-	if !isChange || (e-s < 5) || (e-s == end-start) {
-		*l = append(*l, [2]int{start, end})
-		return nil
+	decision := sg.decide(sp, end-start)
+	sg.explain(start, end, sp, decision)
+	if decision != SplitAccepted {
+		return sg.add(start, end)
+	}
+	if s > 0 {
+		sg.record(start+s, sp)
+	}
+	if start+e < end {
+		sg.record(start+e, sp)
 	}
 
 	// Recursively call for the sub-segments.
+	sg.depth++
+	defer func() { sg.depth-- }()
 	// Segment before the changepoint
 	if s > 0 {
-		if err := rsegment(x, start, start+s, l, shuffles, p, rng); err != nil {
+		if err := sg.rsegment(start, start+s); err != nil {
 			return err
 		}
 	}
 	// Segment of the changepoint itself
 	if e-s > 0 {
-		*l = append(*l, [2]int{start + s, start + e})
+		if err := sg.add(start+s, start+e); err != nil {
+			return err
+		}
 	}
 	// Segment after the changepoint
 	if start+e < end {
-		if err := rsegment(x, start+e, end, l, shuffles, p, rng); err != nil {
+		if err := sg.rsegment(start+e, end); err != nil {
 			return err
 		}
 	}
@@ -76,51 +107,352 @@ func rsegment(x []float64, start, end int, l *[][2]int, shuffles int, p float64,
 	return nil
 }
 
-// cbsInner determines if there is a significant changepoint in the slice `x`.
-func cbsInner(x []float64, shuffles int, p float64, rng *rand.Rand) (bool, float64, int, int, error) {
-	maxT, maxStart, maxEnd, err := cbsStat(x)
+// decide returns whether the split sp of an interval of n bins is accepted,
+// or why it is rejected.
+func (sg *segmenter) decide(sp split, n int) SplitDecision {
+	s, e := sp.start, sp.end
+	switch {
+	case !sp.significant:
+		return SplitInsignificant
+	case e-s < 5:
+		return SplitTooShort
+	case e-s == n:
+		return SplitAbsorbed
+	case sg.tooNarrow(s, e, n):
+		return SplitTooNarrow
+	}
+	return SplitAccepted
+}
+
+// tooNarrow reports whether the split of an interval of n bins at s and e
+// creates a segment narrower than the minimum width of WithMinWidth.
+func (sg *segmenter) tooNarrow(s, e, n int) bool {
+	k := sg.cfg.minWidth
+	narrow := func(width int) bool { return width > 0 && width < k }
+	return narrow(s) || narrow(e-s) || narrow(n-e)
+}
+
+// add records the segment of the bins [start, end). Segments are added in
+// order of position.
+func (sg *segmenter) add(start, end int) error {
+	sg.segments.add(start, end)
+	if sg.emit == nil {
+		return nil
+	}
+	return sg.emit(start, end)
+}
+
+// addUnexplored records the segment of the bins [start, end), which the run
+// was interrupted before testing for changepoints.
+func (sg *segmenter) addUnexplored(start, end int) error {
+	if sg.unexplored == nil {
+		sg.unexplored = make(map[int]bool)
+	}
+	sg.unexplored[start] = true
+	return sg.add(start, end)
+}
+
+// errInterrupted reports that the context of a run ended during a test.
+var errInterrupted = errors.New("cbsgo: interrupted")
+
+// split describes the best split of an interval and its significance.
+type split struct {
+	significant bool
+	stat        float64
+	// pvalue is the estimated p-value of the split. Permutation tests stop
+	// as soon as the split cannot be significant, so that the p-values of
+	// rejected splits are rough estimates.
+	pvalue float64
+	// start and end bound the arc of the split, relative to the interval.
+	start, end int
+}
+
+// test runs cbsInner, with the profiler labels of the interval if enabled by
+// WithProfileLabels.
+func (sg *segmenter) test(start, end int) (split, error) {
+	if !sg.cfg.profileLabels {
+		return sg.cbsInner(start, end)
+	}
+	var sp split
+	var err error
+	labels := pprof.Labels("sample", sg.cfg.profileID, "chromosome", sg.cfg.chrom, "length", lengthBucket(end-start))
+	pprof.Do(sg.ctx, labels, func(context.Context) {
+		sp, err = sg.cbsInner(start, end)
+	})
+	return sp, err
+}
+
+// lengthBucket returns the decade of the length n, such as "100-999".
+func lengthBucket(n int) string {
+	lo := 1
+	for lo*10 <= n {
+		lo *= 10
+	}
+	return fmt.Sprintf("%d-%d", lo, lo*10-1)
+}
+
+// cbsInner determines if there is a significant changepoint in x[start:end].
+func (sg *segmenter) cbsInner(start, end int) (split, error) {
+	data := sg.interval(start, end)
+	n := data.len()
+
+	maxT, maxStart, maxEnd, err := data.stat()
 	if err != nil {
-		return false, 0, 0, 0, err
+		return split{}, err
 	}
+	sg.stats.Tests++
+	sg.tested++
+	alpha := sg.alpha()
+	sp := split{stat: maxT, pvalue: 1, start: maxStart, end: maxEnd}
 
-	if maxEnd-maxStart == len(x) {
-		return false, maxT, maxStart, maxEnd, nil
+	if maxEnd-maxStart == n {
+		return sp, nil
+	}
+	// Intervals of equal values, up to rounding errors, have no changepoint,
+	// and no permutation could have a lower statistic than a zero one.
+	if maxT == 0 || (sg.samples == nil && constant(sg.x[start:end])) {
+		return sp, nil
 	}
 
 	// Adjust start/end according to the heuristic in the original code.
 	if maxStart < 5 {
 		maxStart = 0
 	}
-	if len(x)-maxEnd < 5 {
-		maxEnd = len(x)
+	if n-maxEnd < 5 {
+		maxEnd = n
+	}
+	sp.start, sp.end = maxStart, maxEnd
+
+	// Splits at large gaps between markers, and into short segments, need
+	// stronger evidence.
+	maxT *= sg.gapPenalty(start, end, start+maxStart, start+maxEnd)
+	maxT *= sg.lengthPenalty(n, maxStart, maxEnd)
+	sp.stat = maxT
+
+	// Cached null distribution
+	if c := sg.cfg.nulls; c != nil && sg.w == nil && sg.samples == nil && sg.cfg.direction == TwoSided {
+		v := stat.PopVariance(sg.x[start:end], nil)
+		if v == 0 {
+			return sp, nil
+		}
+		exceed := c.exceed(n, maxT/v)
+		sp.significant = float64(exceed) <= float64(c.draws)*alpha
+		sp.pvalue = float64(exceed+1) / float64(c.draws+1)
+		return sp, nil
 	}
 
 	// Permutation test
 	threshCount := 0
-	alpha := float64(shuffles) * p
-	xt := make([]float64, len(x))
-	copy(xt, x)
+	shuffles := sg.shuffles(n)
+	null := sg.buf.null[:0]
+	defer func() { sg.buf.null = null }()
+	batch := sg.newBatch(data)
+	if batch != nil {
+		defer func() { sg.buf.stats = sg.buf.stats[:0] }()
+	}
 
 	for i := 0; i < shuffles; i++ {
-		rng.Shuffle(len(xt), func(i, j int) { xt[i], xt[j] = xt[j], xt[i] })
-		threshold, _, _, err := cbsStat(xt)
-		if err != nil {
-			return false, 0, 0, 0, err
+		if i%64 == 63 {
+			if err := sg.ctx.Err(); err != nil {
+				sg.interrupted = err
+				return split{}, errInterrupted
+			}
+		}
+		var threshold float64
+		if batch != nil {
+			if batch.empty() {
+				// Batches end where the loop could stop or check the
+				// context, so that the random source is not advanced
+				// beyond the permutations used.
+				rows := min(shuffles-i, int(float64(shuffles)*alpha)-threshCount+1, max(63-i%64, 1))
+				batch.fill(sg.rng, rows)
+			}
+			threshold = batch.next()
+		} else {
+			data.shuffle(sg.rng)
+			var err error
+			if threshold, _, _, err = data.stat(); err != nil {
+				return split{}, err
+			}
+		}
+		sg.stats.Permutations++
+		if sg.cfg.tail {
+			null = append(null, threshold)
 		}
 		if threshold >= maxT {
 			threshCount++
 		}
-		if float64(threshCount) > alpha {
-			return false, maxT, maxStart, maxEnd, nil
+		if float64(threshCount) > float64(shuffles)*alpha {
+			sg.stats.EarlyStops++
+			sp.pvalue = float64(threshCount) / float64(i+1)
+			return sp, nil
+		}
+		// Scheduled tests close to the significance level continue up to
+		// the full number of shuffles.
+		if i == shuffles-1 && shuffles < sg.cfg.shuffles && float64(threshCount+1)/float64(shuffles+1) > alpha/2 {
+			shuffles = sg.cfg.shuffles
+		}
+	}
+
+	sp.significant = true
+	sp.pvalue = float64(threshCount+1) / float64(shuffles+1)
+	if sg.cfg.tail && threshCount < tailExceedances {
+		if p := TailPValue(null, maxT); p > 0 {
+			sp.pvalue = p
 		}
 	}
+	return sp, nil
+}
+
+// constant reports whether the values of x are equal up to a relative
+// difference of 1e-10, as left by rounding errors.
+func constant(x []float64) bool {
+	lo, hi := floats.Min(x), floats.Max(x)
+	return hi-lo <= 1e-10*math.Max(1, math.Max(math.Abs(lo), math.Abs(hi)))
+}
+
+// alpha returns the significance level of the current test, adjusted as
+// configured by WithAlphaAdjustment.
+func (sg *segmenter) alpha() float64 {
+	switch sg.cfg.adjustment {
+	case BonferroniAdjustment:
+		k := float64(sg.tested)
+		return sg.cfg.alpha * 6 / (math.Pi * math.Pi * k * k)
+	case DepthAdjustment:
+		return math.Ldexp(sg.cfg.alpha, -(2*sg.depth + 1))
+	}
+	return sg.cfg.alpha
+}
+
+// shuffles returns the number of permutations of the test of n bins.
+func (sg *segmenter) shuffles(n int) int {
+	if sg.cfg.schedule == nil {
+		return sg.cfg.shuffles
+	}
+	return max(sg.cfg.schedule(n), 1)
+}
+
+// interval holds a copy of the data of an interval under test, which the
+// permutation test shuffles in place.
+type interval interface {
+	len() int
+	// stat calculates the test statistic and the bounds of the best split.
+	stat() (float64, int, int, error)
+	// shuffle permutes the bins.
+	shuffle(rng *rand.Rand)
+}
+
+// interval returns a copy of the data of the bins [start, end).
+func (sg *segmenter) interval(start, end int) interval {
+	if sg.samples != nil {
+		return sg.jointInterval(start, end)
+	}
+	n := end - start
+	buf := sg.buf
+	data := &singleInterval{x: grow(&buf.x, n), y: grow(&buf.y, n), dir: sg.cfg.direction, tie: sg.cfg.tie}
+	copy(data.x, sg.x[start:end])
+	if sg.w != nil {
+		data.w = grow(&buf.w, n)
+		data.cw = grow(&buf.cw, n)
+		copy(data.w, sg.w[start:end])
+	}
+	return data
+}
+
+// scratch holds the buffers backing the intervals under test. Intervals are
+// tested one at a time, so that a run needs a single set of buffers.
+type scratch struct {
+	x, w, y, cw []float64
+	// null holds the statistics of the permutations when needed by
+	// WithTailPValues.
+	null []float64
+	// rows and stats back the permutation batches.
+	rows, stats []float64
+}
+
+// grow returns (*buf)[:n], reallocating *buf if it is too small.
+func grow(buf *[]float64, n int) []float64 {
+	if cap(*buf) < n {
+		*buf = make([]float64, n)
+	}
+	return (*buf)[:n]
+}
+
+// singleInterval is an interval of a single sample.
+type singleInterval struct {
+	x []float64
+	// w holds the bin widths, or nil for bins of equal width.
+	w []float64
+	// y and cw are scratch space for the statistic, cw only if w is set.
+	y, cw []float64
+	dir   Direction
+	tie   TieBreak
+}
+
+func (d *singleInterval) len() int {
+	return len(d.x)
+}
 
-	return true, maxT, maxStart, maxEnd, nil
+func (d *singleInterval) stat() (float64, int, int, error) {
+	if d.w == nil {
+		return cbsStatInto(d.y, d.x, d.dir, d.tie)
+	}
+	return cbsStatWeighted(d.y, d.cw, d.x, d.w, d.dir, d.tie)
+}
+
+func (d *singleInterval) shuffle(rng *rand.Rand) {
+	if d.w == nil {
+		rng.Shuffle(len(d.x), func(i, j int) { d.x[i], d.x[j] = d.x[j], d.x[i] })
+		return
+	}
+	// Bins keep their width when shuffled.
+	rng.Shuffle(len(d.x), func(i, j int) {
+		d.x[i], d.x[j] = d.x[j], d.x[i]
+		d.w[i], d.w[j] = d.w[j], d.w[i]
+	})
+}
+
+// cbsStatWeighted calculates the CBS test statistic for bins of width w,
+// using y and cw, of the length of x, as scratch space.
+func cbsStatWeighted(y, cw, x, w []float64, dir Direction, tie TieBreak) (float64, int, int, error) {
+	if len(x) == 0 {
+		return 0.0, 0, 0, nil
+	}
+
+	total := floats.Sum(w)
+	mean := stat.Mean(x, w)
+
+	// Cumulative sums of the weighted, mean-centered values and of the weights.
+	for i, val := range x {
+		y[i] = w[i] * (val - mean)
+	}
+	floats.CumSum(y, y)
+	floats.CumSum(cw, w)
+
+	i0, i1 := dir.arc(y, tie)
+
+	// The weighted counterparts of the arc and complement lengths used by cbsStat.
+	inner := cw[i1] - cw[i0] + w[i0]
+	outer := total - (cw[i1] - cw[i0])
+	denominator := inner * outer
+	if denominator == 0 {
+		return 0.0, i0, i1 + 1, nil // Avoid division by zero
+	}
+	stat := math.Pow(y[i1]-y[i0], 2) * total / denominator
+
+	return stat, i0, i1 + 1, nil
 }
 
 // cbsStat calculates the CBS test statistic.
 // It uses gonum for efficient calculations.
 func cbsStat(x []float64) (float64, int, int, error) {
+	return cbsStatInto(make([]float64, len(x)), x, TwoSided, Leftmost)
+}
+
+// cbsStatInto calculates the CBS test statistic like cbsStat, for changes in
+// direction dir and ties broken by tie, using y, of the length of x, as
+// scratch space.
+func cbsStatInto(y, x []float64, dir Direction, tie TieBreak) (float64, int, int, error) {
 	if len(x) == 0 {
 		return 0.0, 0, 0, nil
 	}
@@ -129,25 +461,15 @@ func cbsStat(x []float64) (float64, int, int, error) {
 	mean := stat.Mean(x, nil)
 
 	// Create a mean-centered slice
-	x0 := make([]float64, len(x))
 	for i, val := range x {
-		x0[i] = val - mean
+		y[i] = val - mean
 	}
 
 	// Calculate the cumulative sum with gonum
-	y := make([]float64, len(x0))
-	floats.CumSum(y, x0)
+	floats.CumSum(y, y)
 
 	// Find the indices of the max and min values in the cumulative sum
-	e0 := floats.MaxIdx(y)
-	e1 := floats.MinIdx(y)
-
-	i0 := e0
-	i1 := e1
-	if e1 < e0 {
-		i0 = e1
-		i1 = e0
-	}
+	i0, i1 := dir.arc(y, tie)
 
 	s0 := y[i0]
 	s1 := y[i1]
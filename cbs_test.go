@@ -1,11 +1,12 @@
 package cbsgo_test
 
 import (
+	"math/rand"
 	"reflect"
 	"sort"
 	"testing"
 
-	"github.com/matthds/cbsgo"
+	"github.com/mattdsm/cbsgo"
 )
 
 func TestCBS(t *testing.T) {
@@ -34,3 +35,20 @@ func TestCBS(t *testing.T) {
 		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, res)
 	}
 }
+
+func BenchmarkRun(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	x := make([]float64, 20000)
+	for i := range x {
+		x[i] = rng.NormFloat64()
+		if i >= 8000 && i < 12000 {
+			x[i] += 0.5
+		}
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := cbsgo.Run(x, cbsgo.WithSeed(1)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,549 @@
+// Package cbspb encodes tracks, segments and runs in the Protocol Buffers
+// wire format, giving a compact and stable representation for consumers in
+// other languages. The messages are defined in cbsgo.proto, next to this
+// file, from which any language can generate its own decoder.
+//
+// The encoding is written directly with protowire rather than generated, so
+// that the cbsgo types are marshaled without copying them into generated
+// structs first.
+package cbspb
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// Run is the result of segmenting the tracks of one sample, with the
+// metadata needed to reproduce it.
+type Run struct {
+	ID     string
+	Sample string
+	// Version is the version of cbsgo that produced the run.
+	Version  string
+	Started  time.Time
+	Duration time.Duration
+	// Parameters holds the options of the run, such as "alpha".
+	Parameters map[string]string
+	Segments   []cbsgo.Segment
+}
+
+// MarshalTrack returns the encoding of t as a Track message.
+func MarshalTrack(t *cbsgo.Track) []byte {
+	var b []byte
+	b = appendString(b, 1, t.Chrom)
+	b = appendInts(b, 2, t.Starts)
+	b = appendInts(b, 3, t.Ends)
+	b = appendDoubles(b, 4, t.Values)
+	b = appendDoubles(b, 5, t.Weights)
+	b = appendBools(b, 6, t.Mask)
+	return b
+}
+
+// UnmarshalTrack decodes a Track message.
+func UnmarshalTrack(b []byte) (*cbsgo.Track, error) {
+	t := new(cbsgo.Track)
+	err := decode(b, "Track", func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch num {
+		case 1:
+			return consumeString(typ, b, &t.Chrom)
+		case 2:
+			return consumeInts(typ, b, &t.Starts)
+		case 3:
+			return consumeInts(typ, b, &t.Ends)
+		case 4:
+			return consumeDoubles(typ, b, &t.Values)
+		case 5:
+			return consumeDoubles(typ, b, &t.Weights)
+		case 6:
+			return consumeBools(typ, b, &t.Mask)
+		}
+		return protowire.ConsumeFieldValue(num, typ, b)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// MarshalSegment returns the encoding of s as a Segment message.
+func MarshalSegment(s cbsgo.Segment) []byte {
+	return appendSegment(nil, s)
+}
+
+func appendSegment(b []byte, s cbsgo.Segment) []byte {
+	b = appendString(b, 1, s.Chrom)
+	b = appendInt(b, 2, s.Start)
+	b = appendInt(b, 3, s.End)
+	b = appendInt(b, 4, s.BinStart)
+	b = appendInt(b, 5, s.BinEnd)
+	b = appendDouble(b, 6, s.Mean)
+	b = appendDouble(b, 7, s.SD)
+	b = appendInt(b, 8, s.SNPs)
+	b = appendDouble(b, 9, s.BAF)
+	b = appendInt(b, 10, int(s.Allelic))
+	b = appendDouble(b, 11, s.CellFraction)
+	b = appendDouble(b, 12, s.CellFractionLower)
+	b = appendDouble(b, 13, s.CellFractionUpper)
+	b = appendInt(b, 14, int(s.State))
+	b = appendDouble(b, 15, s.SSE)
+	b = appendDouble(b, 16, s.R2)
+	b = appendString(b, 17, s.Cytoband)
+	b = appendBool(b, 18, s.Unexplored)
+	b = appendDouble(b, 19, s.PopulationFrequency)
+	b = appendBool(b, 20, s.Spike)
+	if c := s.BestSplit; c != nil {
+		b = appendMessage(b, 21, appendCandidate(nil, *c))
+	}
+	for _, f := range s.Focal {
+		b = appendMessage(b, 22, appendFocalEvent(nil, f))
+	}
+	for _, sub := range s.Subsegments {
+		b = appendMessage(b, 23, appendSegment(nil, sub))
+	}
+	return b
+}
+
+func appendFocalEvent(b []byte, f cbsgo.FocalEvent) []byte {
+	b = appendString(b, 1, f.Chrom)
+	b = appendInt(b, 2, f.Start)
+	b = appendInt(b, 3, f.End)
+	b = appendInt(b, 4, f.BinStart)
+	b = appendInt(b, 5, f.BinEnd)
+	b = appendDouble(b, 6, f.Mean)
+	b = appendDouble(b, 7, f.Amplitude)
+	return b
+}
+
+func appendCandidate(b []byte, c cbsgo.Candidate) []byte {
+	b = appendString(b, 1, c.Chrom)
+	b = appendInt(b, 2, c.Start)
+	b = appendInt(b, 3, c.End)
+	b = appendInt(b, 4, c.SplitStart)
+	b = appendInt(b, 5, c.SplitEnd)
+	b = appendInt(b, 6, c.Depth)
+	b = appendDouble(b, 7, c.Stat)
+	b = appendDouble(b, 8, c.PValue)
+	b = appendInt(b, 9, int(c.Decision))
+	return b
+}
+
+// UnmarshalSegment decodes a Segment message.
+func UnmarshalSegment(b []byte) (cbsgo.Segment, error) {
+	var s cbsgo.Segment
+	var allelic, state int
+	var errs []error
+	err := decode(b, "Segment", func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch num {
+		case 1:
+			return consumeString(typ, b, &s.Chrom)
+		case 2:
+			return consumeInt(typ, b, &s.Start)
+		case 3:
+			return consumeInt(typ, b, &s.End)
+		case 4:
+			return consumeInt(typ, b, &s.BinStart)
+		case 5:
+			return consumeInt(typ, b, &s.BinEnd)
+		case 6:
+			return consumeDouble(typ, b, &s.Mean)
+		case 7:
+			return consumeDouble(typ, b, &s.SD)
+		case 8:
+			return consumeInt(typ, b, &s.SNPs)
+		case 9:
+			return consumeDouble(typ, b, &s.BAF)
+		case 10:
+			return consumeInt(typ, b, &allelic)
+		case 11:
+			return consumeDouble(typ, b, &s.CellFraction)
+		case 12:
+			return consumeDouble(typ, b, &s.CellFractionLower)
+		case 13:
+			return consumeDouble(typ, b, &s.CellFractionUpper)
+		case 14:
+			return consumeInt(typ, b, &state)
+		case 15:
+			return consumeDouble(typ, b, &s.SSE)
+		case 16:
+			return consumeDouble(typ, b, &s.R2)
+		case 17:
+			return consumeString(typ, b, &s.Cytoband)
+		case 18:
+			return consumeBool(typ, b, &s.Unexplored)
+		case 19:
+			return consumeDouble(typ, b, &s.PopulationFrequency)
+		case 20:
+			return consumeBool(typ, b, &s.Spike)
+		case 21:
+			var msg []byte
+			n := consumeBytes(typ, b, &msg)
+			c, err := unmarshalCandidate(msg)
+			errs = append(errs, err)
+			s.BestSplit = &c
+			return n
+		case 22:
+			var msg []byte
+			n := consumeBytes(typ, b, &msg)
+			f, err := unmarshalFocalEvent(msg)
+			errs = append(errs, err)
+			s.Focal = append(s.Focal, f)
+			return n
+		case 23:
+			var msg []byte
+			n := consumeBytes(typ, b, &msg)
+			sub, err := UnmarshalSegment(msg)
+			errs = append(errs, err)
+			s.Subsegments = append(s.Subsegments, sub)
+			return n
+		}
+		return protowire.ConsumeFieldValue(num, typ, b)
+	})
+	s.Allelic, s.State = cbsgo.AllelicState(allelic), cbsgo.CopyNumberState(state)
+	return s, errors.Join(append(errs, err)...)
+}
+
+func unmarshalFocalEvent(b []byte) (cbsgo.FocalEvent, error) {
+	var f cbsgo.FocalEvent
+	err := decode(b, "FocalEvent", func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch num {
+		case 1:
+			return consumeString(typ, b, &f.Chrom)
+		case 2:
+			return consumeInt(typ, b, &f.Start)
+		case 3:
+			return consumeInt(typ, b, &f.End)
+		case 4:
+			return consumeInt(typ, b, &f.BinStart)
+		case 5:
+			return consumeInt(typ, b, &f.BinEnd)
+		case 6:
+			return consumeDouble(typ, b, &f.Mean)
+		case 7:
+			return consumeDouble(typ, b, &f.Amplitude)
+		}
+		return protowire.ConsumeFieldValue(num, typ, b)
+	})
+	return f, err
+}
+
+func unmarshalCandidate(b []byte) (cbsgo.Candidate, error) {
+	var c cbsgo.Candidate
+	var decision int
+	err := decode(b, "Candidate", func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch num {
+		case 1:
+			return consumeString(typ, b, &c.Chrom)
+		case 2:
+			return consumeInt(typ, b, &c.Start)
+		case 3:
+			return consumeInt(typ, b, &c.End)
+		case 4:
+			return consumeInt(typ, b, &c.SplitStart)
+		case 5:
+			return consumeInt(typ, b, &c.SplitEnd)
+		case 6:
+			return consumeInt(typ, b, &c.Depth)
+		case 7:
+			return consumeDouble(typ, b, &c.Stat)
+		case 8:
+			return consumeDouble(typ, b, &c.PValue)
+		case 9:
+			return consumeInt(typ, b, &decision)
+		}
+		return protowire.ConsumeFieldValue(num, typ, b)
+	})
+	c.Decision = cbsgo.SplitDecision(decision)
+	return c, err
+}
+
+// MarshalRun returns the encoding of r as a Run message. Parameters are
+// written in the order of their names, so that equal runs have equal
+// encodings.
+func MarshalRun(r *Run) []byte {
+	var b []byte
+	b = appendString(b, 1, r.ID)
+	b = appendString(b, 2, r.Sample)
+	b = appendString(b, 3, r.Version)
+	if !r.Started.IsZero() {
+		b = appendInt(b, 4, int(r.Started.UnixNano()))
+	}
+	b = appendInt(b, 5, int(r.Duration))
+	for _, k := range slices.Sorted(maps.Keys(r.Parameters)) {
+		var entry []byte
+		entry = appendString(entry, 1, k)
+		entry = appendString(entry, 2, r.Parameters[k])
+		b = protowire.AppendTag(b, 6, protowire.BytesType)
+		b = protowire.AppendBytes(b, entry)
+	}
+	for _, s := range r.Segments {
+		b = appendMessage(b, 7, appendSegment(nil, s))
+	}
+	return b
+}
+
+// UnmarshalRun decodes a Run message.
+func UnmarshalRun(b []byte) (*Run, error) {
+	r := new(Run)
+	var started int
+	var errs []error
+	err := decode(b, "Run", func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch num {
+		case 1:
+			return consumeString(typ, b, &r.ID)
+		case 2:
+			return consumeString(typ, b, &r.Sample)
+		case 3:
+			return consumeString(typ, b, &r.Version)
+		case 4:
+			return consumeInt(typ, b, &started)
+		case 5:
+			var d int
+			n := consumeInt(typ, b, &d)
+			r.Duration = time.Duration(d)
+			return n
+		case 6:
+			var entry []byte
+			n := consumeBytes(typ, b, &entry)
+			var k, v string
+			errs = append(errs, decode(entry, "Run.parameters", func(num protowire.Number, typ protowire.Type, b []byte) int {
+				switch num {
+				case 1:
+					return consumeString(typ, b, &k)
+				case 2:
+					return consumeString(typ, b, &v)
+				}
+				return protowire.ConsumeFieldValue(num, typ, b)
+			}))
+			if r.Parameters == nil {
+				r.Parameters = make(map[string]string)
+			}
+			r.Parameters[k] = v
+			return n
+		case 7:
+			var msg []byte
+			n := consumeBytes(typ, b, &msg)
+			s, err := UnmarshalSegment(msg)
+			errs = append(errs, err)
+			r.Segments = append(r.Segments, s)
+			return n
+		}
+		return protowire.ConsumeFieldValue(num, typ, b)
+	})
+	if err := errors.Join(append(errs, err)...); err != nil {
+		return nil, err
+	}
+	if started != 0 {
+		r.Started = time.Unix(0, int64(started)).UTC()
+	}
+	return r, nil
+}
+
+// errWireType is returned by the consume functions for a field encoded with
+// a wire type that its declaration does not allow.
+const errWireType = -100
+
+// decode calls field for every field of the message in b, which returns the
+// length of the field value or a negative protowire error code.
+func decode(b []byte, message string, field func(num protowire.Number, typ protowire.Type, b []byte) int) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return fmt.Errorf("cbsgo: decoding %s: %w", message, protowire.ParseError(n))
+		}
+		b = b[n:]
+		if n = field(num, typ, b); n == errWireType {
+			return fmt.Errorf("cbsgo: decoding %s: field %d has unexpected wire type %d", message, num, typ)
+		} else if n < 0 {
+			return fmt.Errorf("cbsgo: decoding %s: field %d: %w", message, num, protowire.ParseError(n))
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// The append functions omit fields holding the zero value, as proto3 does.
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendInt(b []byte, num protowire.Number, v int) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+func appendBool(b []byte, num protowire.Number, v bool) []byte {
+	if !v {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, 1)
+}
+
+func appendDouble(b []byte, num protowire.Number, v float64) []byte {
+	bits := math.Float64bits(v)
+	if bits == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, bits)
+}
+
+// appendMessage appends the encoded message msg as field num, even if empty,
+// as embedded messages are present whatever their fields.
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+// The repeated fields are packed.
+
+func appendInts(b []byte, num protowire.Number, v []int) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	size := 0
+	for _, x := range v {
+		size += protowire.SizeVarint(uint64(x))
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	b = protowire.AppendVarint(b, uint64(size))
+	for _, x := range v {
+		b = protowire.AppendVarint(b, uint64(x))
+	}
+	return b
+}
+
+func appendDoubles(b []byte, num protowire.Number, v []float64) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	b = protowire.AppendVarint(b, uint64(8*len(v)))
+	for _, x := range v {
+		b = protowire.AppendFixed64(b, math.Float64bits(x))
+	}
+	return b
+}
+
+func appendBools(b []byte, num protowire.Number, v []bool) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	b = protowire.AppendVarint(b, uint64(len(v)))
+	for _, x := range v {
+		b = protowire.AppendVarint(b, protowire.EncodeBool(x))
+	}
+	return b
+}
+
+// The consume functions decode a field value of b into v and return its
+// length. Repeated fields are accepted both packed and unpacked.
+
+func consumeBytes(typ protowire.Type, b []byte, v *[]byte) int {
+	if typ != protowire.BytesType {
+		return errWireType
+	}
+	var n int
+	*v, n = protowire.ConsumeBytes(b)
+	return n
+}
+
+func consumeString(typ protowire.Type, b []byte, v *string) int {
+	if typ != protowire.BytesType {
+		return errWireType
+	}
+	var n int
+	*v, n = protowire.ConsumeString(b)
+	return n
+}
+
+func consumeInt(typ protowire.Type, b []byte, v *int) int {
+	if typ != protowire.VarintType {
+		return errWireType
+	}
+	x, n := protowire.ConsumeVarint(b)
+	*v = int(int64(x))
+	return n
+}
+
+func consumeBool(typ protowire.Type, b []byte, v *bool) int {
+	if typ != protowire.VarintType {
+		return errWireType
+	}
+	x, n := protowire.ConsumeVarint(b)
+	*v = protowire.DecodeBool(x)
+	return n
+}
+
+func consumeDouble(typ protowire.Type, b []byte, v *float64) int {
+	if typ != protowire.Fixed64Type {
+		return errWireType
+	}
+	x, n := protowire.ConsumeFixed64(b)
+	*v = math.Float64frombits(x)
+	return n
+}
+
+// consumePacked calls element for every value of a packed repeated field,
+// or for the single value of an unpacked one of wire type elem.
+func consumePacked(typ, elem protowire.Type, b []byte, element func(b []byte) int) int {
+	switch typ {
+	case elem:
+		return element(b)
+	case protowire.BytesType:
+		packed, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return n
+		}
+		for len(packed) > 0 {
+			m := element(packed)
+			if m < 0 {
+				return m
+			}
+			packed = packed[m:]
+		}
+		return n
+	}
+	return errWireType
+}
+
+func consumeInts(typ protowire.Type, b []byte, v *[]int) int {
+	return consumePacked(typ, protowire.VarintType, b, func(b []byte) int {
+		x, n := protowire.ConsumeVarint(b)
+		*v = append(*v, int(int64(x)))
+		return n
+	})
+}
+
+func consumeDoubles(typ protowire.Type, b []byte, v *[]float64) int {
+	return consumePacked(typ, protowire.Fixed64Type, b, func(b []byte) int {
+		x, n := protowire.ConsumeFixed64(b)
+		*v = append(*v, math.Float64frombits(x))
+		return n
+	})
+}
+
+func consumeBools(typ protowire.Type, b []byte, v *[]bool) int {
+	return consumePacked(typ, protowire.VarintType, b, func(b []byte) int {
+		x, n := protowire.ConsumeVarint(b)
+		*v = append(*v, protowire.DecodeBool(x))
+		return n
+	})
+}
@@ -0,0 +1,133 @@
+package cbspb_test
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/cbspb"
+)
+
+func TestMarshalSegmentWireFormat(t *testing.T) {
+	// chrom = "chr1" (field 1, length-delimited), end = 100 (field 3, varint)
+	// and state = GAIN (field 14, varint); zero fields are omitted.
+	expected := []byte{0x0a, 4, 'c', 'h', 'r', '1', 0x18, 100, 0x70, 4}
+	got := cbspb.MarshalSegment(cbsgo.Segment{Chrom: "chr1", End: 100, State: cbsgo.Gain})
+	if !bytes.Equal(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %x\nGot: %x", expected, got)
+	}
+}
+
+func TestSegmentRoundTrip(t *testing.T) {
+	s := cbsgo.Segment{
+		Chrom: "chr7", Start: 1000, End: 5000, BinStart: 10, BinEnd: 50,
+		Mean: -0.75, SD: 0.2, SNPs: 12, BAF: 0.31, Allelic: cbsgo.LOH,
+		CellFraction: 0.6, CellFractionLower: 0.5, CellFractionUpper: 0.7,
+		State: cbsgo.Loss, SSE: 1.5, R2: 0.9, Cytoband: "p21.1-p15.3", Unexplored: true,
+		PopulationFrequency: 0.02, Spike: true,
+		BestSplit: &cbsgo.Candidate{
+			Chrom: "chr7", Start: 10, End: 50, SplitStart: 20, SplitEnd: 30, Depth: 2,
+			Stat: 3.5, PValue: 0.2, Decision: cbsgo.SplitInsignificant,
+		},
+		Focal: []cbsgo.FocalEvent{
+			{Chrom: "chr7", Start: 2000, End: 2300, BinStart: 20, BinEnd: 23, Mean: 1.1, Amplitude: 1.85},
+			{Chrom: "chr7", Start: 4000, End: 4200, BinStart: 40, BinEnd: 42, Mean: -2, Amplitude: -1.25},
+		},
+		Subsegments: []cbsgo.Segment{
+			{Chrom: "chr7", Start: 1000, End: 3000, BinStart: 10, BinEnd: 30, Mean: -0.5},
+			{Chrom: "chr7", Start: 3000, End: 5000, BinStart: 30, BinEnd: 50, Mean: -1, Spike: true},
+		},
+	}
+	got, err := cbspb.UnmarshalSegment(cbspb.MarshalSegment(s))
+	if err != nil {
+		t.Fatalf("UnmarshalSegment returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, s) {
+		t.Errorf("Unexpected result.\nExpected: %+v\nGot: %+v", s, got)
+	}
+
+	nan, err := cbspb.UnmarshalSegment(cbspb.MarshalSegment(cbsgo.Segment{Mean: math.NaN()}))
+	if err != nil || !math.IsNaN(nan.Mean) {
+		t.Errorf("Expected a NaN mean to survive, got %v, %v", nan.Mean, err)
+	}
+}
+
+func TestTrackRoundTrip(t *testing.T) {
+	track := &cbsgo.Track{
+		Chrom:   "chrX",
+		Starts:  []int{0, 100, 250},
+		Ends:    []int{100, 250, 400},
+		Values:  []float64{0.1, -2, 0},
+		Weights: []float64{1, 0.5, 2},
+		Mask:    []bool{false, true, false},
+	}
+	got, err := cbspb.UnmarshalTrack(cbspb.MarshalTrack(track))
+	if err != nil {
+		t.Fatalf("UnmarshalTrack returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, track) {
+		t.Errorf("Unexpected result.\nExpected: %+v\nGot: %+v", track, got)
+	}
+
+	// Repeated fields may also be encoded unpacked: values = [1.5, 2].
+	unpacked := []byte{
+		0x21, 0, 0, 0, 0, 0, 0, 0xf8, 0x3f,
+		0x21, 0, 0, 0, 0, 0, 0, 0, 0x40,
+	}
+	got, err = cbspb.UnmarshalTrack(unpacked)
+	if err != nil {
+		t.Fatalf("UnmarshalTrack returned an unexpected error: %v", err)
+	}
+	if expected := []float64{1.5, 2}; !reflect.DeepEqual(got.Values, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got.Values)
+	}
+}
+
+func TestRunRoundTrip(t *testing.T) {
+	run := &cbspb.Run{
+		ID:         "run-1",
+		Sample:     "tumor",
+		Version:    "v1.2.0",
+		Started:    time.Date(2024, 5, 6, 7, 8, 9, 10, time.UTC),
+		Duration:   1500 * time.Millisecond,
+		Parameters: map[string]string{"alpha": "0.01", "shuffles": "10000"},
+		Segments: []cbsgo.Segment{
+			{Chrom: "chr1", End: 100, Mean: 0.1},
+			{Chrom: "chr1", Start: 100, End: 300, BinStart: 100, BinEnd: 300, Mean: 1.2, State: cbsgo.Gain},
+		},
+	}
+	b := cbspb.MarshalRun(run)
+	got, err := cbspb.UnmarshalRun(b)
+	if err != nil {
+		t.Fatalf("UnmarshalRun returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, run) {
+		t.Errorf("Unexpected result.\nExpected: %+v\nGot: %+v", run, got)
+	}
+	if !bytes.Equal(cbspb.MarshalRun(got), b) {
+		t.Errorf("Expected equal runs to have equal encodings")
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	tests := map[string][]byte{
+		"truncated":  {0x0a, 10, 'c'},
+		"wire type":  {0x0d, 0, 0, 0, 0},
+		"bad tag":    {0x80},
+		"bad packed": {0x12, 1, 0x80},
+	}
+	for name, b := range tests {
+		if _, err := cbspb.UnmarshalTrack(b); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := cbspb.UnmarshalRun([]byte{0x3a, 2, 0x0d, 0}); err == nil {
+		t.Errorf("Expected an error for an invalid segment of a run")
+	}
+	if _, err := cbspb.UnmarshalSegment([]byte{0xb2, 0x01, 2, 0x0d, 0}); err == nil {
+		t.Errorf("Expected an error for an invalid focal event of a segment")
+	}
+}
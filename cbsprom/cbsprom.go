@@ -0,0 +1,90 @@
+// Package cbsprom exposes the statistics of cbsgo segmentation runs as
+// Prometheus metrics. It is a module of its own, so that only its users
+// depend on the Prometheus client library.
+package cbsprom
+
+import (
+	"github.com/mattdsm/cbsgo"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Recorder is a cbsgo.MetricsRecorder updating Prometheus metrics. Pass it
+// to cbsgo.WithMetrics and register it with a prometheus.Registerer.
+type Recorder struct {
+	runs         *prometheus.CounterVec
+	segments     prometheus.Counter
+	tests        prometheus.Counter
+	permutations prometheus.Counter
+	earlyStops   prometheus.Counter
+	duration     prometheus.Histogram
+}
+
+// NewRecorder returns a Recorder whose metrics are named with the given
+// namespace, such as "cbsgo".
+func NewRecorder(namespace string) *Recorder {
+	return &Recorder{
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "runs_total",
+			Help:      "Number of segmentation runs, by whether they were interrupted.",
+		}, []string{"interrupted"}),
+		segments: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "segments_total",
+			Help:      "Number of segments reported.",
+		}),
+		tests: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tests_total",
+			Help:      "Number of intervals tested for a changepoint.",
+		}),
+		permutations: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "permutations_total",
+			Help:      "Number of permutations performed.",
+		}),
+		earlyStops: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "early_stops_total",
+			Help:      "Number of permutation tests stopped early.",
+		}),
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "run_duration_seconds",
+			Help:      "Wall-clock time of segmentation runs.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+		}),
+	}
+}
+
+// Record updates the metrics with the statistics of a run.
+func (r *Recorder) Record(s cbsgo.RunStats) {
+	interrupted := "false"
+	if s.Interrupted {
+		interrupted = "true"
+	}
+	r.runs.WithLabelValues(interrupted).Inc()
+	r.segments.Add(float64(s.Segments))
+	r.tests.Add(float64(s.Tests))
+	r.permutations.Add(float64(s.Permutations))
+	r.earlyStops.Add(float64(s.EarlyStops))
+	r.duration.Observe(s.Duration.Seconds())
+}
+
+// Describe implements prometheus.Collector.
+func (r *Recorder) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range r.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (r *Recorder) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range r.collectors() {
+		c.Collect(ch)
+	}
+}
+
+func (r *Recorder) collectors() []prometheus.Collector {
+	return []prometheus.Collector{r.runs, r.segments, r.tests, r.permutations, r.earlyStops, r.duration}
+}
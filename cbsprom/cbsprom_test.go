@@ -0,0 +1,42 @@
+package cbsprom_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/cbsprom"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecorder(t *testing.T) {
+	r := cbsprom.NewRecorder("cbsgo")
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(r); err != nil {
+		t.Fatalf("Register returned an unexpected error: %v", err)
+	}
+
+	x := []float64{1, 1, 1, 3, 3, 2, 1, 2, 3, 300, 310, 321, 310, 299, 1, 2, 1, 2, 1, 1}
+	for range 2 {
+		if _, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithMetrics(r)); err != nil {
+			t.Fatalf("Run returned an unexpected error: %v", err)
+		}
+	}
+
+	n, err := testutil.GatherAndCount(reg, "cbsgo_runs_total", "cbsgo_segments_total", "cbsgo_run_duration_seconds")
+	if err != nil {
+		t.Fatalf("GatherAndCount returned an unexpected error: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("Expected 3 metrics, got %d", n)
+	}
+	expected := `
+# HELP cbsgo_segments_total Number of segments reported.
+# TYPE cbsgo_segments_total counter
+cbsgo_segments_total 6
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "cbsgo_segments_total"); err != nil {
+		t.Errorf("Unexpected metrics: %v", err)
+	}
+}
@@ -0,0 +1,246 @@
+package cbsgo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SampleTree is a hierarchical clustering of the samples of a cohort by their
+// copy number profiles, as returned by ClusterSamples.
+type SampleTree struct {
+	// Names holds the names of the samples.
+	Names []string
+	// Order lists the samples in the order of the leaves of the tree, such
+	// as for the rows of a heatmap.
+	Order []int
+
+	merges []merge
+}
+
+// BinMeans returns the copy number profile of a sample on the bins: the mean
+// of the segments of set overlapping every bin, weighted by their overlap, or
+// 0 for bins without segments.
+func BinMeans(set SegmentSet, bins SegmentSet) []float64 {
+	idx := newSegmentIndex(set)
+	means := make([]float64, len(bins))
+	for j, bin := range bins {
+		var sum, total float64
+		for _, s := range idx.overlapping(bin) {
+			ov := float64(min(s.End, bin.End) - max(s.Start, bin.Start))
+			sum += ov * s.Mean
+			total += ov
+		}
+		if total > 0 {
+			means[j] = sum / total
+		}
+	}
+	return means
+}
+
+// ClusterSamples clusters the segmentations of a cohort, one SegmentSet per
+// sample named by names, by average-linkage hierarchical clustering of their
+// profiles on the bins, such as those of Genome.Bins. The distance between two
+// samples is the root mean square difference of their BinMeans, in which
+// every bin counts by its length.
+func ClusterSamples(names []string, samples []SegmentSet, bins SegmentSet) (*SampleTree, error) {
+	if len(samples) == 0 || len(bins) == 0 {
+		return nil, errors.New("cbsgo: no samples or bins to cluster")
+	}
+	if len(names) != len(samples) {
+		return nil, fmt.Errorf("cbsgo: %d names for %d samples", len(names), len(samples))
+	}
+	var total float64
+	w := make([]float64, len(bins))
+	for i, b := range bins {
+		w[i] = float64(b.Len())
+		total += w[i]
+	}
+	if total == 0 {
+		return nil, errors.New("cbsgo: bins are empty")
+	}
+	for i := range w {
+		w[i] /= total
+	}
+	profiles := make([][]float64, len(samples))
+	for i, set := range samples {
+		profiles[i] = BinMeans(set, bins)
+	}
+
+	t := &SampleTree{Names: names, merges: averageLinkage(euclidean(profiles, w))}
+	var walk func(node int)
+	walk = func(node int) {
+		if node < len(names) {
+			t.Order = append(t.Order, node)
+			return
+		}
+		m := t.merges[node-len(names)]
+		walk(m.a)
+		walk(m.b)
+	}
+	walk(len(names) + len(t.merges) - 1)
+	return t, nil
+}
+
+// Cut assigns the samples to k clusters, numbered in order of their first
+// sample.
+func (t *SampleTree) Cut(k int) []int {
+	return cutTree(t.merges, len(t.Names), k)
+}
+
+// Newick returns the tree in Newick format, with branch lengths in units of
+// the distance between samples, such that the path between two samples is
+// as long as the distance at which they are joined. Names with spaces or
+// Newick punctuation are quoted.
+func (t *SampleTree) Newick() string {
+	n := len(t.Names)
+	height := func(node int) float64 {
+		if node < n {
+			return 0
+		}
+		return t.merges[node-n].height / 2
+	}
+	var b strings.Builder
+	var write func(node int)
+	write = func(node int) {
+		if node < n {
+			b.WriteString(newickName(t.Names[node]))
+			return
+		}
+		m := t.merges[node-n]
+		b.WriteByte('(')
+		for i, child := range []int{m.a, m.b} {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			write(child)
+			b.WriteString(":" + strconv.FormatFloat(height(node)-height(child), 'g', 6, 64))
+		}
+		b.WriteByte(')')
+	}
+	write(n + len(t.merges) - 1)
+	b.WriteByte(';')
+	return b.String()
+}
+
+// newickName quotes a name for the Newick format if needed.
+func newickName(name string) string {
+	if name != "" && !strings.ContainsAny(name, " \t()[]':;,") {
+		return name
+	}
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
+
+// merge records the joining of two clusters during hierarchical clustering.
+// Leaves are numbered 0 to n-1, and the cluster formed by the i-th merge is
+// numbered n+i.
+type merge struct {
+	a, b   int
+	height float64
+}
+
+// averageLinkage clusters n items hierarchically with average linkage (UPGMA)
+// given their symmetric pairwise distances, and returns the n-1 merges in
+// order of increasing height. Ties are broken by the lowest cluster numbers.
+func averageLinkage(dist [][]float64) []merge {
+	n := len(dist)
+	// d holds the distances between active clusters, indexed by the position
+	// of the clusters in ids.
+	d := make([][]float64, n)
+	for i := range d {
+		d[i] = append([]float64(nil), dist[i]...)
+	}
+	ids := make([]int, n)
+	sizes := make([]int, n)
+	for i := range ids {
+		ids[i], sizes[i] = i, 1
+	}
+
+	merges := make([]merge, 0, max(n-1, 0))
+	for len(ids) > 1 {
+		bi, bj, best := 0, 1, math.Inf(1)
+		for i := range ids {
+			for j := i + 1; j < len(ids); j++ {
+				if d[i][j] < best {
+					bi, bj, best = i, j, d[i][j]
+				}
+			}
+		}
+		merges = append(merges, merge{a: ids[bi], b: ids[bj], height: best})
+
+		// Replace cluster bi by the merged cluster and drop cluster bj.
+		for k := range ids {
+			if k == bi || k == bj {
+				continue
+			}
+			v := (d[bi][k]*float64(sizes[bi]) + d[bj][k]*float64(sizes[bj])) / float64(sizes[bi]+sizes[bj])
+			d[bi][k], d[k][bi] = v, v
+		}
+		ids[bi] = n + len(merges) - 1
+		sizes[bi] += sizes[bj]
+		ids = append(ids[:bj], ids[bj+1:]...)
+		sizes = append(sizes[:bj], sizes[bj+1:]...)
+		d = append(d[:bj], d[bj+1:]...)
+		for k := range d {
+			d[k] = append(d[k][:bj], d[k][bj+1:]...)
+		}
+	}
+	return merges
+}
+
+// cutTree assigns the n leaves of a hierarchical clustering to k clusters by
+// undoing the last k-1 merges. Clusters are numbered in order of their
+// first leaf.
+func cutTree(merges []merge, n, k int) []int {
+	parent := make([]int, n+len(merges))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i, m := range merges[:max(0, min(len(merges), n-k))] {
+		parent[find(m.a)] = n + i
+		parent[find(m.b)] = n + i
+	}
+
+	labels := make([]int, n)
+	ids := make(map[int]int)
+	for i := range labels {
+		root := find(i)
+		id, ok := ids[root]
+		if !ok {
+			id = len(ids)
+			ids[root] = id
+		}
+		labels[i] = id
+	}
+	return labels
+}
+
+// euclidean returns the pairwise Euclidean distances between the rows of x,
+// weighting column j by w[j].
+func euclidean(x [][]float64, w []float64) [][]float64 {
+	d := make([][]float64, len(x))
+	for i := range d {
+		d[i] = make([]float64, len(x))
+	}
+	for i := range x {
+		for j := i + 1; j < len(x); j++ {
+			sum := 0.0
+			for k := range x[i] {
+				diff := x[i][k] - x[j][k]
+				sum += w[k] * diff * diff
+			}
+			d[i][j] = math.Sqrt(sum)
+			d[j][i] = d[i][j]
+		}
+	}
+	return d
+}
@@ -0,0 +1,49 @@
+package cbsgo_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestBinMeans(t *testing.T) {
+	set := cbsgo.SegmentSet{{Chrom: "chr1", Start: 0, End: 150, Mean: 1}, {Chrom: "chr1", Start: 150, End: 200, Mean: -0.5}}
+	bins := cbsgo.Genome{{Name: "chr1", Length: 300}}.Bins(100)
+	expected := []float64{1, 0.25, 0}
+	if got := cbsgo.BinMeans(set, bins); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+	}
+}
+
+func TestClusterSamples(t *testing.T) {
+	genome := cbsgo.Genome{{Name: "chr1", Length: 1000}, {Name: "chr2", Length: 1000}}
+	gain := func(chrom string, mean float64) cbsgo.SegmentSet {
+		return cbsgo.SegmentSet{{Chrom: chrom, Start: 0, End: 1000, Mean: mean}}
+	}
+	names := []string{"a1", "b1", "a2", "b 2"}
+	samples := []cbsgo.SegmentSet{gain("chr1", 1), gain("chr2", 1), gain("chr1", 0.8), gain("chr2", 0.9)}
+	tree, err := cbsgo.ClusterSamples(names, samples, genome.Bins(100))
+	if err != nil {
+		t.Fatalf("ClusterSamples returned an unexpected error: %v", err)
+	}
+
+	if expected, got := []int{0, 1, 0, 1}, tree.Cut(2); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+	}
+	if got := tree.Order; !reflect.DeepEqual(got, []int{1, 3, 0, 2}) && !reflect.DeepEqual(got, []int{0, 2, 1, 3}) {
+		t.Errorf("Expected the samples of a cluster next to each other, got order %v", got)
+	}
+
+	// b1 and b 2 differ by 0.1 on half the genome, a root mean square of
+	// sqrt(0.01/2).
+	newick := tree.Newick()
+	if !strings.HasSuffix(newick, ";") || !strings.Contains(newick, "(b1:0.0353553,'b 2':0.0353553)") {
+		t.Errorf("Unexpected Newick tree %s", newick)
+	}
+
+	if _, err := cbsgo.ClusterSamples(names[:1], samples, genome.Bins(100)); err == nil {
+		t.Errorf("Expected an error for missing names")
+	}
+}
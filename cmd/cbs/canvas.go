@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+)
+
+// canvas is a drawing surface of a plot, with the origin at the top left and
+// coordinates in pixels, or points in PDF.
+type canvas interface {
+	// rect fills the rectangle [x0, x1) × [y0, y1) with c.
+	rect(x0, y0, x1, y1 float64, c color.RGBA)
+	// text writes s with its baseline starting at (x, y), in a font of the
+	// given size.
+	text(x, y, size float64, s string)
+	// encode writes the drawing to w.
+	encode(w io.Writer) error
+}
+
+// newCanvas returns a white canvas of the given size in the named format:
+// png, svg or pdf.
+func newCanvas(format string, width, height int) (canvas, error) {
+	switch format {
+	case "png":
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+		return &pngCanvas{img}, nil
+	case "svg":
+		c := &svgCanvas{}
+		fmt.Fprintf(&c.b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", width, height, width, height)
+		fmt.Fprintf(&c.b, `<rect width="%d" height="%d" fill="white"/>`+"\n", width, height)
+		return c, nil
+	case "pdf":
+		return &pdfCanvas{width: width, height: height}, nil
+	}
+	return nil, fmt.Errorf("unknown plot format %q", format)
+}
+
+// pngCanvas draws into an image, with text in the bitmap font of font.go
+// scaled to whole pixels.
+type pngCanvas struct {
+	img *image.RGBA
+}
+
+func (c *pngCanvas) rect(x0, y0, x1, y1 float64, col color.RGBA) {
+	r := image.Rect(int(math.Floor(x0)), int(math.Floor(y0)), int(math.Ceil(x1)), int(math.Ceil(y1)))
+	draw.Draw(c.img, r, &image.Uniform{col}, image.Point{}, draw.Src)
+}
+
+func (c *pngCanvas) text(x, y, size float64, s string) {
+	scale := max(1, math.Round(size/10))
+	top := y - glyphHeight*scale
+	for _, r := range s {
+		g := glyph(r)
+		for col, bits := range g {
+			for row := range glyphHeight {
+				if bits&(1<<row) != 0 {
+					px, py := x+float64(col)*scale, top+float64(row)*scale
+					c.rect(px, py, px+scale, py+scale, color.RGBA{A: 255})
+				}
+			}
+		}
+		x += (glyphWidth + 1) * scale
+	}
+}
+
+func (c *pngCanvas) encode(w io.Writer) error {
+	return png.Encode(w, c.img)
+}
+
+// svgCanvas writes SVG elements.
+type svgCanvas struct {
+	b strings.Builder
+}
+
+func (c *svgCanvas) rect(x0, y0, x1, y1 float64, col color.RGBA) {
+	fmt.Fprintf(&c.b, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="#%02x%02x%02x"/>`+"\n", x0, y0, x1-x0, y1-y0, col.R, col.G, col.B)
+}
+
+func (c *svgCanvas) text(x, y, size float64, s string) {
+	fmt.Fprintf(&c.b, `<text x="%.2f" y="%.2f" font-family="sans-serif" font-size="%g">`, x, y, size)
+	xmlEscaper.WriteString(&c.b, s)
+	c.b.WriteString("</text>\n")
+}
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func (c *svgCanvas) encode(w io.Writer) error {
+	_, err := io.WriteString(w, c.b.String()+"</svg>\n")
+	return err
+}
+
+// pdfCanvas writes the content stream of a single-page PDF document, with
+// text in Helvetica. PDF coordinates start at the bottom left, so y is
+// flipped.
+type pdfCanvas struct {
+	width, height int
+	b             strings.Builder
+	fill          color.RGBA
+	filled        bool
+}
+
+func (c *pdfCanvas) setFill(col color.RGBA) {
+	if c.filled && col == c.fill {
+		return
+	}
+	c.fill, c.filled = col, true
+	fmt.Fprintf(&c.b, "%.3f %.3f %.3f rg\n", float64(col.R)/255, float64(col.G)/255, float64(col.B)/255)
+}
+
+func (c *pdfCanvas) rect(x0, y0, x1, y1 float64, col color.RGBA) {
+	c.setFill(col)
+	fmt.Fprintf(&c.b, "%.2f %.2f %.2f %.2f re f\n", x0, float64(c.height)-y1, x1-x0, y1-y0)
+}
+
+func (c *pdfCanvas) text(x, y, size float64, s string) {
+	c.setFill(color.RGBA{A: 255})
+	fmt.Fprintf(&c.b, "BT /F1 %g Tf %.2f %.2f Td (%s) Tj ET\n", size, x, float64(c.height)-y, pdfEscaper.Replace(s))
+}
+
+var pdfEscaper = strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`)
+
+func (c *pdfCanvas) encode(w io.Writer) error {
+	content := c.b.String()
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>", c.width, c.height),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+	bw := bufio.NewWriter(w)
+	n, _ := bw.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = n
+		m, _ := fmt.Fprintf(bw, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+		n += m
+	}
+	fmt.Fprintf(bw, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(bw, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(bw, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, n)
+	return bw.Flush()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func cluster(args []string, stdout io.Writer) error {
+	fs := newFlagSet("cluster", "file...")
+	binSize := fs.Int("bin-size", 1_000_000, "size in base pairs of the bins on which the profiles are compared")
+	output := fs.String("o", "-", "output `file` of the tree in Newick format")
+	heatmapFile := fs.String("heatmap", "", "`file` to plot a heatmap of the samples in the order of the tree to")
+	format := fs.String("format", "", "heatmap `format`: png, svg or pdf (default from the file extension, or png)")
+	limit := fs.Float64("limit", 1, "segment mean at which the colors of the heatmap saturate, in both directions")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("cluster: expected one or more SEG files")
+	}
+	if *binSize < 1 {
+		return fmt.Errorf("cluster: bin size %d is not positive", *binSize)
+	}
+	if !(*limit > 0) {
+		return fmt.Errorf("cluster: limit %v is not positive", *limit)
+	}
+
+	names, samples, err := readSEGFiles(fs.Args())
+	if err != nil {
+		return err
+	}
+	tree, err := cbsgo.ClusterSamples(names, samples, cohortGenome(samples).Bins(*binSize))
+	if err != nil {
+		return err
+	}
+
+	if *heatmapFile != "" {
+		ordered := make([]cbsgo.SegmentSet, len(samples))
+		orderedNames := make([]string, len(names))
+		for i, j := range tree.Order {
+			ordered[i], orderedNames[i] = samples[j], names[j]
+		}
+		c, err := drawHeatmap(plotFormat(*format, *heatmapFile), orderedNames, ordered, *limit)
+		if err != nil {
+			return err
+		}
+		f, err := create(*heatmapFile, "none", stdout)
+		if err != nil {
+			return err
+		}
+		if err := firstError(c.encode(f), f.Close()); err != nil {
+			return err
+		}
+	}
+
+	out, err := create(*output, "", stdout)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, tree.Newick())
+	return firstError(err, out.Close())
+}
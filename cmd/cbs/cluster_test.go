@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCluster(t *testing.T) {
+	dir := t.TempDir()
+	segments := filepath.Join(dir, "cohort.seg")
+	input := "ID\tchrom\tloc.start\tloc.end\tnum.mark\tseg.mean\n" +
+		"a1\tchr1\t1\t1000\t10\t1\na1\tchr2\t1\t1000\t10\t0\n" +
+		"b1\tchr1\t1\t1000\t10\t0\nb1\tchr2\t1\t1000\t10\t-1\n" +
+		"a2\tchr1\t1\t1000\t10\t0.9\na2\tchr2\t1\t1000\t10\t0\n"
+	if err := os.WriteFile(segments, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	heatmap := filepath.Join(dir, "heatmap.svg")
+	var out bytes.Buffer
+	if err := run([]string{"cluster", "-bin-size", "100", "-heatmap", heatmap, segments}, &out); err != nil {
+		t.Fatalf("cluster returned an unexpected error: %v", err)
+	}
+	if expected := "((a1:0.0353553,a2:0.0353553):0.452473,b1:0.487829);\n"; out.String() != expected {
+		t.Errorf("Unexpected result.\nExpected: %q\nGot: %q", expected, out.String())
+	}
+	svg, err := os.ReadFile(heatmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b1, a1, a2 := bytes.Index(svg, []byte(">b1<")), bytes.Index(svg, []byte(">a1<")), bytes.Index(svg, []byte(">a2<"))
+	if a1 < 0 || !(a1 < a2 && a2 < b1) {
+		t.Errorf("Expected the heatmap rows in the order of the tree, got a1 at %d, a2 at %d and b1 at %d", a1, a2, b1)
+	}
+
+	if err := run([]string{"cluster", "-bin-size", "0", segments}, &out); err == nil {
+		t.Errorf("Expected an error for a zero bin size")
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/coverage"
+)
+
+// pipelineConfig is the configuration of "cbs run", read from a YAML or TOML
+// file with --config and overridden by command line flags.
+type pipelineConfig struct {
+	// Sample names the sample in the output files.
+	Sample string `yaml:"sample" toml:"sample"`
+	// BAM is the alignment file or URL of the sample, and Control an optional
+	// alignment file of a matched normal sample.
+	BAM     string `yaml:"bam" toml:"bam"`
+	Control string `yaml:"control" toml:"control"`
+	// Output is the directory receiving the results and intermediate files.
+	Output string `yaml:"output" toml:"output"`
+	// Compress is the compression of the output files: none, gzip or zstd.
+	Compress string `yaml:"compress" toml:"compress"`
+
+	Bins struct {
+		// Size is the bin size in base pairs, or 0 to select it from the
+		// depth of the sample, targeting ReadsPerBin reads per bin.
+		Size           int  `yaml:"size" toml:"size"`
+		ReadsPerBin    int  `yaml:"reads_per_bin" toml:"reads_per_bin"`
+		MinMapQ        int  `yaml:"min_mapq" toml:"min_mapq"`
+		KeepDuplicates bool `yaml:"keep_duplicates" toml:"keep_duplicates"`
+	} `yaml:"bins" toml:"bins"`
+
+	Normalize struct {
+		// Method is the normalization of the counts to log2 ratios; only
+		// "median" is supported, which centers the ratios on their median.
+		Method string `yaml:"method" toml:"method"`
+	} `yaml:"normalize" toml:"normalize"`
+
+	Segment struct {
+		Alpha    float64 `yaml:"alpha" toml:"alpha"`
+		Shuffles int     `yaml:"shuffles" toml:"shuffles"`
+		Seed     int64   `yaml:"seed" toml:"seed"`
+		Workers  int     `yaml:"workers" toml:"workers"`
+	} `yaml:"segment" toml:"segment"`
+
+	Call struct {
+		DeepDeletion  float64 `yaml:"deep_deletion" toml:"deep_deletion"`
+		Loss          float64 `yaml:"loss" toml:"loss"`
+		Gain          float64 `yaml:"gain" toml:"gain"`
+		Amplification float64 `yaml:"amplification" toml:"amplification"`
+	} `yaml:"call" toml:"call"`
+
+	Filter struct {
+		// Population is an optional BED file or URL of population CNV
+		// frequencies. Calls covered by at least the fraction Overlap of
+		// a population CNV with a frequency of at least Frequency are
+		// common germline CNVs, written apart from the other calls.
+		Population string  `yaml:"population" toml:"population"`
+		Frequency  float64 `yaml:"frequency" toml:"frequency"`
+		Overlap    float64 `yaml:"overlap" toml:"overlap"`
+	} `yaml:"filter" toml:"filter"`
+}
+
+// defaultPipelineConfig returns the configuration that the settings of the
+// configuration file override.
+func defaultPipelineConfig() *pipelineConfig {
+	c := &pipelineConfig{Sample: "sample", Output: ".", Compress: "none"}
+	bins := coverage.DefaultOptions()
+	c.Bins.Size, c.Bins.MinMapQ = bins.BinSize, int(bins.MinMapQ)
+	c.Bins.ReadsPerBin = 200
+	c.Normalize.Method = "median"
+	c.Segment.Alpha, c.Segment.Shuffles, c.Segment.Seed = 0.01, 10000, 1
+	t := cbsgo.DefaultCallThresholds()
+	c.Call.DeepDeletion, c.Call.Loss, c.Call.Gain, c.Call.Amplification = t.DeepDeletion, t.Loss, t.Gain, t.Amplification
+	c.Filter.Frequency, c.Filter.Overlap = 0.01, 0.5
+	return c
+}
+
+// configFlags registers the flags of "cbs run" that override the settings of
+// the configuration file.
+func configFlags(fs *flag.FlagSet) {
+	fs.String("config", "", "YAML or TOML configuration `file`")
+	fs.String("sample", "", "sample `name` (sample)")
+	fs.String("bam", "", "alignment `file` of the sample (bam)")
+	fs.String("control", "", "alignment `file` of a matched normal sample (control)")
+	fs.String("output", "", "output `directory` (output)")
+	fs.String("compress", "", "`compression` of the output files: none, gzip or zstd (compress)")
+	fs.String("bin-size", "", "bin size in base pairs, or auto to select it from the depth (bins.size)")
+	fs.String("reads-per-bin", "", "reads per bin targeted by -bin-size auto (bins.reads_per_bin)")
+	fs.String("alpha", "", "significance level (segment.alpha)")
+	fs.String("shuffles", "", "number of permutations (segment.shuffles)")
+	fs.String("seed", "", "random seed (segment.seed)")
+	fs.String("workers", "", "number of tracks segmented concurrently (segment.workers)")
+	fs.String("population", "", "BED `file` of population CNV frequencies to filter common CNVs with (filter.population)")
+}
+
+// loadPipelineConfig returns the configuration of the file named by the
+// -config flag of fs, if any, overridden by the other flags set in fs.
+func loadPipelineConfig(fs *flag.FlagSet) (*pipelineConfig, error) {
+	c := defaultPipelineConfig()
+	name := fs.Lookup("config").Value.String()
+	if name != "" {
+		if err := readConfig(name, c); err != nil {
+			return nil, err
+		}
+	}
+
+	var errs []error
+	fs.Visit(func(f *flag.Flag) {
+		v := f.Value.String()
+		var err error
+		switch f.Name {
+		case "sample":
+			c.Sample = v
+		case "bam":
+			c.BAM = v
+		case "control":
+			c.Control = v
+		case "output":
+			c.Output = v
+		case "compress":
+			c.Compress = v
+		case "bin-size":
+			if v == "auto" {
+				c.Bins.Size = 0
+			} else {
+				c.Bins.Size, err = strconv.Atoi(v)
+			}
+		case "reads-per-bin":
+			c.Bins.ReadsPerBin, err = strconv.Atoi(v)
+		case "alpha":
+			c.Segment.Alpha, err = strconv.ParseFloat(v, 64)
+		case "shuffles":
+			c.Segment.Shuffles, err = strconv.Atoi(v)
+		case "seed":
+			c.Segment.Seed, err = strconv.ParseInt(v, 10, 64)
+		case "workers":
+			c.Segment.Workers, err = strconv.Atoi(v)
+		case "population":
+			c.Filter.Population = v
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("-%s: invalid value %q", f.Name, v))
+		}
+	})
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	if err := c.validate(); err != nil {
+		if name != "" {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// readConfig reads the named YAML or TOML file into c, choosing the format
+// by the file extension. Unknown settings are an error.
+func readConfig(name string, c *pipelineConfig) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	switch ext := strings.ToLower(filepath.Ext(name)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), c)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("%s: unknown setting %s", name, undecoded[0])
+		}
+	default:
+		return fmt.Errorf("%s: unknown configuration format %q, expected .yaml, .yml or .toml", name, ext)
+	}
+	return nil
+}
+
+// validate checks the settings of c, reporting every invalid setting by its
+// path in the configuration file.
+func (c *pipelineConfig) validate() error {
+	var errs []error
+	check := func(ok bool, path, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+		}
+	}
+	check(c.BAM != "", "bam", "required")
+	check(c.Sample != "" && !strings.ContainsRune(c.Sample, filepath.Separator), "sample", "must be a non-empty file name, got %q", c.Sample)
+	_, err := cbsgo.ParseCompression(c.Compress)
+	check(err == nil, "compress", "must be none, gzip or zstd, got %q", c.Compress)
+	check(c.Bins.Size >= 0, "bins.size", "must be positive, or 0 to select it, got %d", c.Bins.Size)
+	check(c.Bins.Size > 0 || c.Bins.ReadsPerBin > 0, "bins.reads_per_bin", "must be positive to select the bin size, got %d", c.Bins.ReadsPerBin)
+	check(c.Bins.MinMapQ >= 0 && c.Bins.MinMapQ <= 255, "bins.min_mapq", "must be in [0, 255], got %d", c.Bins.MinMapQ)
+	check(c.Normalize.Method == "median", "normalize.method", "must be median, got %q", c.Normalize.Method)
+	check(c.Segment.Alpha > 0 && c.Segment.Alpha < 1, "segment.alpha", "must be in (0, 1), got %g", c.Segment.Alpha)
+	check(c.Segment.Shuffles > 0, "segment.shuffles", "must be positive, got %d", c.Segment.Shuffles)
+	check(c.Segment.Workers >= 0, "segment.workers", "must not be negative, got %d", c.Segment.Workers)
+	check(c.Call.DeepDeletion <= c.Call.Loss && c.Call.Loss < c.Call.Gain && c.Call.Gain <= c.Call.Amplification,
+		"call", "thresholds must satisfy deep_deletion <= loss < gain <= amplification")
+	check(c.Filter.Frequency > 0 && c.Filter.Frequency <= 1, "filter.frequency", "must be in (0, 1], got %g", c.Filter.Frequency)
+	check(c.Filter.Overlap > 0 && c.Filter.Overlap <= 1, "filter.overlap", "must be in (0, 1], got %g", c.Filter.Overlap)
+	return errors.Join(errs...)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// loadConfig writes content to a file of the given name and loads it with
+// the flags args.
+func loadConfig(t *testing.T, name, content string, args ...string) (*pipelineConfig, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fs := newFlagSet("run", "")
+	configFlags(fs)
+	if err := fs.Parse(append([]string{"--config", path}, args...)); err != nil {
+		t.Fatal(err)
+	}
+	return loadPipelineConfig(fs)
+}
+
+func TestConfig(t *testing.T) {
+	yaml := "bam: tumor.bam\nbins:\n  size: 20000\nsegment:\n  alpha: 0.05\n"
+	toml := "bam = \"tumor.bam\"\n\n[bins]\nsize = 20000\n\n[segment]\nalpha = 0.05\n"
+	for name, content := range map[string]string{"run.yaml": yaml, "run.yml": yaml, "run.toml": toml} {
+		c, err := loadConfig(t, name, content)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if c.BAM != "tumor.bam" || c.Bins.Size != 20000 || c.Segment.Alpha != 0.05 || c.Segment.Shuffles != 10000 {
+			t.Errorf("%s: unexpected configuration %+v", name, c)
+		}
+	}
+
+	c, err := loadConfig(t, "run.yaml", yaml, "-alpha", "0.001", "-bam", "other.bam")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if c.BAM != "other.bam" || c.Segment.Alpha != 0.001 || c.Bins.Size != 20000 {
+		t.Errorf("Flags do not override the configuration: %+v", c)
+	}
+
+	c, err = loadConfig(t, "run.yaml", yaml, "-bin-size", "auto", "-reads-per-bin", "500")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if c.Bins.Size != 0 || c.Bins.ReadsPerBin != 500 {
+		t.Errorf("Expected the bin size to be selected with 500 reads per bin: %+v", c)
+	}
+}
+
+func TestConfigInvalid(t *testing.T) {
+	tests := []struct {
+		name, content string
+		args          []string
+		message       string
+	}{
+		{"run.yaml", "bam: x.bam\nbins:\n  sise: 10\n", nil, "line 3: field sise not found"},
+		{"run.toml", "bam = \"x.bam\"\n[bins]\nsise = 10\n", nil, "unknown setting bins.sise"},
+		{"run.yaml", "bam: x.bam\nsegment:\n  shuffles: many\n", nil, "line 3"},
+		{"run.yaml", "sample: x\n", nil, "bam: required"},
+		{"run.yaml", "bam: x.bam\nsegment:\n  alpha: 2\n", nil, "segment.alpha: must be in (0, 1), got 2"},
+		{"run.yaml", "bam: x.bam\nbins:\n  size: 0\n  reads_per_bin: 0\n", nil, "bins.reads_per_bin: must be positive"},
+		{"run.yaml", "bam: x.bam\nnormalize:\n  method: quantile\n", nil, "normalize.method"},
+		{"run.yaml", "bam: x.bam\ncall:\n  gain: -1\n", nil, "call: thresholds"},
+		{"run.yaml", "bam: x.bam\nfilter:\n  frequency: 0\n", nil, "filter.frequency: must be in (0, 1], got 0"},
+		{"run.yaml", "bam: x.bam\n", []string{"-shuffles", "many"}, "-shuffles: invalid value"},
+		{"run.json", "{}", nil, "unknown configuration format"},
+	}
+	for _, tt := range tests {
+		_, err := loadConfig(t, tt.name, tt.content, tt.args...)
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%q: expected an error containing %q, got %v", tt.content, tt.message, err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/stream"
+)
+
+// consume implements "cbs consume", a service segmenting the series of a
+// NATS subject as their samples arrive and publishing their level shifts,
+// until interrupted.
+func consume(args []string, stdout io.Writer) error {
+	fs := newFlagSet("consume", "")
+	server := fs.String("nats", "nats://localhost:4222", "`URL` of the NATS server")
+	subject := fs.String("subject", "metrics.>", "`subject` of the samples, whose subjects name their series")
+	publish := fs.String("publish", "cbs.changepoints", "`subject` to publish the level shifts to, as JSON")
+	o := stream.DefaultMonitorOptions()
+	fs.IntVar(&o.Window, "window", o.Window, "number of samples kept per series")
+	fs.IntVar(&o.Hold, "hold", o.Hold, "number of samples at the new level confirming a level shift")
+	fs.Float64Var(&o.MinMagnitude, "min-magnitude", 0, "smallest absolute magnitude of the level shifts reported")
+	fs.DurationVar(&o.Idle, "idle", o.Idle, "time after which series without samples are dropped, 0 to keep them")
+	fs.Var((*seasons)(&o.Series.Seasons), "seasons", "comma-separated `periods` of the seasonal patterns to remove, such as 24h")
+	fs.DurationVar(&o.Interval, "interval", 0, "sampling interval of the series, needed by -seasons")
+	alpha := fs.Float64("alpha", 0.001, "significance level, lower than usual as every sample triggers a test")
+	shuffles := fs.Int("shuffles", 1000, "number of permutations")
+	seed := fs.Int64("seed", 1, "random seed, 0 for a time-based seed")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errors.New("consume: expected no arguments")
+	}
+	m, err := stream.NewMonitor(o, cbsgo.WithSeed(*seed), cbsgo.WithAlpha(*alpha), cbsgo.WithShuffles(*shuffles))
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	c, err := stream.DialNATS(ctx, *server)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	fmt.Fprintf(stdout, "consuming %s from %s\n", *subject, *server)
+	err = stream.ConsumeNATS(ctx, c, m, *subject, *publish)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
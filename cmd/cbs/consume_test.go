@@ -0,0 +1,18 @@
+package main
+
+import (
+	"io"
+	"testing"
+)
+
+func TestConsumeInvalid(t *testing.T) {
+	for _, args := range [][]string{
+		{"consume", "extra"},
+		{"consume", "-window", "10", "-hold", "6"},
+		{"consume", "-min-magnitude", "-1"},
+	} {
+		if err := run(args, io.Discard); err == nil {
+			t.Errorf("%q: expected an error", args)
+		}
+	}
+}
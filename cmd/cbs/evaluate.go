@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// summary is the machine-readable output of "cbs evaluate".
+type summary struct {
+	Tolerance      int     `json:"tolerance"`
+	TruePositives  int     `json:"true_positives"`
+	FalsePositives int     `json:"false_positives"`
+	FalseNegatives int     `json:"false_negatives"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+	F1             float64 `json:"f1"`
+
+	// The distribution of the absolute boundary errors in base pairs.
+	MeanError   float64 `json:"mean_abs_error"`
+	MedianError float64 `json:"median_abs_error"`
+	P90Error    float64 `json:"p90_abs_error"`
+	MaxError    float64 `json:"max_abs_error"`
+	Errors      []int   `json:"errors"`
+}
+
+// evaluate implements "cbs evaluate", which scores the breakpoints of a
+// segmentation in BED format against a truth BED.
+func evaluate(args []string, stdout io.Writer) error {
+	fs := newFlagSet("evaluate", "segments.bed")
+	truthFile := fs.String("truth", "", "`file` of the true segments in BED format (required)")
+	tolerance := fs.Int("tolerance", 10000, "largest matching distance between breakpoints in base pairs")
+	format := fs.String("format", "json", "output `format`: json or text")
+	minF1 := fs.Float64("min-f1", 0, "fail when the F1 score is below this value")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *truthFile == "" {
+		fs.Usage()
+		return errors.New("evaluate: expected -truth and one segmentation")
+	}
+	if *format != "json" && *format != "text" {
+		return fmt.Errorf("evaluate: unknown format %q", *format)
+	}
+
+	truth, err := readBED(*truthFile)
+	if err != nil {
+		return err
+	}
+	predicted, err := readBED(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	e := cbsgo.Evaluate(predicted, truth, *tolerance)
+	s := summarize(e)
+
+	if *format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(s)
+	} else {
+		_, err = fmt.Fprintf(stdout, "tolerance\t%d\ntrue positives\t%d\nfalse positives\t%d\nfalse negatives\t%d\n"+
+			"precision\t%.4f\nrecall\t%.4f\nF1\t%.4f\nmean abs error\t%.1f\nmedian abs error\t%.1f\n"+
+			"p90 abs error\t%.1f\nmax abs error\t%.0f\n",
+			s.Tolerance, s.TruePositives, s.FalsePositives, s.FalseNegatives,
+			s.Precision, s.Recall, s.F1, s.MeanError, s.MedianError, s.P90Error, s.MaxError)
+	}
+	if err != nil {
+		return err
+	}
+	if e.F1 < *minF1 {
+		return fmt.Errorf("evaluate: F1 score %.4f below %.4f", e.F1, *minF1)
+	}
+	return nil
+}
+
+func summarize(e cbsgo.Evaluation) summary {
+	s := summary{
+		Tolerance:      e.Tolerance,
+		TruePositives:  e.TruePositives,
+		FalsePositives: e.FalsePositives,
+		FalseNegatives: e.FalseNegatives,
+		Precision:      e.Precision,
+		Recall:         e.Recall,
+		F1:             e.F1,
+		Errors:         e.Errors,
+	}
+	if s.Errors == nil {
+		s.Errors = []int{}
+	}
+	if len(e.Errors) == 0 {
+		return s
+	}
+	errs := make([]float64, len(e.Errors))
+	for i, d := range e.Errors {
+		errs[i] = math.Abs(float64(d))
+		s.MeanError += errs[i] / float64(len(errs))
+	}
+	sort.Float64s(errs)
+	s.MedianError = quantile(errs, 0.5)
+	s.P90Error = quantile(errs, 0.9)
+	s.MaxError = errs[len(errs)-1]
+	return s
+}
+
+// quantile returns the q-quantile of sorted x, interpolating linearly.
+func quantile(x []float64, q float64) float64 {
+	pos := q * float64(len(x)-1)
+	i := int(pos)
+	if i+1 >= len(x) {
+		return x[len(x)-1]
+	}
+	return x[i] + (pos-float64(i))*(x[i+1]-x[i])
+}
+
+func readBED(name string) (cbsgo.SegmentSet, error) {
+	f, err := open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return cbsgo.ReadBED(f)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	dir := t.TempDir()
+	truth := filepath.Join(dir, "truth.bed")
+	segments := filepath.Join(dir, "segments.bed")
+	if err := os.WriteFile(truth, []byte("chr1\t0\t1000\t0\nchr1\t1000\t2000\t1\nchr1\t2000\t3000\t0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(segments, []byte("chr1\t0\t1010\t0\nchr1\t1010\t3000\t0.5\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := run([]string{"evaluate", "-truth", truth, "-tolerance", "50", segments}, &out); err != nil {
+		t.Fatalf("evaluate returned an unexpected error: %v", err)
+	}
+	var got summary
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("Invalid JSON output %q: %v", out.String(), err)
+	}
+	expected := summary{
+		Tolerance: 50, TruePositives: 1, FalseNegatives: 1, Precision: 1, Recall: 0.5, F1: 2.0 / 3,
+		MeanError: 10, MedianError: 10, P90Error: 10, MaxError: 10, Errors: []int{10},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %+v\nGot: %+v", expected, got)
+	}
+
+	out.Reset()
+	if err := run([]string{"evaluate", "-truth", truth, "-format", "text", segments}, &out); err != nil {
+		t.Fatalf("evaluate returned an unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "recall\t0.5000") {
+		t.Errorf("Unexpected text output %q", out.String())
+	}
+
+	if err := run([]string{"evaluate", "-truth", truth, "-min-f1", "0.9", segments}, &out); err == nil {
+		t.Errorf("Expected an error for a low F1 score")
+	}
+	if err := run([]string{"evaluate", segments}, &out); err == nil {
+		t.Errorf("Expected an error without truth")
+	}
+}
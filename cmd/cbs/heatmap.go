@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// Heatmap layout, in pixels.
+const (
+	heatmapWidth     = 1200
+	heatmapLabels    = 120
+	heatmapRowHeight = 12
+	heatmapLegend    = 60
+)
+
+func heatmap(args []string, stdout io.Writer) error {
+	fs := newFlagSet("heatmap", "file...")
+	output := fs.String("o", "-", "output `file`")
+	format := fs.String("format", "", "output `format`: png, svg or pdf (default from the file extension, or png)")
+	limit := fs.Float64("limit", 1, "segment mean at which the colors saturate, in both directions")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("heatmap: expected one or more SEG files")
+	}
+	if !(*limit > 0) {
+		return fmt.Errorf("heatmap: limit %v is not positive", *limit)
+	}
+
+	names, samples, err := readSEGFiles(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	c, err := drawHeatmap(plotFormat(*format, *output), names, samples, *limit)
+	if err != nil {
+		return err
+	}
+	out, err := create(*output, "none", stdout)
+	if err != nil {
+		return err
+	}
+	return firstError(c.encode(out), out.Close())
+}
+
+// readSEGFiles reads the samples of the named SEG files with readSEG.
+func readSEGFiles(files []string) ([]string, []cbsgo.SegmentSet, error) {
+	var (
+		names   []string
+		samples []cbsgo.SegmentSet
+	)
+	for _, name := range files {
+		f, err := open(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		n, s, err := readSEG(f)
+		f.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		names, samples = append(names, n...), append(samples, s...)
+	}
+	return names, samples, nil
+}
+
+// readSEG reads the segments of a SEG file, as written by SEGWriter, by
+// sample in order of appearance. Header and comment lines are skipped.
+func readSEG(r io.Reader) ([]string, []cbsgo.SegmentSet, error) {
+	var (
+		names   []string
+		samples []cbsgo.SegmentSet
+	)
+	sc := bufio.NewScanner(r)
+	header := true
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 6 {
+			return nil, nil, fmt.Errorf("line %d: expected 6 columns, got %d", line, len(fields))
+		}
+		start, err := strconv.Atoi(fields[2])
+		if err != nil && header {
+			header = false
+			continue
+		}
+		header = false
+		end, err2 := strconv.Atoi(fields[3])
+		bins, err3 := strconv.Atoi(fields[4])
+		mean, err4 := strconv.ParseFloat(fields[5], 64)
+		if err := firstError(err, err2, err3, err4); err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(names) == 0 || names[len(names)-1] != fields[0] {
+			names, samples = append(names, fields[0]), append(samples, nil)
+		}
+		i := len(samples) - 1
+		samples[i] = append(samples[i], cbsgo.Segment{Chrom: fields[1], Start: start - 1, End: end, BinEnd: bins, Mean: mean})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, err
+	}
+	return names, samples, nil
+}
+
+// cohortGenome returns the chromosomes of the segments of the samples in
+// order of first appearance, extending to the end of their last segment.
+func cohortGenome(samples []cbsgo.SegmentSet) cbsgo.Genome {
+	var genome cbsgo.Genome
+	index := make(map[string]int)
+	for _, set := range samples {
+		for _, s := range set {
+			i, ok := index[s.Chrom]
+			if !ok {
+				i = len(genome)
+				index[s.Chrom] = i
+				genome = append(genome, cbsgo.Chromosome{Name: s.Chrom})
+			}
+			genome[i].Length = max(genome[i].Length, s.End)
+		}
+	}
+	return genome
+}
+
+// drawHeatmap draws the segment means of the samples as rows of colors along
+// the genome of cohortGenome, separated into chromosomes, on a canvas of the
+// named format. Means are blue for losses and red for gains, saturating
+// at ±limit.
+func drawHeatmap(format string, names []string, samples []cbsgo.SegmentSet, limit float64) (canvas, error) {
+	if len(samples) == 0 {
+		return nil, errors.New("heatmap: no segments to plot")
+	}
+	genome := cohortGenome(samples)
+	index := make(map[string]int)
+	for i, c := range genome {
+		index[c.Name] = i
+	}
+	offsets := make([]float64, len(genome)+1)
+	for i, c := range genome {
+		offsets[i+1] = offsets[i] + float64(c.Length)
+	}
+	if offsets[len(genome)] == 0 {
+		return nil, errors.New("heatmap: no segments to plot")
+	}
+
+	height := plotMargin + len(samples)*heatmapRowHeight + heatmapLegend
+	c, err := newCanvas(format, heatmapWidth, height)
+	if err != nil {
+		return nil, err
+	}
+	x0, w := float64(heatmapLabels), float64(heatmapWidth-heatmapLabels-plotMargin/2)
+	y0, h := float64(plotMargin), float64(len(samples)*heatmapRowHeight)
+	posX := func(chrom int, pos int) float64 {
+		return x0 + w*(offsets[chrom]+float64(pos))/offsets[len(genome)]
+	}
+	for row, set := range samples {
+		y := y0 + float64(row*heatmapRowHeight)
+		c.text(4, y+heatmapRowHeight-2, 9, names[row])
+		for _, s := range set {
+			i := index[s.Chrom]
+			c.rect(posX(i, s.Start), y, posX(i, s.End), y+heatmapRowHeight, divergingColor(s.Mean, limit))
+		}
+	}
+	for i, chrom := range genome {
+		if i > 0 {
+			c.rect(posX(i, 0)-0.5, y0, posX(i, 0)+0.5, y0+h, black)
+		}
+		c.text((posX(i, 0)+posX(i, chrom.Length))/2-4, y0-6, 8, strings.TrimPrefix(chrom.Name, "chr"))
+	}
+	frame(c, x0, y0, w, h)
+
+	// The legend is a color bar from -limit to limit.
+	ly := y0 + h + 20
+	for i := range 100 {
+		v := limit * (float64(i)/50 - 1)
+		c.rect(x0+float64(2*i), ly, x0+float64(2*i+2), ly+10, divergingColor(v, limit))
+	}
+	c.text(x0, ly+22, 8, formatTick(-limit))
+	c.text(x0+96, ly+22, 8, "0")
+	c.text(x0+192, ly+22, 8, formatTick(limit))
+	return c, nil
+}
+
+// divergingColor returns the color of the value v: white for 0, shading to
+// full blue at -limit and below and to full red at limit and above. NaN is
+// gray.
+func divergingColor(v, limit float64) color.RGBA {
+	if math.IsNaN(v) {
+		return gray
+	}
+	f := min(math.Abs(v)/limit, 1)
+	shade := uint8(math.Round(255 * (1 - f)))
+	if v < 0 {
+		return color.RGBA{shade, shade, 0xff, 0xff}
+	}
+	return color.RGBA{0xff, shade, shade, 0xff}
+}
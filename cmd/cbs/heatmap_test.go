@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestReadSEG(t *testing.T) {
+	input := "# cbsgo version=(devel)\nID\tchrom\tloc.start\tloc.end\tnum.mark\tseg.mean\n" +
+		"s1\tchr1\t1\t100\t10\t0.5\ns1\tchr2\t1\t50\t5\t-1\ns2\tchr1\t1\t100\t10\t0\n"
+	names, samples, err := readSEG(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("readSEG returned an unexpected error: %v", err)
+	}
+	expected := []cbsgo.SegmentSet{
+		{{Chrom: "chr1", Start: 0, End: 100, BinEnd: 10, Mean: 0.5}, {Chrom: "chr2", Start: 0, End: 50, BinEnd: 5, Mean: -1}},
+		{{Chrom: "chr1", Start: 0, End: 100, BinEnd: 10, Mean: 0}},
+	}
+	if !reflect.DeepEqual(names, []string{"s1", "s2"}) || !reflect.DeepEqual(samples, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v %v\nGot: %v %v", []string{"s1", "s2"}, expected, names, samples)
+	}
+	if _, _, err := readSEG(strings.NewReader("s1\tchr1\t1\t100\n")); err == nil {
+		t.Errorf("Expected an error for missing columns")
+	}
+}
+
+func TestHeatmap(t *testing.T) {
+	dir := t.TempDir()
+	segments := filepath.Join(dir, "cohort.seg")
+	input := "ID\tchrom\tloc.start\tloc.end\tnum.mark\tseg.mean\n" +
+		"s1\tchr1\t1\t1000\t10\t1\ns1\tchr2\t1\t1000\t10\t0\n" +
+		"s2\tchr1\t1\t1000\t10\t-1\ns2\tchr2\t1\t1000\t10\t0.5\n"
+	if err := os.WriteFile(segments, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := run([]string{"heatmap", "-format", "svg", segments}, &out); err != nil {
+		t.Fatalf("heatmap returned an unexpected error: %v", err)
+	}
+	for _, s := range []string{">s1</text>", ">s2</text>", ">1</text>", ">2</text>", `fill="#ff0000"`, `fill="#0000ff"`} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("Expected %q in the SVG output", s)
+		}
+	}
+
+	output := filepath.Join(dir, "heatmap.png")
+	if err := run([]string{"heatmap", "-o", output, segments}, new(bytes.Buffer)); err != nil {
+		t.Fatalf("heatmap returned an unexpected error: %v", err)
+	}
+	f, err := os.Open(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("Invalid PNG output: %v", err)
+	}
+	// The first half of the first row is s1 on chr1, a gain.
+	x, y := heatmapLabels+100, plotMargin+heatmapRowHeight/2
+	if got := color.RGBAModel.Convert(img.At(x, y)); got != (color.RGBA{0xff, 0, 0, 0xff}) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", color.RGBA{0xff, 0, 0, 0xff}, got)
+	}
+
+	if err := run([]string{"heatmap", "-limit", "0", segments}, &out); err == nil {
+		t.Errorf("Expected an error for a zero limit")
+	}
+}
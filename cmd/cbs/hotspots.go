@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func hotspots(args []string, stdout io.Writer) error {
+	fs := newFlagSet("hotspots", "file...")
+	binSize := fs.Int("bin-size", 100_000, "size in base pairs of the windows in which breakpoints are counted")
+	minSamples := fs.Int("min-samples", 2, "smallest number of samples with a breakpoint in a hotspot")
+	alpha := fs.Float64("alpha", 0.05, "genome-wide significance level")
+	permutations := fs.Int("permutations", 1000, "number of permutations")
+	seed := fs.Int64("seed", 1, "random seed, 0 for a time-based seed")
+	output := fs.String("o", "-", "output BED `file`")
+	compress := compressFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("hotspots: expected one or more SEG files")
+	}
+	if *binSize < 1 {
+		return fmt.Errorf("hotspots: bin size %d is not positive", *binSize)
+	}
+
+	_, samples, err := readSEGFiles(fs.Args())
+	if err != nil {
+		return err
+	}
+	opts := cbsgo.HotspotOptions{MinSamples: *minSamples, Permutations: *permutations, Alpha: *alpha, Seed: *seed}
+	res, err := cbsgo.FindHotspots(samples, cohortGenome(samples).Bins(*binSize), opts)
+	if err != nil {
+		return err
+	}
+	out, err := create(*output, *compress, stdout)
+	if err != nil {
+		return err
+	}
+	return firstError(cbsgo.WriteHotspots(out, res), out.Close())
+}
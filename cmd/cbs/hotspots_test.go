@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHotspots(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("ID\tchrom\tloc.start\tloc.end\tnum.mark\tseg.mean\n")
+	for i := range 8 {
+		own := 1000 + 23000*i
+		fmt.Fprintf(&b, "s%d\tchr1\t1\t52000\t10\t0\ns%d\tchr1\t52001\t200000\t10\t1\n", i, i)
+		fmt.Fprintf(&b, "s%d\tchr2\t1\t%d\t10\t0\ns%d\tchr2\t%d\t200000\t10\t-1\n", i, own, i, own+1)
+	}
+	segments := filepath.Join(t.TempDir(), "cohort.seg")
+	if err := os.WriteFile(segments, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := run([]string{"hotspots", "-bin-size", "10000", segments}, &out); err != nil {
+		t.Fatalf("hotspots returned an unexpected error: %v", err)
+	}
+	if expected := "chr1\t50000\t60000\t8\t1000\n"; out.String() != expected {
+		t.Errorf("Unexpected result.\nExpected: %q\nGot: %q", expected, out.String())
+	}
+	if err := run([]string{"hotspots", "-bin-size", "0", segments}, &out); err == nil {
+		t.Errorf("Expected an error for a zero bin size")
+	}
+}
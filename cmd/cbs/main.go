@@ -0,0 +1,183 @@
+// Command cbs segments copy number profiles with cbsgo.
+//
+// Usage:
+//
+//	cbs <command> [flags]
+//
+// The commands are:
+//
+//	simulate  generate synthetic profiles with known changes
+//	evaluate  score a segmentation against true segments
+//	run       count, normalize, segment and call a BAM file
+//	segment   segment values read from a file, stdin or Prometheus
+//	plot      plot values and their segments to PNG, SVG or PDF
+//	heatmap   plot the segments of many samples to PNG, SVG or PDF
+//	cluster   cluster samples by their segments into a Newick tree
+//	hotspots  find breakpoints recurring across samples
+//	consume   report level shifts of series streamed from NATS
+//	serve     segment jobs submitted over HTTP
+//
+// Run "cbs <command> -h" for the flags of a command. Input files may be
+// compressed with gzip or zstd, and given as s3://, gs:// or http(s):// URLs.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/blob"
+)
+
+// command is a subcommand of cbs.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout io.Writer) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"simulate", "generate synthetic profiles with known changes", simulate},
+		{"evaluate", "score a segmentation against true segments", evaluate},
+		{"run", "count, normalize, segment and call a BAM file", pipeline},
+		{"segment", "segment values read from a file, stdin or Prometheus", segment},
+		{"plot", "plot values and their segments to PNG, SVG or PDF", plot},
+		{"heatmap", "plot the segments of many samples to PNG, SVG or PDF", heatmap},
+		{"cluster", "cluster samples by their segments into a Newick tree", cluster},
+		{"hotspots", "find breakpoints recurring across samples", hotspots},
+		{"consume", "report level shifts of series streamed from NATS", consume},
+		{"serve", "segment jobs submitted over HTTP", serve},
+	}
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "cbs:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+// run runs the command named by args[0] with the remaining arguments.
+func run(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		usage(os.Stderr)
+		return flag.ErrHelp
+	}
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(args[1:], stdout)
+		}
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(stdout)
+		return nil
+	}
+	return fmt.Errorf("unknown command %q; run \"cbs help\" for a list", args[0])
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: cbs <command> [flags]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s%s\n", c.name, c.summary)
+	}
+}
+
+// newFlagSet returns a flag set for the named command that reports errors
+// instead of exiting.
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: cbs %s [flags] %s\n\nFlags:\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// stdin is the input of commands reading from "-".
+var stdin io.Reader = os.Stdin
+
+// open opens the named file or URL for reading, or returns stdin for "-",
+// decompressing gzip and zstd input. See blob.Open for the supported URLs.
+func open(name string) (io.ReadCloser, error) {
+	var f io.ReadCloser = io.NopCloser(stdin)
+	if name != "-" {
+		var err error
+		if f, err = blob.Open(context.Background(), name); err != nil {
+			return nil, err
+		}
+	}
+	r, err := cbsgo.Decompress(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return readCloser{r, f}, nil
+}
+
+// compressFlag registers the -compress flag of commands writing files.
+func compressFlag(fs *flag.FlagSet) *string {
+	return fs.String("compress", "", "`compression` of the output: none, gzip or zstd (default from the file extension)")
+}
+
+// create opens the named file for writing, or returns stdout for "-" and the
+// empty name. The output is compressed as named by compression, or when it is
+// empty as implied by the file extension.
+func create(name, compression string, stdout io.Writer) (io.WriteCloser, error) {
+	c := cbsgo.CompressionOf(name)
+	if compression != "" {
+		var err error
+		if c, err = cbsgo.ParseCompression(compression); err != nil {
+			return nil, err
+		}
+	}
+	var f io.WriteCloser = nopCloser{stdout}
+	if name != "" && name != "-" {
+		var err error
+		if f, err = os.Create(name); err != nil {
+			return nil, err
+		}
+	}
+	w, err := cbsgo.NewCompressedWriter(f, c)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return writeCloser{w, f}, nil
+}
+
+// readCloser closes a decompressor and the file it reads.
+type readCloser struct {
+	io.ReadCloser
+	f io.Closer
+}
+
+func (r readCloser) Close() error {
+	return firstError(r.ReadCloser.Close(), r.f.Close())
+}
+
+// writeCloser closes a compressor and the file it writes.
+type writeCloser struct {
+	io.WriteCloser
+	f io.Closer
+}
+
+func (w writeCloser) Close() error {
+	return firstError(w.WriteCloser.Close(), w.f.Close())
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
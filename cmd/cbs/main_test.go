@@ -0,0 +1,25 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"help"}, &out); err != nil {
+		t.Fatalf("run returned an unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "simulate") {
+		t.Errorf("Expected the commands in the usage, got %q", out.String())
+	}
+	if err := run([]string{"unknown"}, &out); err == nil {
+		t.Errorf("Expected an error for an unknown command")
+	}
+	if err := run(nil, &out); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Expected flag.ErrHelp without a command, got %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/blob"
+	"github.com/mattdsm/cbsgo/coverage"
+	"github.com/mattdsm/cbsgo/normalize"
+)
+
+// pipeline implements "cbs run", which counts the reads of a BAM file in
+// bins, normalizes the counts to log2 ratios, segments them and calls copy
+// number states. It is configured by a configuration file, given with
+// --config or as the only argument, and by flags overriding its settings.
+// The result of every step is written to the output directory, with a .gz or
+// .zst extension when compressed:
+//
+//	<sample>.counts.bedgraph  read counts per bin
+//	<sample>.log2.bedgraph    normalized log2 ratios of the unmasked bins
+//	<sample>.seg              segments in SEG format
+//	<sample>.calls.bed        segments with their copy number state
+//	<sample>.common.bed       calls of common germline CNVs, left out of the
+//	                          calls, with filter.population
+//	<sample>.summary.tsv      fractions of the genome gained and lost,
+//	                          breakpoints and genome instability index
+func pipeline(args []string, stdout io.Writer) error {
+	fs := newFlagSet("run", "[config]")
+	configFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch {
+	case fs.NArg() == 1 && fs.Lookup("config").Value.String() == "":
+		fs.Set("config", fs.Arg(0))
+	case fs.NArg() > 0:
+		fs.Usage()
+		return errors.New("run: expected at most one configuration file")
+	}
+	c, err := loadPipelineConfig(fs)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.Output, 0o755); err != nil {
+		return err
+	}
+	compression, _ := cbsgo.ParseCompression(c.Compress)
+	extension := map[cbsgo.Compression]string{cbsgo.Gzip: ".gz", cbsgo.Zstd: ".zst"}[compression]
+	out := func(suffix string) string {
+		return filepath.Join(c.Output, c.Sample+suffix+extension)
+	}
+
+	opts := coverage.Options{
+		BinSize:        c.Bins.Size,
+		ReadsPerBin:    c.Bins.ReadsPerBin,
+		MinMapQ:        byte(c.Bins.MinMapQ),
+		KeepDuplicates: c.Bins.KeepDuplicates,
+	}
+	counts, err := countFile(c.BAM, opts)
+	if err != nil {
+		return err
+	}
+	if opts.BinSize == 0 {
+		// The control is counted in the bins selected for the sample.
+		opts.BinSize = binSize(counts)
+		if _, err := fmt.Fprintf(stdout, "%s: selected bins of %d bp\n", c.Sample, opts.BinSize); err != nil {
+			return err
+		}
+	}
+	if err := writeTracks(out(".counts.bedgraph"), counts); err != nil {
+		return err
+	}
+	var control []*cbsgo.Track
+	if c.Control != "" {
+		if control, err = countFile(c.Control, opts); err != nil {
+			return err
+		}
+	}
+	ratios, err := normalize.Log2Ratios(counts, control)
+	if err != nil {
+		return err
+	}
+	if err := writeTracks(out(".log2.bedgraph"), ratios); err != nil {
+		return err
+	}
+
+	thresholds := cbsgo.CallThresholds{
+		DeepDeletion:  c.Call.DeepDeletion,
+		Loss:          c.Call.Loss,
+		Gain:          c.Call.Gain,
+		Amplification: c.Call.Amplification,
+	}
+	res, err := cbsgo.SegmentGenome(context.Background(), ratios,
+		cbsgo.WithAlpha(c.Segment.Alpha),
+		cbsgo.WithShuffles(c.Segment.Shuffles),
+		cbsgo.WithSeed(c.Segment.Seed),
+		cbsgo.WithSample(c.Sample),
+		cbsgo.WithWorkers(c.Segment.Workers),
+		cbsgo.WithCalls(thresholds))
+	if err != nil {
+		return err
+	}
+
+	f, err := create(out(".seg"), "", nil)
+	if err != nil {
+		return err
+	}
+	if err := writeSegments(cbsgo.NewSEGWriter(f, c.Sample, true), res.Segments, f); err != nil {
+		return err
+	}
+	calls := []cbsgo.Segment(res.Segments)
+	if c.Filter.Population != "" {
+		population, err := readPopulation(c.Filter.Population)
+		if err != nil {
+			return err
+		}
+		var common []cbsgo.Segment
+		calls, common = population.Filter(population.Annotate(calls, c.Filter.Overlap), c.Filter.Overlap, c.Filter.Frequency)
+		if f, err = create(out(".common.bed"), "", nil); err != nil {
+			return err
+		}
+		if err := writeSegments(cbsgo.NewBEDWriter(f), common, f); err != nil {
+			return err
+		}
+	}
+	if f, err = create(out(".calls.bed"), "", nil); err != nil {
+		return err
+	}
+	if err := writeSegments(cbsgo.NewBEDWriter(f), calls, f); err != nil {
+		return err
+	}
+	if f, err = create(out(".summary.tsv"), "", nil); err != nil {
+		return err
+	}
+	if err := writeSummary(f, c.Sample, cbsgo.SummarizeAlterations(res.Segments)); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(stdout, "%s: %d segments in %d tracks written to %s\n", c.Sample, len(res.Segments), len(ratios), c.Output)
+	return err
+}
+
+// readPopulation reads the population CNVs of the named file or URL.
+func readPopulation(name string) (*cbsgo.PopulationCNVs, error) {
+	f, err := open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	p, err := cbsgo.ReadPopulationCNVs(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return p, nil
+}
+
+// writeSummary writes the alteration summary of a sample to f as a header
+// line and a row, so that the summaries of a batch can be concatenated, and
+// closes f.
+func writeSummary(f io.WriteCloser, sample string, a cbsgo.AlterationSummary) error {
+	_, err := fmt.Fprintf(f, "sample\tgained_fraction\tlost_fraction\taltered_fraction\tbreakpoints\twgii\n%s\t%.4f\t%.4f\t%.4f\t%d\t%.4f\n",
+		sample, a.Gained, a.Lost, a.Altered(), a.Breakpoints, a.WGII)
+	return firstError(err, f.Close())
+}
+
+// countFile counts the reads of the named BAM file or URL.
+func countFile(name string, opts coverage.Options) ([]*cbsgo.Track, error) {
+	f, err := blob.Open(context.Background(), name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tracks, err := coverage.CountBAM(bufio.NewReader(f), opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return tracks, nil
+}
+
+// binSize returns the size of the bins of tracks, which is that of their
+// largest bin as only the last bin of a track may be shorter.
+func binSize(tracks []*cbsgo.Track) int {
+	size := 0
+	for _, t := range tracks {
+		if len(t.Values) > 0 {
+			size = max(size, t.Ends[0]-t.Starts[0])
+		}
+	}
+	return size
+}
+
+// writeTracks writes the unmasked bins of tracks to the named file in
+// bedGraph format.
+func writeTracks(name string, tracks []*cbsgo.Track) error {
+	f, err := create(name, "", nil)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	for _, t := range tracks {
+		for i, v := range t.Values {
+			if t.Mask == nil || !t.Mask[i] {
+				fmt.Fprintf(bw, "%s\t%d\t%d\t%g\n", t.Chrom, t.Starts[i], t.Ends[i], v)
+			}
+		}
+	}
+	return firstError(bw.Flush(), f.Close())
+}
+
+// writeSegments writes segments with w, then closes w and f.
+func writeSegments(w cbsgo.SegmentWriter, segments []cbsgo.Segment, f io.Closer) error {
+	for _, s := range segments {
+		if err := w.Write(s); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return firstError(w.Close(), f.Close())
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/biogo/hts/bam"
+	"github.com/biogo/hts/sam"
+)
+
+// writeBAM writes a BAM file of a chromosome of 100 bins of 10 kb with
+// depth reads of 10 bp starting in every bin, and 1.5 times as many in the
+// bins [40, 60).
+func writeBAM(t *testing.T, name string, depth int) {
+	t.Helper()
+	ref, err := sam.NewReference("chr1", "", "", 1_000_000, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := sam.NewHeader(nil, []*sam.Reference{ref})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w, err := bam.NewWriter(f, h, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq, qual := []byte("ACGTACGTAC"), bytes.Repeat([]byte{30}, 10)
+	cigar := []sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 10)}
+	for bin := range 100 {
+		n := depth + bin%7 - 3
+		if bin >= 40 && bin < 60 {
+			n = n * 3 / 2
+		}
+		for i := range n {
+			rec, err := sam.NewRecord(fmt.Sprintf("r%d.%d", bin, i), ref, nil, bin*10_000+i*10_000/n, -1, 0, 60, cigar, seq, qual, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Write(rec); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	dir := t.TempDir()
+	writeBAM(t, filepath.Join(dir, "tumor.bam"), 100)
+	config := filepath.Join(dir, "run.yaml")
+	yaml := fmt.Sprintf("sample: tumor\nbam: %s\noutput: %s\nbins:\n  size: 10000\nsegment:\n  shuffles: 1000\n",
+		filepath.Join(dir, "tumor.bam"), filepath.Join(dir, "out"))
+	if err := os.WriteFile(config, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := run([]string{"run", config}, &out); err != nil {
+		t.Fatalf("run returned an unexpected error: %v", err)
+	}
+	for _, suffix := range []string{".counts.bedgraph", ".log2.bedgraph", ".seg"} {
+		if _, err := os.Stat(filepath.Join(dir, "out", "tumor"+suffix)); err != nil {
+			t.Errorf("Missing output: %v", err)
+		}
+	}
+	calls, err := os.ReadFile(filepath.Join(dir, "out", "tumor.calls.bed"))
+	if err != nil {
+		t.Fatalf("Missing calls: %v", err)
+	}
+	var states []string
+	for _, line := range strings.Split(strings.TrimSpace(string(calls)), "\n") {
+		fields := strings.Split(line, "\t")
+		states = append(states, fields[len(fields)-1])
+	}
+	if expected := []string{"neutral", "gain", "neutral"}; !reflect.DeepEqual(states, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %q", expected, calls)
+	}
+	summary, err := os.ReadFile(filepath.Join(dir, "out", "tumor.summary.tsv"))
+	if err != nil {
+		t.Fatalf("Missing summary: %v", err)
+	}
+	expected := "sample\tgained_fraction\tlost_fraction\taltered_fraction\tbreakpoints\twgii\ntumor\t0.2100\t0.0000\t0.2100\t2\t0.2100\n"
+	if string(summary) != expected {
+		t.Errorf("Unexpected result.\nExpected: %q\nGot: %q", expected, summary)
+	}
+
+	// Flags override the configuration, here to compress the output.
+	if err := run([]string{"run", "-compress", "gzip", "-output", filepath.Join(dir, "gz"), config}, &out); err != nil {
+		t.Fatalf("run returned an unexpected error: %v", err)
+	}
+	f, err := open(filepath.Join(dir, "gz", "tumor.calls.bed.gz"))
+	if err != nil {
+		t.Fatalf("Missing compressed calls: %v", err)
+	}
+	defer f.Close()
+	if got, err := io.ReadAll(f); err != nil || !bytes.Equal(got, calls) {
+		t.Errorf("Unexpected result.\nExpected: %q\nGot: %q, %v", calls, got, err)
+	}
+
+	// A common germline CNV at the gain moves it out of the calls.
+	gain := strings.Split(strings.Split(strings.TrimSpace(string(calls)), "\n")[1], "\t")
+	population := filepath.Join(dir, "population.bed")
+	if err := os.WriteFile(population, []byte(strings.Join(append(gain[:3], "0.3"), "\t")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := run([]string{"run", "-population", population, "-output", filepath.Join(dir, "filtered"), config}, &out); err != nil {
+		t.Fatalf("run returned an unexpected error: %v", err)
+	}
+	common, err := os.ReadFile(filepath.Join(dir, "filtered", "tumor.common.bed"))
+	if err != nil {
+		t.Fatalf("Missing common CNVs: %v", err)
+	}
+	filtered, err := os.ReadFile(filepath.Join(dir, "filtered", "tumor.calls.bed"))
+	if err != nil {
+		t.Fatalf("Missing calls: %v", err)
+	}
+	if !strings.HasSuffix(string(common), "\tgain\n") || strings.Count(string(common), "\n") != 1 || strings.Contains(string(filtered), "gain") {
+		t.Errorf("Expected the gain among the common CNVs, got %q and calls %q", common, filtered)
+	}
+
+	// The bin size is selected for about 1000 reads of 10 bp per bin, at a
+	// depth of about 0.11.
+	out.Reset()
+	if err := run([]string{"run", "-bin-size", "auto", "-reads-per-bin", "1000", "-output", filepath.Join(dir, "auto"), config}, &out); err != nil {
+		t.Fatalf("run returned an unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "tumor: selected bins of 91000 bp") {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+
+	for name, yaml := range map[string]string{
+		"no bam":        "sample: x\n",
+		"unknown field": "bam: x.bam\nbins:\n  sise: 10\n",
+		"missing bam":   "bam: " + filepath.Join(dir, "missing.bam") + "\n",
+	} {
+		if err := os.WriteFile(config, []byte(yaml), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := run([]string{"run", config}, &out); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
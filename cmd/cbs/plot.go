@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// Plot layout, in pixels.
+const (
+	plotWidth      = 1200
+	plotMargin     = 40
+	plotColumns    = 4
+	overviewHeight = 220
+	panelHeight    = 160
+	panelGap       = 50
+	bafFraction    = 0.3
+)
+
+var (
+	// segmentColors colors the points of consecutive segments.
+	segmentColors = []color.RGBA{
+		{0x1f, 0x77, 0xb4, 0xff}, {0xff, 0x7f, 0x0e, 0xff}, {0x2c, 0xa0, 0x2c, 0xff},
+		{0xd6, 0x27, 0x28, 0xff}, {0x94, 0x67, 0xbd, 0xff}, {0x8c, 0x56, 0x4b, 0xff},
+	}
+	gray  = color.RGBA{0xa0, 0xa0, 0xa0, 0xff}
+	black = color.RGBA{0, 0, 0, 0xff}
+)
+
+func plot(args []string, stdout io.Writer) error {
+	fs := newFlagSet("plot", "file|-")
+	chrom := fs.String("chrom", "chr1", "chromosome `name` of input without chromosome column")
+	segments := fs.String("segments", "", "BED `file` of the segments, as written by segment; the input is segmented when empty")
+	baf := fs.String("baf", "", "`file` of B-allele frequencies to plot below the values, in the layouts of the input")
+	output := fs.String("o", "-", "output `file`")
+	format := fs.String("format", "", "output `format`: png, svg or pdf (default from the file extension, or png)")
+	alpha := fs.Float64("alpha", 0.01, "significance level")
+	shuffles := fs.Int("shuffles", 10000, "number of permutations")
+	seed := fs.Int64("seed", 1, "random seed, 0 for a time-based seed")
+	coordinates := fs.String("coordinates", "default", "coordinate `convention` of the input columns: default, 0-based or 1-based")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	conv, err := cbsgo.ParseConvention(*coordinates)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("plot: expected one input file, or - for stdin")
+	}
+	tracks, err := readValuesFile(fs.Arg(0), *chrom, conv)
+	if err != nil {
+		return err
+	}
+	var set cbsgo.SegmentSet
+	if *segments != "" {
+		if set, err = readBED(*segments); err != nil {
+			return fmt.Errorf("%s: %w", *segments, err)
+		}
+	} else {
+		res, err := cbsgo.SegmentGenome(context.Background(), tracks, cbsgo.WithSeed(*seed), cbsgo.WithAlpha(*alpha), cbsgo.WithShuffles(*shuffles))
+		if err != nil {
+			return err
+		}
+		set = res.Segments
+	}
+	var bafs []*cbsgo.Track
+	if *baf != "" {
+		if bafs, err = readValuesFile(*baf, *chrom, conv); err != nil {
+			return err
+		}
+	}
+
+	c, err := drawPlot(plotFormat(*format, *output), newPlotChroms(tracks, set, bafs))
+	if err != nil {
+		return err
+	}
+	out, err := create(*output, "none", stdout)
+	if err != nil {
+		return err
+	}
+	return firstError(c.encode(out), out.Close())
+}
+
+// plotFormat returns the plot format, or when it is empty that implied by the
+// extension of the output file, png by default.
+func plotFormat(format, output string) string {
+	if format != "" {
+		return format
+	}
+	if ext := strings.ToLower(filepath.Ext(output)); ext == ".svg" || ext == ".pdf" {
+		return ext[1:]
+	}
+	return "png"
+}
+
+// readValuesFile reads the tracks of the named file with readValues.
+func readValuesFile(name, chrom string, conv cbsgo.Convention) ([]*cbsgo.Track, error) {
+	in, err := open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	tracks, err := readValues(in, chrom, conv)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return tracks, nil
+}
+
+// plotChrom holds what is plotted of one chromosome.
+type plotChrom struct {
+	track    *cbsgo.Track
+	segments []cbsgo.Segment
+	baf      *cbsgo.Track
+	// span is the extent of the chromosome: the end of its last bin, or its
+	// number of bins when the positions are unknown.
+	span float64
+}
+
+// newPlotChroms matches the segments and B-allele frequencies to the tracks
+// by chromosome.
+func newPlotChroms(tracks []*cbsgo.Track, segments cbsgo.SegmentSet, bafs []*cbsgo.Track) []plotChrom {
+	chroms := make([]plotChrom, len(tracks))
+	for i, t := range tracks {
+		p := plotChrom{track: t, span: float64(t.Len())}
+		if t.Ends != nil && t.Len() > 0 {
+			p.span = float64(t.Ends[t.Len()-1])
+		}
+		for _, s := range segments {
+			if s.Chrom == t.Chrom {
+				p.segments = append(p.segments, s)
+			}
+		}
+		slices.SortFunc(p.segments, func(a, b cbsgo.Segment) int { return a.Start - b.Start })
+		for _, b := range bafs {
+			if b.Chrom == t.Chrom {
+				p.baf = b
+			}
+		}
+		chroms[i] = p
+	}
+	return chroms
+}
+
+// position returns the position of bin i of t on the x axis.
+func position(t *cbsgo.Track, i int) float64 {
+	if t.Starts != nil {
+		return float64(t.Starts[i]+t.Ends[i]) / 2
+	}
+	return float64(i) + 0.5
+}
+
+// drawPlot draws a genome overview of the chromosomes above a grid of panels
+// of every chromosome, on a canvas of the named format.
+func drawPlot(format string, chroms []plotChrom) (canvas, error) {
+	if len(chroms) == 0 {
+		return nil, errors.New("plot: no values to plot")
+	}
+	rows := (len(chroms) + plotColumns - 1) / plotColumns
+	height := plotMargin + overviewHeight + rows*(panelHeight+panelGap) + plotMargin
+	c, err := newCanvas(format, plotWidth, height)
+	if err != nil {
+		return nil, err
+	}
+
+	lo, hi := valueRange(chroms)
+	withBAF := slices.ContainsFunc(chroms, func(p plotChrom) bool { return p.baf != nil })
+	inner := float64(plotWidth - 2*plotMargin)
+	drawPanel(c, plotMargin, plotMargin, inner, overviewHeight, chroms, "genome", lo, hi, withBAF)
+	width := (inner - float64(plotColumns-1)*panelGap/2) / plotColumns
+	for i, p := range chroms {
+		x := plotMargin + float64(i%plotColumns)*(width+panelGap/2)
+		y := float64(plotMargin + overviewHeight + panelGap + i/plotColumns*(panelHeight+panelGap))
+		drawPanel(c, x, y, width, panelHeight, []plotChrom{p}, p.track.Chrom, lo, hi, withBAF)
+	}
+	return c, nil
+}
+
+// valueRange returns the range of the y axis shared by the panels: that of
+// the values between their 0.5th and 99.5th percentiles and of the segment
+// means, padded by 5%.
+func valueRange(chroms []plotChrom) (lo, hi float64) {
+	var x []float64
+	for _, p := range chroms {
+		x = append(x, p.track.Values...)
+	}
+	slices.Sort(x)
+	lo, hi = quantile(x, 0.005), quantile(x, 0.995)
+	for _, p := range chroms {
+		for _, s := range p.segments {
+			lo, hi = min(lo, s.Mean), max(hi, s.Mean)
+		}
+	}
+	if hi == lo {
+		return lo - 1, hi + 1
+	}
+	pad := 0.05 * (hi - lo)
+	return lo - pad, hi + pad
+}
+
+// drawPanel draws the chromosomes side by side in the box at (x, y) of size
+// w × h: their values colored by segment with the segment means as bars, and
+// their B-allele frequencies below when withBAF is set. Values outside [lo,
+// hi] are drawn at the edges.
+func drawPanel(c canvas, x, y, w, h float64, chroms []plotChrom, title string, lo, hi float64, withBAF bool) {
+	mainH, bafY, bafH := h, 0.0, 0.0
+	if withBAF {
+		bafH = h * bafFraction
+		mainH = h - bafH - 6
+		bafY = y + mainH + 6
+	}
+	c.text(x, y-6, 12, title)
+	c.text(x+3, y+9, 8, formatTick(hi))
+	c.text(x+3, y+mainH, 8, formatTick(lo))
+	frame(c, x, y, w, mainH)
+	if withBAF {
+		frame(c, x, bafY, w, bafH)
+		c.rect(x, bafY+bafH/2, x+w, bafY+bafH/2+0.5, gray)
+		c.text(x+3, bafY+bafH/2+3, 8, "BAF")
+	}
+
+	var total float64
+	for _, p := range chroms {
+		total += p.span
+	}
+	valueY := func(v float64) float64 {
+		return y + mainH*(1-(min(max(v, lo), hi)-lo)/(hi-lo))
+	}
+	var offset float64
+	for i, p := range chroms {
+		posX := func(pos float64) float64 { return x + w*(offset+pos)/total }
+		if i > 0 {
+			c.rect(posX(0), y, posX(0)+0.5, y+h, gray)
+		}
+		if len(chroms) > 1 {
+			c.text(posX(p.span/2)-4, y+h+12, 8, strings.TrimPrefix(p.track.Chrom, "chr"))
+		}
+
+		t, j := p.track, 0
+		for k, v := range t.Values {
+			pos := position(t, k)
+			for j < len(p.segments) && float64(p.segments[j].End) <= pos {
+				j++
+			}
+			col := gray
+			if j < len(p.segments) && float64(p.segments[j].Start) <= pos {
+				col = segmentColors[j%len(segmentColors)]
+			}
+			px, py := posX(pos), valueY(v)
+			c.rect(px-1, py-1, px+1, py+1, col)
+		}
+		for _, s := range p.segments {
+			py := valueY(s.Mean)
+			c.rect(posX(float64(s.Start)), py-1, posX(float64(s.End)), py+1, black)
+		}
+		if b := p.baf; withBAF && b != nil {
+			for k, v := range b.Values {
+				px, py := posX(position(b, k)), bafY+bafH*(1-min(max(v, 0), 1))
+				c.rect(px-0.75, py-0.75, px+0.75, py+0.75, gray)
+			}
+		}
+		offset += p.span
+	}
+}
+
+// frame draws the outline of the box at (x, y) of size w × h.
+func frame(c canvas, x, y, w, h float64) {
+	c.rect(x, y, x+w, y+0.5, black)
+	c.rect(x, y+h-0.5, x+w, y+h, black)
+	c.rect(x, y, x+0.5, y+h, black)
+	c.rect(x+w-0.5, y, x+w, y+h, black)
+}
+
+// formatTick formats a value of the y axis.
+func formatTick(v float64) string {
+	return strconv.FormatFloat(v, 'g', 3, 64)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlot(t *testing.T) {
+	dir := t.TempDir()
+	values := filepath.Join(dir, "values.bedgraph")
+	baf := filepath.Join(dir, "baf.txt")
+	var b, bb strings.Builder
+	for _, chrom := range []string{"chr1", "chr2"} {
+		for i := range 40 {
+			v := 0.0
+			if chrom == "chr2" && i >= 20 {
+				v = 1
+			}
+			fmt.Fprintf(&b, "%s\t%d\t%d\t%g\n", chrom, i*100, i*100+100, v+0.01*float64(i%3))
+			fmt.Fprintf(&bb, "%s\t%d\t0.5\n", chrom, i*100+50)
+		}
+	}
+	if err := os.WriteFile(values, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(baf, []byte(bb.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []string{"png", "svg", "pdf"} {
+		output := filepath.Join(dir, "plot."+format)
+		if err := run([]string{"plot", "-shuffles", "1000", "-baf", baf, "-o", output, values}, new(bytes.Buffer)); err != nil {
+			t.Fatalf("%s: plot returned an unexpected error: %v", format, err)
+		}
+		got, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch format {
+		case "png":
+			img, err := png.Decode(bytes.NewReader(got))
+			if err != nil {
+				t.Fatalf("Invalid PNG output: %v", err)
+			}
+			if w := img.Bounds().Dx(); w != plotWidth {
+				t.Errorf("Unexpected width.\nExpected: %v\nGot: %v", plotWidth, w)
+			}
+		case "svg":
+			for _, s := range []string{"<svg ", ">genome</text>", ">chr1</text>", ">chr2</text>", ">BAF</text>", "</svg>\n"} {
+				if !bytes.Contains(got, []byte(s)) {
+					t.Errorf("Expected %q in the SVG output", s)
+				}
+			}
+		case "pdf":
+			if !bytes.HasPrefix(got, []byte("%PDF-")) || !bytes.HasSuffix(got, []byte("%%EOF\n")) || !bytes.Contains(got, []byte("(chr2) Tj")) {
+				t.Errorf("Unexpected PDF output %.100q", got)
+			}
+		}
+	}
+
+	segments := filepath.Join(dir, "segments.bed")
+	if err := os.WriteFile(segments, []byte("chr1\t0\t4000\t0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := run([]string{"plot", "-format", "svg", "-segments", segments, values}, &out); err != nil {
+		t.Fatalf("plot returned an unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "<svg ") {
+		t.Errorf("Expected SVG output, got %.100q", out.String())
+	}
+	if err := run([]string{"plot", "-format", "gif", values}, &out); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+}
+
+func TestPNGCanvasText(t *testing.T) {
+	// count returns the number of black pixels of the text s drawn at size.
+	count := func(s string, size float64) int {
+		c, err := newCanvas("png", 200, 40)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img := c.(*pngCanvas).img
+		c.text(2, 30, size, s)
+		var n int
+		for y := range img.Bounds().Dy() {
+			for x := range img.Bounds().Dx() {
+				if img.RGBAAt(x, y) == (color.RGBA{A: 255}) {
+					n++
+				}
+			}
+		}
+		return n
+	}
+	// 'I' and 'L' have 11 pixels each, a column of 7 and 4 more, which are
+	// blocks of 2×2 pixels at size 20.
+	for _, tt := range []struct {
+		s        string
+		size     float64
+		expected int
+	}{
+		{"", 8, 0},
+		{" ", 8, 0},
+		{"I", 8, 11},
+		{"IL", 8, 22},
+		{"I", 20, 44},
+		{"\u00e9", 8, count("?", 8)},
+	} {
+		if got := count(tt.s, tt.size); got != tt.expected {
+			t.Errorf("%q: unexpected result.\nExpected: %v\nGot: %v", tt.s, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/promql"
+	"github.com/mattdsm/cbsgo/timeseries"
+)
+
+// promQuery is a Prometheus range query whose series "cbs segment -promql"
+// segments.
+type promQuery struct {
+	query  string
+	server string
+	end    string
+	span   time.Duration
+	step   time.Duration
+	// seasons lists the periods of the seasonal patterns to remove.
+	seasons seasons
+}
+
+// flags defines the flags of the query on fs.
+func (q *promQuery) flags(fs *flag.FlagSet) {
+	fs.StringVar(&q.query, "promql", "", "PromQL `query` whose series to segment instead of an input file")
+	fs.StringVar(&q.server, "prometheus", "http://localhost:9090", "`URL` of the Prometheus server of -promql")
+	fs.StringVar(&q.end, "end", "", "end `time` of the -promql range, in RFC 3339 format (default now)")
+	fs.DurationVar(&q.span, "range", 24*time.Hour, "`duration` of the -promql range")
+	fs.DurationVar(&q.step, "step", time.Minute, "`resolution` of the -promql range")
+	fs.Var(&q.seasons, "seasons", "comma-separated `periods` of the seasonal patterns of the -promql series to remove, such as 24h,168h")
+}
+
+// seasons is a flag.Value holding comma-separated periods.
+type seasons []time.Duration
+
+func (s *seasons) String() string {
+	var periods []string
+	for _, d := range *s {
+		periods = append(periods, d.String())
+	}
+	return strings.Join(periods, ",")
+}
+
+func (s *seasons) Set(v string) error {
+	*s = nil
+	for _, p := range strings.Split(v, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(p))
+		if err != nil {
+			return err
+		}
+		*s = append(*s, d)
+	}
+	return nil
+}
+
+// segment queries the series, segments them with timeseries.Detect
+// configured by opts, and writes their level shifts to the output file as
+// tab-separated values with a header line: the series, the times of the
+// samples before and after every shift, and the levels before and after.
+func (q *promQuery) segment(output, compression string, stdout io.Writer, opts ...cbsgo.Option) error {
+	end := time.Now()
+	if q.end != "" {
+		var err error
+		if end, err = time.Parse(time.RFC3339, q.end); err != nil {
+			return fmt.Errorf("segment: invalid end time: %w", err)
+		}
+	}
+	if q.span <= 0 {
+		return fmt.Errorf("segment: range %v is not positive", q.span)
+	}
+	ctx := context.Background()
+	c := &promql.Client{URL: q.server}
+	series, err := c.QueryRange(ctx, q.query, end.Add(-q.span), end, q.step)
+	if err != nil {
+		return err
+	}
+
+	out, err := create(output, compression, stdout)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(out)
+	fmt.Fprintln(bw, "series\tprevious\ttime\tfrom\tto")
+	for _, s := range series {
+		if len(s.Points) == 0 {
+			continue
+		}
+		res, err := timeseries.Detect(ctx, s.Points, timeseries.Options{Seasons: q.seasons}, opts...)
+		if err != nil {
+			out.Close()
+			return fmt.Errorf("%s: %w", s.Name(), err)
+		}
+		for _, cp := range res.Changepoints {
+			fmt.Fprintf(bw, "%s\t%s\t%s\t%g\t%g\n", s.Name(), cp.Previous.Format(time.RFC3339), cp.Time.Format(time.RFC3339), cp.From, cp.To)
+		}
+	}
+	return firstError(bw.Flush(), out.Close())
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSegmentPromQL(t *testing.T) {
+	// The latency of a service steps up at the 60th sample, at 1700003600.
+	rng := rand.New(rand.NewSource(1))
+	var values []string
+	for i := range 120 {
+		v := 0.1 + 0.01*rng.NormFloat64()
+		if i >= 60 {
+			v += 0.05
+		}
+		values = append(values, fmt.Sprintf(`[%d,"%g"]`, 1700000000+60*i, v))
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("query") != "latency" || r.FormValue("end") != "1700007140" || r.FormValue("start") != "1700000000" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"job":"api"},"values":[%s]}]}}`, strings.Join(values, ","))
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	args := []string{"segment", "-promql", "latency", "-prometheus", srv.URL, "-end", "2023-11-15T00:12:20Z", "-range", "7140s", "-shuffles", "1000"}
+	if err := run(args, &out); err != nil {
+		t.Fatalf("segment returned an unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || lines[0] != "series\tprevious\ttime\tfrom\tto" {
+		t.Fatalf("Expected a single level shift, got %q", out.String())
+	}
+	// Changes may be placed a sample early.
+	fields := strings.Split(lines[1], "\t")
+	if fields[0] != `{job="api"}` || fields[2] != "2023-11-14T23:13:20Z" && fields[2] != "2023-11-14T23:12:20Z" {
+		t.Errorf("Unexpected level shift %q", lines[1])
+	}
+
+	if err := run([]string{"segment", "-promql", "latency", "-prometheus", srv.URL, "-"}, &out); err == nil {
+		t.Error("Expected an error for an input file with -promql")
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/store"
+)
+
+// segment implements "cbs segment", which segments the values of a file or
+// of stdin and writes the segments in the chosen format, so that it can be
+// used in pipelines such as
+//
+//	samtools depth in.bam | cbs segment - > segments.bed
+//
+// With -promql, it segments the series of a Prometheus query instead, and
+// reports their level shifts.
+func segment(args []string, stdout io.Writer) error {
+	fs := newFlagSet("segment", "file|-")
+	chrom := fs.String("chrom", "chr1", "chromosome `name` of input without chromosome column")
+	format := fs.String("format", "bed", "output `format`: bed, bedgraph, seg or jsonl")
+	sample := fs.String("sample", "sample", "sample `name` in SEG and JSONL output and the database")
+	output := fs.String("o", "-", "output `file`")
+	compress := compressFlag(fs)
+	database := fs.String("db", "", "SQLite `database` to store the segments and quality metrics in, in addition to the output")
+	preset := fs.String("preset", "", "assay `preset` whose settings the other flags override: "+presetNames())
+	alpha := fs.Float64("alpha", 0.01, "significance level")
+	shuffles := fs.Int("shuffles", 10000, "number of permutations")
+	seed := fs.Int64("seed", 1, "random seed, 0 for a time-based seed")
+	transform := fs.String("transform", "none", "`transform` of the values before segmentation, robust to heavy tails: none, rank or normal-scores")
+	manifest := fs.Bool("manifest", false, "record the seed, parameters, backend and version in a header of the output")
+	coordinates := fs.String("coordinates", "default", "coordinate `convention` of the input columns and JSONL output: default, 0-based or 1-based")
+	bins := fs.Bool("bins", false, "write the bin indices of the segments instead of their positions")
+	var pq promQuery
+	pq.flags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	t, err := cbsgo.ParseTransform(*transform)
+	if err != nil {
+		return err
+	}
+	conv, err := cbsgo.ParseConvention(*coordinates)
+	if err != nil {
+		return err
+	}
+	if pq.query != "" {
+		if fs.NArg() != 0 {
+			fs.Usage()
+			return errors.New("segment: expected no input file with -promql")
+		}
+		return pq.segment(*output, *compress, stdout, cbsgo.WithSeed(*seed), cbsgo.WithAlpha(*alpha), cbsgo.WithShuffles(*shuffles), cbsgo.WithTransform(t))
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("segment: expected one input file, or - for stdin")
+	}
+	if *preset != "" {
+		if _, err := cbsgo.LookupPreset(*preset); err != nil {
+			return err
+		}
+	}
+	if *database != "" && sqliteDriver == "" {
+		return errors.New("segment: -db needs a build of cbs with cgo")
+	}
+
+	tracks, err := readValuesFile(fs.Arg(0), *chrom, conv)
+	if err != nil {
+		return err
+	}
+
+	out, err := create(*output, *compress, stdout)
+	if err != nil {
+		return err
+	}
+	var w cbsgo.SegmentWriter
+	switch *format {
+	case "bed":
+		w = cbsgo.NewBEDWriter(out)
+	case "bedgraph":
+		w = cbsgo.NewBedGraphWriter(out)
+	case "seg":
+		w = cbsgo.NewSEGWriter(out, *sample, true)
+	case "jsonl":
+		w = cbsgo.NewJSONLWriter(out, *sample)
+	default:
+		out.Close()
+		return fmt.Errorf("segment: unknown format %q", *format)
+	}
+	coords := cbsgo.Coordinates{Convention: conv, Bins: *bins}
+	if *format != "jsonl" {
+		// The convention applies to the input, those of the other formats
+		// are fixed.
+		coords.Convention = cbsgo.DefaultConvention
+	}
+	if err := w.(interface{ SetCoordinates(cbsgo.Coordinates) error }).SetCoordinates(coords); err != nil {
+		out.Close()
+		return err
+	}
+	ctx := context.Background()
+	// The segments are streamed to w, and kept for the database if any.
+	kept := &keptSegments{SegmentWriter: w}
+	opts := []cbsgo.Option{cbsgo.WithSeed(*seed), cbsgo.WithSample(*sample), cbsgo.WithWriter(w)}
+	if *database != "" {
+		opts = append(opts, cbsgo.WithWriter(kept), cbsgo.WithFit())
+	}
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if *preset != "" {
+		opts = append(opts, cbsgo.WithPreset(*preset))
+	}
+	if *preset == "" || set["alpha"] {
+		opts = append(opts, cbsgo.WithAlpha(*alpha))
+	}
+	if *preset == "" || set["shuffles"] {
+		opts = append(opts, cbsgo.WithShuffles(*shuffles))
+	}
+	if t != cbsgo.NoTransform {
+		opts = append(opts, cbsgo.WithTransform(t))
+	}
+	if *manifest {
+		if err := w.(interface{ SetManifest(cbsgo.Manifest) error }).SetManifest(cbsgo.NewManifest(opts...)); err != nil {
+			out.Close()
+			return err
+		}
+	}
+	res, err := cbsgo.SegmentGenome(ctx, tracks, opts...)
+	if err := firstError(err, w.Close(), out.Close()); err != nil {
+		return err
+	}
+	if *database != "" {
+		res.Segments = kept.segments
+		return storeResult(ctx, *database, *sample, res)
+	}
+	return nil
+}
+
+// keptSegments is a cbsgo.SegmentWriter keeping the segments it writes.
+type keptSegments struct {
+	cbsgo.SegmentWriter
+	segments []cbsgo.Segment
+}
+
+func (k *keptSegments) Write(s cbsgo.Segment) error {
+	k.segments = append(k.segments, s)
+	return k.SegmentWriter.Write(s)
+}
+
+// presetNames returns the names of the built-in presets.
+func presetNames() string {
+	var names []string
+	for _, p := range cbsgo.Presets() {
+		names = append(names, p.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// storeResult stores the segments and quality metrics of a sample in the
+// named SQLite database, creating it if needed.
+func storeResult(ctx context.Context, name, sample string, res *cbsgo.GenomeResult) error {
+	db, err := sql.Open(sqliteDriver, name)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	s, err := store.Open(ctx, db)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return s.Write(ctx, sample, res.Segments, store.Metrics(res))
+}
+
+// readValues reads tracks from r, which holds tab or space separated
+// columns in one of three layouts, chosen by the number of columns of the
+// first line:
+//
+//	value                  one value per line, on chromosome chrom
+//	chrom pos value        one-based positions, as from samtools depth
+//	chrom start end value  zero-based, half-open intervals, as in bedGraph
+//
+// unless conv sets another convention of the coordinates. Comment and header
+// lines are skipped.
+func readValues(r io.Reader, chrom string, conv cbsgo.Convention) ([]*cbsgo.Track, error) {
+	var (
+		tracks  []*cbsgo.Track
+		t       *cbsgo.Track
+		columns int
+	)
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || text[0] == '#' || strings.HasPrefix(text, "track") || strings.HasPrefix(text, "browser") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if columns == 0 {
+			columns = len(fields)
+			if columns == 2 || columns > 4 {
+				return nil, fmt.Errorf("line %d: expected 1, 3 or 4 columns, got %d", line, columns)
+			}
+		}
+		if len(fields) != columns {
+			return nil, fmt.Errorf("line %d: expected %d columns, got %d", line, columns, len(fields))
+		}
+
+		name, start, end := chrom, 0, 0
+		var errs [3]error
+		switch columns {
+		case 3:
+			name = fields[0]
+			start, errs[0] = strconv.Atoi(fields[1])
+			// A position is the interval of a single base.
+			if conv.Or(cbsgo.OneBased) == cbsgo.OneBased {
+				start, end = cbsgo.OneBased.Decode(start, start)
+			} else {
+				end = start + 1
+			}
+		case 4:
+			name = fields[0]
+			start, errs[0] = strconv.Atoi(fields[1])
+			end, errs[1] = strconv.Atoi(fields[2])
+			start, end = conv.Decode(start, end)
+		}
+		value, err := strconv.ParseFloat(fields[columns-1], 64)
+		errs[2] = err
+		if err := firstError(errs[:]...); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if t == nil || t.Chrom != name {
+			t = &cbsgo.Track{Chrom: name}
+			tracks = append(tracks, t)
+		}
+		if columns > 1 {
+			t.Starts = append(t.Starts, start)
+			t.Ends = append(t.Ends, end)
+		}
+		t.Values = append(t.Values, value)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	for _, t := range tracks {
+		if err := t.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return tracks, nil
+}
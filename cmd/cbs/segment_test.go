@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestSegment(t *testing.T) {
+	values := "1\n1\n1\n3\n3\n2\n1\n2\n3\n300\n310\n321\n310\n299\n"
+	tests := []struct {
+		name, input string
+		args        []string
+		expected    string
+	}{
+		{
+			name:     "values",
+			input:    values,
+			expected: "chr1\t0\t8\t1.75\nchr1\t8\t14\t257.2\n",
+		},
+		{
+			name:     "depth",
+			input:    "chr2 1 1\nchr2 2 1\n",
+			args:     []string{"-format", "bedgraph"},
+			expected: "chr2\t0\t2\t1\n",
+		},
+		{
+			name:     "bedgraph",
+			input:    "track type=bedGraph\nchr3\t0\t10\t1\nchr3\t10\t20\t1\n",
+			args:     []string{"-format", "seg", "-sample", "s1"},
+			expected: "ID\tchrom\tloc.start\tloc.end\tnum.mark\tseg.mean\ns1\tchr3\t1\t20\t2\t1\n",
+		},
+		{
+			name:     "preset",
+			input:    strings.Repeat("1\n2\n", 15) + strings.Repeat("10\n11\n", 15),
+			args:     []string{"-preset", "aCGH", "-shuffles", "1000"},
+			expected: "chr1\t0\t29\t1.483\nchr1\t29\t60\t10.23\n",
+		},
+		{
+			name:     "jsonl",
+			input:    "chr4\t0\t10\t1\nchr4\t10\t20\t3\n",
+			args:     []string{"-format", "jsonl", "-sample", "s2"},
+			expected: `{"sample":"s2","chrom":"chr4","start":0,"end":20,"bin_start":0,"bin_end":2,"mean":2,"sd":1.025978352085154}` + "\n",
+		},
+		{
+			name:     "one-based",
+			input:    "chr4\t1\t10\t1\nchr4\t11\t20\t3\n",
+			args:     []string{"-format", "jsonl", "-sample", "s2", "-coordinates", "1-based"},
+			expected: `{"sample":"s2","chrom":"chr4","start":1,"end":20,"bin_start":0,"bin_end":2,"mean":2,"sd":1.025978352085154}` + "\n",
+		},
+		{
+			name:     "bins",
+			input:    "chr2 100 1\nchr2 101 1\n",
+			args:     []string{"-coordinates", "0-based", "-bins"},
+			expected: "chr2\t0\t2\t1\n",
+		},
+	}
+	for _, tt := range tests {
+		stdin = strings.NewReader(tt.input)
+		var out bytes.Buffer
+		args := append(append([]string{"segment", "-seed", "42"}, tt.args...), "-")
+		if err := run(args, &out); err != nil {
+			t.Fatalf("%s: segment returned an unexpected error: %v", tt.name, err)
+		}
+		if out.String() != tt.expected {
+			t.Errorf("%s: unexpected result.\nExpected: %q\nGot: %q", tt.name, tt.expected, out.String())
+		}
+	}
+
+	for name, input := range map[string]string{
+		"columns":  "chr1 1\n",
+		"mixed":    "chr1 1 1\n2\n",
+		"number":   "x\n",
+		"overlaps": "chr1 0 10 1\nchr1 5 15 1\n",
+	} {
+		stdin = strings.NewReader(input)
+		if err := run([]string{"segment", "-"}, new(bytes.Buffer)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if err := run([]string{"segment", "-coordinates", "2-based", "-"}, new(bytes.Buffer)); err == nil {
+		t.Errorf("Expected an error for an unknown coordinate convention")
+	}
+	if err := run([]string{"segment", "-format", "xml", "-"}, new(bytes.Buffer)); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+	if err := run([]string{"segment", "-preset", "nanopore", "-"}, new(bytes.Buffer)); err == nil {
+		t.Errorf("Expected an error for an unknown preset")
+	}
+}
+
+func TestSegmentCompressed(t *testing.T) {
+	var input bytes.Buffer
+	w := gzip.NewWriter(&input)
+	w.Write([]byte("1\n1\n1\n1\n1\n1\n"))
+	w.Close()
+	stdin = &input
+
+	output := filepath.Join(t.TempDir(), "segments.bed.zst")
+	if err := run([]string{"segment", "-o", output, "-"}, new(bytes.Buffer)); err != nil {
+		t.Fatalf("segment returned an unexpected error: %v", err)
+	}
+	f, err := open(output)
+	if err != nil {
+		t.Fatalf("open returned an unexpected error: %v", err)
+	}
+	defer f.Close()
+	got, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("Reading the output failed: %v", err)
+	}
+	if expected := "chr1\t0\t6\t1\n"; string(got) != expected {
+		t.Errorf("Unexpected result.\nExpected: %q\nGot: %q", expected, got)
+	}
+	if raw, _ := os.ReadFile(output); bytes.Equal(raw, got) {
+		t.Errorf("Expected compressed output")
+	}
+}
+
+func TestSegmentURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "chr5\t0\t10\t2\nchr5\t10\t20\t2\n")
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	if err := run([]string{"segment", srv.URL + "/values.bedgraph"}, &out); err != nil {
+		t.Fatalf("segment returned an unexpected error: %v", err)
+	}
+	if expected := "chr5\t0\t20\t2\n"; out.String() != expected {
+		t.Errorf("Unexpected result.\nExpected: %q\nGot: %q", expected, out.String())
+	}
+}
+
+func TestReadValues(t *testing.T) {
+	got, err := readValues(strings.NewReader("# depth\nchr1\t10\t5\nchr1\t11\t6\nchr2\t3\t7\n"), "", cbsgo.DefaultConvention)
+	if err != nil {
+		t.Fatalf("readValues returned an unexpected error: %v", err)
+	}
+	expected := []*cbsgo.Track{
+		{Chrom: "chr1", Starts: []int{9, 10}, Ends: []int{10, 11}, Values: []float64{5, 6}},
+		{Chrom: "chr2", Starts: []int{2}, Ends: []int{3}, Values: []float64{7}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+	}
+
+	got, err = readValues(strings.NewReader("chr1\t10\t5\n"), "", cbsgo.ZeroBased)
+	if err != nil {
+		t.Fatalf("readValues returned an unexpected error: %v", err)
+	}
+	expected = []*cbsgo.Track{{Chrom: "chr1", Starts: []int{10}, Ends: []int{11}, Values: []float64{5}}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+	}
+	got, err = readValues(strings.NewReader("chr1\t1\t10\t5\nchr1\t11\t20\t6\n"), "", cbsgo.OneBased)
+	if err != nil {
+		t.Fatalf("readValues returned an unexpected error: %v", err)
+	}
+	expected = []*cbsgo.Track{{Chrom: "chr1", Starts: []int{0, 10}, Ends: []int{10, 20}, Values: []float64{5, 6}}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+	}
+}
+
+func TestSegmentManifest(t *testing.T) {
+	stdin = strings.NewReader("1\n1\n1\n1\n1\n1\n")
+	var out bytes.Buffer
+	if err := run([]string{"segment", "-manifest", "-seed", "42", "-"}, &out); err != nil {
+		t.Fatalf("segment returned an unexpected error: %v", err)
+	}
+	header, rest, _ := strings.Cut(out.String(), "\n")
+	if !strings.HasPrefix(header, "# cbsgo version=") || !strings.Contains(header, " seed=42 ") || !strings.Contains(header, " alpha=0.01 ") {
+		t.Errorf("Unexpected manifest %q", header)
+	}
+	if expected := "chr1\t0\t6\t1\n"; rest != expected {
+		t.Errorf("Unexpected result.\nExpected: %q\nGot: %q", expected, rest)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/server"
+)
+
+// serve implements "cbs serve", a service segmenting the jobs submitted over
+// HTTP, until interrupted. On interruption it stops accepting jobs, cancels
+// the queued ones and completes the running ones within the shutdown
+// timeout. With -api-keys, only the clients with one of the keys of the
+// file are served, as the clients the keys belong to.
+func serve(args []string, stdout io.Writer) error {
+	fs := newFlagSet("serve", "")
+	addr := fs.String("addr", "localhost:8080", "`address` to listen on")
+	cfg := server.DefaultConfig()
+	fs.IntVar(&cfg.Workers, "workers", cfg.Workers, "number of jobs segmented concurrently")
+	fs.IntVar(&cfg.QueueSize, "queue", cfg.QueueSize, "number of jobs waiting for a worker")
+	fs.IntVar(&cfg.ClientLimit, "client-limit", 0, "number of jobs of a client segmented concurrently, 0 for no limit")
+	fs.IntVar(&cfg.ClientQueue, "client-queue", 0, "number of jobs of a client waiting, 0 for no limit")
+	fs.DurationVar(&cfg.Retention, "retention", cfg.Retention, "how long the results of finished jobs are kept")
+	fs.IntVar(&cfg.MaxShuffles, "max-shuffles", cfg.MaxShuffles, "number of permutations of a job at most, 0 for no limit")
+	fs.IntVar(&cfg.MaxBins, "max-bins", cfg.MaxBins, "number of bins of a job at most, 0 for no limit")
+	keys := fs.String("api-keys", "", "`file` of the API keys of the clients, a line \"<client> <key>\" per key")
+	memory := fs.Int("memory", 0, "memory limit of every job in `bytes`, 0 for no limit")
+	timeout := fs.Duration("shutdown-timeout", time.Minute, "how long running jobs may take to complete on shutdown")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errors.New("serve: expected no arguments")
+	}
+	if *keys != "" {
+		m, err := readAPIKeys(*keys)
+		if err != nil {
+			return err
+		}
+		cfg.Auth = server.APIKeys(m)
+	}
+	var opts []cbsgo.Option
+	if *memory != 0 {
+		opts = append(opts, cbsgo.WithMemoryLimit(*memory))
+	}
+	s, err := server.New(cfg, opts...)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	hs := &http.Server{Handler: s}
+	served := make(chan error, 1)
+	go func() { served <- hs.Serve(l) }()
+	fmt.Fprintf(stdout, "serving on %s\n", l.Addr())
+
+	select {
+	case err := <-served:
+		s.Shutdown(context.Background())
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	// The jobs are shut down first, so that their results can be fetched
+	// until they complete.
+	err = s.Shutdown(ctx)
+	return firstError(err, hs.Shutdown(ctx))
+}
+
+// readAPIKeys reads the file name of the API keys of the clients, mapping
+// each key to its client. Blank lines and lines starting with # are ignored.
+func readAPIKeys(name string) (map[string]string, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]string)
+	for i, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("serve: %s:%d: expected a client and a key", name, i+1)
+		}
+		if _, ok := keys[fields[1]]; ok {
+			return nil, fmt.Errorf("serve: %s:%d: duplicate key", name, i+1)
+		}
+		keys[fields[1]] = fields[0]
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("serve: %s: no API keys", name)
+	}
+	return keys, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestServeInvalid(t *testing.T) {
+	for _, args := range [][]string{
+		{"serve", "extra"},
+		{"serve", "-workers", "0"},
+		{"serve", "-client-limit", "-1"},
+		{"serve", "-api-keys", filepath.Join(t.TempDir(), "missing")},
+	} {
+		if err := run(args, io.Discard); err == nil {
+			t.Errorf("%q: expected an error", args)
+		}
+	}
+}
+
+func TestReadAPIKeys(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	keys, err := readAPIKeys(write("keys", "# lab keys\nlab1 k1\n\n  lab1 k1b\nlab2 k2\n"))
+	if err != nil {
+		t.Fatalf("readAPIKeys returned an unexpected error: %v", err)
+	}
+	expected := map[string]string{"k1": "lab1", "k1b": "lab1", "k2": "lab2"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, keys)
+	}
+	for name, content := range map[string]string{
+		"fields":    "lab1\n",
+		"duplicate": "lab1 k1\nlab2 k1\n",
+		"empty":     "# none\n",
+	} {
+		if _, err := readAPIKeys(write(name, content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
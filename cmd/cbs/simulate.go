@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// simulate implements "cbs simulate", which writes a simulated profile in
+// bedGraph format and optionally its truth in BED format.
+func simulate(args []string, stdout io.Writer) error {
+	sim := cbsgo.DefaultSimulation()
+	fs := newFlagSet("simulate", "")
+	fs.StringVar(&sim.Chrom, "chrom", sim.Chrom, "chromosome `name`")
+	fs.IntVar(&sim.Bins, "bins", sim.Bins, "number of bins")
+	fs.IntVar(&sim.BinSize, "bin-size", sim.BinSize, "bin size in base pairs")
+	fs.Float64Var(&sim.NoiseSD, "noise", sim.NoiseSD, "standard deviation of the noise")
+	fs.Float64Var(&sim.Autocorrelation, "autocorrelation", sim.Autocorrelation, "lag-one autocorrelation of the noise")
+	fs.Float64Var(&sim.OutlierRate, "outliers", sim.OutlierRate, "fraction of outlying bins")
+	fs.Float64Var(&sim.OutlierSD, "outlier-sd", sim.OutlierSD, "size of outliers in noise standard deviations")
+	fs.IntVar(&sim.Events, "events", sim.Events, "number of copy number changes")
+	fs.IntVar(&sim.MinLength, "min-length", sim.MinLength, "minimum event length in bins")
+	fs.IntVar(&sim.MaxLength, "max-length", sim.MaxLength, "maximum event length in bins")
+	levels := fs.String("levels", formatFloats(sim.Levels), "comma-separated event `levels`")
+	seed := fs.Int64("seed", 1, "random seed, 0 for a time-based seed")
+	output := fs.String("o", "-", "output `file` of the profile in bedGraph format")
+	truthFile := fs.String("truth", "", "output `file` of the true segments in BED format")
+	compress := compressFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("simulate: unexpected arguments %q", fs.Args())
+	}
+	var err error
+	if sim.Levels, err = parseFloats(*levels); err != nil {
+		return fmt.Errorf("simulate: -levels: %w", err)
+	}
+
+	track, truth, err := sim.Generate(*seed)
+	if err != nil {
+		return err
+	}
+
+	out, err := create(*output, *compress, stdout)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(out)
+	for i, v := range track.Values {
+		fmt.Fprintf(bw, "%s\t%d\t%d\t%g\n", track.Chrom, track.Starts[i], track.Ends[i], v)
+	}
+	if err := firstError(bw.Flush(), out.Close()); err != nil {
+		return err
+	}
+
+	if *truthFile == "" {
+		return nil
+	}
+	f, err := create(*truthFile, *compress, stdout)
+	if err != nil {
+		return err
+	}
+	w := cbsgo.NewBEDWriter(f)
+	for _, s := range truth {
+		if err := w.Write(s); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return firstError(w.Close(), f.Close())
+}
+
+// parseFloats parses a comma-separated list of numbers.
+func parseFloats(s string) ([]float64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	fields := strings.Split(s, ",")
+	x := make([]float64, len(fields))
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(f), 64)
+		if err != nil {
+			return nil, err
+		}
+		x[i] = v
+	}
+	return x, nil
+}
+
+func formatFloats(x []float64) string {
+	s := make([]string, len(x))
+	for i, v := range x {
+		s[i] = strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return strings.Join(s, ",")
+}
+
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
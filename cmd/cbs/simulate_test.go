@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestSimulate(t *testing.T) {
+	truth := filepath.Join(t.TempDir(), "truth.bed")
+	var out bytes.Buffer
+	args := []string{"-bins", "500", "-bin-size", "1000", "-events", "3", "-max-length", "100", "-levels", "-1,1", "-seed", "7", "-truth", truth}
+	if err := run(append([]string{"simulate"}, args...), &out); err != nil {
+		t.Fatalf("simulate returned an unexpected error: %v", err)
+	}
+
+	tracks, err := cbsgo.ReadBedGraph(&out)
+	if err != nil {
+		t.Fatalf("ReadBedGraph returned an unexpected error: %v", err)
+	}
+	if len(tracks) != 1 || tracks[0].Len() != 500 || tracks[0].Ends[499] != 500_000 {
+		t.Fatalf("Unexpected profile %v", tracks)
+	}
+
+	data, err := os.ReadFile(truth)
+	if err != nil {
+		t.Fatalf("Reading the truth failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) < 2 || !strings.HasPrefix(lines[0], "chr1\t0\t") || !strings.Contains(lines[len(lines)-1], "\t500000\t") {
+		t.Errorf("Unexpected truth %q", data)
+	}
+
+	for _, args := range [][]string{{"-levels", "a"}, {"-bins", "0"}, {"extra"}} {
+		if err := run(append([]string{"simulate"}, args...), &out); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build cgo
+
+package main
+
+// The SQLite driver of -db needs cgo; builds without it reject -db.
+import _ "github.com/mattn/go-sqlite3"
+
+// sqliteDriver is the name of the SQLite driver of database/sql.
+const sqliteDriver = "sqlite3"
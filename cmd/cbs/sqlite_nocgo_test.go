@@ -0,0 +1,16 @@
+//go:build !cgo
+
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSegmentDatabaseWithoutCgo(t *testing.T) {
+	stdin = strings.NewReader("1\n1\n3\n3\n")
+	if err := run([]string{"segment", "-db", "results.sqlite", "-"}, io.Discard); err == nil {
+		t.Error("Expected an error for -db without cgo")
+	}
+}
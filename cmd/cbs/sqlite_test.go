@@ -0,0 +1,34 @@
+//go:build cgo
+
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSegmentDatabase(t *testing.T) {
+	database := filepath.Join(t.TempDir(), "results.sqlite")
+	for _, sample := range []string{"s1", "s2"} {
+		stdin = strings.NewReader("1\n1\n1\n3\n3\n2\n1\n2\n3\n300\n310\n321\n310\n299\n")
+		if err := run([]string{"segment", "-db", database, "-sample", sample, "-"}, new(bytes.Buffer)); err != nil {
+			t.Fatalf("segment returned an unexpected error: %v", err)
+		}
+	}
+
+	db, err := sql.Open(sqliteDriver, database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var segments, metric float64
+	if err := db.QueryRow(`SELECT count(*) FROM segments WHERE sample = 's2'`).Scan(&segments); err != nil || segments != 2 {
+		t.Errorf("Expected 2 segments, got %v, %v", segments, err)
+	}
+	if err := db.QueryRow(`SELECT value FROM qc WHERE sample = 's1' AND metric = 'segments'`).Scan(&metric); err != nil || metric != 2 {
+		t.Errorf("Expected a segments metric of 2, got %v, %v", metric, err)
+	}
+}
@@ -0,0 +1,47 @@
+package cbsgo
+
+import (
+	"slices"
+	"sync"
+)
+
+// collector accumulates the bounds of the segments of a run. It is safe for
+// concurrent use, so that workers testing disjoint intervals in parallel can
+// add their segments in any order, and returns them in the canonical order
+// of the bins whatever the order of the additions.
+type collector struct {
+	mu     sync.Mutex
+	bounds [][2]int
+	// sorted tells whether bounds is known to be sorted by start.
+	sorted bool
+}
+
+// add records the segment of the bins [start, end).
+func (c *collector) add(start, end int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(c.bounds)
+	c.sorted = n == 0 || c.sorted && c.bounds[n-1][0] < start
+	c.bounds = append(c.bounds, [2]int{start, end})
+}
+
+// segments returns a copy of the bounds of the segments recorded, sorted by
+// start.
+func (c *collector) segments() [][2]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.sorted {
+		slices.SortFunc(c.bounds, func(a, b [2]int) int { return a[0] - b[0] })
+		c.sorted = true
+	}
+	return slices.Clone(c.bounds)
+}
+
+// reset replaces the segments recorded by those of bounds, which must be
+// sorted by start.
+func (c *collector) reset(bounds [][2]int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bounds = append(c.bounds[:0], bounds...)
+	c.sorted = true
+}
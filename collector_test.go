@@ -0,0 +1,53 @@
+package cbsgo_test
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestCollectorOrder(t *testing.T) {
+	var c cbsgo.Collector
+	for _, b := range [][2]int{{5, 8}, {0, 2}, {8, 10}, {2, 5}} {
+		c.Add(b[0], b[1])
+	}
+	expected := [][2]int{{0, 2}, {2, 5}, {5, 8}, {8, 10}}
+	if got := c.Segments(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+	}
+
+	// Adding after a read keeps the segments sorted.
+	c.Add(10, 12)
+	expected = append(expected, [2]int{10, 12})
+	if got := c.Segments(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+	}
+}
+
+func TestCollectorConcurrent(t *testing.T) {
+	const n = 1000
+	var c cbsgo.Collector
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := w; i < n; i += 4 {
+				c.Add(i, i+1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got := c.Segments()
+	if len(got) != n {
+		t.Fatalf("Unexpected result.\nExpected: %v\nGot: %v", n, len(got))
+	}
+	for i, b := range got {
+		if b != [2]int{i, i + 1} {
+			t.Fatalf("Unexpected result.\nExpected: %v\nGot: %v", [2]int{i, i + 1}, b)
+		}
+	}
+}
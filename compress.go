@@ -0,0 +1,108 @@
+package cbsgo
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is a compression format of the files read and written by
+// cbsgo. Readers such as ReadBedGraph, ReadBED and ReadWIG decompress gzip
+// and zstd input transparently; see Decompress.
+type Compression int
+
+const (
+	Uncompressed Compression = iota
+	// Gzip also covers the BGZF format written by bgzip, which is readable
+	// as gzip.
+	Gzip
+	Zstd
+)
+
+// String returns the name of the format, as accepted by ParseCompression.
+func (c Compression) String() string {
+	switch c {
+	case Gzip:
+		return "gzip"
+	case Zstd:
+		return "zstd"
+	}
+	return "none"
+}
+
+// ParseCompression returns the format named "none", "gzip" or "zstd".
+func ParseCompression(name string) (Compression, error) {
+	for _, c := range []Compression{Uncompressed, Gzip, Zstd} {
+		if name == c.String() {
+			return c, nil
+		}
+	}
+	return Uncompressed, fmt.Errorf("cbsgo: unknown compression %q", name)
+}
+
+// CompressionOf returns the format implied by the extension of a file name:
+// Gzip for .gz and .bgz, Zstd for .zst, and Uncompressed otherwise.
+func CompressionOf(name string) Compression {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".gz", ".bgz":
+		return Gzip
+	case ".zst":
+		return Zstd
+	}
+	return Uncompressed
+}
+
+// NewCompressedWriter returns a writer compressing the data written to it
+// to w in format c. Close flushes the compressed data; it does not close w.
+func NewCompressedWriter(w io.Writer, c Compression) (io.WriteCloser, error) {
+	switch c {
+	case Uncompressed:
+		return nopWriteCloser{w}, nil
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Zstd:
+		return zstd.NewWriter(w)
+	}
+	return nil, fmt.Errorf("cbsgo: unknown compression %d", c)
+}
+
+// Decompress returns a reader of the decompressed data of r when r holds
+// gzip or zstd data, detected by their magic numbers, and of the data of r
+// itself otherwise. Close releases the resources of the decompressor; it does
+// not close r.
+func Decompress(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("cbsgo: %w", err)
+		}
+		return zr, nil
+	case bytes.Equal(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		zr, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("cbsgo: %w", err)
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return io.NopCloser(br), nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
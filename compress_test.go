@@ -0,0 +1,64 @@
+package cbsgo_test
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestCompression(t *testing.T) {
+	input := "chr1\t0\t10\t1.5\nchr1\t10\t20\t2.5\n"
+	for _, c := range []cbsgo.Compression{cbsgo.Uncompressed, cbsgo.Gzip, cbsgo.Zstd} {
+		var buf bytes.Buffer
+		w, err := cbsgo.NewCompressedWriter(&buf, c)
+		if err != nil {
+			t.Fatalf("%v: NewCompressedWriter returned an unexpected error: %v", c, err)
+		}
+		io.WriteString(w, input)
+		if err := w.Close(); err != nil {
+			t.Fatalf("%v: Close returned an unexpected error: %v", c, err)
+		}
+		if c != cbsgo.Uncompressed && buf.String() == input {
+			t.Errorf("%v: expected compressed output", c)
+		}
+
+		tracks, err := cbsgo.ReadBedGraph(&buf)
+		if err != nil {
+			t.Fatalf("%v: ReadBedGraph returned an unexpected error: %v", c, err)
+		}
+		if expected := []float64{1.5, 2.5}; len(tracks) != 1 || !reflect.DeepEqual(tracks[0].Values, expected) {
+			t.Errorf("%v: unexpected result %v", c, tracks)
+		}
+
+		parsed, err := cbsgo.ParseCompression(c.String())
+		if err != nil || parsed != c {
+			t.Errorf("%v: ParseCompression returned %v, %v", c, parsed, err)
+		}
+	}
+
+	// Empty and short input is not compressed.
+	for _, input := range []string{"", "1"} {
+		r, err := cbsgo.Decompress(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("Decompress returned an unexpected error: %v", err)
+		}
+		if got, _ := io.ReadAll(r); string(got) != input {
+			t.Errorf("Unexpected result.\nExpected: %q\nGot: %q", input, got)
+		}
+	}
+
+	for name, expected := range map[string]cbsgo.Compression{
+		"a.bed": cbsgo.Uncompressed, "a.bed.gz": cbsgo.Gzip, "a.BGZ": cbsgo.Gzip, "a.tsv.zst": cbsgo.Zstd,
+	} {
+		if got := cbsgo.CompressionOf(name); got != expected {
+			t.Errorf("%s: expected %v, got %v", name, expected, got)
+		}
+	}
+	if _, err := cbsgo.ParseCompression("bzip2"); err == nil {
+		t.Errorf("Expected an error for an unknown compression")
+	}
+}
@@ -0,0 +1,174 @@
+package cbsgo
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// WithConfirmation segments in two stages, so that the reported
+// significance of the breakpoints is not biased by testing them on the
+// values that placed them: candidate breakpoints are discovered by
+// segmenting the even bins at the significance level of WithAlpha, which
+// can be loose, and each is then confirmed by a permutation test of the
+// difference between the means of the odd bins on either side, at the
+// significance level alpha. Unconfirmed breakpoints are removed, least
+// significant first, and only the breakpoints next to them tested again, as
+// the segments they separated are merged. The p-values of WithBreakpoints are
+// those of the confirmation tests.
+//
+// Breakpoints are placed at a resolution of two bins, and the minimum width
+// of WithMinWidth applies to the even bins. WithConfirmation cannot be
+// combined with WithConsensus, segments are written by WithWriter once the
+// run is complete, and interrupted runs return no result.
+func WithConfirmation(alpha float64) Option {
+	return func(c *config) {
+		c.confirmAlpha = alpha
+	}
+}
+
+// confirm segments sg.x as configured by WithConfirmation.
+func (sg *segmenter) confirm() error {
+	n := len(sg.x)
+	if n < 4 {
+		return sg.add(0, n)
+	}
+	even, odd := alternate(sg.x)
+	var evenW, oddW []float64
+	if sg.w != nil {
+		evenW, oddW = alternate(sg.w)
+	}
+	seed := sg.cfg.seed
+	if seed != 0 {
+		seed = DeriveSeed(seed, sg.cfg.sample, fmt.Sprintf("%s:discovery", sg.cfg.chrom))
+	}
+	res, err := RunContext(sg.ctx, even,
+		WithSeed(seed),
+		WithShuffles(sg.cfg.shuffles),
+		WithAlpha(sg.cfg.alpha),
+		WithAlphaAdjustment(sg.cfg.adjustment),
+		WithWeights(evenW),
+		WithDirection(sg.cfg.direction),
+		WithTieBreak(sg.cfg.tie),
+		WithMinWidth((sg.cfg.minWidth+1)/2),
+		WithNullCache(sg.cfg.nulls),
+	)
+	if err != nil {
+		return err
+	}
+
+	// The breakpoint before even bin b is placed before bin 2b, so that the
+	// odd bins before it are the first b.
+	bins := make([]int, 0, len(res.Segments))
+	for _, s := range res.Segments[1:] {
+		bins = append(bins, 2*s.BinStart)
+	}
+	// The p-values are cached, NaN until tested: removing a breakpoint only
+	// merges the segments next to it, so that only its neighbours are tested
+	// again.
+	pvalues := make([]float64, len(bins))
+	for i := range pvalues {
+		pvalues[i] = math.NaN()
+	}
+	bound := func(i int) int {
+		switch i {
+		case -1:
+			return 0
+		case len(bins):
+			return len(odd)
+		}
+		return bins[i] / 2
+	}
+	for {
+		worst := -1
+		for i := range bins {
+			if math.IsNaN(pvalues[i]) {
+				lo, mid, hi := bound(i-1), bound(i), bound(i+1)
+				var wl, wr []float64
+				if oddW != nil {
+					wl, wr = oddW[lo:mid], oddW[mid:hi]
+				}
+				pvalues[i] = sg.permuteMeans(odd[lo:mid], odd[mid:hi], wl, wr)
+				sg.stats.Tests++
+			}
+			if worst < 0 || pvalues[i] > pvalues[worst] {
+				worst = i
+			}
+		}
+		if worst < 0 || pvalues[worst] <= sg.cfg.confirmAlpha {
+			break
+		}
+		bins = slices.Delete(bins, worst, worst+1)
+		pvalues = slices.Delete(pvalues, worst, worst+1)
+		if worst > 0 {
+			pvalues[worst-1] = math.NaN()
+		}
+		if worst < len(bins) {
+			pvalues[worst] = math.NaN()
+		}
+	}
+
+	start := 0
+	for i, bin := range bins {
+		sg.record(bin, split{pvalue: pvalues[i]})
+		if err := sg.add(start, bin); err != nil {
+			return err
+		}
+		start = bin
+	}
+	return sg.add(start, n)
+}
+
+// alternate returns the values of x at even and at odd indices.
+func alternate(x []float64) (even, odd []float64) {
+	for i, v := range x {
+		if i%2 == 0 {
+			even = append(even, v)
+		} else {
+			odd = append(odd, v)
+		}
+	}
+	return even, odd
+}
+
+// permuteMeans returns the permutation p-value of the absolute difference
+// between the means of a and b, weighted by wa and wb unless nil, with the
+// shuffles of the run. It is 1 if either is empty.
+func (sg *segmenter) permuteMeans(a, b, wa, wb []float64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 1
+	}
+	x := append(slices.Clone(a), b...)
+	w := make([]float64, len(x))
+	for i := range w {
+		w[i] = 1
+	}
+	if wa != nil {
+		copy(w, wa)
+		copy(w[len(a):], wb)
+	}
+	diff := func() float64 {
+		var sa, swa, sb, swb float64
+		for i, v := range x {
+			if i < len(a) {
+				sa, swa = sa+w[i]*v, swa+w[i]
+			} else {
+				sb, swb = sb+w[i]*v, swb+w[i]
+			}
+		}
+		return math.Abs(sa/swa - sb/swb)
+	}
+	observed := diff()
+	exceed := 0
+	for range sg.cfg.shuffles {
+		sg.rng.Shuffle(len(x), func(i, j int) {
+			x[i], x[j] = x[j], x[i]
+			w[i], w[j] = w[j], w[i]
+		})
+		sg.stats.Permutations++
+		if diff() >= observed {
+			exceed++
+		}
+	}
+	return float64(exceed+1) / float64(sg.cfg.shuffles+1)
+}
@@ -0,0 +1,88 @@
+package cbsgo_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestWithConfirmation(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	noise := make([]float64, 400)
+	x := make([]float64, 400)
+	for i := range x {
+		noise[i] = rng.NormFloat64()
+		x[i] = noise[i]
+		if i >= 200 {
+			x[i] += 2
+		}
+	}
+
+	// The loose significance level of the discovery finds spurious
+	// changepoints in noise, which are not confirmed.
+	res, err := cbsgo.Run(noise, cbsgo.WithSeed(42), cbsgo.WithAlpha(0.5))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if len(res.Segments) < 2 {
+		t.Fatalf("Expected spurious segments at a significance level of 0.5, got %v", res.Segments)
+	}
+	res, err = cbsgo.Run(noise, cbsgo.WithSeed(42), cbsgo.WithAlpha(0.5), cbsgo.WithConfirmation(0.01))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if len(res.Segments) != 1 {
+		t.Errorf("Expected a single confirmed segment, got %v", res.Segments)
+	}
+
+	// Every candidate of the discovery is tested once, and the neighbours of
+	// every removed one once more, rather than all remaining ones.
+	long := make([]float64, 4000)
+	var even []float64
+	for i := range long {
+		long[i] = rng.NormFloat64()
+		if i%2 == 0 {
+			even = append(even, long[i])
+		}
+	}
+	discovery, err := cbsgo.Run(even, cbsgo.WithSeed(cbsgo.DeriveSeed(42, "", ":discovery")), cbsgo.WithAlpha(0.9))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	var r recorder
+	if _, err := cbsgo.Run(long, cbsgo.WithSeed(42), cbsgo.WithAlpha(0.9), cbsgo.WithConfirmation(0.01), cbsgo.WithMetrics(&r)); err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	candidates := len(discovery.Segments) - 1
+	if tests := r.stats[0].Tests; candidates < 10 || tests > 3*candidates {
+		t.Errorf("Expected at most %d tests of %d candidates, got %d", 3*candidates, candidates, tests)
+	}
+
+	res, err = cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithAlpha(0.5), cbsgo.WithConfirmation(0.01), cbsgo.WithBreakpoints(0.95))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if len(res.Breakpoints) != 1 || abs(res.Breakpoints[0].Bin-200) > 4 || res.Breakpoints[0].Bin%2 != 0 {
+		t.Fatalf("Expected a single breakpoint near 200, got %+v", res.Breakpoints)
+	}
+	if p := res.Breakpoints[0].PValue; !(p > 0 && p <= 0.01) {
+		t.Errorf("Unexpected p-value %v of the confirmation test", p)
+	}
+	if n := len(res.Segments); n != 2 || res.Segments[1].BinEnd != len(x) {
+		t.Errorf("Unexpected segments %v", res.Segments)
+	}
+
+	for _, opts := range [][]cbsgo.Option{
+		{cbsgo.WithConfirmation(-0.1)},
+		{cbsgo.WithConfirmation(1)},
+		{cbsgo.WithConfirmation(0.01), cbsgo.WithConsensus(3)},
+	} {
+		if _, err := cbsgo.Run(x, opts...); err == nil {
+			t.Error("Expected an error for invalid confirmation settings")
+		}
+	}
+	if _, err := cbsgo.RunJoint([][]float64{x, noise}, cbsgo.WithConfirmation(0.01)); err == nil {
+		t.Error("Expected an error for a joint segmentation")
+	}
+}
@@ -0,0 +1,92 @@
+package cbsgo
+
+import (
+	"sort"
+)
+
+// Breakpoint is a boundary between two adjacent segments.
+type Breakpoint struct {
+	Chrom string
+	// Bin is the index of the first bin after the breakpoint.
+	Bin int
+	// Position is the coordinate of the breakpoint: the start of Bin when the
+	// bins have genomic positions, Bin otherwise, unless refined.
+	Position int
+
+	// Lower and Upper bound the confidence interval of Position, Delta is the
+	// difference between the means after and before the breakpoint, and
+	// PValue the p-value of the split that introduced it. See
+	// WithBreakpoints.
+	Lower  int
+	Upper  int
+	Delta  float64
+	PValue float64
+
+	// Support is the fraction of repeated runs supporting the breakpoint, such
+	// as consensus runs, ensemble methods, or jackknife replicates.
+	Support float64
+	// Unstable is set when not all repeated runs support the breakpoint.
+	Unstable bool
+
+	// SplitReads is the number of split reads supporting Position, when
+	// refined to base-pair resolution. See coverage.RefineBreakpoints.
+	SplitReads int
+}
+
+// consensus segments sg.x once per configured run and combines the results.
+func (sg *segmenter) consensus() (*Result, error) {
+	runs := sg.cfg.consensus
+	seeds := newRand(sg.cfg.seed)
+
+	counts := make(map[int]int)
+	for i := 0; i < runs; i++ {
+		if err := sg.ctx.Err(); err != nil {
+			return nil, err
+		}
+		seed := seeds.Int63()
+		if seed == 0 {
+			seed = 1
+		}
+		sg.rng = newRand(seed)
+		sg.segments.reset(nil)
+		sg.tested = 0
+		if err := sg.rsegment(0, len(sg.x)); err != nil {
+			return nil, err
+		}
+		if sg.interrupted != nil {
+			return nil, sg.interrupted
+		}
+		// Every segment but the first starts at a breakpoint.
+		bounds := sg.segments.segments()
+		for j := 1; j < len(bounds); j++ {
+			counts[bounds[j][0]]++
+		}
+	}
+
+	bins := make([]int, 0, len(counts))
+	for bin := range counts {
+		bins = append(bins, bin)
+	}
+	sort.Ints(bins)
+
+	res := &Result{Breakpoints: make([]Breakpoint, len(bins))}
+	var bounds [][2]int
+	start := 0
+	for i, bin := range bins {
+		res.Breakpoints[i] = Breakpoint{
+			Bin:      bin,
+			Position: sg.position(bin),
+			Support:  float64(counts[bin]) / float64(runs),
+			Unstable: counts[bin] < runs,
+		}
+		if 2*counts[bin] > runs {
+			bounds = append(bounds, [2]int{start, bin})
+			start = bin
+		}
+	}
+	if start < len(sg.x) {
+		bounds = append(bounds, [2]int{start, len(sg.x)})
+	}
+	res.Segments = sg.summarize(bounds)
+	return res, nil
+}
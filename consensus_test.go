@@ -0,0 +1,80 @@
+package cbsgo_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// borderline returns a profile with a strong breakpoint at bin 39 and a
+// breakpoint of the given strength at bin 18.
+func borderline(shift float64) []float64 {
+	pattern := []float64{0, 0.3, -0.2, 0.1, -0.3, 0.2}
+	x := make([]float64, 60)
+	for i := range x {
+		x[i] = pattern[i%len(pattern)]
+		if i >= 20 && i < 40 {
+			x[i] += shift
+		}
+		if i >= 40 {
+			x[i] += 10
+		}
+	}
+	return x
+}
+
+func TestRunConsensus(t *testing.T) {
+	tests := []struct {
+		name        string
+		shift       float64
+		breakpoints []cbsgo.Breakpoint
+		segments    [][2]int
+	}{
+		{
+			name:  "minority",
+			shift: 0.24,
+			breakpoints: []cbsgo.Breakpoint{
+				{Bin: 18, Position: 18, Support: 0.25, Unstable: true},
+				{Bin: 39, Position: 39, Support: 1},
+			},
+			segments: [][2]int{{0, 39}, {39, 60}},
+		},
+		{
+			name:  "majority",
+			shift: 0.26,
+			breakpoints: []cbsgo.Breakpoint{
+				{Bin: 18, Position: 18, Support: 0.9, Unstable: true},
+				{Bin: 39, Position: 39, Support: 1},
+			},
+			segments: [][2]int{{0, 18}, {18, 39}, {39, 60}},
+		},
+	}
+
+	for _, tt := range tests {
+		res, err := cbsgo.Run(borderline(tt.shift), cbsgo.WithSeed(5), cbsgo.WithShuffles(200), cbsgo.WithConsensus(20))
+		if err != nil {
+			t.Fatalf("%s: Run returned an unexpected error: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(res.Breakpoints, tt.breakpoints) {
+			t.Errorf("%s: unexpected breakpoints.\nExpected: %v\nGot: %v", tt.name, tt.breakpoints, res.Breakpoints)
+		}
+		var segments [][2]int
+		for _, s := range res.Segments {
+			segments = append(segments, [2]int{s.BinStart, s.BinEnd})
+		}
+		if !reflect.DeepEqual(segments, tt.segments) {
+			t.Errorf("%s: unexpected segments.\nExpected: %v\nGot: %v", tt.name, tt.segments, segments)
+		}
+	}
+}
+
+func TestRunConsensusEmpty(t *testing.T) {
+	res, err := cbsgo.Run(nil, cbsgo.WithConsensus(3))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if len(res.Segments) != 0 || len(res.Breakpoints) != 0 {
+		t.Errorf("Expected an empty result, got %+v", res)
+	}
+}
@@ -0,0 +1,105 @@
+package cbsgo
+
+import "fmt"
+
+// Convention is a convention of the coordinates of genomic intervals.
+// cbsgo works with zero-based, half-open intervals throughout, as in BED,
+// and converts the coordinates of other conventions when reading or writing
+// them.
+//
+// Formats that define their convention, such as BED, bedGraph, WIG, VCF and
+// SEG, are always read and written in it, so that a mismatch is reported by
+// their SetCoordinates methods rather than producing files other tools
+// misread.
+type Convention int
+
+const (
+	// DefaultConvention is the convention of the format read or written,
+	// zero-based for those that define none.
+	DefaultConvention Convention = iota
+	// ZeroBased intervals [start, end) hold the bases start to end-1,
+	// counted from 0, as in BED and bedGraph.
+	ZeroBased
+	// OneBased intervals [start, end] hold the bases start to end, counted
+	// from 1, as in VCF, GFF, SAM and SEG.
+	OneBased
+)
+
+// String returns the name of the convention, as accepted by
+// ParseConvention.
+func (c Convention) String() string {
+	switch c {
+	case ZeroBased:
+		return "0-based"
+	case OneBased:
+		return "1-based"
+	}
+	return "default"
+}
+
+// ParseConvention returns the convention named s: default, 0-based or
+// 1-based.
+func ParseConvention(s string) (Convention, error) {
+	for _, c := range []Convention{DefaultConvention, ZeroBased, OneBased} {
+		if s == c.String() {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("cbsgo: unknown coordinate convention %q", s)
+}
+
+// Or returns c, or def if c is DefaultConvention.
+func (c Convention) Or(def Convention) Convention {
+	if c == DefaultConvention {
+		return def
+	}
+	return c
+}
+
+// Encode returns the coordinates of the zero-based, half-open interval
+// [start, end) in the convention c, DefaultConvention being zero-based.
+func (c Convention) Encode(start, end int) (int, int) {
+	if c == OneBased {
+		return start + 1, end
+	}
+	return start, end
+}
+
+// Decode returns the zero-based, half-open interval of the coordinates
+// start and end in the convention c, DefaultConvention being zero-based.
+func (c Convention) Decode(start, end int) (int, int) {
+	if c == OneBased {
+		return start - 1, end
+	}
+	return start, end
+}
+
+// Coordinates configures the coordinates of the segments written by the
+// SegmentWriters of cbsgo, set with their SetCoordinates methods.
+type Coordinates struct {
+	// Convention is the convention of the coordinates written.
+	Convention Convention
+	// Bins writes the bin indices BinStart and BinEnd of the segments in
+	// place of their positions Start and End, such as to match them with
+	// the values segmented.
+	Bins bool
+}
+
+// interval returns the coordinates of the bins [binStart, binEnd) at the
+// positions [start, end), as configured by c, in the convention def if c
+// has none.
+func (c Coordinates) interval(start, end, binStart, binEnd int, def Convention) (int, int) {
+	if c.Bins {
+		start, end = binStart, binEnd
+	}
+	return c.Convention.Or(def).Encode(start, end)
+}
+
+// check returns an error if c sets a convention other than that of the
+// named format, def.
+func (c Coordinates) check(format string, def Convention) error {
+	if c.Convention.Or(def) != def {
+		return fmt.Errorf("cbsgo: %s coordinates are %v, not %v", format, def, c.Convention)
+	}
+	return nil
+}
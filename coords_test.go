@@ -0,0 +1,100 @@
+package cbsgo_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestConvention(t *testing.T) {
+	for _, c := range []cbsgo.Convention{cbsgo.DefaultConvention, cbsgo.ZeroBased, cbsgo.OneBased} {
+		if got, err := cbsgo.ParseConvention(c.String()); err != nil || got != c {
+			t.Errorf("Unexpected result %v, %v for %v", got, err, c)
+		}
+		start, end := c.Encode(9, 20)
+		if start, end = c.Decode(start, end); start != 9 || end != 20 {
+			t.Errorf("%v: unexpected round trip [%d, %d) of [9, 20)", c, start, end)
+		}
+	}
+	if start, end := cbsgo.OneBased.Encode(9, 20); start != 10 || end != 20 {
+		t.Errorf("Unexpected one-based coordinates %d-%d of [9, 20)", start, end)
+	}
+	if c := cbsgo.DefaultConvention.Or(cbsgo.OneBased); c != cbsgo.OneBased {
+		t.Errorf("Unexpected convention %v", c)
+	}
+	if c := cbsgo.ZeroBased.Or(cbsgo.OneBased); c != cbsgo.ZeroBased {
+		t.Errorf("Unexpected convention %v", c)
+	}
+	if _, err := cbsgo.ParseConvention("2-based"); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestSetCoordinates(t *testing.T) {
+	s := cbsgo.Segment{Chrom: "chr1", Start: 1000, End: 3000, BinStart: 1, BinEnd: 3, Mean: 0.5}
+	tests := []struct {
+		name     string
+		writer   func(*bytes.Buffer) cbsgo.SegmentWriter
+		coords   cbsgo.Coordinates
+		expected string
+	}{
+		{
+			name:     "BED",
+			writer:   func(b *bytes.Buffer) cbsgo.SegmentWriter { return cbsgo.NewBEDWriter(b) },
+			coords:   cbsgo.Coordinates{Convention: cbsgo.ZeroBased},
+			expected: "chr1\t1000\t3000\t0.5\n",
+		},
+		{
+			name:     "BED bins",
+			writer:   func(b *bytes.Buffer) cbsgo.SegmentWriter { return cbsgo.NewBEDWriter(b) },
+			coords:   cbsgo.Coordinates{Bins: true},
+			expected: "chr1\t1\t3\t0.5\n",
+		},
+		{
+			name:     "bedGraph bins",
+			writer:   func(b *bytes.Buffer) cbsgo.SegmentWriter { return cbsgo.NewBedGraphWriter(b) },
+			coords:   cbsgo.Coordinates{Bins: true},
+			expected: "chr1\t1\t3\t0.5\n",
+		},
+		{
+			name:     "SEG bins",
+			writer:   func(b *bytes.Buffer) cbsgo.SegmentWriter { return cbsgo.NewSEGWriter(b, "s", false) },
+			coords:   cbsgo.Coordinates{Convention: cbsgo.OneBased, Bins: true},
+			expected: "s\tchr1\t2\t3\t2\t0.5\n",
+		},
+		{
+			name:     "JSONL",
+			writer:   func(b *bytes.Buffer) cbsgo.SegmentWriter { return cbsgo.NewJSONLWriter(b, "") },
+			coords:   cbsgo.Coordinates{Convention: cbsgo.OneBased},
+			expected: `{"chrom":"chr1","start":1001,"end":3000,"bin_start":1,"bin_end":3,"mean":0.5,"sd":0}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		w := tt.writer(&b)
+		if err := w.(interface{ SetCoordinates(cbsgo.Coordinates) error }).SetCoordinates(tt.coords); err != nil {
+			t.Fatalf("%s: SetCoordinates returned an unexpected error: %v", tt.name, err)
+		}
+		if err := w.Write(s); err != nil {
+			t.Fatalf("%s: Write returned an unexpected error: %v", tt.name, err)
+		}
+		w.Close()
+		if b.String() != tt.expected {
+			t.Errorf("%s: unexpected result.\nExpected: %q\nGot: %q", tt.name, tt.expected, b.String())
+		}
+	}
+
+	var b strings.Builder
+	for name, err := range map[string]error{
+		"BED":      cbsgo.NewBEDWriter(&b).SetCoordinates(cbsgo.Coordinates{Convention: cbsgo.OneBased}),
+		"bedGraph": cbsgo.NewBedGraphWriter(&b).SetCoordinates(cbsgo.Coordinates{Convention: cbsgo.OneBased}),
+		"SEG":      cbsgo.NewSEGWriter(&b, "s", true).SetCoordinates(cbsgo.Coordinates{Convention: cbsgo.ZeroBased}),
+		"VCF":      cbsgo.NewVCFWriter(&b, 0.2).SetCoordinates(cbsgo.Coordinates{Bins: true}),
+	} {
+		if err == nil {
+			t.Errorf("%s: expected an error for coordinates other than those of the format", name)
+		}
+	}
+}
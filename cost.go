@@ -0,0 +1,110 @@
+package cbsgo
+
+import (
+	"math"
+	"math/rand"
+	"time"
+	"unsafe"
+)
+
+// Cost is an estimate of the resources taken by a segmentation run.
+type Cost struct {
+	// Tests is the number of intervals tested for a changepoint, and
+	// Permutations the number of permutations performed by the tests.
+	Tests        int
+	Permutations int
+	// Runtime is the expected wall-clock time of the run.
+	Runtime time.Duration
+	// PeakMemory is the peak number of bytes allocated by the run, not
+	// counting the input.
+	PeakMemory int
+}
+
+// CostModel estimates the cost of segmentation runs.
+type CostModel struct {
+	// PerBin is the time taken to shuffle a single bin and compute its
+	// contribution to the test statistic.
+	PerBin time.Duration
+}
+
+// DefaultCostModel returns a cost model typical of a current desktop CPU.
+func DefaultCostModel() CostModel {
+	return CostModel{PerBin: 10 * time.Nanosecond}
+}
+
+// CalibrateCostModel returns a cost model measured on the current machine
+// by a micro-benchmark taking a few milliseconds.
+func CalibrateCostModel() CostModel {
+	const n, shuffles = 4096, 64
+	rng := rand.New(rand.NewSource(1))
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = rng.NormFloat64()
+	}
+	data := &singleInterval{x: x, y: make([]float64, n)}
+	start := time.Now()
+	for range shuffles {
+		data.shuffle(rng)
+		data.stat()
+	}
+	return CostModel{PerBin: max(time.Since(start)/(n*shuffles), time.Nanosecond)}
+}
+
+// EstimateCost estimates the cost of segmenting n values with Run configured
+// by opts, using DefaultCostModel.
+func EstimateCost(n int, opts ...Option) Cost {
+	return DefaultCostModel().Estimate(n, opts...)
+}
+
+// Estimate estimates the cost of segmenting n values with Run configured by
+// opts.
+//
+// The estimate is pessimistic: it assumes that every test finds a
+// significant changepoint splitting its interval in halves, down to
+// intervals of 10 bins, so that every test performs all permutations. Tests
+// of intervals without changepoints stop their permutations early, so that
+// profiles with few changepoints take a fraction of the estimate.
+func (m CostModel) Estimate(n int, opts ...Option) Cost {
+	cfg := newConfig(opts)
+	if n <= 0 {
+		return Cost{}
+	}
+	runs := max(cfg.consensus, 1)
+
+	var c Cost
+	var binOps float64
+	for length := float64(n); ; length /= 2 {
+		tests := math.Ceil(float64(n) / length)
+		c.Tests += int(tests)
+		binOps += float64(n)
+		if length < 20 {
+			break
+		}
+	}
+	if cfg.nulls != nil && cfg.direction == TwoSided && cfg.starts == nil && cfg.weights == nil {
+		// Cached null distributions replace the permutations.
+		binOps = float64(c.Tests)
+	} else {
+		c.Permutations = c.Tests * cfg.shuffles
+		binOps *= float64(cfg.shuffles)
+	}
+
+	// Weighted statistics take about half as long again.
+	perBin := float64(m.PerBin)
+	buffers := 2
+	if cfg.starts != nil || cfg.weights != nil {
+		perBin *= 1.5
+		buffers = 4
+	}
+	c.Tests *= runs
+	c.Permutations *= runs
+	c.Runtime = time.Duration(binOps * perBin * float64(runs))
+
+	// The scratch buffers, the bin widths, and the segments.
+	segments := n/10 + 1
+	c.PeakMemory = 8*n*buffers + segments*int(unsafe.Sizeof(Segment{})+unsafe.Sizeof([2]int{}))
+	if cfg.starts != nil {
+		c.PeakMemory += 8 * n
+	}
+	return c
+}
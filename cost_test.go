@@ -0,0 +1,45 @@
+package cbsgo_test
+
+import (
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestEstimateCost(t *testing.T) {
+	c := cbsgo.EstimateCost(1000, cbsgo.WithShuffles(100))
+	if c.Tests == 0 || c.Permutations != 100*c.Tests || c.Runtime <= 0 || c.PeakMemory < 16000 {
+		t.Errorf("Unexpected cost %+v", c)
+	}
+
+	// The actual run takes no more permutations than estimated.
+	x := make([]float64, 1000)
+	for i := range x {
+		x[i] = float64(i / 100)
+	}
+	var r recorder
+	if _, err := cbsgo.Run(x, cbsgo.WithShuffles(100), cbsgo.WithSeed(42), cbsgo.WithMetrics(&r)); err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if got := r.stats[0].Permutations; got > c.Permutations {
+		t.Errorf("Expected at most %d permutations, got %d", c.Permutations, got)
+	}
+
+	consensus := cbsgo.EstimateCost(1000, cbsgo.WithShuffles(100), cbsgo.WithConsensus(5))
+	if consensus.Permutations != 5*c.Permutations {
+		t.Errorf("Expected %d permutations in consensus mode, got %d", 5*c.Permutations, consensus.Permutations)
+	}
+	cached := cbsgo.EstimateCost(1000, cbsgo.WithNullCache(cbsgo.NewNullCache(100, 1)))
+	if cached.Permutations != 0 || cached.Runtime >= c.Runtime {
+		t.Errorf("Unexpected cost with a null cache %+v", cached)
+	}
+	if (cbsgo.EstimateCost(0) != cbsgo.Cost{}) {
+		t.Errorf("Expected no cost for no values")
+	}
+}
+
+func TestCalibrateCostModel(t *testing.T) {
+	if m := cbsgo.CalibrateCostModel(); m.PerBin <= 0 {
+		t.Errorf("Unexpected cost model %+v", m)
+	}
+}
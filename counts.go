@@ -0,0 +1,94 @@
+package cbsgo
+
+import (
+	"fmt"
+	"math"
+)
+
+// CountTransform is a variance stabilizing transformation of counts, such as
+// read depths. The variance of Poisson counts grows with their mean; that of
+// the transformed counts is close to 1 whatever the mean, on which the
+// Gaussian test statistic is appropriate.
+type CountTransform int
+
+const (
+	// AnscombeTransform maps counts x to 2*sqrt(x + 3/8).
+	AnscombeTransform CountTransform = iota
+	// FreemanTukeyTransform maps counts x to sqrt(x) + sqrt(x + 1), which is
+	// more stable than the Anscombe transform for counts close to zero.
+	FreemanTukeyTransform
+)
+
+// Transform returns the transformed counts. Negative counts are treated as
+// zero.
+func (t CountTransform) Transform(counts []float64) []float64 {
+	res := make([]float64, len(counts))
+	for i, x := range counts {
+		x = math.Max(0, x)
+		switch t {
+		case FreemanTukeyTransform:
+			res[i] = math.Sqrt(x) + math.Sqrt(x+1)
+		default:
+			res[i] = 2 * math.Sqrt(x+3.0/8)
+		}
+	}
+	return res
+}
+
+// Inverse maps a transformed value back to a count. Values below the
+// transform of zero map to zero.
+func (t CountTransform) Inverse(v float64) float64 {
+	switch t {
+	case FreemanTukeyTransform:
+		if v <= 1 {
+			return 0
+		}
+		r := (v*v - 1) / (2 * v)
+		return r * r
+	default:
+		return math.Max(0, v*v/4-3.0/8)
+	}
+}
+
+// mean returns the mean count of the Poisson distribution whose transformed
+// values have mean v. Because the transforms are concave, the algebraic
+// inverse of a mean underestimates the mean count. For the Anscombe
+// transform, this uses the closed-form approximation of the exact unbiased
+// inverse by Mäkitalo and Foi (2011).
+func (t CountTransform) mean(v float64) float64 {
+	if t != AnscombeTransform || v <= 0 {
+		return t.Inverse(v)
+	}
+	s := math.Sqrt(1.5)
+	return math.Max(0, v*v/4+s/(4*v)-11/(8*v*v)+5*s/(8*v*v*v)-1.0/8)
+}
+
+// RunCounts segments counts, such as the read depths of bins, after
+// transforming them with t to stabilize their variance, and segments them
+// with Run configured by opts.
+//
+// The means of the returned segments are estimates of the mean counts,
+// mapped back from the mean of the transformed counts, and the standard
+// deviations are those of the counts.
+func RunCounts(counts []float64, t CountTransform, opts ...Option) (*Result, error) {
+	for i, x := range counts {
+		if x < 0 || math.IsNaN(x) || math.IsInf(x, 1) {
+			return nil, fmt.Errorf("cbsgo: invalid count %v at %d", x, i)
+		}
+	}
+	res, err := Run(t.Transform(counts), opts...)
+	if err != nil {
+		return nil, err
+	}
+	means := make([]float64, len(res.Segments))
+	for i, s := range res.Segments {
+		means[i] = t.mean(s.Mean)
+	}
+	if res.Segments, err = Refit(res.Segments, counts); err != nil {
+		return nil, err
+	}
+	for i := range res.Segments {
+		res.Segments[i].Mean = means[i]
+	}
+	return res, nil
+}
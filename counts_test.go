@@ -0,0 +1,65 @@
+package cbsgo_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestCountTransform(t *testing.T) {
+	for _, tr := range []cbsgo.CountTransform{cbsgo.AnscombeTransform, cbsgo.FreemanTukeyTransform} {
+		counts := []float64{0, 1, 2, 10, 250}
+		for i, v := range tr.Transform(counts) {
+			if got := tr.Inverse(v); math.Abs(got-counts[i]) > 1e-9 {
+				t.Errorf("Transform %d: unexpected round trip.\nExpected: %v\nGot: %v", tr, counts[i], got)
+			}
+		}
+		if got := tr.Inverse(-1); got != 0 {
+			t.Errorf("Transform %d: expected 0 below the transform of 0, got %v", tr, got)
+		}
+	}
+}
+
+// poisson returns a Poisson distributed count of the given mean.
+func poisson(rng *rand.Rand, mean float64) float64 {
+	limit, k, p := math.Exp(-mean), 0.0, rng.Float64()
+	for p > limit {
+		k++
+		p *= rng.Float64()
+	}
+	return k
+}
+
+func TestRunCounts(t *testing.T) {
+	// A loss from a depth of 20 to 10 between bins 100 and 200.
+	rng := rand.New(rand.NewSource(1))
+	counts := make([]float64, 300)
+	for i := range counts {
+		mean := 20.0
+		if i >= 100 && i < 200 {
+			mean = 10
+		}
+		counts[i] = poisson(rng, mean)
+	}
+
+	for _, tr := range []cbsgo.CountTransform{cbsgo.AnscombeTransform, cbsgo.FreemanTukeyTransform} {
+		res, err := cbsgo.RunCounts(counts, tr, cbsgo.WithSeed(42), cbsgo.WithAlpha(0.01))
+		if err != nil {
+			t.Fatalf("Transform %d: RunCounts returned an unexpected error: %v", tr, err)
+		}
+		if len(res.Segments) != 3 || abs(res.Segments[1].BinStart-100) > 3 || abs(res.Segments[1].BinEnd-200) > 3 {
+			t.Fatalf("Transform %d: expected a loss at bins [100, 200), got %v", tr, res.Segments)
+		}
+		for i, m := range []float64{20, 10, 20} {
+			if s := res.Segments[i]; math.Abs(s.Mean-m) > 1.5 || math.Abs(s.SD-math.Sqrt(m)) > 1.5 {
+				t.Errorf("Transform %d: segment %d has mean %v and SD %v, expected about %v and %v", tr, i, s.Mean, s.SD, m, math.Sqrt(m))
+			}
+		}
+	}
+
+	if _, err := cbsgo.RunCounts([]float64{1, -1}, cbsgo.AnscombeTransform); err == nil {
+		t.Errorf("Expected an error for a negative count")
+	}
+}
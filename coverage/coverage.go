@@ -0,0 +1,153 @@
+// Package coverage counts sequencing reads in fixed-size genomic bins,
+// producing the read depth tracks that cbsgo segments after normalization.
+package coverage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/biogo/hts/bam"
+	"github.com/biogo/hts/sam"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// Options configures the counting of reads.
+type Options struct {
+	// BinSize is the size of the bins in base pairs, or 0 to select it from
+	// the data with ReadsPerBin.
+	BinSize int
+	// ReadsPerBin is the number of reads per bin targeted when BinSize is 0.
+	// The reads are then counted in bins of MinBinSize base pairs, which are
+	// merged into bins of the multiple of MinBinSize closest to the size
+	// returned by SelectBinSize for the mean depth and read length of the
+	// counted reads. MinBinSize defaults to 1 kb.
+	ReadsPerBin int
+	MinBinSize  int
+	// MinMapQ is the smallest mapping quality of counted reads.
+	MinMapQ byte
+	// KeepDuplicates counts reads flagged as PCR or optical duplicates.
+	KeepDuplicates bool
+}
+
+// DefaultOptions returns options suited to shallow whole genome sequencing:
+// bins of 50 kb and reads with a mapping quality of at least 20.
+func DefaultOptions() Options {
+	return Options{BinSize: 50_000, MinMapQ: 20}
+}
+
+// CountBAM counts the reads of the BAM file read from r by the bin holding
+// their leftmost aligned base, and returns one track of counts per reference
+// sequence of the header, in header order, including those without reads, so
+// that the tracks of files aligned to the same reference match. Unmapped,
+// secondary, supplementary and QC-failed reads are skipped, as are duplicates
+// unless opts.KeepDuplicates is set. The last bin of a reference ends at the
+// end of the reference.
+func CountBAM(r io.Reader, opts Options) ([]*cbsgo.Track, error) {
+	binSize := opts.BinSize
+	auto := binSize == 0 && opts.ReadsPerBin > 0
+	if auto {
+		if binSize = opts.MinBinSize; binSize == 0 {
+			binSize = 1000
+		}
+	}
+	if binSize < 1 {
+		return nil, fmt.Errorf("cbsgo: invalid bin size %d", binSize)
+	}
+	br, err := bam.NewReader(r, 1)
+	if err != nil {
+		return nil, fmt.Errorf("cbsgo: reading BAM: %w", err)
+	}
+	defer br.Close()
+
+	refs := br.Header().Refs()
+	counts := make([][]float64, len(refs))
+	for id, ref := range refs {
+		counts[id] = make([]float64, (ref.Len()+binSize-1)/binSize)
+	}
+	skip := sam.Unmapped | sam.Secondary | sam.Supplementary | sam.QCFail
+	if !opts.KeepDuplicates {
+		skip |= sam.Duplicate
+	}
+	// reads and bases count the reads and their bases, to select the bin size.
+	var reads, bases int
+	for {
+		rec, err := br.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cbsgo: reading BAM: %w", err)
+		}
+		if rec.Flags&skip != 0 || rec.MapQ < opts.MinMapQ || rec.Ref == nil || rec.Pos < 0 {
+			continue
+		}
+		id := rec.Ref.ID()
+		if bin := rec.Pos / binSize; bin < len(counts[id]) {
+			counts[id][bin]++
+		}
+		reads++
+		bases += rec.Seq.Length
+	}
+
+	if auto {
+		genome := 0
+		for _, ref := range refs {
+			genome += ref.Len()
+		}
+		if reads == 0 || bases == 0 {
+			return nil, errors.New("cbsgo: no reads to select the bin size from")
+		}
+		size, err := SelectBinSize(float64(bases)/float64(genome), bases/reads, opts.ReadsPerBin)
+		if err != nil {
+			return nil, err
+		}
+		factor := max(1, int(math.Round(float64(size)/float64(binSize))))
+		for id, c := range counts {
+			counts[id] = merge(c, factor)
+		}
+		binSize *= factor
+	}
+
+	var tracks []*cbsgo.Track
+	for id, c := range counts {
+		if len(c) == 0 {
+			// References of unknown length have no bins.
+			continue
+		}
+		t := &cbsgo.Track{Chrom: refs[id].Name(), Starts: make([]int, len(c)), Ends: make([]int, len(c)), Values: c}
+		for i := range c {
+			t.Starts[i], t.Ends[i] = i*binSize, min((i+1)*binSize, refs[id].Len())
+		}
+		tracks = append(tracks, t)
+	}
+	return tracks, nil
+}
+
+// SelectBinSize returns the bin size in base pairs expected to hold
+// readsPerBin reads of the given length at the given mean depth, which is the
+// number of sequenced bases divided by the genome size. Larger bins reduce
+// the noise of the counts, which is dominated by their Poisson variance,
+// while smaller bins resolve shorter events; a few hundred reads per bin is a
+// common compromise.
+func SelectBinSize(depth float64, readLength, readsPerBin int) (int, error) {
+	if !(depth > 0) || math.IsInf(depth, 1) || readLength < 1 || readsPerBin < 1 {
+		return 0, fmt.Errorf("cbsgo: cannot select a bin size for a depth of %v with reads of %d bp and %d reads per bin",
+			depth, readLength, readsPerBin)
+	}
+	return max(1, int(math.Round(float64(readsPerBin*readLength)/depth))), nil
+}
+
+// merge returns the sums of the consecutive groups of factor counts of c.
+func merge(c []float64, factor int) []float64 {
+	if c == nil || factor == 1 {
+		return c
+	}
+	res := make([]float64, (len(c)+factor-1)/factor)
+	for i, v := range c {
+		res[i/factor] += v
+	}
+	return res
+}
@@ -0,0 +1,194 @@
+package coverage_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/biogo/hts/bam"
+	"github.com/biogo/hts/sam"
+
+	"github.com/mattdsm/cbsgo/coverage"
+	"github.com/mattdsm/cbsgo/normalize"
+)
+
+// read is an alignment of a test BAM file.
+type read struct {
+	ref   int
+	pos   int
+	mapQ  byte
+	flags sam.Flags
+	// cigar defaults to 10M.
+	cigar []sam.CigarOp
+}
+
+// writeBAM returns a BAM file with references chr1 of 250 bp, chr2 of 100 bp
+// and chr3 of 100 bp, holding reads of 10 bp.
+func writeBAM(t *testing.T, reads []read) *bytes.Buffer {
+	t.Helper()
+	var refs []*sam.Reference
+	for _, r := range []struct {
+		name   string
+		length int
+	}{{"chr1", 250}, {"chr2", 100}, {"chr3", 100}} {
+		ref, err := sam.NewReference(r.name, "", "", r.length, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		refs = append(refs, ref)
+	}
+	h, err := sam.NewHeader(nil, refs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	w, err := bam.NewWriter(&buf, h, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq, qual := []byte("ACGTACGTAC"), bytes.Repeat([]byte{30}, 10)
+	cigar := []sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 10)}
+	for i, r := range reads {
+		c := cigar
+		if r.cigar != nil {
+			c = r.cigar
+		}
+		rec, err := sam.NewRecord("read"+string(rune('a'+i)), h.Refs()[r.ref], nil, r.pos, -1, 0, r.mapQ, c, seq, qual, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec.Flags = r.flags
+		if err := w.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestCountBAM(t *testing.T) {
+	reads := []read{
+		{ref: 0, pos: 5, mapQ: 60},
+		{ref: 0, pos: 99, mapQ: 60},
+		{ref: 0, pos: 100, mapQ: 60},
+		{ref: 0, pos: 120, mapQ: 60, flags: sam.Duplicate},
+		{ref: 0, pos: 130, mapQ: 5},
+		{ref: 0, pos: 140, mapQ: 60, flags: sam.Secondary},
+		{ref: 0, pos: 240, mapQ: 60},
+		{ref: 2, pos: 50, mapQ: 60},
+	}
+	opts := coverage.Options{BinSize: 100, MinMapQ: 20}
+	tracks, err := coverage.CountBAM(writeBAM(t, reads), opts)
+	if err != nil {
+		t.Fatalf("CountBAM returned an unexpected error: %v", err)
+	}
+	// chr2 has no reads, and its bins no counts.
+	if len(tracks) != 3 || tracks[0].Chrom != "chr1" || tracks[1].Chrom != "chr2" || tracks[2].Chrom != "chr3" {
+		t.Fatalf("Unexpected tracks %v", tracks)
+	}
+	if expected := []float64{0}; !reflect.DeepEqual(tracks[1].Values, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, tracks[1].Values)
+	}
+	if expected := []float64{2, 1, 1}; !reflect.DeepEqual(tracks[0].Values, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, tracks[0].Values)
+	}
+	if expected := []int{100, 200, 250}; !reflect.DeepEqual(tracks[0].Ends, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, tracks[0].Ends)
+	}
+	if err := tracks[0].Validate(); err != nil {
+		t.Errorf("Invalid track: %v", err)
+	}
+
+	opts.KeepDuplicates = true
+	tracks, err = coverage.CountBAM(writeBAM(t, reads), opts)
+	if err != nil {
+		t.Fatalf("CountBAM returned an unexpected error: %v", err)
+	}
+	if expected := []float64{2, 2, 1}; !reflect.DeepEqual(tracks[0].Values, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, tracks[0].Values)
+	}
+
+	if _, err := coverage.CountBAM(writeBAM(t, reads), coverage.Options{}); err == nil {
+		t.Errorf("Expected an error for a bin size of 0")
+	}
+	if _, err := coverage.CountBAM(bytes.NewBufferString("not a BAM file"), opts); err == nil {
+		t.Errorf("Expected an error for invalid input")
+	}
+}
+
+func TestCountBAMSelectBinSize(t *testing.T) {
+	reads := []read{
+		{ref: 0, pos: 5, mapQ: 60},
+		{ref: 0, pos: 99, mapQ: 60},
+		{ref: 0, pos: 100, mapQ: 60},
+		{ref: 0, pos: 120, mapQ: 60, flags: sam.Duplicate},
+		{ref: 0, pos: 240, mapQ: 60},
+		{ref: 2, pos: 50, mapQ: 60},
+	}
+	// 5 reads of 10 bp on 450 bp give a depth of 1/9, so that 2 reads per bin
+	// take bins of 180 bp.
+	opts := coverage.Options{ReadsPerBin: 2, MinBinSize: 10, MinMapQ: 20}
+	tracks, err := coverage.CountBAM(writeBAM(t, reads), opts)
+	if err != nil {
+		t.Fatalf("CountBAM returned an unexpected error: %v", err)
+	}
+	if len(tracks) != 3 {
+		t.Fatalf("Unexpected tracks %v", tracks)
+	}
+	if expected := []int{180, 250}; !reflect.DeepEqual(tracks[0].Ends, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, tracks[0].Ends)
+	}
+	if expected := []float64{3, 1}; !reflect.DeepEqual(tracks[0].Values, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, tracks[0].Values)
+	}
+	if expected := []float64{1}; !reflect.DeepEqual(tracks[2].Values, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, tracks[2].Values)
+	}
+
+	if _, err := coverage.CountBAM(writeBAM(t, nil), opts); err == nil {
+		t.Errorf("Expected an error for a BAM file without reads")
+	}
+}
+
+func TestSelectBinSize(t *testing.T) {
+	// 0.1x coverage with 150 bp reads and 200 reads per bin.
+	size, err := coverage.SelectBinSize(0.1, 150, 200)
+	if err != nil {
+		t.Fatalf("SelectBinSize returned an unexpected error: %v", err)
+	}
+	if size != 300_000 {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", 300_000, size)
+	}
+
+	for _, args := range []struct {
+		depth               float64
+		length, readsPerBin int
+	}{{0, 150, 200}, {0.1, 0, 200}, {0.1, 150, 0}} {
+		if _, err := coverage.SelectBinSize(args.depth, args.length, args.readsPerBin); err == nil {
+			t.Errorf("Expected an error for %+v", args)
+		}
+	}
+}
+
+func TestCountBAMMatchingTracks(t *testing.T) {
+	// The sample has no reads on chr3, like a female sample on chrY, and the
+	// control none on chr2: their tracks still match for normalization.
+	opts := coverage.Options{BinSize: 100, MinMapQ: 20}
+	sample, err := coverage.CountBAM(writeBAM(t, []read{{ref: 0, pos: 5, mapQ: 60}, {ref: 1, pos: 5, mapQ: 60}}), opts)
+	if err != nil {
+		t.Fatalf("CountBAM returned an unexpected error: %v", err)
+	}
+	control, err := coverage.CountBAM(writeBAM(t, []read{{ref: 0, pos: 5, mapQ: 60}, {ref: 2, pos: 5, mapQ: 60}}), opts)
+	if err != nil {
+		t.Fatalf("CountBAM returned an unexpected error: %v", err)
+	}
+	ratios, err := normalize.Log2Ratios(sample, control)
+	if err != nil {
+		t.Fatalf("Log2Ratios returned an unexpected error: %v", err)
+	}
+	if len(ratios) != 3 || ratios[0].Mask[0] || !ratios[1].Mask[0] || !ratios[2].Mask[0] {
+		t.Errorf("Unexpected ratios %v", ratios)
+	}
+}
@@ -0,0 +1,139 @@
+package coverage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/biogo/hts/bam"
+	"github.com/biogo/hts/sam"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// RefineOptions configures RefineBreakpoints.
+type RefineOptions struct {
+	// Window is the distance in base pairs from the position of a
+	// breakpoint, such as the bin size, within which clipped reads are
+	// searched.
+	Window int
+	// MinReads is the smallest number of reads clipped at the same position
+	// to refine a breakpoint.
+	MinReads int
+	// MinClip is the smallest number of clipped bases of a read.
+	MinClip int
+	// MinMapQ is the smallest mapping quality of the reads.
+	MinMapQ byte
+}
+
+// DefaultRefineOptions returns options suited to the breakpoints of bins of
+// 50 kb: a window of 50 kb and at least 3 reads clipped by 10 bases or more
+// with a mapping quality of at least 20.
+func DefaultRefineOptions() RefineOptions {
+	return RefineOptions{Window: 50_000, MinReads: 3, MinClip: 10, MinMapQ: 20}
+}
+
+// RefineBreakpoints refines the positions of breakpoints found at bin
+// resolution to base-pair resolution with the split reads of the BAM file
+// read from r, such as the file counted by CountBAM.
+//
+// Reads spanning the junction of a structural variant at a breakpoint are
+// clipped at the junction, soft-clipped or hard-clipped when the clipped part
+// is aligned elsewhere as a supplementary alignment. The position within
+// opts.Window of that of a breakpoint at which most reads are clipped, the
+// nearest and leftmost one for ties, then replaces it when supported by at
+// least opts.MinReads reads, and SplitReads is set to their number. Other
+// breakpoints are returned unchanged. The positions of the breakpoints must
+// be genomic, as are those of tracks counted by CountBAM.
+//
+// Unmapped, secondary, QC-failed and duplicate reads are skipped.
+func RefineBreakpoints(r io.Reader, breakpoints []cbsgo.Breakpoint, opts RefineOptions) ([]cbsgo.Breakpoint, error) {
+	if opts.Window < 0 || opts.MinReads < 1 || opts.MinClip < 1 {
+		return nil, fmt.Errorf("cbsgo: invalid refinement options %+v", opts)
+	}
+	br, err := bam.NewReader(r, 1)
+	if err != nil {
+		return nil, fmt.Errorf("cbsgo: reading BAM: %w", err)
+	}
+	defer br.Close()
+
+	// byChrom holds the indices of the breakpoints of every chromosome by
+	// position, and clips the numbers of reads clipped at every position
+	// near every breakpoint.
+	byChrom := make(map[string][]int)
+	for i, b := range breakpoints {
+		byChrom[b.Chrom] = append(byChrom[b.Chrom], i)
+	}
+	for _, idx := range byChrom {
+		sort.Slice(idx, func(a, b int) bool { return breakpoints[idx[a]].Position < breakpoints[idx[b]].Position })
+	}
+	clips := make([]map[int]int, len(breakpoints))
+	count := func(chrom string, pos int) {
+		idx := byChrom[chrom]
+		i := sort.Search(len(idx), func(i int) bool { return breakpoints[idx[i]].Position >= pos-opts.Window })
+		for ; i < len(idx) && breakpoints[idx[i]].Position <= pos+opts.Window; i++ {
+			if clips[idx[i]] == nil {
+				clips[idx[i]] = make(map[int]int)
+			}
+			clips[idx[i]][pos]++
+		}
+	}
+
+	skip := sam.Unmapped | sam.Secondary | sam.QCFail | sam.Duplicate
+	for {
+		rec, err := br.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cbsgo: reading BAM: %w", err)
+		}
+		if rec.Flags&skip != 0 || rec.MapQ < opts.MinMapQ || rec.Ref == nil || rec.Pos < 0 || len(rec.Cigar) == 0 {
+			continue
+		}
+		chrom := rec.Ref.Name()
+		if byChrom[chrom] == nil {
+			continue
+		}
+		if clipped(rec.Cigar[0]) >= opts.MinClip {
+			count(chrom, rec.Pos)
+		}
+		if clipped(rec.Cigar[len(rec.Cigar)-1]) >= opts.MinClip {
+			count(chrom, rec.End())
+		}
+	}
+
+	res := append([]cbsgo.Breakpoint(nil), breakpoints...)
+	for i, c := range clips {
+		b := &res[i]
+		best, reads := 0, 0
+		for pos, n := range c {
+			d, bestD := distance(pos, b.Position), distance(best, b.Position)
+			if n > reads || n == reads && (d < bestD || d == bestD && pos < best) {
+				best, reads = pos, n
+			}
+		}
+		if reads >= opts.MinReads {
+			b.Position, b.SplitReads = best, reads
+		}
+	}
+	return res, nil
+}
+
+// clipped returns the number of bases clipped by op, which may be a soft or
+// a hard clip.
+func clipped(op sam.CigarOp) int {
+	if t := op.Type(); t != sam.CigarSoftClipped && t != sam.CigarHardClipped {
+		return 0
+	}
+	return op.Len()
+}
+
+// distance returns the distance between the positions a and b.
+func distance(a, b int) int {
+	if a < b {
+		return b - a
+	}
+	return a - b
+}
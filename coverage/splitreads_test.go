@@ -0,0 +1,70 @@
+package coverage_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/biogo/hts/sam"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/coverage"
+)
+
+func TestRefineBreakpoints(t *testing.T) {
+	op := sam.NewCigarOp
+	// Reads clipped at 117 of chr1: at the start of their alignment or at
+	// its end, after 5 aligned bases from 112.
+	left := []sam.CigarOp{op(sam.CigarSoftClipped, 5), op(sam.CigarMatch, 5)}
+	right := []sam.CigarOp{op(sam.CigarMatch, 5), op(sam.CigarSoftClipped, 5)}
+	hard := []sam.CigarOp{op(sam.CigarHardClipped, 20), op(sam.CigarMatch, 10)}
+	reads := []read{
+		{ref: 0, pos: 117, mapQ: 60, cigar: left},
+		{ref: 0, pos: 117, mapQ: 60, cigar: hard, flags: sam.Supplementary},
+		{ref: 0, pos: 112, mapQ: 60, cigar: right},
+		{ref: 0, pos: 130, mapQ: 60, cigar: left},
+		{ref: 0, pos: 117, mapQ: 5, cigar: left},
+		{ref: 0, pos: 117, mapQ: 60, cigar: left, flags: sam.Duplicate},
+		{ref: 0, pos: 120, mapQ: 60},
+		{ref: 2, pos: 40, mapQ: 60, cigar: left},
+	}
+	breakpoints := []cbsgo.Breakpoint{
+		{Chrom: "chr1", Bin: 2, Position: 100},
+		{Chrom: "chr3", Bin: 1, Position: 50},
+		{Chrom: "chr2", Bin: 1, Position: 50},
+	}
+	opts := coverage.RefineOptions{Window: 50, MinReads: 2, MinClip: 5, MinMapQ: 20}
+	got, err := coverage.RefineBreakpoints(writeBAM(t, reads), breakpoints, opts)
+	if err != nil {
+		t.Fatalf("RefineBreakpoints returned an unexpected error: %v", err)
+	}
+	expected := []cbsgo.Breakpoint{
+		{Chrom: "chr1", Bin: 2, Position: 117, SplitReads: 3},
+		{Chrom: "chr3", Bin: 1, Position: 50},
+		{Chrom: "chr2", Bin: 1, Position: 50},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %+v\nGot: %+v", expected, got)
+	}
+	if breakpoints[0].Position != 100 {
+		t.Errorf("Expected the input to be left unchanged, got %+v", breakpoints[0])
+	}
+
+	// A single read suffices with MinReads 1, but MinClip 10 leaves only the
+	// hard-clipped read, on chr1.
+	opts.MinReads, opts.MinClip = 1, 10
+	got, err = coverage.RefineBreakpoints(writeBAM(t, reads), breakpoints, opts)
+	if err != nil {
+		t.Fatalf("RefineBreakpoints returned an unexpected error: %v", err)
+	}
+	if got[0].Position != 117 || got[0].SplitReads != 1 || got[1].Position != 50 || got[1].SplitReads != 0 {
+		t.Errorf("Unexpected refined breakpoints %+v", got)
+	}
+
+	if _, err := coverage.RefineBreakpoints(writeBAM(t, reads), breakpoints, coverage.RefineOptions{}); err == nil {
+		t.Errorf("Expected an error for invalid options")
+	}
+	if _, err := coverage.RefineBreakpoints(bytes.NewBufferString("not a BAM file"), breakpoints, opts); err == nil {
+		t.Errorf("Expected an error for invalid input")
+	}
+}
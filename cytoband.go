@@ -0,0 +1,143 @@
+package cbsgo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Band is a chromosome band of a cytoband file, such as q24.21 of chr8.
+type Band struct {
+	Chrom string
+	Start int
+	End   int
+	// Name is the name of the band within the chromosome, starting with the
+	// arm, and Stain its Giemsa stain, "acen" for centromeric bands.
+	Name  string
+	Stain string
+}
+
+// Arm returns the chromosome arm of the band, "p" or "q".
+func (b Band) Arm() string {
+	return b.Name[:1]
+}
+
+// Cytobands holds the chromosome bands of a reference genome.
+type Cytobands struct {
+	// bands holds the bands by chromosome, sorted by start.
+	bands map[string][]Band
+}
+
+// ReadCytobands reads a UCSC cytoband file, such as cytoBand.txt, with the
+// chromosome, start, end, name and stain of every band.
+func ReadCytobands(r io.Reader) (*Cytobands, error) {
+	c := &Cytobands{bands: make(map[string][]Band)}
+	dr, err := Decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	defer dr.Close()
+	sc := bufio.NewScanner(dr)
+	for line := 1; sc.Scan(); line++ {
+		if skipHeader(sc.Text()) {
+			continue
+		}
+		fields := strings.Split(sc.Text(), "\t")
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("cbsgo: cytoband line %d: expected 4 fields, got %d", line, len(fields))
+		}
+		start, err1 := strconv.Atoi(fields[1])
+		end, err2 := strconv.Atoi(fields[2])
+		if err := firstError(err1, err2); err != nil {
+			return nil, fmt.Errorf("cbsgo: cytoband line %d: %w", line, err)
+		}
+		b := Band{Chrom: fields[0], Start: start, End: end, Name: fields[3]}
+		if len(fields) > 4 {
+			b.Stain = strings.TrimSpace(fields[4])
+		}
+		if b.Name == "" || (b.Name[0] != 'p' && b.Name[0] != 'q') {
+			return nil, fmt.Errorf("cbsgo: cytoband line %d: invalid band name %q", line, b.Name)
+		}
+		c.bands[b.Chrom] = append(c.bands[b.Chrom], b)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	for _, bands := range c.bands {
+		sort.Slice(bands, func(i, j int) bool { return bands[i].Start < bands[j].Start })
+	}
+	return c, nil
+}
+
+// Span returns the bands spanned by s in ISCN notation, such as 8q24.21 for a
+// segment within a single band or 8q24.13-q24.21 otherwise, or "" if s
+// overlaps no band.
+func (c *Cytobands) Span(s Segment) string {
+	bands := c.overlapping(s)
+	if len(bands) == 0 {
+		return ""
+	}
+	chrom := strings.TrimPrefix(s.Chrom, "chr")
+	first, last := bands[0].Name, bands[len(bands)-1].Name
+	if first == last {
+		return chrom + first
+	}
+	return chrom + first + "-" + last
+}
+
+// ArmCoverage is the fraction of a chromosome arm covered by a segment.
+type ArmCoverage struct {
+	// Arm names the arm, such as 8q.
+	Arm      string
+	Fraction float64
+}
+
+// Arms returns the fraction of each chromosome arm covered by s, for the arms
+// it overlaps, p before q. Whole-arm events cover a fraction close to one.
+func (c *Cytobands) Arms(s Segment) []ArmCoverage {
+	var res []ArmCoverage
+	chrom := strings.TrimPrefix(s.Chrom, "chr")
+	for _, arm := range [2]string{"p", "q"} {
+		start, end := -1, -1
+		for _, b := range c.bands[s.Chrom] {
+			if b.Arm() != arm {
+				continue
+			}
+			if start < 0 {
+				start = b.Start
+			}
+			end = b.End
+		}
+		covered := min(s.End, end) - max(s.Start, start)
+		if start < 0 || covered <= 0 {
+			continue
+		}
+		res = append(res, ArmCoverage{Arm: chrom + arm, Fraction: float64(covered) / float64(end-start)})
+	}
+	return res
+}
+
+// Annotate returns a copy of segments with the Cytoband field of every
+// segment set to its band span. See Span.
+func (c *Cytobands) Annotate(segments []Segment) []Segment {
+	res := make([]Segment, len(segments))
+	for i, s := range segments {
+		s.Cytoband = c.Span(s)
+		res[i] = s
+	}
+	return res
+}
+
+// overlapping returns the bands overlapping s, sorted by start.
+func (c *Cytobands) overlapping(s Segment) []Band {
+	bands := c.bands[s.Chrom]
+	lo := sort.Search(len(bands), func(i int) bool { return bands[i].End > s.Start })
+	hi := sort.Search(len(bands), func(i int) bool { return bands[i].Start >= s.End })
+	if lo >= hi {
+		return nil
+	}
+	return bands[lo:hi]
+}
@@ -0,0 +1,51 @@
+package cbsgo_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+const testCytobands = `chr8	0	2000	p23.3	gneg
+chr8	2000	4000	p11.1	acen
+chr8	4000	6000	q11.1	acen
+chr8	6000	8000	q24.13	gpos50
+chr8	8000	10000	q24.21	gneg
+`
+
+func readCytobands(t *testing.T) *cbsgo.Cytobands {
+	c, err := cbsgo.ReadCytobands(strings.NewReader(testCytobands))
+	if err != nil {
+		t.Fatalf("ReadCytobands returned an unexpected error: %v", err)
+	}
+	return c
+}
+
+func TestCytobandsAnnotate(t *testing.T) {
+	c := readCytobands(t)
+	segments := c.Annotate([]cbsgo.Segment{
+		{Chrom: "chr8", Start: 8500, End: 9000},
+		{Chrom: "chr8", Start: 7000, End: 10000},
+		{Chrom: "chr8", Start: 1000, End: 5000},
+		{Chrom: "chr9", Start: 0, End: 100},
+	})
+	var got []string
+	for _, s := range segments {
+		got = append(got, s.Cytoband)
+	}
+	expected := []string{"8q24.21", "8q24.13-q24.21", "8p23.3-q11.1", ""}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+	}
+}
+
+func TestCytobandsArms(t *testing.T) {
+	c := readCytobands(t)
+	got := c.Arms(cbsgo.Segment{Chrom: "chr8", Start: 3000, End: 10000})
+	expected := []cbsgo.ArmCoverage{{Arm: "8p", Fraction: 0.25}, {Arm: "8q", Fraction: 1}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+	}
+}
@@ -0,0 +1,89 @@
+package cbsgo
+
+// Direction restricts the changes in mean detected by the segmentation.
+//
+// A split of an interval cuts out an arc and leaves its complement, which
+// wraps around the ends of the interval. With a direction, the shorter of the
+// two is taken to be the event, which must have a higher mean than the other
+// for GainOnly and a lower one for LossOnly. As CBS tests every interval
+// against its own mean, the direction is relative to the rest of the interval.
+type Direction int
+
+const (
+	// TwoSided detects both increases and decreases of the mean.
+	TwoSided Direction = iota
+	// GainOnly only detects events with a higher mean than their
+	// surroundings, such as amplifications.
+	GainOnly
+	// LossOnly only detects events with a lower mean than their
+	// surroundings, such as deletions.
+	LossOnly
+)
+
+// String returns the name of the direction.
+func (dir Direction) String() string {
+	switch dir {
+	case TwoSided:
+		return "two-sided"
+	case GainOnly:
+		return "gain only"
+	case LossOnly:
+		return "loss only"
+	}
+	return "unknown"
+}
+
+// arc returns the bounds i0 <= i1 of the extremes of the cumulative sums y
+// of the mean-centered values that delimit the arc (i0, i1] of the best split.
+//
+// Without a direction these are the maximum and the minimum, chosen among
+// equal extremes by tie. With one, they are those of the largest change of y
+// in the direction of the event: a rise over a short arc or a fall over a long
+// one for GainOnly, and the converse for LossOnly, the leftmost on ties. i0
+// and i1 are equal when there is no such change.
+func (dir Direction) arc(y []float64, tie TieBreak) (int, int) {
+	if dir == TwoSided {
+		i0, i1 := tie.maxIdx(y), tie.minIdx(y)
+		if i1 < i0 {
+			i0, i1 = i1, i0
+		}
+		return i0, i1
+	}
+
+	sign := 1.0
+	if dir == LossOnly {
+		sign = -1
+	}
+	n := len(y)
+	h := n / 2
+	var i0, i1 int
+	best := 0.0
+
+	// Short arcs: the largest rise of sign*y over at most h bins, with the
+	// minimum over the sliding window kept in a monotonic queue.
+	queue := make([]int, 0, n)
+	for j := 1; j < n; j++ {
+		for len(queue) > 0 && sign*y[queue[len(queue)-1]] >= sign*y[j-1] {
+			queue = queue[:len(queue)-1]
+		}
+		queue = append(queue, j-1)
+		if queue[0] < j-h {
+			queue = queue[1:]
+		}
+		if d := sign * (y[j] - y[queue[0]]); d > best {
+			best, i0, i1 = d, queue[0], j
+		}
+	}
+
+	// Long arcs: the largest fall of sign*y over more than h bins.
+	hi := 0
+	for j := h + 1; j < n; j++ {
+		if sign*y[j-h-1] > sign*y[hi] {
+			hi = j - h - 1
+		}
+		if d := sign * (y[hi] - y[j]); d > best {
+			best, i0, i1 = d, hi, j
+		}
+	}
+	return i0, i1
+}
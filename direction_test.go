@@ -0,0 +1,61 @@
+package cbsgo_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestWithDirection(t *testing.T) {
+	// A strong focal loss and a weaker focal gain.
+	x := make([]float64, 400)
+	for i := range x {
+		x[i] = math.Sin(float64(i)) / 2
+		switch {
+		case i >= 100 && i < 130:
+			x[i] -= 3
+		case i >= 250 && i < 280:
+			x[i] += 1
+		}
+	}
+
+	hasSegment := func(res *cbsgo.Result, start, end int) bool {
+		for _, s := range res.Segments {
+			if abs(s.BinStart-start) <= 3 && abs(s.BinEnd-end) <= 3 {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, tt := range []struct {
+		dir        cbsgo.Direction
+		start, end int
+	}{
+		{cbsgo.GainOnly, 250, 280},
+		{cbsgo.LossOnly, 100, 130},
+		{cbsgo.TwoSided, 100, 130},
+	} {
+		res, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithDirection(tt.dir))
+		if err != nil {
+			t.Fatalf("Run returned an unexpected error: %v", err)
+		}
+		if !hasSegment(res, tt.start, tt.end) {
+			t.Errorf("Direction %d: expected a segment [%d, %d), got %v", tt.dir, tt.start, tt.end, res.Segments)
+		}
+		// The first split cuts out the arc in the requested direction.
+		for _, s := range res.Segments {
+			if tt.dir == cbsgo.GainOnly && s.BinStart > 0 && s.BinEnd < len(x) && s.Mean < -2 {
+				t.Errorf("Direction %d: unexpected loss %+v", tt.dir, s)
+			}
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,71 @@
+package cbsgo
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+)
+
+// ResultVersion is the version of the schema of the results written by
+// EncodeResult. It is increased whenever a change of Result is not just the
+// addition of a field, which decoding leaves at its zero value, and
+// DecodeResult then migrates results of earlier versions.
+//
+// The versions are:
+//
+//  1. Results encoded with encoding/gob as is, before EncodeResult.
+//  2. Results wrapped with their version.
+const ResultVersion = 2
+
+// encodedResult is the envelope of an encoded result.
+type encodedResult struct {
+	Version int
+	Result  *Result
+}
+
+// EncodeResult writes res to w with encoding/gob, with the version of its
+// schema, for caches and databases of results that must survive upgrades of
+// the library. Decode it with DecodeResult.
+func EncodeResult(w io.Writer, res *Result) error {
+	if err := gob.NewEncoder(w).Encode(encodedResult{Version: ResultVersion, Result: res}); err != nil {
+		return fmt.Errorf("cbsgo: encoding result: %w", err)
+	}
+	return nil
+}
+
+// DecodeResult reads a result written by EncodeResult, of the current or an
+// earlier version, and migrates it to the current schema. Results of later
+// versions, written by a newer library, are rejected.
+func DecodeResult(r io.Reader) (*Result, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("cbsgo: reading result: %w", err)
+	}
+	var e encodedResult
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&e); err != nil {
+		// Results of version 1 have no envelope.
+		e = encodedResult{Version: 1}
+		if err1 := gob.NewDecoder(bytes.NewReader(data)).Decode(&e.Result); err1 != nil {
+			return nil, fmt.Errorf("cbsgo: decoding result: %w", err)
+		}
+	}
+	switch {
+	case e.Version < 1:
+		return nil, fmt.Errorf("cbsgo: result of invalid version %d", e.Version)
+	case e.Version > ResultVersion:
+		return nil, fmt.Errorf("cbsgo: result of version %d is newer than the supported version %d", e.Version, ResultVersion)
+	case e.Result == nil:
+		e.Result = &Result{}
+	}
+	for v := e.Version; v < ResultVersion; v++ {
+		resultMigrations[v](e.Result)
+	}
+	return e.Result, nil
+}
+
+// resultMigrations migrates a result of every version to the next one.
+var resultMigrations = map[int]func(*Result){
+	// Version 2 only added the envelope.
+	1: func(*Result) {},
+}
@@ -0,0 +1,74 @@
+package cbsgo_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestEncodeResult(t *testing.T) {
+	res, err := cbsgo.Run(borderline(0.3), cbsgo.WithSeed(42), cbsgo.WithFit(), cbsgo.WithBreakpoints(0.95), cbsgo.WithExplain())
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := cbsgo.EncodeResult(&buf, res); err != nil {
+		t.Fatalf("EncodeResult returned an unexpected error: %v", err)
+	}
+	got, err := cbsgo.DecodeResult(&buf)
+	if err != nil {
+		t.Fatalf("DecodeResult returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, res) {
+		t.Errorf("Unexpected result.\nExpected: %+v\nGot: %+v", res, got)
+	}
+
+	// Undefined values survive.
+	buf.Reset()
+	if err := cbsgo.EncodeResult(&buf, &cbsgo.Result{Fit: &cbsgo.Fit{Residuals: []float64{1, math.NaN()}}}); err != nil {
+		t.Fatalf("EncodeResult returned an unexpected error: %v", err)
+	}
+	got, err = cbsgo.DecodeResult(&buf)
+	if err != nil || got.Fit == nil || got.Fit.Residuals[0] != 1 || !math.IsNaN(got.Fit.Residuals[1]) {
+		t.Errorf("Expected a NaN residual to survive, got %+v, %v", got, err)
+	}
+}
+
+func TestDecodeResultVersions(t *testing.T) {
+	res := &cbsgo.Result{Segments: []cbsgo.Segment{{Chrom: "chr1", End: 10, BinEnd: 10, Mean: 1.5}}}
+
+	// Results of version 1 were encoded as is.
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(res); err != nil {
+		t.Fatal(err)
+	}
+	got, err := cbsgo.DecodeResult(&buf)
+	if err != nil {
+		t.Fatalf("DecodeResult returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, res) {
+		t.Errorf("Unexpected result.\nExpected: %+v\nGot: %+v", res, got)
+	}
+
+	// envelope has the fields of the envelope of EncodeResult.
+	type envelope struct {
+		Version int
+		Result  *cbsgo.Result
+	}
+	for _, version := range []int{0, cbsgo.ResultVersion + 1} {
+		buf.Reset()
+		if err := gob.NewEncoder(&buf).Encode(envelope{version, res}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cbsgo.DecodeResult(&buf); err == nil {
+			t.Errorf("Expected an error for version %d", version)
+		}
+	}
+	if _, err := cbsgo.DecodeResult(bytes.NewBufferString("not a result")); err == nil {
+		t.Errorf("Expected an error for invalid input")
+	}
+}
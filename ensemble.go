@@ -0,0 +1,124 @@
+package cbsgo
+
+import (
+	"sort"
+)
+
+// Method is a segmentation algorithm that can take part in an ensemble.
+type Method func(x []float64) (*Result, error)
+
+// CBSMethod returns a Method performing Circular Binary Segmentation with opts.
+func CBSMethod(opts ...Option) Method {
+	return func(x []float64) (*Result, error) {
+		return Run(x, opts...)
+	}
+}
+
+// EnsembleBreakpoint is a breakpoint supported by one or more methods of an
+// ensemble.
+type EnsembleBreakpoint struct {
+	Breakpoint
+
+	// Methods lists the names of the supporting methods in lexical order.
+	Methods []string
+}
+
+// Ensemble segments x with every method and combines their breakpoints with
+// CombineBreakpoints.
+func Ensemble(x []float64, methods map[string]Method, tolerance int) ([]EnsembleBreakpoint, error) {
+	results := make(map[string]*Result, len(methods))
+	for name, m := range methods {
+		res, err := m(x)
+		if err != nil {
+			return nil, err
+		}
+		results[name] = res
+	}
+	return CombineBreakpoints(results, tolerance), nil
+}
+
+// CombineBreakpoints merges the breakpoints of the results of several methods,
+// keyed by method name.
+//
+// Breakpoints are clustered by single linkage: breakpoints at most tolerance
+// bins apart end up in the same cluster. Each cluster is reported at the median
+// bin of its members, and its Support is the fraction of methods with at least
+// one breakpoint in the cluster. Breakpoints are returned in order of position.
+func CombineBreakpoints(results map[string]*Result, tolerance int) []EnsembleBreakpoint {
+	var calls []breakpointCall
+	for name, res := range results {
+		calls = append(calls, resultCalls(name, res)...)
+	}
+
+	var res []EnsembleBreakpoint
+	for _, cluster := range clusterBreakpoints(calls, tolerance) {
+		methods := cluster.sources()
+		median := cluster[(len(cluster)-1)/2]
+		res = append(res, EnsembleBreakpoint{
+			Breakpoint: Breakpoint{
+				Bin:      median.bin,
+				Position: median.pos,
+				Support:  float64(len(methods)) / float64(len(results)),
+				Unstable: len(methods) < len(results),
+			},
+			Methods: methods,
+		})
+	}
+	return res
+}
+
+// breakpointCall is a breakpoint called by some source, such as a method or
+// a sample.
+type breakpointCall struct {
+	bin, pos int
+	source   string
+}
+
+// breakpointCluster is a group of nearby breakpoint calls sorted by bin.
+type breakpointCluster []breakpointCall
+
+// sources returns the distinct sources of the calls in lexical order.
+func (c breakpointCluster) sources() []string {
+	seen := make(map[string]bool)
+	var res []string
+	for _, call := range c {
+		if !seen[call.source] {
+			seen[call.source] = true
+			res = append(res, call.source)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// resultCalls returns the breakpoints of res attributed to source.
+func resultCalls(source string, res *Result) []breakpointCall {
+	var calls []breakpointCall
+	for i := 1; i < len(res.Segments); i++ {
+		s := res.Segments[i]
+		calls = append(calls, breakpointCall{bin: s.BinStart, pos: s.Start, source: source})
+	}
+	return calls
+}
+
+// clusterBreakpoints groups calls by single linkage, putting calls at most
+// tolerance bins apart in the same cluster. Clusters are returned in order.
+func clusterBreakpoints(calls []breakpointCall, tolerance int) []breakpointCluster {
+	sort.Slice(calls, func(i, j int) bool {
+		if calls[i].bin != calls[j].bin {
+			return calls[i].bin < calls[j].bin
+		}
+		return calls[i].source < calls[j].source
+	})
+
+	var res []breakpointCluster
+	for lo := 0; lo < len(calls); {
+		hi := lo + 1
+		for hi < len(calls) && calls[hi].bin-calls[hi-1].bin <= tolerance {
+			hi++
+		}
+		res = append(res, breakpointCluster(calls[lo:hi]))
+		lo = hi
+	}
+	return res
+}
@@ -0,0 +1,58 @@
+package cbsgo_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// segmentsAt returns a result with breakpoints at the given bins of a profile
+// of n bins.
+func segmentsAt(n int, bins ...int) *cbsgo.Result {
+	res := &cbsgo.Result{}
+	start := 0
+	for _, b := range append(bins, n) {
+		res.Segments = append(res.Segments, cbsgo.Segment{Start: start, End: b, BinStart: start, BinEnd: b})
+		start = b
+	}
+	return res
+}
+
+func TestCombineBreakpoints(t *testing.T) {
+	results := map[string]*cbsgo.Result{
+		"cbs":  segmentsAt(100, 20, 50),
+		"pelt": segmentsAt(100, 21, 50, 80),
+		"hmm":  segmentsAt(100, 22, 23, 90),
+	}
+
+	expected := []cbsgo.EnsembleBreakpoint{
+		{Breakpoint: cbsgo.Breakpoint{Bin: 21, Position: 21, Support: 1}, Methods: []string{"cbs", "hmm", "pelt"}},
+		{Breakpoint: cbsgo.Breakpoint{Bin: 50, Position: 50, Support: 2.0 / 3, Unstable: true}, Methods: []string{"cbs", "pelt"}},
+		{Breakpoint: cbsgo.Breakpoint{Bin: 80, Position: 80, Support: 1.0 / 3, Unstable: true}, Methods: []string{"pelt"}},
+		{Breakpoint: cbsgo.Breakpoint{Bin: 90, Position: 90, Support: 1.0 / 3, Unstable: true}, Methods: []string{"hmm"}},
+	}
+	res := cbsgo.CombineBreakpoints(results, 1)
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, res)
+	}
+}
+
+func TestEnsemble(t *testing.T) {
+	steps := []float64{1, 1, 1, 3, 3, 2, 1, 2, 3, 300, 310, 321, 310, 299}
+	methods := map[string]cbsgo.Method{
+		"cbs":   cbsgo.CBSMethod(cbsgo.WithSeed(42)),
+		"fixed": func(x []float64) (*cbsgo.Result, error) { return segmentsAt(len(x), 9), nil },
+	}
+
+	res, err := cbsgo.Ensemble(steps, methods, 2)
+	if err != nil {
+		t.Fatalf("Ensemble returned an unexpected error: %v", err)
+	}
+	expected := []cbsgo.EnsembleBreakpoint{
+		{Breakpoint: cbsgo.Breakpoint{Bin: 8, Position: 8, Support: 1}, Methods: []string{"cbs", "fixed"}},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, res)
+	}
+}
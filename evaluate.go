@@ -0,0 +1,105 @@
+package cbsgo
+
+import (
+	"sort"
+)
+
+// Evaluation scores the breakpoints of a segmentation against known true
+// breakpoints, such as those of a Simulation. See Evaluate.
+type Evaluation struct {
+	// Tolerance is the largest distance in base pairs at which a predicted
+	// breakpoint matches a true one.
+	Tolerance int
+
+	TruePositives  int
+	FalsePositives int
+	FalseNegatives int
+
+	// Precision is the fraction of the predicted breakpoints matching a true
+	// one, and Recall the fraction of true breakpoints matched. Both are 1
+	// when there is nothing to match. F1 is their harmonic mean.
+	Precision float64
+	Recall    float64
+	F1        float64
+
+	// Errors holds the signed distance from every matched true breakpoint to
+	// its predicted breakpoint, in genome order of the true breakpoints.
+	Errors []int
+}
+
+// Evaluate matches the breakpoints of predicted to those of truth. A
+// breakpoint is the start of every segment but the first of each chromosome.
+// Each true breakpoint matches at most one predicted breakpoint within
+// tolerance, with the closest pairs matched first.
+func Evaluate(predicted, truth SegmentSet, tolerance int) Evaluation {
+	e := Evaluation{Tolerance: tolerance}
+	pred, real := breakpointsByChrom(predicted), breakpointsByChrom(truth)
+
+	chroms := make([]string, 0, len(real))
+	for chrom := range real {
+		chroms = append(chroms, chrom)
+	}
+	sort.Slice(chroms, func(i, j int) bool { return chromLess(chroms[i], chroms[j]) })
+
+	for _, p := range pred {
+		e.FalsePositives += len(p)
+	}
+	for _, chrom := range chroms {
+		t, p := real[chrom], pred[chrom]
+
+		type pair struct{ t, p, d int }
+		var pairs []pair
+		for i, tv := range t {
+			lo := sort.SearchInts(p, tv-tolerance)
+			for j := lo; j < len(p) && p[j] <= tv+tolerance; j++ {
+				pairs = append(pairs, pair{i, j, p[j] - tv})
+			}
+		}
+		sort.SliceStable(pairs, func(a, b int) bool { return abs(pairs[a].d) < abs(pairs[b].d) })
+
+		matched := make([]int, len(t))
+		for i := range matched {
+			matched[i] = -1
+		}
+		used := make([]bool, len(p))
+		for _, pr := range pairs {
+			if matched[pr.t] < 0 && !used[pr.p] {
+				matched[pr.t], used[pr.p] = pr.p, true
+			}
+		}
+		for i, j := range matched {
+			if j < 0 {
+				e.FalseNegatives++
+				continue
+			}
+			e.TruePositives++
+			e.FalsePositives--
+			e.Errors = append(e.Errors, p[j]-t[i])
+		}
+	}
+	e.Precision, e.Recall = 1, 1
+	if n := e.TruePositives + e.FalsePositives; n > 0 {
+		e.Precision = float64(e.TruePositives) / float64(n)
+	}
+	if n := e.TruePositives + e.FalseNegatives; n > 0 {
+		e.Recall = float64(e.TruePositives) / float64(n)
+	}
+	if e.Precision+e.Recall > 0 {
+		e.F1 = 2 * e.Precision * e.Recall / (e.Precision + e.Recall)
+	}
+	return e
+}
+
+// breakpointsByChrom returns the sorted breakpoints of s per chromosome.
+func breakpointsByChrom(s SegmentSet) map[string][]int {
+	res := make(map[string][]int)
+	sorted := s.Sorted()
+	for i, seg := range sorted {
+		if i > 0 && sorted[i-1].Chrom == seg.Chrom {
+			res[seg.Chrom] = append(res[seg.Chrom], seg.Start)
+		} else if _, ok := res[seg.Chrom]; !ok {
+			res[seg.Chrom] = nil
+		}
+	}
+	return res
+}
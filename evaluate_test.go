@@ -0,0 +1,48 @@
+package cbsgo_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// tiling returns the segments of chrom delimited by bounds.
+func tiling(chrom string, bounds ...int) cbsgo.SegmentSet {
+	var s cbsgo.SegmentSet
+	for i := 1; i < len(bounds); i++ {
+		s = append(s, cbsgo.Segment{Chrom: chrom, Start: bounds[i-1], End: bounds[i]})
+	}
+	return s
+}
+
+func TestEvaluate(t *testing.T) {
+	truth := append(tiling("chr1", 0, 1000, 2000, 3000, 4000), tiling("chr2", 0, 500, 1000)...)
+	predicted := append(tiling("chr1", 0, 1040, 1060, 2990, 3500, 4000), tiling("chr2", 0, 1000)...)
+
+	got := cbsgo.Evaluate(predicted, truth, 50)
+	expected := cbsgo.Evaluation{
+		Tolerance:      50,
+		TruePositives:  2,
+		FalsePositives: 2,
+		FalseNegatives: 2,
+		Precision:      0.5,
+		Recall:         0.5,
+		F1:             0.5,
+		Errors:         []int{40, -10},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %+v\nGot: %+v", expected, got)
+	}
+
+	// Each true breakpoint matches the closest predicted one, once.
+	got = cbsgo.Evaluate(tiling("chr1", 0, 990, 1005, 2000), tiling("chr1", 0, 1000, 2000), 50)
+	if got.TruePositives != 1 || got.FalsePositives != 1 || !reflect.DeepEqual(got.Errors, []int{5}) {
+		t.Errorf("Unexpected result %+v", got)
+	}
+
+	got = cbsgo.Evaluate(tiling("chr1", 0, 100), tiling("chr1", 0, 100), 0)
+	if got.Precision != 1 || got.Recall != 1 || got.F1 != 1 {
+		t.Errorf("Expected a perfect score without breakpoints, got %+v", got)
+	}
+}
@@ -0,0 +1,106 @@
+package cbsgo
+
+// SplitDecision is the outcome of the test of an interval for a changepoint.
+type SplitDecision int
+
+const (
+	// SplitAccepted is reported for splits that were made.
+	SplitAccepted SplitDecision = iota
+	// SplitInsignificant is reported when the best split of the interval is
+	// not significant.
+	SplitInsignificant
+	// SplitTooShort is reported when the changepoint segment of the split
+	// has fewer than 5 bins, too few to assess.
+	SplitTooShort
+	// SplitAbsorbed is reported when both ends of the arc of the split lie
+	// within 5 bins of the ends of the interval. The ends are moved onto
+	// those of the interval, which absorbs the split.
+	SplitAbsorbed
+	// SplitTooNarrow is reported when the split would create a segment
+	// narrower than the minimum width set with WithMinWidth.
+	SplitTooNarrow
+	// SplitScreened is reported when the Haar screen of WithHaarScreen found
+	// no candidate breakpoint in the interval, which was not tested.
+	SplitScreened
+)
+
+// String returns a short description of the decision.
+func (d SplitDecision) String() string {
+	switch d {
+	case SplitAccepted:
+		return "accepted"
+	case SplitInsignificant:
+		return "insignificant"
+	case SplitTooShort:
+		return "too short"
+	case SplitAbsorbed:
+		return "absorbed"
+	case SplitTooNarrow:
+		return "too narrow"
+	case SplitScreened:
+		return "screened"
+	}
+	return "unknown"
+}
+
+// Candidate is the best split of an interval tested for a changepoint, as
+// listed by WithExplain.
+type Candidate struct {
+	Chrom string
+	// Start and End delimit the bins of the interval, [Start, End).
+	Start, End int
+	// SplitStart and SplitEnd delimit the bins of the arc of the split,
+	// [SplitStart, SplitEnd), whose mean differs from that of the rest of
+	// the interval. An arc starting at Start or ending at End splits the
+	// interval in two, otherwise in three.
+	SplitStart, SplitEnd int
+	// Depth is the recursion depth of the test, 0 for the whole profile.
+	Depth int
+	// Stat is the test statistic of the split, after the penalties of
+	// WithGapPenalty and WithLengthPrior, and PValue its p-value. Tests stop
+	// permuting once the split cannot be significant, so that the p-values
+	// of rejected splits are rough estimates.
+	Stat   float64
+	PValue float64
+	// Decision tells whether the split was made, or why not.
+	Decision SplitDecision
+}
+
+// WithExplain lists every interval tested for a changepoint in
+// Result.Explain, in the order of the tests, with its best split and why it
+// was accepted or rejected, such as to find out why an apparent breakpoint
+// was not called. In consensus mode, the tests of all runs are listed.
+//
+// It also sets the BestSplit of every segment that was tested and left
+// unsplit, to tell how close it came to being split.
+func WithExplain() Option {
+	return func(c *config) {
+		c.explain = true
+	}
+}
+
+// explain records the test of the bins [start, end) with the split sp, if
+// enabled by WithExplain.
+func (sg *segmenter) explain(start, end int, sp split, d SplitDecision) {
+	if !sg.cfg.explain {
+		return
+	}
+	c := Candidate{
+		Chrom:      sg.cfg.chrom,
+		Start:      start,
+		End:        end,
+		SplitStart: start + sp.start,
+		SplitEnd:   start + sp.end,
+		Depth:      sg.depth,
+		Stat:       sp.stat,
+		PValue:     sp.pvalue,
+		Decision:   d,
+	}
+	sg.explained = append(sg.explained, c)
+	if d != SplitAccepted && d != SplitScreened {
+		if sg.rejected == nil {
+			sg.rejected = make(map[[2]int]Candidate)
+		}
+		sg.rejected[[2]int{start, end}] = c
+	}
+}
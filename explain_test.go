@@ -0,0 +1,159 @@
+package cbsgo_test
+
+import (
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestWithExplain(t *testing.T) {
+	x := borderline(0.3)
+	res, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithExplain())
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	plain, err := cbsgo.Run(x, cbsgo.WithSeed(42))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if len(plain.Explain) != 0 || len(res.Segments) != len(plain.Segments) {
+		t.Errorf("Expected explaining to leave the result unchanged, got %v and %v", res, plain)
+	}
+
+	first := res.Explain[0]
+	if first.Start != 0 || first.End != len(x) || first.Depth != 0 || first.Decision != cbsgo.SplitAccepted {
+		t.Errorf("Expected the whole profile to be split first, got %+v", first)
+	}
+	// The accepted splits introduce the breakpoints, and every segment was
+	// tested and rejected unless it is the arc of a split, which is not
+	// tested again.
+	breakpoints := map[int]bool{}
+	rejected := map[[2]int]bool{}
+	for _, c := range res.Explain {
+		if c.Decision != cbsgo.SplitAccepted {
+			rejected[[2]int{c.Start, c.End}] = true
+			if c.Decision == cbsgo.SplitInsignificant && c.PValue <= 0.05 {
+				t.Errorf("Unexpected p-value of an insignificant split: %+v", c)
+			}
+			continue
+		}
+		for _, b := range []int{c.SplitStart, c.SplitEnd} {
+			if b != c.Start && b != c.End {
+				breakpoints[b] = true
+			}
+		}
+	}
+	for i, s := range res.Segments {
+		if i > 0 && !breakpoints[s.BinStart] {
+			t.Errorf("Segment %+v does not start at an accepted split", s)
+		}
+	}
+	if len(breakpoints) != len(res.Segments)-1 || len(rejected) == 0 {
+		t.Errorf("Unexpected tests %+v for segments %v", res.Explain, res.Segments)
+	}
+}
+
+func TestWithExplainDecisions(t *testing.T) {
+	pattern := []float64{0, 0.3, -0.2, 0.1, -0.3, 0.2}
+	profile := func(start, end int) []float64 {
+		x := make([]float64, 60)
+		for i := range x {
+			x[i] = pattern[i%len(pattern)]
+			if i >= start && i < end {
+				x[i] += 10
+			}
+		}
+		return x
+	}
+	tests := []struct {
+		name     string
+		x        []float64
+		opts     []cbsgo.Option
+		decision cbsgo.SplitDecision
+	}{
+		{name: "flat", x: profile(0, 0), decision: cbsgo.SplitInsignificant},
+		{name: "spike", x: profile(30, 32), decision: cbsgo.SplitTooShort},
+		{name: "edges", x: profile(2, 58), decision: cbsgo.SplitAbsorbed},
+		{name: "narrow", x: profile(30, 40), opts: []cbsgo.Option{cbsgo.WithMinWidth(15)}, decision: cbsgo.SplitTooNarrow},
+		{name: "screened", x: profile(0, 0), opts: []cbsgo.Option{cbsgo.WithHaarScreen(4)}, decision: cbsgo.SplitScreened},
+	}
+	for _, tt := range tests {
+		res, err := cbsgo.Run(tt.x, append(tt.opts, cbsgo.WithSeed(42), cbsgo.WithExplain())...)
+		if err != nil {
+			t.Fatalf("%s: Run returned an unexpected error: %v", tt.name, err)
+		}
+		if len(res.Explain) != 1 || res.Explain[0].Decision != tt.decision {
+			t.Errorf("%s: expected a single test with decision %v, got %+v", tt.name, tt.decision, res.Explain)
+		}
+	}
+}
+
+func TestTrackWithExplain(t *testing.T) {
+	x := borderline(0.3)
+	mask := make([]bool, len(x))
+	mask[10], mask[59] = true, true
+	track := &cbsgo.Track{Chrom: "chr2", Values: x, Mask: mask}
+	res, err := track.Segment(cbsgo.WithSeed(42), cbsgo.WithExplain())
+	if err != nil {
+		t.Fatalf("Segment returned an unexpected error: %v", err)
+	}
+	first := res.Explain[0]
+	if first.Chrom != "chr2" || first.Start != 0 || first.End != 59 {
+		t.Errorf("Expected the test of the whole track in track bins, got %+v", first)
+	}
+	if first.Decision != cbsgo.SplitAccepted || (first.SplitStart != 40 && first.SplitEnd != 40 && first.SplitStart != 39 && first.SplitEnd != 39) {
+		t.Errorf("Expected the strong breakpoint to be split first, got %+v", first)
+	}
+}
+
+func TestWithExplainBestSplit(t *testing.T) {
+	x := borderline(0.3)
+	res, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithExplain())
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	rejected := map[[2]int]cbsgo.Candidate{}
+	for _, c := range res.Explain {
+		if c.Decision != cbsgo.SplitAccepted {
+			rejected[[2]int{c.Start, c.End}] = c
+		}
+	}
+	found := false
+	for _, s := range res.Segments {
+		c, ok := rejected[[2]int{s.BinStart, s.BinEnd}]
+		switch {
+		case !ok && s.BestSplit != nil:
+			t.Errorf("Unexpected best split of untested segment %+v: %+v", s, *s.BestSplit)
+		case ok && (s.BestSplit == nil || *s.BestSplit != c):
+			t.Errorf("Expected best split %+v of segment %+v, got %v", c, s, s.BestSplit)
+		case ok:
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected a segment with a best split, got %v", res.Segments)
+	}
+
+	plain, err := cbsgo.Run(x, cbsgo.WithSeed(42))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	for _, s := range plain.Segments {
+		if s.BestSplit != nil {
+			t.Errorf("Unexpected best split without WithExplain: %+v", s)
+		}
+	}
+
+	// The best split is relabeled with the bins of a track.
+	mask := make([]bool, len(x))
+	mask[0] = true
+	tres, err := (&cbsgo.Track{Chrom: "chr3", Values: x, Mask: mask}).Segment(cbsgo.WithSeed(42), cbsgo.WithExplain())
+	if err != nil {
+		t.Fatalf("Segment returned an unexpected error: %v", err)
+	}
+	for _, s := range tres.Segments {
+		if c := s.BestSplit; c != nil && (c.Chrom != "chr3" || c.Start != s.BinStart || c.End != s.BinEnd) {
+			t.Errorf("Expected the best split of segment %+v in track bins, got %+v", s, *c)
+		}
+	}
+}
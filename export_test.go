@@ -0,0 +1,15 @@
+package cbsgo
+
+// Exported for the tests of the cbsgo_test package.
+var (
+	CenteredExtremes        = centeredExtremes
+	CenteredExtremesGeneric = centeredExtremesGeneric
+	KernelBackend           = kernelBackend
+)
+
+// Collector exposes collector to the tests of the cbsgo_test package.
+type Collector = collector
+
+func (c *Collector) Add(start, end int) { c.add(start, end) }
+
+func (c *Collector) Segments() [][2]int { return c.segments() }
@@ -0,0 +1,91 @@
+package cbsgo
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// Fit describes how well a segmentation fits the values it was derived from,
+// to judge its quality and compare parameter settings.
+type Fit struct {
+	// Residuals holds the value of every bin minus the mean of its segment,
+	// or NaN for bins not covered by any segment.
+	Residuals []float64
+
+	// SSE is the sum of squared residuals, and R2 the fraction of the sum of
+	// squared deviations from the overall mean explained by the segment means.
+	SSE float64
+	R2  float64
+
+	// BIC is the Bayesian information criterion of the piecewise constant
+	// model with Gaussian noise, n ln(SSE/n) + k ln(n) for n bins and k
+	// parameters: the segment means and the breakpoints. Lower is better.
+	// SSE/n is floored at 1e-12 times the variance of the values, so that
+	// exact fits, such as of noise-free profiles, have a finite BIC still
+	// favoring fewer parameters.
+	BIC float64
+}
+
+// GoodnessOfFit evaluates the fit of segments to the values x they were
+// derived from. The returned segments are copies of segments with the SSE and
+// R2 fields set, the sum of squared residuals of the segment and the fraction
+// of its sum of squared deviations from the overall mean of x explained by
+// the segment mean.
+func GoodnessOfFit(segments []Segment, x []float64) ([]Segment, *Fit, error) {
+	return fitSegments(segments, x, nil, stat.Mean(x, nil))
+}
+
+// fitSegments implements GoodnessOfFit for bins weighted by w, unless w is
+// nil, with mean the overall mean of x.
+func fitSegments(segments []Segment, x, w []float64, mean float64) ([]Segment, *Fit, error) {
+	fit := &Fit{Residuals: make([]float64, len(x))}
+	for i := range fit.Residuals {
+		fit.Residuals[i] = math.NaN()
+	}
+	res := make([]Segment, len(segments))
+	var sst float64
+	n := 0
+	for i, s := range segments {
+		if s.BinStart < 0 || s.BinEnd > len(x) || s.BinStart >= s.BinEnd {
+			return nil, nil, fmt.Errorf("cbsgo: segment %d spans bins [%d, %d) outside of %d values", i, s.BinStart, s.BinEnd, len(x))
+		}
+		sst += fitSegment(&s, x, w, mean)
+		for j := s.BinStart; j < s.BinEnd; j++ {
+			fit.Residuals[j] = x[j] - s.Mean
+		}
+		res[i] = s
+		fit.SSE += s.SSE
+		n += s.BinEnd - s.BinStart
+	}
+
+	if sst > 0 {
+		fit.R2 = 1 - fit.SSE/sst
+	}
+	if n > 0 {
+		k := float64(2*len(segments) - 1)
+		v := max(fit.SSE/float64(n), 1e-12*sst/float64(n), math.SmallestNonzeroFloat64)
+		fit.BIC = float64(n)*math.Log(v) + k*math.Log(float64(n))
+	}
+	return res, fit, nil
+}
+
+// fitSegment sets the SSE and R2 fields of s, and returns the sum of squared
+// deviations of its values from mean.
+func fitSegment(s *Segment, x, w []float64, mean float64) float64 {
+	var sse, ss float64
+	for j := s.BinStart; j < s.BinEnd; j++ {
+		wj := 1.0
+		if w != nil {
+			wj = w[j]
+		}
+		sse += wj * (x[j] - s.Mean) * (x[j] - s.Mean)
+		ss += wj * (x[j] - mean) * (x[j] - mean)
+	}
+	s.SSE, s.R2 = sse, 0
+	if ss > 0 {
+		s.R2 = 1 - sse/ss
+	}
+	return ss
+}
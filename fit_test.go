@@ -0,0 +1,80 @@
+package cbsgo_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestGoodnessOfFit(t *testing.T) {
+	x := []float64{0, 2, 4, 6, 9}
+	segments := []cbsgo.Segment{
+		{BinStart: 0, BinEnd: 2, Mean: 1},
+		{BinStart: 2, BinEnd: 4, Mean: 5},
+	}
+
+	res, fit, err := cbsgo.GoodnessOfFit(segments, x)
+	if err != nil {
+		t.Fatalf("GoodnessOfFit returned an unexpected error: %v", err)
+	}
+	// The overall mean is 4.2, and the last bin is not covered.
+	if res[0].SSE != 2 || res[1].SSE != 2 || math.Abs(res[0].R2-(1-2/(4.2*4.2+2.2*2.2))) > 1e-12 {
+		t.Errorf("Unexpected segments %+v", res)
+	}
+	if !math.IsNaN(fit.Residuals[4]) || !reflect.DeepEqual(fit.Residuals[:4], []float64{-1, 1, -1, 1}) {
+		t.Errorf("Unexpected residuals %v", fit.Residuals)
+	}
+	sst := 4.2*4.2 + 2.2*2.2 + 0.2*0.2 + 1.8*1.8
+	if fit.SSE != 4 || math.Abs(fit.R2-(1-4/sst)) > 1e-12 || math.Abs(fit.BIC-3*math.Log(4)) > 1e-12 {
+		t.Errorf("Unexpected fit %+v", fit)
+	}
+
+	// Exact fits have a finite BIC, lower for fewer segments.
+	step := []float64{0, 0, 0, 1, 1, 1}
+	_, two, err := cbsgo.GoodnessOfFit([]cbsgo.Segment{{BinStart: 0, BinEnd: 3}, {BinStart: 3, BinEnd: 6, Mean: 1}}, step)
+	if err != nil {
+		t.Fatalf("GoodnessOfFit returned an unexpected error: %v", err)
+	}
+	_, three, err := cbsgo.GoodnessOfFit([]cbsgo.Segment{{BinStart: 0, BinEnd: 3}, {BinStart: 3, BinEnd: 4, Mean: 1}, {BinStart: 4, BinEnd: 6, Mean: 1}}, step)
+	if err != nil {
+		t.Fatalf("GoodnessOfFit returned an unexpected error: %v", err)
+	}
+	if math.IsInf(two.BIC, 0) || math.IsNaN(two.BIC) || !(two.BIC < three.BIC) {
+		t.Errorf("Expected finite BICs favoring 2 segments, got %v and %v", two.BIC, three.BIC)
+	}
+
+	if _, _, err := cbsgo.GoodnessOfFit([]cbsgo.Segment{{BinStart: 3, BinEnd: 6}}, x); err == nil {
+		t.Errorf("Expected an error for a segment beyond the values")
+	}
+}
+
+func TestRunWithFit(t *testing.T) {
+	x := []float64{1, 1, 1, 3, 3, 2, 1, 2, 3, 300, 310, 321, 310, 299}
+	res, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithFit())
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	plain, err := cbsgo.Run(x, cbsgo.WithSeed(42))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	segments, fit, err := cbsgo.GoodnessOfFit(plain.Segments, x)
+	if err != nil {
+		t.Fatalf("GoodnessOfFit returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(res.Segments, segments) || !reflect.DeepEqual(res.Fit, fit) {
+		t.Errorf("Unexpected result.\nExpected: %v %+v\nGot: %v %+v", segments, fit, res.Segments, res.Fit)
+	}
+
+	// Streamed segments carry the same fit.
+	var c collector
+	streamed, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithFit(), cbsgo.WithWriter(&c))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(streamed.Fit, fit) || !reflect.DeepEqual(c.segments, segments) {
+		t.Errorf("Unexpected streamed result.\nExpected: %v %+v\nGot: %v %+v", segments, fit, c.segments, streamed.Fit)
+	}
+}
@@ -0,0 +1,124 @@
+package cbsgo
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// focalAlpha is the least significance level of the tests of the focal pass.
+const focalAlpha = 0.05
+
+// FocalEvent is a short gain or loss within a segment, such as a focal
+// amplification or deep deletion, found by the pass of WithFocal.
+type FocalEvent struct {
+	Chrom string
+	Start int
+	End   int
+
+	// BinStart and BinEnd delimit the bins covered by the event, like those
+	// of a Segment.
+	BinStart int
+	BinEnd   int
+
+	Mean float64
+	// Amplitude is the difference between the mean of the event and that of
+	// the segment holding it: positive for amplifications and negative for
+	// deletions.
+	Amplitude float64
+}
+
+// Len returns the length of the event.
+func (f FocalEvent) Len() int {
+	return f.End - f.Start
+}
+
+// WithFocal searches the segments for focal events after the segmentation,
+// listing them in the Focal field of their segments without splitting them.
+//
+// Every segment of at least twice maxBins bins is segmented again, at a
+// significance level of 0.05 or that set with WithAlpha if higher, and so
+// are the resulting sub-segments longer than maxBins. The sub-segments of
+// minBins to maxBins bins whose means differ from that of the segment by at
+// least minAmplitude are reported. As CBS does not split off arcs of fewer
+// than 5 bins, shorter events are not found.
+func WithFocal(minAmplitude float64, minBins, maxBins int) Option {
+	return func(c *config) {
+		c.focalAmplitude, c.focalMin, c.focalMax = minAmplitude, minBins, maxBins
+	}
+}
+
+// focal returns the focal events of the segment s, if enabled by WithFocal.
+func (sg *segmenter) focal(s Segment) ([]FocalEvent, error) {
+	start, end := s.BinStart, s.BinEnd
+	if sg.cfg.focalMax == 0 || end-start < 2*sg.cfg.focalMax {
+		return nil, nil
+	}
+	var w []float64
+	if sg.w != nil {
+		w = sg.w[start:end]
+	}
+	return sg.focalIn(start, end, stat.Mean(sg.x[start:end], w), nil)
+}
+
+// focalIn appends the focal events in the bins [start, end) of a segment
+// with the given mean to events. Sub-segments longer than focal events are
+// searched again, as the arcs of splits are not tested further by CBS.
+func (sg *segmenter) focalIn(start, end int, mean float64, events []FocalEvent) ([]FocalEvent, error) {
+	bounds, err := sg.resegment(start, end, max(sg.cfg.alpha, focalAlpha))
+	if err != nil || len(bounds) < 2 {
+		return events, err
+	}
+	for _, sub := range sg.summarize(bounds) {
+		n := sub.BinEnd - sub.BinStart
+		if n > sg.cfg.focalMax {
+			if events, err = sg.focalIn(sub.BinStart, sub.BinEnd, mean, events); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if amplitude := sub.Mean - mean; n >= sg.cfg.focalMin && math.Abs(amplitude) >= sg.cfg.focalAmplitude {
+			events = append(events, FocalEvent{
+				Chrom:     sg.cfg.chrom,
+				Start:     sub.Start,
+				End:       sub.End,
+				BinStart:  sub.BinStart,
+				BinEnd:    sub.BinEnd,
+				Mean:      sub.Mean,
+				Amplitude: amplitude,
+			})
+		}
+	}
+	return events, nil
+}
+
+// resegment segments the bins [start, end) again at the significance level
+// alpha, with the shuffles of the run and a seed of their own, so that
+// streaming does not change the permutations, and returns the bounds of the
+// segments.
+func (sg *segmenter) resegment(start, end int, alpha float64) ([][2]int, error) {
+	var w []float64
+	if sg.w != nil {
+		w = sg.w[start:end]
+	}
+	seed := sg.cfg.seed
+	if seed != 0 {
+		seed = DeriveSeed(seed, sg.cfg.sample, fmt.Sprintf("%s:%d-%d", sg.cfg.chrom, start, end))
+	}
+	res, err := RunContext(sg.ctx, sg.x[start:end],
+		WithSeed(seed),
+		WithShuffles(sg.cfg.shuffles),
+		WithAlpha(alpha),
+		WithWeights(w),
+		WithNullCache(sg.cfg.nulls),
+	)
+	if err != nil {
+		return nil, err
+	}
+	bounds := make([][2]int, len(res.Segments))
+	for i, s := range res.Segments {
+		bounds[i] = [2]int{start + s.BinStart, start + s.BinEnd}
+	}
+	return bounds, nil
+}
@@ -0,0 +1,95 @@
+package cbsgo_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// focalProfile returns noisy values with an amplification at bins [100, 108)
+// and a deep deletion at bins [250, 256).
+func focalProfile() []float64 {
+	rng := rand.New(rand.NewSource(5))
+	x := make([]float64, 400)
+	for i := range x {
+		x[i] = 0.2 * rng.NormFloat64()
+		switch {
+		case i >= 100 && i < 108:
+			x[i] += 2
+		case i >= 250 && i < 256:
+			x[i] -= 3
+		}
+	}
+	return x
+}
+
+func TestWithFocal(t *testing.T) {
+	x := focalProfile()
+	// The minimum width and undo keep the events out of the primary
+	// segmentation.
+	res, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithMinWidth(20), cbsgo.WithUndoSD(3), cbsgo.WithFocal(1, 5, 20))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if len(res.Segments) != 1 {
+		t.Fatalf("Expected a single segment, got %v", res.Segments)
+	}
+	focal := res.Segments[0].Focal
+	if len(focal) != 2 {
+		t.Fatalf("Expected 2 focal events, got %+v", focal)
+	}
+	// Like those of segments, the events may start a bin early.
+	for i, expected := range []cbsgo.FocalEvent{
+		{End: 108, BinStart: 100, BinEnd: 108, Amplitude: 2},
+		{End: 256, BinStart: 250, BinEnd: 256, Amplitude: -3},
+	} {
+		f := focal[i]
+		if abs(f.BinStart-expected.BinStart) > 1 || abs(f.BinEnd-expected.BinEnd) > 1 || f.End != f.BinEnd ||
+			math.Abs(f.Amplitude-expected.Amplitude) > 0.6 {
+			t.Errorf("Expected a focal event near %+v, got %+v", expected, f)
+		}
+	}
+
+	for name, opt := range map[string]cbsgo.Option{
+		"disabled":  cbsgo.WithMinWidth(20),
+		"amplitude": cbsgo.WithFocal(5, 5, 20),
+		"short":     cbsgo.WithFocal(1, 5, 4*len(x)),
+	} {
+		res, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithMinWidth(20), cbsgo.WithUndoSD(3), opt)
+		if err != nil {
+			t.Fatalf("%s: Run returned an unexpected error: %v", name, err)
+		}
+		if f := res.Segments[0].Focal; f != nil {
+			t.Errorf("%s: expected no focal events, got %+v", name, f)
+		}
+	}
+
+	for _, opt := range []cbsgo.Option{cbsgo.WithFocal(-1, 5, 20), cbsgo.WithFocal(1, 20, 5)} {
+		if _, err := cbsgo.Run(x, opt); err == nil {
+			t.Errorf("Expected an error for invalid focal settings")
+		}
+	}
+}
+
+func TestTrackWithFocal(t *testing.T) {
+	x := focalProfile()
+	starts, ends := make([]int, len(x)), make([]int, len(x))
+	for i := range x {
+		starts[i], ends[i] = 1000*i, 1000*i+1000
+	}
+	track := &cbsgo.Track{Chrom: "chr8", Starts: starts, Ends: ends, Values: x}
+	res, err := track.Segment(cbsgo.WithSeed(42), cbsgo.WithMinWidth(20), cbsgo.WithUndoSD(3), cbsgo.WithFocal(1, 5, 20))
+	if err != nil {
+		t.Fatalf("Segment returned an unexpected error: %v", err)
+	}
+	for _, f := range res.Segments[0].Focal {
+		if f.Chrom != "chr8" || f.Start != starts[f.BinStart] || f.End != ends[f.BinEnd-1] {
+			t.Errorf("Expected the focal event in track coordinates, got %+v", f)
+		}
+	}
+	if len(res.Segments[0].Focal) != 2 {
+		t.Errorf("Expected 2 focal events, got %+v", res.Segments[0].Focal)
+	}
+}
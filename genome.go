@@ -0,0 +1,103 @@
+package cbsgo
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// GenomeResult holds the segmentation of the tracks of a genome.
+type GenomeResult struct {
+	// Segments lists the segments of all tracks, in track order.
+	Segments SegmentSet
+	// Breakpoints lists the breakpoints of all tracks, in track order.
+	Breakpoints []Breakpoint
+	// Tracks holds the result of every track, in order, such as its Fit.
+	Tracks []*Result
+}
+
+// SegmentGenome segments every track with Track.SegmentContext, configured by
+// opts, processing up to the number of tracks set by WithWorkers concurrently.
+//
+// Every track is segmented with its own seed, derived by DeriveSeed from the
+// seed set with WithSeed, the sample set with WithSample and the chromosome
+// of the track, so that the result depends neither on the number of workers
+// nor on the other tracks. Tracks of the same chromosome get the same seed.
+// Segments of a writer set with WithWriter are written in track order once
+// all tracks are segmented. When ctx ends early, the partial result is
+// returned together with the error of ctx, like RunContext.
+func SegmentGenome(ctx context.Context, tracks []*Track, opts ...Option) (*GenomeResult, error) {
+	cfg := newConfig(opts)
+	workers := cfg.workers
+	if workers < 0 {
+		return nil, fmt.Errorf("cbsgo: invalid number of workers %d", workers)
+	}
+	if workers == 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = cfg.genomeWorkers(workers, tracks)
+	master := cfg.seed
+	if master == 0 {
+		master = time.Now().UnixNano()
+	}
+	trackOpts := make([][]Option, len(tracks))
+	for i, t := range tracks {
+		seed := DeriveSeed(master, cfg.sample, t.Chrom)
+		trackOpts[i] = append(opts[:len(opts):len(opts)], func(c *config) {
+			c.seed, c.writer = seed, nil
+		})
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make([]*Result, len(tracks))
+	errs := make([]error, len(tracks))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(tracks)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i], errs[i] = tracks[i].SegmentContext(ctx, trackOpts[i]...)
+				if results[i] == nil {
+					// Stop the other tracks; the first failure is reported.
+					cancel()
+				}
+			}
+		}()
+	}
+	for i := range tracks {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	var interrupted error
+	for i, err := range errs {
+		switch {
+		case err == nil:
+		case results[i] == nil:
+			return nil, err
+		default:
+			interrupted = err
+		}
+	}
+
+	res := &GenomeResult{Tracks: results}
+	for _, r := range results {
+		res.Segments = append(res.Segments, r.Segments...)
+		res.Breakpoints = append(res.Breakpoints, r.Breakpoints...)
+	}
+	if cfg.writer != nil {
+		for _, s := range res.Segments {
+			if err := cfg.writer(s); err != nil {
+				return nil, err
+			}
+		}
+		res.Segments = nil
+	}
+	return res, interrupted
+}
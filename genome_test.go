@@ -0,0 +1,117 @@
+package cbsgo_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// genomeTracks returns tracks with a gain in the middle of every chromosome.
+func genomeTracks(n int) []*cbsgo.Track {
+	tracks := make([]*cbsgo.Track, n)
+	for i := range tracks {
+		x := make([]float64, 60)
+		for j := range x {
+			x[j] = float64(j%3) / 10
+			if j >= 20 && j < 40 {
+				x[j] += 2
+			}
+		}
+		tracks[i] = &cbsgo.Track{Chrom: fmt.Sprintf("chr%d", i+1), Values: x}
+	}
+	return tracks
+}
+
+func TestSegmentGenome(t *testing.T) {
+	tracks := genomeTracks(6)
+	expected, err := cbsgo.SegmentGenome(context.Background(), tracks, cbsgo.WithSeed(42), cbsgo.WithWorkers(1))
+	if err != nil {
+		t.Fatalf("SegmentGenome returned an unexpected error: %v", err)
+	}
+	if len(expected.Segments) != 3*len(tracks) || len(expected.Tracks) != len(tracks) {
+		t.Fatalf("Expected 3 segments per track, got %v", expected.Segments)
+	}
+	for i, s := range expected.Segments {
+		if s.Chrom != tracks[i/3].Chrom {
+			t.Errorf("Segment %d: expected chromosome %s, got %s", i, tracks[i/3].Chrom, s.Chrom)
+		}
+	}
+
+	got, err := cbsgo.SegmentGenome(context.Background(), tracks, cbsgo.WithSeed(42), cbsgo.WithWorkers(4))
+	if err != nil {
+		t.Fatalf("SegmentGenome returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got.Segments, expected.Segments) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected.Segments, got.Segments)
+	}
+
+	var c collector
+	got, err = cbsgo.SegmentGenome(context.Background(), tracks, cbsgo.WithSeed(42), cbsgo.WithWriter(&c))
+	if err != nil {
+		t.Fatalf("SegmentGenome returned an unexpected error: %v", err)
+	}
+	if len(got.Segments) != 0 || !reflect.DeepEqual(cbsgo.SegmentSet(c.segments), expected.Segments) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected.Segments, c.segments)
+	}
+}
+
+func TestSegmentGenomeErrors(t *testing.T) {
+	tracks := genomeTracks(3)
+	tracks[1].Mask = []bool{true}
+	if _, err := cbsgo.SegmentGenome(context.Background(), tracks); err == nil {
+		t.Errorf("Expected an error for an invalid track")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res, err := cbsgo.SegmentGenome(ctx, genomeTracks(3), cbsgo.WithSeed(42))
+	if err != context.Canceled {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	for _, s := range res.Segments {
+		if !s.Unexplored {
+			t.Errorf("Expected unexplored segments, got %+v", s)
+		}
+	}
+}
+
+func TestSegmentGenomeSeeds(t *testing.T) {
+	// The p-values of the breakpoints depend on the permutations.
+	opts := []cbsgo.Option{cbsgo.WithShuffles(200), cbsgo.WithBreakpoints(0.95)}
+	var tracks []*cbsgo.Track
+	for i := range 4 {
+		tracks = append(tracks, &cbsgo.Track{Chrom: fmt.Sprintf("chr%d", i+1), Values: borderline(0.3)})
+	}
+	full, err := cbsgo.SegmentGenome(context.Background(), tracks, append(opts, cbsgo.WithSeed(7), cbsgo.WithSample("s1"))...)
+	if err != nil {
+		t.Fatalf("SegmentGenome returned an unexpected error: %v", err)
+	}
+	for i, track := range tracks {
+		// A single track reproduces its result in the full run.
+		alone, err := cbsgo.SegmentGenome(context.Background(), tracks[i:i+1], append(opts, cbsgo.WithSeed(7), cbsgo.WithSample("s1"))...)
+		if err != nil {
+			t.Fatalf("SegmentGenome returned an unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(alone.Tracks[0], full.Tracks[i]) {
+			t.Errorf("Track %s: unexpected result.\nExpected: %v\nGot: %v", track.Chrom, full.Tracks[i], alone.Tracks[0])
+		}
+		res, err := track.Segment(append(opts, cbsgo.WithSeed(cbsgo.DeriveSeed(7, "s1", track.Chrom)))...)
+		if err != nil {
+			t.Fatalf("Segment returned an unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(res, full.Tracks[i]) {
+			t.Errorf("Track %s: unexpected result.\nExpected: %v\nGot: %v", track.Chrom, full.Tracks[i], res)
+		}
+	}
+
+	other, err := cbsgo.SegmentGenome(context.Background(), tracks, append(opts, cbsgo.WithSeed(7), cbsgo.WithSample("s2"))...)
+	if err != nil {
+		t.Fatalf("SegmentGenome returned an unexpected error: %v", err)
+	}
+	if reflect.DeepEqual(other.Breakpoints, full.Breakpoints) {
+		t.Errorf("Expected the seeds to depend on the sample")
+	}
+}
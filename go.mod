@@ -2,4 +2,12 @@ module github.com/mattdsm/cbsgo
 
 go 1.23.5
 
-require gonum.org/v1/gonum v0.16.0
+require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/biogo/hts v1.4.5
+	github.com/klauspost/compress v1.18.0
+	github.com/mattn/go-sqlite3 v1.14.28
+	gonum.org/v1/gonum v0.16.0
+	google.golang.org/protobuf v1.36.11
+	gopkg.in/yaml.v3 v3.0.1
+)
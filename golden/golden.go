@@ -0,0 +1,102 @@
+// Package golden provides curated reference datasets together with the
+// segmentations cbsgo produces for them, so that integrations can verify
+// they reproduce the reference results.
+//
+// The datasets cover simulated profiles and known tricky cases, such as a
+// single outlying bin, a staircase of small steps and a long profile without
+// any change:
+//
+//	for _, name := range golden.Names() {
+//		d, err := golden.Load(name)
+//		if err != nil {
+//			return err
+//		}
+//		res, err := myPipeline(d.Values, cbsgo.WithSeed(d.Seed))
+//		if err != nil {
+//			return err
+//		}
+//		if err := d.Check(res.Segments); err != nil {
+//			return err
+//		}
+//	}
+//
+// The expected segmentations are those of Run with the default options and
+// the seed of the dataset.
+package golden
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+//go:embed testdata/*.json
+var files embed.FS
+
+// Dataset is a profile with its reference segmentation.
+type Dataset struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Seed is the seed of the reference run.
+	Seed   int64     `json:"seed"`
+	Values []float64 `json:"values"`
+	// Segments holds the bin bounds [start, end) of the expected segments.
+	Segments [][2]int `json:"segments"`
+}
+
+// Names returns the names of the datasets in alphabetical order.
+func Names() []string {
+	entries, err := fs.ReadDir(files, "testdata")
+	if err != nil {
+		panic(err)
+	}
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = strings.TrimSuffix(e.Name(), ".json")
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load returns the dataset with the given name.
+func Load(name string) (*Dataset, error) {
+	data, err := files.ReadFile(path.Join("testdata", name+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("cbsgo: unknown golden dataset %q", name)
+	}
+	d := new(Dataset)
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, fmt.Errorf("cbsgo: golden dataset %q: %w", name, err)
+	}
+	return d, nil
+}
+
+// Run segments the dataset with its seed followed by opts.
+func (d *Dataset) Run(opts ...cbsgo.Option) (*cbsgo.Result, error) {
+	return cbsgo.Run(d.Values, append([]cbsgo.Option{cbsgo.WithSeed(d.Seed)}, opts...)...)
+}
+
+// Check returns an error describing the first difference between the bounds
+// of segments and the expected segments, or nil if they match.
+func (d *Dataset) Check(segments []cbsgo.Segment) error {
+	for i, s := range segments {
+		if i >= len(d.Segments) {
+			return fmt.Errorf("cbsgo: golden dataset %q: unexpected segment %d [%d, %d)", d.Name, i, s.BinStart, s.BinEnd)
+		}
+		if e := d.Segments[i]; s.BinStart != e[0] || s.BinEnd != e[1] {
+			return fmt.Errorf("cbsgo: golden dataset %q: segment %d is [%d, %d), expected [%d, %d)",
+				d.Name, i, s.BinStart, s.BinEnd, e[0], e[1])
+		}
+	}
+	if len(segments) < len(d.Segments) {
+		e := d.Segments[len(segments)]
+		return fmt.Errorf("cbsgo: golden dataset %q: missing segment %d [%d, %d)", d.Name, len(segments), e[0], e[1])
+	}
+	return nil
+}
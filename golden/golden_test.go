@@ -0,0 +1,53 @@
+package golden_test
+
+import (
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/golden"
+)
+
+func TestGolden(t *testing.T) {
+	names := golden.Names()
+	if len(names) == 0 {
+		t.Fatalf("Expected golden datasets")
+	}
+	for _, name := range names {
+		d, err := golden.Load(name)
+		if err != nil {
+			t.Fatalf("Load returned an unexpected error: %v", err)
+		}
+		if d.Name != name || len(d.Values) == 0 {
+			t.Errorf("%s: unexpected dataset %q with %d values", name, d.Name, len(d.Values))
+		}
+		res, err := d.Run()
+		if err != nil {
+			t.Fatalf("%s: Run returned an unexpected error: %v", name, err)
+		}
+		if err := d.Check(res.Segments); err != nil {
+			t.Error(err)
+		}
+		if err := cbsgo.ValidateSegmentation(res.Segments, len(d.Values)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	d, err := golden.Load("steps")
+	if err != nil {
+		t.Fatalf("Load returned an unexpected error: %v", err)
+	}
+	for name, segments := range map[string][]cbsgo.Segment{
+		"missing": {{BinStart: 0, BinEnd: 8}},
+		"extra":   {{BinStart: 0, BinEnd: 8}, {BinStart: 8, BinEnd: 10}, {BinStart: 10, BinEnd: 14}},
+		"moved":   {{BinStart: 0, BinEnd: 9}, {BinStart: 9, BinEnd: 14}},
+	} {
+		if err := d.Check(segments); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := golden.Load("unknown"); err == nil {
+		t.Errorf("Expected an error for an unknown dataset")
+	}
+}
@@ -0,0 +1,101 @@
+package cbsgo
+
+import (
+	"math"
+	"sort"
+)
+
+// HaarCandidates proposes candidate breakpoints of x with a HaarSeg-style
+// screen, returning the bins at which a change starts, in order.
+//
+// The differences between the means of the h bins after and before every bin
+// are computed for every scale h = 1, 2, 4, ... up to half the length of x,
+// and standardized by the noise level of x, estimated from the differences
+// between adjacent values. Bins where a standardized difference exceeds
+// threshold and is the largest within h bins at its scale are peaks, which
+// are localized by following the largest differences within half as many
+// bins at the next finer scale down to single bins.
+func HaarCandidates(x []float64, threshold float64) []int {
+	n := len(x)
+	sd := DiffMAD(x)
+	cum := make([]float64, n+1)
+	for i, v := range x {
+		cum[i+1] = cum[i] + v
+	}
+
+	// d[l][b] is the standardized difference at bin b and scale 2^l.
+	var d [][]float64
+	for h := 1; 2*h <= n; h *= 2 {
+		scale := sd * math.Sqrt(2/float64(h))
+		dl := make([]float64, n+1)
+		for b := h; b <= n-h; b++ {
+			dl[b] = math.Abs((cum[b+h]-2*cum[b]+cum[b-h])/float64(h)) / scale
+		}
+		d = append(d, dl)
+	}
+	// peak returns the bin with the largest difference at level l within h
+	// bins of b, the earliest on ties.
+	peak := func(l, b, h int) int {
+		best := b
+		for c := max(0, b-h); c <= min(n, b+h); c++ {
+			if d[l][c] > d[l][best] || (d[l][c] == d[l][best] && c < best) {
+				best = c
+			}
+		}
+		return best
+	}
+
+	found := make(map[int]bool)
+	for l, dl := range d {
+		h := 1 << l
+		top := windowMax(dl, h)
+		for b := h; b <= n-h; b++ {
+			if !(dl[b] > threshold) || dl[b] < top[b] {
+				continue
+			}
+			c := b
+			for k := l - 1; k >= 0; k-- {
+				c = peak(k, c, 1<<k)
+			}
+			found[c] = true
+		}
+	}
+
+	res := make([]int, 0, len(found))
+	for b := range found {
+		res = append(res, b)
+	}
+	sort.Ints(res)
+	return res
+}
+
+// windowMax returns the maximum of a within h elements of every element.
+func windowMax(a []float64, h int) []float64 {
+	res := make([]float64, len(a))
+	var window []int // indices of decreasing values
+	next := 0
+	for i := range a {
+		for ; next < len(a) && next <= i+h; next++ {
+			for len(window) > 0 && a[window[len(window)-1]] <= a[next] {
+				window = window[:len(window)-1]
+			}
+			window = append(window, next)
+		}
+		for window[0] < i-h {
+			window = window[1:]
+		}
+		res[i] = a[window[0]]
+	}
+	return res
+}
+
+// screened reports whether the Haar screen enabled by WithHaarScreen found no
+// candidate breakpoint within the bins [start, end), which then need no test.
+// Candidates within 5 bins of the ends are ignored, like the splits there.
+func (sg *segmenter) screened(start, end int) bool {
+	if sg.candidates == nil {
+		return false
+	}
+	i := sort.SearchInts(sg.candidates, start+5)
+	return i == len(sg.candidates) || sg.candidates[i] > end-5
+}
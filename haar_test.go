@@ -0,0 +1,57 @@
+package cbsgo_test
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// stepProfile returns a long noisy profile with a gain at bins 500 to 600.
+func stepProfile() []float64 {
+	rng := rand.New(rand.NewSource(1))
+	x := make([]float64, 2000)
+	for i := range x {
+		x[i] = rng.NormFloat64() / 5
+		if i >= 500 && i < 600 {
+			x[i] += 1
+		}
+	}
+	return x
+}
+
+func TestHaarCandidates(t *testing.T) {
+	got := cbsgo.HaarCandidates(stepProfile(), 4)
+	for _, b := range []int{500, 600} {
+		found := false
+		for _, c := range got {
+			found = found || abs(c-b) <= 2
+		}
+		if !found {
+			t.Errorf("Expected a candidate near %d, got %v", b, got)
+		}
+	}
+	if len(got) > 10 {
+		t.Errorf("Expected few candidates, got %v", got)
+	}
+}
+
+func TestWithHaarScreen(t *testing.T) {
+	x := stepProfile()
+	var full, screened recorder
+	expected, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithMetrics(&full))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	got, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithMetrics(&screened), cbsgo.WithHaarScreen(4))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got.Segments, expected.Segments) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected.Segments, got.Segments)
+	}
+	if screened.stats[0].Tests >= full.stats[0].Tests {
+		t.Errorf("Expected fewer tests with the screen, got %d and %d", screened.stats[0].Tests, full.stats[0].Tests)
+	}
+}
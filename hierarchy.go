@@ -0,0 +1,31 @@
+package cbsgo
+
+// WithHierarchy reports the changes within the segments at the relaxed
+// significance level alpha, such as 0.05 with WithAlpha(0.001), so that both
+// broad, such as arm-level, and focal events are represented: every segment
+// found at the strict level of WithAlpha is segmented again at alpha, and
+// the resulting sub-segments are listed in its Subsegments, without
+// splitting it. The sub-segments have the positions, means and standard
+// deviations of their bins, but none of the other annotations.
+func WithHierarchy(alpha float64) Option {
+	return func(c *config) {
+		c.hierarchyAlpha = alpha
+	}
+}
+
+// subsegments returns the sub-segments of the segment s, if enabled by
+// WithHierarchy and any.
+func (sg *segmenter) subsegments(s Segment) ([]Segment, error) {
+	if sg.cfg.hierarchyAlpha == 0 {
+		return nil, nil
+	}
+	bounds, err := sg.resegment(s.BinStart, s.BinEnd, sg.cfg.hierarchyAlpha)
+	if err != nil || len(bounds) < 2 {
+		return nil, err
+	}
+	subsegments := sg.summarize(bounds)
+	for i := range subsegments {
+		subsegments[i].Chrom = s.Chrom
+	}
+	return subsegments, nil
+}
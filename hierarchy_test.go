@@ -0,0 +1,78 @@
+package cbsgo_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestWithHierarchy(t *testing.T) {
+	x := focalProfile()
+	for i := 200; i < len(x); i++ {
+		x[i] += 1
+	}
+	broad, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithMinWidth(20), cbsgo.WithUndoSD(3))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	res, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithMinWidth(20), cbsgo.WithUndoSD(3), cbsgo.WithHierarchy(0.05))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if len(res.Segments) != 2 || len(res.Segments) != len(broad.Segments) {
+		t.Fatalf("Expected the 2 broad segments %v, got %v", broad.Segments, res.Segments)
+	}
+	for i, s := range res.Segments {
+		if s.BinStart != broad.Segments[i].BinStart || s.BinEnd != broad.Segments[i].BinEnd {
+			t.Errorf("Expected the broad segment %+v, got %+v", broad.Segments[i], s)
+		}
+		subs := s.Subsegments
+		if len(subs) < 2 || subs[0].BinStart != s.BinStart || subs[len(subs)-1].BinEnd != s.BinEnd {
+			t.Fatalf("Expected sub-segments covering segment %+v, got %v", s, subs)
+		}
+		for j := 1; j < len(subs); j++ {
+			if subs[j].BinStart != subs[j-1].BinEnd {
+				t.Errorf("Expected adjacent sub-segments, got %v", subs)
+			}
+		}
+		// Every broad segment holds one of the focal events, in a short
+		// sub-segment with a distinct mean.
+		found := false
+		for _, sub := range subs {
+			found = found || sub.BinEnd-sub.BinStart < 60 && (sub.Mean > s.Mean+0.3 || sub.Mean < s.Mean-0.3)
+		}
+		if !found {
+			t.Errorf("Expected a focal sub-segment in %+v, got %v", s, subs)
+		}
+	}
+
+	if _, err := cbsgo.Run(x, cbsgo.WithHierarchy(1)); err == nil {
+		t.Errorf("Expected an error for an invalid significance level")
+	}
+}
+
+func TestTrackWithHierarchy(t *testing.T) {
+	x := focalProfile()
+	mask := make([]bool, len(x))
+	mask[0] = true
+	track := &cbsgo.Track{Chrom: "chr9", Values: x, Mask: mask}
+	res, err := track.Segment(cbsgo.WithSeed(42), cbsgo.WithMinWidth(20), cbsgo.WithUndoSD(3), cbsgo.WithHierarchy(0.05))
+	if err != nil {
+		t.Fatalf("Segment returned an unexpected error: %v", err)
+	}
+	subs := res.Segments[0].Subsegments
+	if len(subs) < 2 || subs[0].Chrom != "chr9" || subs[0].BinStart != 1 || subs[len(subs)-1].BinEnd != len(x) {
+		t.Errorf("Expected sub-segments in track bins, got %v", subs)
+	}
+
+	var buf bytes.Buffer
+	w := cbsgo.NewJSONLWriter(&buf, "s1")
+	if err := w.Write(res.Segments[0]); err != nil {
+		t.Fatalf("Write returned an unexpected error: %v", err)
+	}
+	if n := strings.Count(buf.String(), `"sample"`); !strings.Contains(buf.String(), `"subsegments":[{"chrom":"chr9","start":1,`) || n != 1 {
+		t.Errorf("Expected nested sub-segments, got %s", buf.String())
+	}
+}
@@ -0,0 +1,159 @@
+package cbsgo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// HotspotOptions configures FindHotspots.
+type HotspotOptions struct {
+	// MinSamples is the smallest number of samples with a breakpoint in a
+	// hotspot.
+	MinSamples int
+
+	// Permutations is the number of permutations used to assess the
+	// significance of the breakpoint frequencies, and Alpha the genome-wide
+	// significance level.
+	Permutations int
+	Alpha        float64
+	// Seed seeds the permutations; 0 selects a time-based seed.
+	Seed int64
+}
+
+// DefaultHotspotOptions returns options requiring breakpoints in at least two
+// samples at a genome-wide significance level of 5%.
+func DefaultHotspotOptions() HotspotOptions {
+	return HotspotOptions{
+		MinSamples:   2,
+		Permutations: 1000,
+		Alpha:        0.05,
+	}
+}
+
+// Hotspot is a run of adjacent bins in which significantly many samples have
+// a breakpoint.
+type Hotspot struct {
+	Chrom string
+	Start int
+	End   int
+
+	// Frequency is the highest fraction of samples with a breakpoint in a bin
+	// of the hotspot.
+	Frequency float64
+	// Samples lists the indices of the samples with a breakpoint in the
+	// hotspot.
+	Samples []int
+	// PValue is the genome-wide permutation p-value of Frequency.
+	PValue float64
+}
+
+// FindHotspots finds the breakpoint hotspots of a cohort, one SegmentSet per
+// sample, on the bins, such as those of Genome.Bins with a size of a few bins
+// of the samples: runs of adjacent bins holding a breakpoint, the start of a
+// segment following another on its chromosome, in significantly many
+// samples. Such hotspots point to fragile sites and recurrent fusions.
+//
+// Every sample counts once per bin, however many breakpoints it has there.
+// Significance is assessed genome-wide like in AnalyzeRecurrence, by
+// circularly shifting the bins with breakpoints of every sample by a random
+// offset, which preserves their number and spacing, and comparing the
+// highest frequency over all bins to the observed ones. Hotspots are returned
+// in genome order.
+func FindHotspots(samples []SegmentSet, bins SegmentSet, opts HotspotOptions) ([]Hotspot, error) {
+	if len(samples) == 0 || len(bins) == 0 {
+		return nil, errors.New("cbsgo: hotspot detection needs samples and bins")
+	}
+	if opts.Permutations < 1 {
+		return nil, fmt.Errorf("cbsgo: invalid number of permutations %d", opts.Permutations)
+	}
+
+	states := make([][]int8, len(samples))
+	for i, set := range samples {
+		states[i] = breakpointStates(set, bins)
+	}
+	freq, _ := frequencies(states, nil)
+
+	rng := newRand(opts.Seed)
+	null := make([]float64, opts.Permutations)
+	offsets := make([]int, len(samples))
+	for p := range null {
+		for i := range offsets {
+			offsets[i] = rng.Intn(len(bins))
+		}
+		f, _ := frequencies(states, offsets)
+		null[p] = maxOf(f)
+	}
+	sort.Float64s(null)
+	threshold := max(upperQuantile(null, opts.Alpha), float64(opts.MinSamples)/float64(len(samples)))
+
+	var res []Hotspot
+	significant := func(f float64) bool { return f > 0 && f >= threshold }
+	for i := 0; i < len(freq); i++ {
+		if !significant(freq[i]) {
+			continue
+		}
+		h := Hotspot{Chrom: bins[i].Chrom, Start: bins[i].Start}
+		in := make([]bool, len(samples))
+		for ; i < len(freq) && significant(freq[i]) && bins[i].Chrom == h.Chrom; i++ {
+			h.End = bins[i].End
+			h.Frequency = max(h.Frequency, freq[i])
+			for k, s := range states {
+				in[k] = in[k] || s[i] != 0
+			}
+		}
+		i--
+		for k, ok := range in {
+			if ok {
+				h.Samples = append(h.Samples, k)
+			}
+		}
+		exceed := len(null) - sort.SearchFloat64s(null, h.Frequency)
+		h.PValue = float64(1+exceed) / float64(1+len(null))
+		res = append(res, h)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.Chrom != b.Chrom {
+			return chromLess(a.Chrom, b.Chrom)
+		}
+		return a.Start < b.Start
+	})
+	return res, nil
+}
+
+// breakpointStates returns 1 for the bins holding a breakpoint of set, and 0
+// for the other bins. The bins of a chromosome must be sorted and disjoint.
+func breakpointStates(set SegmentSet, bins SegmentSet) []int8 {
+	byChrom := make(map[string][]int)
+	for i, b := range bins {
+		byChrom[b.Chrom] = append(byChrom[b.Chrom], i)
+	}
+	states := make([]int8, len(bins))
+	sorted := set.Sorted()
+	for i := 1; i < len(sorted); i++ {
+		s := sorted[i]
+		if s.Chrom != sorted[i-1].Chrom {
+			continue
+		}
+		idx := byChrom[s.Chrom]
+		j := sort.Search(len(idx), func(j int) bool { return bins[idx[j]].End > s.Start })
+		if j < len(idx) && bins[idx[j]].Start <= s.Start {
+			states[idx[j]] = 1
+		}
+	}
+	return states
+}
+
+// WriteHotspots writes the hotspots in BED format, with the name column
+// holding the number of samples with a breakpoint in the hotspot and the
+// score column the peak frequency scaled to [0, 1000].
+func WriteHotspots(w io.Writer, hotspots []Hotspot) error {
+	bw := bufio.NewWriter(w)
+	for _, h := range hotspots {
+		fmt.Fprintf(bw, "%s\t%d\t%d\t%d\t%d\n", h.Chrom, h.Start, h.End, len(h.Samples), int(1000*h.Frequency+0.5))
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,61 @@
+package cbsgo_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestFindHotspots(t *testing.T) {
+	genome := cbsgo.Genome{{Name: "chr1", Length: 2000}, {Name: "chr2", Length: 2000}}
+	bins := genome.Bins(100)
+
+	// Samples 0 to 5 break at chr1:1230, and every sample breaks at its own
+	// position of chr2.
+	var samples []cbsgo.SegmentSet
+	for i := range 10 {
+		set := cbsgo.SegmentSet{{Chrom: "chr1", Start: 0, End: 2000}}
+		if i < 6 {
+			set = cbsgo.SegmentSet{{Chrom: "chr1", Start: 0, End: 1230}, {Chrom: "chr1", Start: 1230, End: 2000, Mean: 0.5}}
+		}
+		own := 150 + 190*i
+		set = append(set, cbsgo.Segment{Chrom: "chr2", Start: 0, End: own}, cbsgo.Segment{Chrom: "chr2", Start: own, End: 2000, Mean: -0.5})
+		samples = append(samples, set)
+	}
+
+	opts := cbsgo.DefaultHotspotOptions()
+	opts.Seed = 1
+	hotspots, err := cbsgo.FindHotspots(samples, bins, opts)
+	if err != nil {
+		t.Fatalf("FindHotspots returned an unexpected error: %v", err)
+	}
+	if len(hotspots) != 1 {
+		t.Fatalf("Expected a single hotspot, got %+v", hotspots)
+	}
+	h := hotspots[0]
+	if h.Chrom != "chr1" || h.Start != 1200 || h.End != 1300 || h.Frequency != 0.6 || !reflect.DeepEqual(h.Samples, []int{0, 1, 2, 3, 4, 5}) {
+		t.Errorf("Unexpected hotspot %+v", h)
+	}
+	if h.PValue > 0.01 {
+		t.Errorf("Expected a significant hotspot, got a p-value of %v", h.PValue)
+	}
+
+	var b bytes.Buffer
+	if err := cbsgo.WriteHotspots(&b, hotspots); err != nil {
+		t.Fatalf("WriteHotspots returned an unexpected error: %v", err)
+	}
+	if expected := "chr1\t1200\t1300\t6\t600\n"; b.String() != expected {
+		t.Errorf("Unexpected result.\nExpected: %q\nGot: %q", expected, b.String())
+	}
+
+	// The hotspot has too few samples for MinSamples of 7.
+	opts.MinSamples = 7
+	if hotspots, err = cbsgo.FindHotspots(samples, bins, opts); err != nil || len(hotspots) != 0 {
+		t.Errorf("Expected no hotspots with 7 samples, got %+v, %v", hotspots, err)
+	}
+	if _, err := cbsgo.FindHotspots(nil, bins, opts); err == nil {
+		t.Errorf("Expected an error without samples")
+	}
+}
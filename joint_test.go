@@ -0,0 +1,80 @@
+package cbsgo_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestRunJointSingle(t *testing.T) {
+	x := borderline(0.3)
+
+	expected, err := cbsgo.Run(x, cbsgo.WithSeed(5))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	res, err := cbsgo.RunJoint([][]float64{x}, cbsgo.WithSeed(5))
+	if err != nil {
+		t.Fatalf("RunJoint returned an unexpected error: %v", err)
+	}
+	if len(res) != 1 || !reflect.DeepEqual(res[0], expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, res)
+	}
+}
+
+func TestRunJoint(t *testing.T) {
+	// A gain of bins 20-39 at diagnosis that is lost at relapse, while a
+	// new gain of bins 40-59 appears.
+	pattern := []float64{0, 0.3, -0.2, 0.1, -0.3, 0.2}
+	diagnosis := make([]float64, 60)
+	relapse := make([]float64, 60)
+	for i := range diagnosis {
+		diagnosis[i] = pattern[i%len(pattern)]
+		relapse[i] = pattern[(i+3)%len(pattern)]
+		if i >= 20 && i < 40 {
+			diagnosis[i] += 2
+		}
+		if i >= 40 {
+			relapse[i] += 2
+		}
+	}
+
+	res, err := cbsgo.RunJoint([][]float64{diagnosis, relapse}, cbsgo.WithSeed(5))
+	if err != nil {
+		t.Fatalf("RunJoint returned an unexpected error: %v", err)
+	}
+	if len(res) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(res))
+	}
+
+	var bounds [][2]int
+	for _, s := range res[0].Segments {
+		bounds = append(bounds, [2]int{s.BinStart, s.BinEnd})
+	}
+	expected := [][2]int{{0, 19}, {19, 39}, {39, 60}}
+	if !reflect.DeepEqual(bounds, expected) {
+		t.Fatalf("Unexpected segments.\nExpected: %v\nGot: %v", expected, bounds)
+	}
+
+	for i, s := range res[1].Segments {
+		if s.BinStart != bounds[i][0] || s.BinEnd != bounds[i][1] {
+			t.Errorf("Segment %d of the relapse differs from the diagnosis: %+v", i, s)
+		}
+	}
+	if m := res[0].Segments[1].Mean; m < 1.5 {
+		t.Errorf("Expected a gain in the second segment at diagnosis, got mean %v", m)
+	}
+	if m := res[1].Segments[1].Mean; m > 0.5 {
+		t.Errorf("Expected no gain in the second segment at relapse, got mean %v", m)
+	}
+}
+
+func TestRunJointInvalid(t *testing.T) {
+	if _, err := cbsgo.RunJoint(nil); err == nil {
+		t.Errorf("Expected an error without samples")
+	}
+	if _, err := cbsgo.RunJoint([][]float64{{1, 2, 3}, {1, 2}}); err == nil {
+		t.Errorf("Expected an error for samples of different lengths")
+	}
+}
@@ -0,0 +1,186 @@
+package cbsgo
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+)
+
+// JSONLWriter writes segments as JSON Lines, one object per segment, for
+// tools such as jq or for streaming ingestion. Every line is written to the
+// underlying writer as soon as the segment is, so that consumers see the
+// segments as they are produced.
+//
+// An object holds the fields chrom, start, end, bin_start, bin_end, mean and
+// sd, the sample if set, and the optional fields that were computed: state,
+// allelic, snps, baf, cell_fraction, sse, r2, cytoband,
+// population_frequency, unexplored, spike and
+//
+//   - best_split, an object with the bins start and end of the arc, stat,
+//     p_value and decision of the rejected split,
+//   - focal, an array of objects with the start, end, bin_start, bin_end,
+//     mean and amplitude of the focal events,
+//   - subsegments, an array of the objects of the sub-segments, without
+//     sample.
+//
+// Undefined statistics, such as the SD of a single bin, are null.
+// Coordinates are zero-based unless set otherwise with SetCoordinates.
+type JSONLWriter struct {
+	enc    *json.Encoder
+	sample string
+	coords Coordinates
+}
+
+// NewJSONLWriter returns a JSONLWriter writing the segments of sample to w.
+// The sample is left out of the objects if empty.
+func NewJSONLWriter(w io.Writer, sample string) *JSONLWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &JSONLWriter{enc: enc, sample: sample}
+}
+
+// jsonSegment is the JSON object of a segment.
+type jsonSegment struct {
+	Sample            string        `json:"sample,omitempty"`
+	Chrom             string        `json:"chrom"`
+	Start             int           `json:"start"`
+	End               int           `json:"end"`
+	BinStart          int           `json:"bin_start"`
+	BinEnd            int           `json:"bin_end"`
+	Mean              jsonFloat     `json:"mean"`
+	SD                jsonFloat     `json:"sd"`
+	State             string        `json:"state,omitempty"`
+	Allelic           string        `json:"allelic,omitempty"`
+	SNPs              int           `json:"snps,omitempty"`
+	BAF               *jsonFloat    `json:"baf,omitempty"`
+	CellFraction      *jsonFloat    `json:"cell_fraction,omitempty"`
+	CellFractionLower *jsonFloat    `json:"cell_fraction_lower,omitempty"`
+	CellFractionUpper *jsonFloat    `json:"cell_fraction_upper,omitempty"`
+	SSE               *jsonFloat    `json:"sse,omitempty"`
+	R2                *jsonFloat    `json:"r2,omitempty"`
+	Cytoband          string        `json:"cytoband,omitempty"`
+	PopulationFreq    *jsonFloat    `json:"population_frequency,omitempty"`
+	Unexplored        bool          `json:"unexplored,omitempty"`
+	Spike             bool          `json:"spike,omitempty"`
+	BestSplit         *jsonSplit    `json:"best_split,omitempty"`
+	Focal             []jsonFocal   `json:"focal,omitempty"`
+	Subsegments       []jsonSegment `json:"subsegments,omitempty"`
+}
+
+// jsonSplit is the JSON object of the best split of a segment.
+type jsonSplit struct {
+	Start    int       `json:"start"`
+	End      int       `json:"end"`
+	Stat     jsonFloat `json:"stat"`
+	PValue   jsonFloat `json:"p_value"`
+	Decision string    `json:"decision"`
+}
+
+// jsonFocal is the JSON object of a focal event.
+type jsonFocal struct {
+	Start     int       `json:"start"`
+	End       int       `json:"end"`
+	BinStart  int       `json:"bin_start"`
+	BinEnd    int       `json:"bin_end"`
+	Mean      jsonFloat `json:"mean"`
+	Amplitude jsonFloat `json:"amplitude"`
+}
+
+// jsonFloat is a float64 encoding NaN and infinities as null, which JSON
+// cannot represent.
+type jsonFloat float64
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+		return []byte("null"), nil
+	}
+	return json.Marshal(float64(f))
+}
+
+// jsonManifest is the JSON object of a manifest.
+type jsonManifest struct {
+	Version      string            `json:"version"`
+	Backend      string            `json:"backend"`
+	Seed         int64             `json:"seed"`
+	Reproducible bool              `json:"reproducible"`
+	Parameters   map[string]string `json:"parameters"`
+}
+
+// SetManifest writes m as a first line holding a single object with the
+// field manifest, an object with the fields version, backend, seed,
+// reproducible and parameters, which readers of the segments skip by its
+// missing chrom. Call it before the first Write.
+func (jw *JSONLWriter) SetManifest(m Manifest) error {
+	return jw.enc.Encode(struct {
+		Manifest jsonManifest `json:"manifest"`
+	}{jsonManifest(m)})
+}
+
+// SetCoordinates sets the coordinates of the start and end fields of the
+// segments and focal events written. The bin_start and bin_end fields hold
+// zero-based bin indices whatever the coordinates. Call it before the first
+// Write.
+func (jw *JSONLWriter) SetCoordinates(c Coordinates) error {
+	jw.coords = c
+	return nil
+}
+
+func (jw *JSONLWriter) Write(s Segment) error {
+	o := newJSONSegment(s, jw.coords)
+	o.Sample = jw.sample
+	return jw.enc.Encode(o)
+}
+
+// newJSONSegment returns the JSON object of s in the coordinates c, without
+// the sample.
+func newJSONSegment(s Segment, c Coordinates) jsonSegment {
+	optional := func(v float64, set bool) *jsonFloat {
+		if !set {
+			return nil
+		}
+		f := jsonFloat(v)
+		return &f
+	}
+	o := jsonSegment{
+		Chrom:          s.Chrom,
+		BinStart:       s.BinStart,
+		BinEnd:         s.BinEnd,
+		Mean:           jsonFloat(s.Mean),
+		SD:             jsonFloat(s.SD),
+		SNPs:           s.SNPs,
+		BAF:            optional(s.BAF, s.SNPs > 0),
+		SSE:            optional(s.SSE, s.SSE != 0 || s.R2 != 0),
+		R2:             optional(s.R2, s.SSE != 0 || s.R2 != 0),
+		Cytoband:       s.Cytoband,
+		PopulationFreq: optional(s.PopulationFrequency, s.PopulationFrequency != 0),
+		Unexplored:     s.Unexplored,
+		Spike:          s.Spike,
+	}
+	o.Start, o.End = c.interval(s.Start, s.End, s.BinStart, s.BinEnd, ZeroBased)
+	if s.State != StateUnknown {
+		o.State = s.State.String()
+	}
+	if s.Allelic != AllelicUnknown {
+		o.Allelic = s.Allelic.String()
+	}
+	if c := s.BestSplit; c != nil {
+		o.BestSplit = &jsonSplit{c.SplitStart, c.SplitEnd, jsonFloat(c.Stat), jsonFloat(c.PValue), c.Decision.String()}
+	}
+	for _, f := range s.Focal {
+		start, end := c.interval(f.Start, f.End, f.BinStart, f.BinEnd, ZeroBased)
+		o.Focal = append(o.Focal, jsonFocal{start, end, f.BinStart, f.BinEnd, jsonFloat(f.Mean), jsonFloat(f.Amplitude)})
+	}
+	mosaic := s.CellFraction != 0 || s.CellFractionLower != 0 || s.CellFractionUpper != 0
+	o.CellFraction = optional(s.CellFraction, mosaic)
+	o.CellFractionLower = optional(s.CellFractionLower, mosaic)
+	o.CellFractionUpper = optional(s.CellFractionUpper, mosaic)
+	for _, sub := range s.Subsegments {
+		o.Subsegments = append(o.Subsegments, newJSONSegment(sub, c))
+	}
+	return o
+}
+
+// Close does nothing, as every segment is written when passed to Write.
+func (jw *JSONLWriter) Close() error {
+	return nil
+}
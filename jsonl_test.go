@@ -0,0 +1,47 @@
+package cbsgo_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestJSONLWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := cbsgo.NewJSONLWriter(&buf, "s1")
+	segments := []cbsgo.Segment{
+		{Chrom: "chr1", End: 1000, BinEnd: 10, Mean: 0.0125, SD: 0.5, BestSplit: &cbsgo.Candidate{
+			Chrom: "chr1", End: 10, SplitStart: 4, SplitEnd: 10, Stat: 2.5, PValue: 0.25, Decision: cbsgo.SplitInsignificant}},
+		{Chrom: "chr1", Start: 1000, End: 2000, BinStart: 10, BinEnd: 11, Mean: 1.5, SD: math.NaN(),
+			State: cbsgo.Gain, Cytoband: "p36.33", Focal: []cbsgo.FocalEvent{
+				{Chrom: "chr1", Start: 1000, End: 2000, BinStart: 10, BinEnd: 11, Mean: 3.5, Amplitude: 2}}},
+	}
+	for i, s := range segments {
+		if err := w.Write(s); err != nil {
+			t.Fatalf("Write returned an unexpected error: %v", err)
+		}
+		// Every segment is written immediately.
+		if n := strings.Count(buf.String(), "\n"); n != i+1 {
+			t.Errorf("Expected %d lines after writing segment %d, got %d", i+1, i, n)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned an unexpected error: %v", err)
+	}
+
+	expected := `{"sample":"s1","chrom":"chr1","start":0,"end":1000,"bin_start":0,"bin_end":10,"mean":0.0125,"sd":0.5,"best_split":{"start":4,"end":10,"stat":2.5,"p_value":0.25,"decision":"insignificant"}}
+{"sample":"s1","chrom":"chr1","start":1000,"end":2000,"bin_start":10,"bin_end":11,"mean":1.5,"sd":null,"state":"gain","cytoband":"p36.33","focal":[{"start":1000,"end":2000,"bin_start":10,"bin_end":11,"mean":3.5,"amplitude":2}]}
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected result.\nExpected: %s\nGot: %s", expected, buf.String())
+	}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if !json.Valid([]byte(line)) {
+			t.Errorf("Invalid JSON line %q", line)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !purego
+
+package cbsgo
+
+// centeredExtremes is centeredExtremesGeneric, implemented in assembly.
+//
+//go:noescape
+func centeredExtremes(x []float64, mean float64) (hi, lo float64, i0, i1 int)
+
+// kernelBackend names the implementation of centeredExtremes.
+const kernelBackend = "amd64"
@@ -0,0 +1,83 @@
+//go:build !purego
+
+#include "textflag.h"
+
+// func centeredExtremes(x []float64, mean float64) (hi, lo float64, i0, i1 int)
+//
+// The loop is unrolled twice; the cumulative sum is computed sequentially,
+// in the order of centeredExtremesGeneric. Comparisons with NaN are false.
+TEXT ·centeredExtremes(SB), NOSPLIT, $0-64
+	MOVQ  x_base+0(FP), SI
+	MOVQ  x_len+8(FP), CX
+	MOVSD mean+24(FP), X1
+	XORPS X0, X0               // sum
+	MOVQ  $0xfff0000000000000, AX
+	MOVQ  AX, X2               // hi = -Inf
+	MOVQ  $0x7ff0000000000000, AX
+	MOVQ  AX, X3               // lo = +Inf
+	XORQ  DX, DX               // i
+	XORQ  R8, R8               // i0
+	XORQ  R9, R9               // i1
+	MOVQ  CX, BX
+	ANDQ  $-2, BX              // end of the unrolled loop
+
+pair:
+	CMPQ    DX, BX
+	JGE     single
+	MOVSD   (SI)(DX*8), X4
+	MOVSD   8(SI)(DX*8), X5
+	SUBSD   X1, X4
+	SUBSD   X1, X5
+	ADDSD   X4, X0
+	UCOMISD X2, X0
+	JBE     lo0
+	MOVSD   X0, X2
+	MOVQ    DX, R8
+
+lo0:
+	UCOMISD X0, X3
+	JBE     next0
+	MOVSD   X0, X3
+	MOVQ    DX, R9
+
+next0:
+	INCQ    DX
+	ADDSD   X5, X0
+	UCOMISD X2, X0
+	JBE     lo1
+	MOVSD   X0, X2
+	MOVQ    DX, R8
+
+lo1:
+	UCOMISD X0, X3
+	JBE     next1
+	MOVSD   X0, X3
+	MOVQ    DX, R9
+
+next1:
+	INCQ DX
+	JMP  pair
+
+single:
+	CMPQ    DX, CX
+	JGE     done
+	MOVSD   (SI)(DX*8), X4
+	SUBSD   X1, X4
+	ADDSD   X4, X0
+	UCOMISD X2, X0
+	JBE     lo2
+	MOVSD   X0, X2
+	MOVQ    DX, R8
+
+lo2:
+	UCOMISD X0, X3
+	JBE     done
+	MOVSD   X0, X3
+	MOVQ    DX, R9
+
+done:
+	MOVSD X2, hi+32(FP)
+	MOVSD X3, lo+40(FP)
+	MOVQ  R8, i0+48(FP)
+	MOVQ  R9, i1+56(FP)
+	RET
@@ -0,0 +1,14 @@
+//go:build !amd64 || purego
+
+package cbsgo
+
+// centeredExtremes is centeredExtremesGeneric on platforms without an
+// assembly version. Only amd64 has one: on arm64 the generic loop, which the
+// compiler keeps sequential like the assembly, is used until an arm64 kernel
+// can be verified there.
+func centeredExtremes(x []float64, mean float64) (hi, lo float64, i0, i1 int) {
+	return centeredExtremesGeneric(x, mean)
+}
+
+// kernelBackend names the implementation of centeredExtremes.
+const kernelBackend = "generic"
@@ -0,0 +1,49 @@
+package cbsgo_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestCenteredExtremes(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := make([]float64, 203)
+	for i := range random {
+		random[i] = rng.NormFloat64()
+	}
+	// Repeated values give ties of the cumulative sum, where the first
+	// extreme is kept.
+	ties := make([]float64, 203)
+	for i := range ties {
+		ties[i] = float64(i % 3)
+	}
+	constant := make([]float64, 203)
+	for i := range constant {
+		constant[i] = 2
+	}
+
+	check := func(name string, x []float64, mean float64) {
+		t.Helper()
+		hi, lo, i0, i1 := cbsgo.CenteredExtremes(x, mean)
+		ghi, glo, gi0, gi1 := cbsgo.CenteredExtremesGeneric(x, mean)
+		if hi != ghi || lo != glo || i0 != gi0 || i1 != gi1 {
+			t.Errorf("%s, %d values: unexpected result.\nExpected: %v %v %d %d\nGot: %v %v %d %d",
+				name, len(x), ghi, glo, gi0, gi1, hi, lo, i0, i1)
+		}
+	}
+	for name, x := range map[string][]float64{"random": random, "ties": ties, "constant": constant} {
+		for n := range 10 {
+			check(name, x[:n], 1)
+		}
+		// Slices starting at every offset of a pair, and ending with an odd
+		// tail or not.
+		for off := range 3 {
+			check(name, x[off:], 1)
+			check(name, x[off:len(x)-1], 1)
+		}
+		check(name, x, 0)
+	}
+	t.Logf("kernel backend %s", cbsgo.KernelBackend)
+}
@@ -0,0 +1,180 @@
+package cbsgo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Chain maps coordinates between genome builds, as described by a UCSC chain
+// file such as hg19ToHg38.over.chain.
+type Chain struct {
+	// blocks holds the aligned blocks by target chromosome, sorted by start,
+	// and maxEnd the running maximum of their ends.
+	blocks map[string][]chainBlock
+	maxEnd map[string][]int
+}
+
+// chainBlock is an ungapped alignment of the target bases [tStart, tEnd) to
+// the query bases [qStart, qEnd), on the reverse strand of the query if
+// reverse is set. Query coordinates are those of the forward strand.
+type chainBlock struct {
+	tStart, tEnd int
+	qName        string
+	qStart, qEnd int
+	reverse      bool
+	// chain is the index of the chain holding the block.
+	chain int
+}
+
+// mapped returns the query interval aligned to the target interval [start,
+// end), which must lie within the block.
+func (b chainBlock) mapped(start, end int) (int, int) {
+	if b.reverse {
+		return b.qEnd - (end - b.tStart), b.qEnd - (start - b.tStart)
+	}
+	return b.qStart + (start - b.tStart), b.qStart + (end - b.tStart)
+}
+
+// ReadChain reads a chain file. The target of the chains is the build the
+// segments are lifted from, and the query the build they are lifted to.
+func ReadChain(r io.Reader) (*Chain, error) {
+	c := &Chain{blocks: make(map[string][]chainBlock), maxEnd: make(map[string][]int)}
+	dr, err := Decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	defer dr.Close()
+	sc := bufio.NewScanner(dr)
+	var head *chainBlock // the next block, nil outside of a chain
+	var tName string
+	var qSize, chains int
+	for line := 1; sc.Scan(); line++ {
+		fields := strings.Fields(sc.Text())
+		switch {
+		case len(fields) == 0 || fields[0][0] == '#':
+			continue
+		case fields[0] == "chain":
+			if head != nil {
+				return nil, fmt.Errorf("cbsgo: chain line %d: previous chain not terminated", line)
+			}
+			if len(fields) < 12 {
+				return nil, fmt.Errorf("cbsgo: chain line %d: expected 12 header fields, got %d", line, len(fields))
+			}
+			tStart, err1 := strconv.Atoi(fields[5])
+			size, err2 := strconv.Atoi(fields[8])
+			qStart, err3 := strconv.Atoi(fields[10])
+			if err := firstError(err1, err2, err3); err != nil {
+				return nil, fmt.Errorf("cbsgo: chain line %d: %w", line, err)
+			}
+			if fields[4] != "+" {
+				return nil, fmt.Errorf("cbsgo: chain line %d: unsupported target strand %q", line, fields[4])
+			}
+			head = &chainBlock{tStart: tStart, qName: fields[7], qStart: qStart, reverse: fields[9] == "-", chain: chains}
+			tName, qSize = fields[2], size
+			chains++
+		case head == nil:
+			return nil, fmt.Errorf("cbsgo: chain line %d: alignment data outside of a chain", line)
+		default:
+			var n [3]int
+			for i, f := range fields {
+				if i == len(n) {
+					return nil, fmt.Errorf("cbsgo: chain line %d: expected at most 3 fields, got %d", line, len(fields))
+				}
+				v, err := strconv.Atoi(f)
+				if err != nil {
+					return nil, fmt.Errorf("cbsgo: chain line %d: %w", line, err)
+				}
+				n[i] = v
+			}
+			b := *head
+			b.tEnd, b.qEnd = b.tStart+n[0], b.qStart+n[0]
+			if b.reverse {
+				b.qStart, b.qEnd = qSize-b.qEnd, qSize-b.qStart
+			}
+			c.blocks[tName] = append(c.blocks[tName], b)
+			if len(fields) == 1 {
+				head = nil
+				continue
+			}
+			head.tStart += n[0] + n[1]
+			head.qStart += n[0] + n[2]
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if head != nil {
+		return nil, fmt.Errorf("cbsgo: chain file ends within a chain")
+	}
+	for name, blocks := range c.blocks {
+		sort.Slice(blocks, func(i, j int) bool { return blocks[i].tStart < blocks[j].tStart })
+		ends := make([]int, len(blocks))
+		for i, b := range blocks {
+			ends[i] = b.tEnd
+			if i > 0 {
+				ends[i] = max(ends[i], ends[i-1])
+			}
+		}
+		c.maxEnd[name] = ends
+	}
+	return c, nil
+}
+
+// Lift maps the segments of s to the other build. Segments are split where
+// parts of them do not map, or map discontinuously, and lifted returns the
+// mapped pieces, sorted, while unmapped holds the parts of the segments that
+// do not map. Parts mapping to several places are lifted to each. The pieces
+// keep the other fields of their segment, including the bin indices, which
+// refer to the original bins.
+func (c *Chain) Lift(s SegmentSet) (lifted, unmapped SegmentSet) {
+	var mapped SegmentSet
+	for _, seg := range s {
+		var prev *chainBlock
+		var piece Segment
+		flush := func() {
+			if prev != nil {
+				lifted = append(lifted, piece)
+			}
+		}
+		for _, b := range c.overlapping(seg) {
+			start, end := max(seg.Start, b.tStart), min(seg.End, b.tEnd)
+			mapped = append(mapped, seg.clip(start, end))
+			qStart, qEnd := b.mapped(start, end)
+
+			// Pieces of the same chain that are contiguous in the source
+			// build stay joined, spanning insertions in the other build.
+			if prev != nil && prev.chain == b.chain && prev.tEnd == start {
+				if b.reverse {
+					piece.Start = qStart
+				} else {
+					piece.End = qEnd
+				}
+			} else {
+				flush()
+				piece = seg
+				piece.Chrom, piece.Start, piece.End = b.qName, qStart, qEnd
+			}
+			prev = &b
+		}
+		flush()
+	}
+	return lifted.Sorted(), s.Subtract(mapped)
+}
+
+// overlapping returns the blocks overlapping seg, sorted by start.
+func (c *Chain) overlapping(seg Segment) []chainBlock {
+	blocks, ends := c.blocks[seg.Chrom], c.maxEnd[seg.Chrom]
+	lo := sort.SearchInts(ends, seg.Start+1)
+	hi := sort.Search(len(blocks), func(i int) bool { return blocks[i].tStart >= seg.End })
+	var res []chainBlock
+	for i := lo; i < hi; i++ {
+		if blocks[i].tEnd > seg.Start {
+			res = append(res, blocks[i])
+		}
+	}
+	return res
+}
@@ -0,0 +1,59 @@
+package cbsgo_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+const testChain = `chain 1000 chr1 1000 + 0 300 chr1 2000 + 100 400 1
+100 50 0
+100 0 50
+50
+
+chain 500 chr2 500 + 0 100 chr2 600 - 0 100 2
+100
+`
+
+func TestLift(t *testing.T) {
+	c, err := cbsgo.ReadChain(strings.NewReader(testChain))
+	if err != nil {
+		t.Fatalf("ReadChain returned an unexpected error: %v", err)
+	}
+	set := cbsgo.SegmentSet{
+		{Chrom: "chr1", Start: 50, End: 280, Mean: 1},
+		{Chrom: "chr2", Start: 10, End: 30, Mean: 2},
+		{Chrom: "chr3", Start: 0, End: 10, Mean: 3},
+	}
+	lifted, unmapped := c.Lift(set)
+
+	expected := cbsgo.SegmentSet{
+		{Chrom: "chr1", Start: 150, End: 200, Mean: 1},
+		{Chrom: "chr1", Start: 200, End: 380, Mean: 1},
+		{Chrom: "chr2", Start: 570, End: 590, Mean: 2},
+	}
+	if !reflect.DeepEqual(lifted, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, lifted)
+	}
+	expected = cbsgo.SegmentSet{
+		{Chrom: "chr1", Start: 100, End: 150, Mean: 1},
+		{Chrom: "chr3", Start: 0, End: 10, Mean: 3},
+	}
+	if !reflect.DeepEqual(unmapped, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, unmapped)
+	}
+}
+
+func TestReadChainErrors(t *testing.T) {
+	for _, data := range []string{
+		"100 0 0\n",
+		"chain 1 chr1 100 + 0 10 chr1 100 + 0 10 1\n10 0\n",
+		"chain 1 chr1 100 + 0 10 chr1 100 + 0 10 1\nten\n",
+	} {
+		if _, err := cbsgo.ReadChain(strings.NewReader(data)); err == nil {
+			t.Errorf("Expected an error for %q", data)
+		}
+	}
+}
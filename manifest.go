@@ -0,0 +1,133 @@
+package cbsgo
+
+import (
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// modulePath is the path of the cbsgo module.
+const modulePath = "github.com/mattdsm/cbsgo"
+
+// Manifest describes how a result was produced, so that output files are
+// self-describing for audits and the result can be reproduced. The writers
+// embed it in their output with SetManifest.
+type Manifest struct {
+	// Version is the version of the cbsgo module, "(devel)" when built from
+	// a working copy.
+	Version string
+	// Backend names the implementation of the test statistic and of the
+	// null distribution: the permutation test or the null cache of
+	// WithNullCache, with the assembly kernel or the generic code.
+	Backend string
+	// Seed is the seed set with WithSeed. It is 0 with WithContentSeed,
+	// whose seeds are derived from the input instead, and for a time-based
+	// seed.
+	Seed int64
+	// Reproducible tells whether the seeds of the runs can be reproduced,
+	// which is not the case of a time-based seed.
+	Reproducible bool
+	// Parameters holds the settings of the run by name: the significance
+	// level and number of shuffles, and the other settings that differ from
+	// their defaults.
+	Parameters map[string]string
+}
+
+// NewManifest returns the manifest of runs configured by opts.
+func NewManifest(opts ...Option) Manifest {
+	cfg := newConfig(opts)
+	backend := "permutation"
+	if cfg.nulls != nil {
+		backend = "null cache"
+	}
+	seed := cfg.seed
+	if cfg.contentSeed {
+		seed = 0
+	}
+	return Manifest{
+		Version:      moduleVersion(),
+		Backend:      backend + "/" + kernelBackend,
+		Seed:         seed,
+		Reproducible: seed != 0 || cfg.contentSeed,
+		Parameters:   cfg.parameters(),
+	}
+}
+
+// String returns the manifest as space-separated name=value pairs: version,
+// backend, seed and reproducible, followed by the parameters in order of
+// name. Spaces in values are replaced by underscores.
+func (m Manifest) String() string {
+	pairs := []string{"version=" + m.Version, "backend=" + m.Backend, "seed=" + strconv.FormatInt(m.Seed, 10),
+		"reproducible=" + strconv.FormatBool(m.Reproducible)}
+	names := make([]string, 0, len(m.Parameters))
+	for name := range m.Parameters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		pairs = append(pairs, name+"="+m.Parameters[name])
+	}
+	for i, p := range pairs {
+		pairs[i] = strings.ReplaceAll(p, " ", "_")
+	}
+	return strings.Join(pairs, " ")
+}
+
+// moduleVersion returns the version of the cbsgo module in the running
+// binary.
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(devel)"
+	}
+	if info.Main.Path == modulePath && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			if dep.Replace != nil {
+				dep = dep.Replace
+			}
+			if dep.Version != "" {
+				return dep.Version
+			}
+		}
+	}
+	return "(devel)"
+}
+
+// parameters returns the settings of c that affect the result, for the
+// manifest.
+func (c *config) parameters() map[string]string {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	p := map[string]string{"alpha": f(c.alpha), "shuffles": strconv.Itoa(c.shuffles)}
+	set := func(name string, value string, ok bool) {
+		if ok {
+			p[name] = value
+		}
+	}
+	set("adjustment", c.adjustment.String(), c.adjustment != NoAdjustment)
+	set("schedule", "custom", c.schedule != nil)
+	set("weights", "custom", c.weights != nil)
+	set("gap_penalty", f(c.gapScale), c.gapScale != 0)
+	set("length_prior", f(c.lengthScale), c.lengthScale != 0)
+	set("consensus", strconv.Itoa(c.consensus), c.consensus > 1)
+	set("direction", c.direction.String(), c.direction != TwoSided)
+	set("tie_break", c.tie.String(), c.tie != Leftmost)
+	set("haar_screen", f(c.haar), c.haar != 0)
+	set("tail_pvalues", "true", c.tail)
+	set("smoothing", fmt.Sprintf("%d,%s,%s", c.smoothRegion, f(c.outlierSD), f(c.smoothSD)), c.smoothRegion != 0)
+	set("min_width", strconv.Itoa(c.minWidth), c.minWidth != 0)
+	set("undo_sd", f(c.undoSD), c.undoSD != 0)
+	set("spike_sd", f(c.spikeSD), c.spikeSD != 0)
+	set("confirmation_alpha", f(c.confirmAlpha), c.confirmAlpha != 0)
+	set("shrinkage", "true", c.shrink)
+	set("transform", c.transform.String(), c.transform != NoTransform)
+	set("focal", fmt.Sprintf("%s,%d,%d", f(c.focalAmplitude), c.focalMin, c.focalMax), c.focalMax != 0)
+	set("hierarchy_alpha", f(c.hierarchyAlpha), c.hierarchyAlpha != 0)
+	set("content_seed", "true", c.contentSeed)
+	set("sample", c.sample, c.sample != "")
+	return p
+}
@@ -0,0 +1,82 @@
+package cbsgo_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestNewManifest(t *testing.T) {
+	m := cbsgo.NewManifest(cbsgo.WithSeed(42), cbsgo.WithAlpha(0.01), cbsgo.WithMinWidth(3), cbsgo.WithDirection(cbsgo.GainOnly))
+	if m.Seed != 42 || !m.Reproducible || m.Version == "" || !strings.HasPrefix(m.Backend, "permutation/") {
+		t.Errorf("Unexpected manifest %+v", m)
+	}
+	expected := map[string]string{"alpha": "0.01", "shuffles": "1000", "min_width": "3", "direction": "gain only"}
+	if !reflect.DeepEqual(m.Parameters, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, m.Parameters)
+	}
+
+	m.Version, m.Backend = "v1.2.3", "permutation/generic"
+	if expected, got := "version=v1.2.3 backend=permutation/generic seed=42 reproducible=true alpha=0.01 direction=gain_only min_width=3 shuffles=1000", m.String(); got != expected {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+	}
+
+	// A time-based seed cannot be reproduced, while seeds derived from the
+	// input can, whatever the seed set.
+	for _, tt := range []struct {
+		opts         []cbsgo.Option
+		reproducible bool
+	}{
+		{nil, false},
+		{[]cbsgo.Option{cbsgo.WithSeed(0)}, false},
+		{[]cbsgo.Option{cbsgo.WithContentSeed()}, true},
+		{[]cbsgo.Option{cbsgo.WithSeed(42), cbsgo.WithContentSeed()}, true},
+	} {
+		m := cbsgo.NewManifest(tt.opts...)
+		if m.Reproducible != tt.reproducible || m.Seed != 0 {
+			t.Errorf("Unexpected result.\nExpected: %v\nGot: %+v", tt.reproducible, m)
+		}
+	}
+}
+
+func TestSetManifest(t *testing.T) {
+	m := cbsgo.Manifest{Version: "v1.2.3", Backend: "permutation/generic", Seed: 42, Reproducible: true, Parameters: map[string]string{"alpha": "0.01"}}
+	s := cbsgo.Segment{Chrom: "chr1", Start: 0, End: 10, BinEnd: 10, Mean: 1}
+	line := "version=v1.2.3 backend=permutation/generic seed=42 reproducible=true alpha=0.01"
+	for _, tt := range []struct {
+		name   string
+		writer func(*bytes.Buffer) cbsgo.SegmentWriter
+		prefix string
+	}{
+		{"bed", func(b *bytes.Buffer) cbsgo.SegmentWriter { return cbsgo.NewBEDWriter(b) }, "# cbsgo " + line + "\nchr1\t"},
+		{"bedgraph", func(b *bytes.Buffer) cbsgo.SegmentWriter { return cbsgo.NewBedGraphWriter(b) }, "# cbsgo " + line + "\nchr1\t"},
+		{"seg", func(b *bytes.Buffer) cbsgo.SegmentWriter { return cbsgo.NewSEGWriter(b, "s1", true) }, "# cbsgo " + line + "\nID\t"},
+		{"vcf", func(b *bytes.Buffer) cbsgo.SegmentWriter { return cbsgo.NewVCFWriter(b, 0.1) }, "##fileformat=VCFv4.2\n##source=cbsgo\n##cbsgoManifest=" + line + "\n##"},
+		{"jsonl", func(b *bytes.Buffer) cbsgo.SegmentWriter { return cbsgo.NewJSONLWriter(b, "s1") }, `{"manifest":{"version":"v1.2.3","backend":"permutation/generic","seed":42,"reproducible":true,"parameters":{"alpha":"0.01"}}}` + "\n{"},
+	} {
+		var b bytes.Buffer
+		w := tt.writer(&b)
+		if err := w.(interface{ SetManifest(cbsgo.Manifest) error }).SetManifest(m); err != nil {
+			t.Fatalf("%s: SetManifest returned an unexpected error: %v", tt.name, err)
+		}
+		if err := w.Write(s); err != nil {
+			t.Fatalf("%s: Write returned an unexpected error: %v", tt.name, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: Close returned an unexpected error: %v", tt.name, err)
+		}
+		if !strings.HasPrefix(b.String(), tt.prefix) {
+			t.Errorf("%s: unexpected result.\nExpected prefix: %q\nGot: %q", tt.name, tt.prefix, b.String())
+		}
+		if tt.name == "jsonl" {
+			var first map[string]any
+			if err := json.Unmarshal([]byte(strings.SplitN(b.String(), "\n", 2)[0]), &first); err != nil || first["manifest"] == nil {
+				t.Errorf("Expected a JSON manifest line, got %v, %v", first, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package cbsgo
+
+import (
+	"gonum.org/v1/gonum/mat"
+)
+
+// CBSVector performs Circular Binary Segmentation on a single sample held in
+// a gonum vector.
+//
+// When v exposes its backing storage with unit stride (as a freshly allocated
+// *mat.VecDense does), the data is segmented in place without copying. Other
+// vectors are copied into a temporary slice first. The vector is never modified.
+//
+// See CBS for the meaning of the remaining parameters and of the result.
+func CBSVector(v mat.Vector, shuffles int, significanceLevel float64, seed int64) ([][2]int, error) {
+	return CBS(vectorData(v), shuffles, significanceLevel, seed)
+}
+
+// CBSDense performs Circular Binary Segmentation on every row of m, which is
+// interpreted as a samples × bins matrix. Each row is segmented independently
+// and the i-th element of the result holds the segments of row i.
+//
+// Rows of matrices implementing mat.RawRowViewer (such as *mat.Dense) are
+// segmented in place without copying. The matrix is never modified.
+//
+// See CBS for the meaning of the remaining parameters.
+func CBSDense(m mat.Matrix, shuffles int, significanceLevel float64, seed int64) ([][][2]int, error) {
+	r, _ := m.Dims()
+	res := make([][][2]int, r)
+	for i := 0; i < r; i++ {
+		segments, err := CBS(rowData(m, i), shuffles, significanceLevel, seed)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = segments
+	}
+	return res, nil
+}
+
+// vectorData returns the elements of v, sharing the backing storage when possible.
+func vectorData(v mat.Vector) []float64 {
+	n := v.Len()
+	if rv, ok := v.(mat.RawVectorer); ok {
+		raw := rv.RawVector()
+		if raw.Inc == 1 {
+			return raw.Data[:n]
+		}
+	}
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = v.AtVec(i)
+	}
+	return x
+}
+
+// rowData returns row i of m, sharing the backing storage when possible.
+func rowData(m mat.Matrix, i int) []float64 {
+	if rv, ok := m.(mat.RawRowViewer); ok {
+		return rv.RawRowView(i)
+	}
+	return mat.Row(nil, i, m)
+}
@@ -0,0 +1,69 @@
+package cbsgo_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestCBSVector(t *testing.T) {
+	steps := []float64{1, 1, 1, 3, 3, 2, 1, 2, 3, 300, 310, 321, 310, 299}
+
+	expected, err := cbsgo.CBS(steps, 1000, 0.05, 42)
+	if err != nil {
+		t.Fatalf("CBS function returned an unexpected error: %v", err)
+	}
+
+	// A column view of a wider matrix is strided and exercises the copying fallback.
+	wide := mat.NewDense(len(steps), 2, nil)
+	wide.SetCol(0, steps)
+
+	for name, v := range map[string]mat.Vector{
+		"dense":   mat.NewVecDense(len(steps), steps),
+		"strided": wide.ColView(0),
+	} {
+		res, err := cbsgo.CBSVector(v, 1000, 0.05, 42)
+		if err != nil {
+			t.Fatalf("%s: CBSVector returned an unexpected error: %v", name, err)
+		}
+		if !reflect.DeepEqual(res, expected) {
+			t.Errorf("%s: unexpected result.\nExpected: %v\nGot: %v", name, expected, res)
+		}
+	}
+}
+
+func TestCBSDense(t *testing.T) {
+	steps := []float64{1, 1, 1, 3, 3, 2, 1, 2, 3, 300, 310, 321, 310, 299}
+	flat := []float64{5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5}
+
+	m := mat.NewDense(2, len(steps), append(append([]float64{}, steps...), flat...))
+
+	res, err := cbsgo.CBSDense(m, 1000, 0.05, 42)
+	if err != nil {
+		t.Fatalf("CBSDense returned an unexpected error: %v", err)
+	}
+	if len(res) != 2 {
+		t.Fatalf("Expected 2 rows of segments, got %d", len(res))
+	}
+
+	for i, x := range [][]float64{steps, flat} {
+		expected, err := cbsgo.CBS(x, 1000, 0.05, 42)
+		if err != nil {
+			t.Fatalf("CBS function returned an unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(res[i], expected) {
+			t.Errorf("Row %d: unexpected result.\nExpected: %v\nGot: %v", i, expected, res[i])
+		}
+	}
+
+	// Hiding the raw row accessor exercises the copying fallback.
+	opaque, err := cbsgo.CBSDense(struct{ mat.Matrix }{m}, 1000, 0.05, 42)
+	if err != nil {
+		t.Fatalf("CBSDense returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(opaque, res) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", res, opaque)
+	}
+}
@@ -0,0 +1,92 @@
+package cbsgo
+
+import "fmt"
+
+// WithMemoryLimit bounds the main working memory of a run, beyond its input
+// and result, to about n bytes, such as on small cloud instances or in WASM.
+// It counts the copies of the values under test, the permutation batches,
+// the copy of the values made by WithSmoothing or WithTransform and the null
+// distribution kept by WithTailPValues. The smaller allocations, such as
+// those growing with the number of segments, and the further segmentations
+// of WithConsensus, WithConfirmation, WithFocal and WithHierarchy are not
+// counted, so that the limit is an estimate rather than a hard bound.
+//
+// Runs adapt to the budget rather than change their result:
+//
+//   - permutations are computed in batches that fit the budget, or one at a
+//     time if it cannot hold two,
+//   - SegmentGenome segments fewer tracks concurrently than set by
+//     WithWorkers, so that their working memory fits together.
+//
+// Runs whose working memory exceeds the budget anyway, for the copies of the
+// values under test and the null distribution kept by WithTailPValues, fail
+// without segmenting, so that long tracks can be split with PlanShards
+// instead. The default of 0 sets no limit.
+func WithMemoryLimit(n int) Option {
+	return func(c *config) {
+		c.memoryLimit = n
+	}
+}
+
+// workingBytes estimates the memory a run of n bins allocates beyond its
+// input and result, without the permutation batches: the copies of the
+// intervals under test, the copy of the values made by WithSmoothing or
+// WithTransform and the null distribution kept by WithTailPValues.
+func (c *config) workingBytes(n int) int {
+	floats := 2 * n
+	if c.starts != nil || c.weights != nil {
+		floats += 2 * n
+	}
+	if c.smoothRegion > 0 || c.transform != NoTransform {
+		floats += n
+	}
+	if c.tail {
+		floats += c.shuffles
+	}
+	return 8 * floats
+}
+
+// checkMemory returns an error if the working memory of a run of n bins
+// exceeds the limit of WithMemoryLimit.
+func (c *config) checkMemory(n int) error {
+	if c.memoryLimit < 0 {
+		return fmt.Errorf("cbsgo: invalid memory limit %d", c.memoryLimit)
+	}
+	if b := c.workingBytes(n); c.memoryLimit > 0 && b > c.memoryLimit {
+		return fmt.Errorf("cbsgo: segmenting %d bins takes about %d bytes, over the memory limit of %d", n, b, c.memoryLimit)
+	}
+	return nil
+}
+
+// batchRows returns the largest number of rows of the permutation batches of
+// intervals of n bins in a run of size bins, within the limit of
+// WithMemoryLimit, or 0 if the permutations are not to be batched.
+func (c *config) batchRows(n, size int) int {
+	rows := max(1, min(16, batchBytes/(8*n)))
+	if c.memoryLimit > 0 {
+		// A row takes its values and statistic.
+		rows = min(rows, (c.memoryLimit-c.workingBytes(size))/(8*(n+1)))
+		if rows < 2 {
+			// A single row takes more memory than shuffling in place.
+			return 0
+		}
+	}
+	return rows
+}
+
+// genomeWorkers returns the number of tracks segmented concurrently, at
+// most workers, so that their working memory fits the limit of
+// WithMemoryLimit.
+func (c *config) genomeWorkers(workers int, tracks []*Track) int {
+	if c.memoryLimit <= 0 {
+		return workers
+	}
+	longest := 0
+	for _, t := range tracks {
+		longest = max(longest, len(t.Values))
+	}
+	if b := c.workingBytes(longest); b > 0 {
+		workers = min(workers, c.memoryLimit/b)
+	}
+	return max(workers, 1)
+}
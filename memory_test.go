@@ -0,0 +1,98 @@
+package cbsgo_test
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestWithMemoryLimit(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	x := make([]float64, 2000)
+	for i := range x {
+		x[i] = rng.NormFloat64()
+		if i >= 700 && i < 1300 {
+			x[i] += 2
+		}
+	}
+	opts := []cbsgo.Option{cbsgo.WithSeed(42), cbsgo.WithShuffles(1000)}
+	expected, err := cbsgo.Run(x, opts...)
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	// The copies of the intervals take 32000 bytes, leaving room for no
+	// batch, and then for a small one.
+	for _, limit := range []int{40000, 100000} {
+		res, err := cbsgo.Run(x, append(opts, cbsgo.WithMemoryLimit(limit))...)
+		if err != nil {
+			t.Fatalf("Run returned an unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(res.Segments, expected.Segments) {
+			t.Errorf("Limit %d: unexpected result.\nExpected: %v\nGot: %v", limit, expected.Segments, res.Segments)
+		}
+	}
+
+	for name, opts := range map[string][]cbsgo.Option{
+		"negative": {cbsgo.WithMemoryLimit(-1)},
+		"small":    {cbsgo.WithMemoryLimit(20000)},
+		"tail":     {cbsgo.WithMemoryLimit(40000), cbsgo.WithTailPValues(), cbsgo.WithShuffles(10000)},
+	} {
+		if _, err := cbsgo.Run(x, opts...); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSegmentGenomeWithMemoryLimit(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	var tracks []*cbsgo.Track
+	for _, chrom := range []string{"chr1", "chr2", "chr3", "chr4"} {
+		x := make([]float64, 500)
+		for i := range x {
+			x[i] = rng.NormFloat64()
+			if i >= 250 {
+				x[i] += 2
+			}
+		}
+		tracks = append(tracks, &cbsgo.Track{Chrom: chrom, Values: x})
+	}
+	// The schedule records the largest number of tracks tested at once.
+	var mu sync.Mutex
+	var active, most int
+	schedule := func(int) int {
+		mu.Lock()
+		active++
+		most = max(most, active)
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		active--
+		mu.Unlock()
+		return 1000
+	}
+	opts := []cbsgo.Option{cbsgo.WithSeed(42), cbsgo.WithShuffleSchedule(schedule), cbsgo.WithWorkers(4)}
+	expected, err := cbsgo.SegmentGenome(context.Background(), tracks, opts...)
+	if err != nil {
+		t.Fatalf("SegmentGenome returned an unexpected error: %v", err)
+	}
+	if most < 2 {
+		t.Errorf("Expected tracks to be segmented concurrently, got %d at most", most)
+	}
+	// The limit holds a single track at a time.
+	most = 0
+	res, err := cbsgo.SegmentGenome(context.Background(), tracks, append(opts, cbsgo.WithMemoryLimit(10000))...)
+	if err != nil {
+		t.Fatalf("SegmentGenome returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(res.Segments, expected.Segments) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected.Segments, res.Segments)
+	}
+	if most != 1 {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", 1, most)
+	}
+}
@@ -0,0 +1,71 @@
+package cbsgo
+
+import (
+	"fmt"
+	"math"
+)
+
+// BetaTransform is a transformation of methylation beta values, bounded to
+// [0, 1], to an unbounded scale with a more homogeneous variance, on which the
+// Gaussian test statistic is appropriate.
+type BetaTransform int
+
+const (
+	// LogitTransform maps beta values to M-values, log2(b / (1 - b)).
+	LogitTransform BetaTransform = iota
+	// ArcsineTransform maps beta values to asin(2b - 1), the variance
+	// stabilizing transformation of proportions.
+	ArcsineTransform
+)
+
+// betaOffset keeps the logit of beta values of exactly 0 or 1 finite.
+const betaOffset = 0.001
+
+// Transform returns the transformed beta values.
+func (t BetaTransform) Transform(beta []float64) []float64 {
+	res := make([]float64, len(beta))
+	for i, b := range beta {
+		switch t {
+		case ArcsineTransform:
+			res[i] = math.Asin(2*math.Max(0, math.Min(1, b)) - 1)
+		default:
+			b = math.Max(betaOffset, math.Min(1-betaOffset, b))
+			res[i] = math.Log2(b / (1 - b))
+		}
+	}
+	return res
+}
+
+// Inverse maps a transformed value back to a beta value.
+func (t BetaTransform) Inverse(v float64) float64 {
+	switch t {
+	case ArcsineTransform:
+		return (math.Sin(v) + 1) / 2
+	default:
+		m := math.Exp2(v)
+		return m / (1 + m)
+	}
+}
+
+// RunMethylation segments methylation beta values, for instance to find
+// differentially methylated regions from array or bisulfite sequencing data.
+//
+// The values are transformed with t and smoothed with SmoothOutliers, using
+// the DNAcopy defaults, before being segmented by Run with opts. The means and
+// standard deviations of the returned segments are those of the beta values.
+func RunMethylation(beta []float64, t BetaTransform, opts ...Option) (*Result, error) {
+	for i, b := range beta {
+		if b < 0 || b > 1 || math.IsNaN(b) {
+			return nil, fmt.Errorf("cbsgo: beta value %v at %d is outside of [0, 1]", b, i)
+		}
+	}
+	res, err := Run(SmoothOutliers(t.Transform(beta), 10, 4, 2), opts...)
+	if err != nil {
+		return nil, err
+	}
+	res.Segments, err = Refit(res.Segments, beta)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
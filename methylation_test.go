@@ -0,0 +1,56 @@
+package cbsgo_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestBetaTransform(t *testing.T) {
+	for _, tr := range []cbsgo.BetaTransform{cbsgo.LogitTransform, cbsgo.ArcsineTransform} {
+		beta := []float64{0.05, 0.3, 0.5, 0.8, 0.95}
+		for i, v := range tr.Transform(beta) {
+			if got := tr.Inverse(v); math.Abs(got-beta[i]) > 1e-12 {
+				t.Errorf("Transform %d: unexpected round trip.\nExpected: %v\nGot: %v", tr, beta[i], got)
+			}
+		}
+		for _, v := range tr.Transform([]float64{0, 1}) {
+			if math.IsInf(v, 0) || math.IsNaN(v) {
+				t.Errorf("Transform %d: got %v at the bounds", tr, v)
+			}
+		}
+	}
+}
+
+func TestRunMethylation(t *testing.T) {
+	// A hypomethylated region between two methylated ones.
+	beta := make([]float64, 60)
+	for i := range beta {
+		beta[i] = 0.85 + 0.05*math.Sin(float64(i))
+		if i >= 20 && i < 40 {
+			beta[i] = 0.15 + 0.05*math.Sin(float64(i))
+		}
+	}
+
+	res, err := cbsgo.RunMethylation(beta, cbsgo.LogitTransform, cbsgo.WithSeed(42))
+	if err != nil {
+		t.Fatalf("RunMethylation returned an unexpected error: %v", err)
+	}
+	var got [][2]int
+	for _, s := range res.Segments {
+		got = append(got, [2]int{s.BinStart, s.BinEnd})
+	}
+	expected := [][2]int{{0, 19}, {19, 40}, {40, 60}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+	}
+	if m := res.Segments[1].Mean; m < 0.1 || m > 0.2 {
+		t.Errorf("Unexpected mean beta value %v", m)
+	}
+
+	if _, err := cbsgo.RunMethylation([]float64{0.5, 1.5}, cbsgo.LogitTransform); err == nil {
+		t.Errorf("Expected an error for a beta value above 1")
+	}
+}
@@ -0,0 +1,26 @@
+package cbsgo
+
+import "time"
+
+// RunStats describes the work done by a segmentation run.
+type RunStats struct {
+	// Segments is the number of segments reported.
+	Segments int
+	// Tests is the number of intervals tested for a changepoint, Permutations
+	// the number of permutations performed by the tests, and EarlyStops the
+	// number of permutation tests stopped early once the split could no
+	// longer be significant.
+	Tests        int
+	Permutations int
+	EarlyStops   int
+	// Duration is the wall-clock time of the run.
+	Duration time.Duration
+	// Interrupted is set when the context of the run ended early.
+	Interrupted bool
+}
+
+// MetricsRecorder receives the statistics of segmentation runs. See
+// WithMetrics. Record may be called concurrently by concurrent runs.
+type MetricsRecorder interface {
+	Record(RunStats)
+}
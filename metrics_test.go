@@ -0,0 +1,49 @@
+package cbsgo_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// recorder is a MetricsRecorder keeping the statistics in memory.
+type recorder struct {
+	mu    sync.Mutex
+	stats []cbsgo.RunStats
+}
+
+func (r *recorder) Record(s cbsgo.RunStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats = append(r.stats, s)
+}
+
+func TestWithMetrics(t *testing.T) {
+	x := []float64{1, 1, 1, 3, 3, 2, 1, 2, 3, 300, 310, 321, 310, 299, 1, 2, 1, 2, 1, 1}
+	var r recorder
+	res, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithMetrics(&r))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if len(r.stats) != 1 {
+		t.Fatalf("Expected the statistics of one run, got %v", r.stats)
+	}
+	s := r.stats[0]
+	if s.Segments != len(res.Segments) || s.Tests == 0 || s.Permutations == 0 || s.Duration <= 0 || s.Interrupted {
+		t.Errorf("Unexpected statistics %+v", s)
+	}
+	// The significant split takes all 1000 permutations, and the tests of
+	// the final segments stop early.
+	if s.Permutations < 1000 || s.EarlyStops == 0 {
+		t.Errorf("Unexpected permutation counts %+v", s)
+	}
+
+	var c collector
+	if _, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithMetrics(&r), cbsgo.WithWriter(&c)); err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if got := r.stats[1].Segments; got != len(c.segments) {
+		t.Errorf("Expected %d segments, got %d", len(c.segments), got)
+	}
+}
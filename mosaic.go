@@ -0,0 +1,82 @@
+package cbsgo
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// EstimateMosaicism estimates, for each segment, the fraction of cells
+// carrying a single-copy gain or loss, for mosaic events in otherwise diploid
+// samples such as in prenatal testing or clonal hematopoiesis.
+//
+// Segment means must be log2 ratios relative to the diploid state. A gain of
+// one copy in a fraction f of the cells yields a log2 ratio of log2((2+f)/2)
+// and a loss one of log2((2-f)/2), so f = 2|2^mean - 1|. The confidence
+// interval at the given level (such as 0.95) is obtained by transforming that
+// of the mean, based on the standard error SD/sqrt(n) over the n bins of the
+// segment.
+//
+// When the segment has a median mirrored B-allele frequency (see CallAllelic),
+// the fraction implied by it is averaged with the one from the log2 ratio. For
+// segments whose log2 ratio does not differ significantly from zero but whose
+// alleles are imbalanced, the event is taken to be a copy-neutral LOH and the
+// fraction is estimated from the B-allele frequency alone, f = 2*BAF - 1, and
+// so is its interval, taking the B-allele frequency for a proportion over the
+// SNPs of the segment, with standard error sqrt(BAF*(1-BAF)/SNPs).
+//
+// Fractions are clamped to [0, 1]. The returned segments are copies of
+// segments with the CellFraction fields set; the other fields are preserved.
+func EstimateMosaicism(segments []Segment, level float64) ([]Segment, error) {
+	if level <= 0 || level >= 1 {
+		return nil, fmt.Errorf("cbsgo: invalid confidence level %v", level)
+	}
+	z := distuv.UnitNormal.Quantile(1 - (1-level)/2)
+
+	res := make([]Segment, len(segments))
+	for i, s := range segments {
+		se := 0.0
+		if n := s.BinEnd - s.BinStart; n > 0 {
+			se = s.SD / math.Sqrt(float64(n))
+		}
+		lo, hi := s.Mean-z*se, s.Mean+z*se
+
+		f := lrFraction(s.Mean)
+		s.CellFractionLower, s.CellFractionUpper = lrFraction(lo), lrFraction(hi)
+		if lo < 0 && hi > 0 {
+			// The interval of the mean straddles the diploid state.
+			s.CellFractionLower = 0
+		}
+		if s.CellFractionLower > s.CellFractionUpper {
+			s.CellFractionLower, s.CellFractionUpper = s.CellFractionUpper, s.CellFractionLower
+		}
+
+		if s.SNPs > 0 && !math.IsNaN(s.BAF) {
+			switch {
+			case s.CellFractionLower == 0 && s.Allelic >= AllelicImbalance:
+				f = clamp01(2*s.BAF - 1)
+				bse := math.Sqrt(s.BAF * (1 - s.BAF) / float64(s.SNPs))
+				s.CellFractionLower = clamp01(2*(s.BAF-z*bse) - 1)
+				s.CellFractionUpper = clamp01(2*(s.BAF+z*bse) - 1)
+			case s.CellFractionLower > 0 && s.Mean > 0:
+				f = (f + clamp01((2*s.BAF-1)/(1-s.BAF))) / 2
+			case s.CellFractionLower > 0 && s.Mean < 0:
+				f = (f + clamp01(2-1/s.BAF)) / 2
+			}
+		}
+		s.CellFraction = f
+		res[i] = s
+	}
+	return res, nil
+}
+
+// lrFraction returns the fraction of cells with a single-copy gain or loss
+// implied by the log2 ratio lr.
+func lrFraction(lr float64) float64 {
+	return clamp01(2 * math.Abs(math.Exp2(lr)-1))
+}
+
+func clamp01(f float64) float64 {
+	return math.Max(0, math.Min(1, f))
+}
@@ -0,0 +1,52 @@
+package cbsgo_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestEstimateMosaicism(t *testing.T) {
+	gain := math.Log2(2.5 / 2)
+	loss := math.Log2(1.7 / 2)
+	segments := []cbsgo.Segment{
+		{BinStart: 0, BinEnd: 100, Mean: gain, SD: 0.1, BAF: math.NaN()},
+		{BinStart: 100, BinEnd: 200, Mean: loss, SD: 0.1, BAF: math.NaN()},
+		{BinStart: 200, BinEnd: 300, Mean: gain, SD: 0.1, SNPs: 20, BAF: 0.6, Allelic: cbsgo.AllelicImbalance},
+		{BinStart: 300, BinEnd: 400, Mean: loss, SD: 0.1, SNPs: 20, BAF: 1 / 1.7, Allelic: cbsgo.AllelicImbalance},
+		{BinStart: 400, BinEnd: 500, Mean: 0.001, SD: 0.1, SNPs: 20, BAF: 0.7, Allelic: cbsgo.AllelicImbalance},
+		{BinStart: 500, BinEnd: 600, Mean: 0, SD: 0.1, BAF: math.NaN()},
+	}
+	expected := []float64{0.5, 0.3, 0.5, 0.3, 0.4, 0}
+
+	res, err := cbsgo.EstimateMosaicism(segments, 0.95)
+	if err != nil {
+		t.Fatalf("EstimateMosaicism returned an unexpected error: %v", err)
+	}
+	for i, s := range res {
+		if math.Abs(s.CellFraction-expected[i]) > 1e-9 {
+			t.Errorf("Segment %d: expected a cell fraction of %v, got %v", i, expected[i], s.CellFraction)
+		}
+		if s.CellFraction < s.CellFractionLower || s.CellFraction > s.CellFractionUpper {
+			t.Errorf("Segment %d: fraction %v outside of its interval [%v, %v]",
+				i, s.CellFraction, s.CellFractionLower, s.CellFractionUpper)
+		}
+	}
+	if s := res[0]; s.CellFractionLower < 0.45 || s.CellFractionUpper > 0.55 {
+		t.Errorf("Expected a narrow interval around 0.5, got [%v, %v]", s.CellFractionLower, s.CellFractionUpper)
+	}
+	// The interval of a copy-neutral LOH comes from the BAF alone: 0.7 over
+	// 20 SNPs has a standard error of 0.1025.
+	if s := res[4]; math.Abs(s.CellFractionLower) > 1e-9 || math.Abs(s.CellFractionUpper-0.8018) > 1e-3 {
+		t.Errorf("Expected an interval of [0, 0.8018], got [%v, %v]", s.CellFractionLower, s.CellFractionUpper)
+	}
+	if s := res[5]; s.CellFractionLower != 0 {
+		t.Errorf("Expected the interval of a neutral segment to include 0, got [%v, %v]",
+			s.CellFractionLower, s.CellFractionUpper)
+	}
+
+	if _, err := cbsgo.EstimateMosaicism(segments, 1); err == nil {
+		t.Errorf("Expected an error for an invalid confidence level")
+	}
+}
@@ -0,0 +1,55 @@
+package cbsgo
+
+import (
+	"math"
+	"sort"
+)
+
+// The estimators below estimate the standard deviation of the noise of a
+// profile from the differences between consecutive values, which are
+// insensitive to changes in the mean: a difference spans a breakpoint only
+// at the breakpoints. They are the estimates used by WithUndoSD,
+// WithSmoothing, WithSpikes and WithHaarScreen, so that thresholds for
+// calling segments can be set on the same scale. Both return 0 for fewer
+// than two values.
+
+// DiffSD estimates the standard deviation of the noise of x as that of the
+// differences between consecutive values divided by √2, assuming a constant
+// mean. It is efficient for Gaussian noise but affected by outliers and, to
+// a lesser extent, by the steps at breakpoints.
+func DiffSD(x []float64) float64 {
+	if len(x) < 2 {
+		return 0
+	}
+	var sumSq float64
+	for i := 1; i < len(x); i++ {
+		d := x[i] - x[i-1]
+		sumSq += d * d
+	}
+	// The differences have mean zero and twice the variance of the noise.
+	return math.Sqrt(sumSq / float64(2*(len(x)-1)))
+}
+
+// DiffMAD estimates the standard deviation of the noise of x robustly from
+// the median absolute deviation of the differences between consecutive
+// values, scaled by 1.4826/√2 to the standard deviation of Gaussian noise.
+// It is hardly affected by outliers or breakpoints, as long as they are a
+// minority of the differences.
+func DiffMAD(x []float64) float64 {
+	if len(x) < 2 {
+		return 0
+	}
+	d := make([]float64, len(x)-1)
+	for i := range d {
+		d[i] = x[i+1] - x[i]
+	}
+	sort.Float64s(d)
+	m := median(d)
+	for i, v := range d {
+		d[i] = math.Abs(v - m)
+	}
+	sort.Float64s(d)
+	// 1.4826 scales the MAD to the SD of a normal distribution, and the
+	// difference of two values has twice the variance of a single one.
+	return 1.4826 * median(d) / math.Sqrt2
+}
@@ -0,0 +1,48 @@
+package cbsgo_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestNoiseEstimators(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	x := make([]float64, 5000)
+	for i := range x {
+		x[i] = 0.3 * rng.NormFloat64()
+		if i >= 2000 && i < 3000 {
+			x[i] += 2
+		}
+	}
+	for name, estimate := range map[string]func([]float64) float64{
+		"DiffSD":  cbsgo.DiffSD,
+		"DiffMAD": cbsgo.DiffMAD,
+	} {
+		if sd := estimate(x); math.Abs(sd-0.3) > 0.02 {
+			t.Errorf("%s: expected a noise SD near 0.3, got %v", name, sd)
+		}
+		for _, short := range [][]float64{nil, {1}} {
+			if sd := estimate(short); sd != 0 {
+				t.Errorf("%s: expected 0 for %v, got %v", name, short, sd)
+			}
+		}
+	}
+
+	// Outliers inflate DiffSD, but hardly DiffMAD.
+	for i := 0; i < len(x); i += 50 {
+		x[i] += 20
+	}
+	if sd := cbsgo.DiffMAD(x); math.Abs(sd-0.3) > 0.03 {
+		t.Errorf("Expected a robust noise SD near 0.3, got %v", sd)
+	}
+	if sd := cbsgo.DiffSD(x); sd < 1 {
+		t.Errorf("Expected outliers to inflate DiffSD, got %v", sd)
+	}
+
+	if expected, got := 1/math.Sqrt2, cbsgo.DiffSD([]float64{1, 2}); math.Abs(got-expected) > 1e-12 {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+	}
+}
@@ -0,0 +1,206 @@
+package normalize
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// The functions below compare the read counts of several samples, each
+// given as its tracks in the same order and with the same bins, such as
+// counted by coverage.CountBAM with the same options. Masked bins are left
+// out of the computations and left unchanged.
+
+// TotalCountFactors returns the size factor of every sample, which is its
+// total count divided by the geometric mean of the totals of all samples.
+// Dividing the counts by the size factors with Scale corrects for
+// differences in sequencing depth.
+func TotalCountFactors(samples [][]*cbsgo.Track) ([]float64, error) {
+	if err := checkBins(samples); err != nil {
+		return nil, err
+	}
+	totals := make([]float64, len(samples))
+	for i, sample := range samples {
+		for _, t := range sample {
+			for j, v := range t.Values {
+				if !masked(t, j) {
+					totals[i] += v
+				}
+			}
+		}
+		if !(totals[i] > 0) {
+			return nil, fmt.Errorf("cbsgo: sample %d has no counts", i)
+		}
+	}
+	logMean := 0.0
+	for _, total := range totals {
+		logMean += math.Log(total) / float64(len(totals))
+	}
+	for i := range totals {
+		totals[i] = math.Exp(math.Log(totals[i]) - logMean)
+	}
+	return totals, nil
+}
+
+// MedianOfRatiosFactors returns the size factor of every sample as in
+// DESeq2: the median over the bins of the ratio of the count of the sample
+// to the geometric mean of the counts of all samples. Only bins with counts
+// in every sample are used. Unlike total-count scaling, the factors are
+// hardly affected by large gains or losses in some of the samples.
+func MedianOfRatiosFactors(samples [][]*cbsgo.Track) ([]float64, error) {
+	if err := checkBins(samples); err != nil {
+		return nil, err
+	}
+	if len(samples) == 0 {
+		return nil, nil
+	}
+	logRatios := make([][]float64, len(samples))
+	logCounts := make([]float64, len(samples))
+	for k, t := range samples[0] {
+	bins:
+		for j := range t.Values {
+			logMean := 0.0
+			for i, sample := range samples {
+				v := sample[k].Values[j]
+				if masked(sample[k], j) || !(v > 0) {
+					continue bins
+				}
+				logCounts[i] = math.Log(v)
+				logMean += logCounts[i] / float64(len(samples))
+			}
+			for i := range samples {
+				logRatios[i] = append(logRatios[i], logCounts[i]-logMean)
+			}
+		}
+	}
+	if len(logRatios[0]) == 0 {
+		return nil, errors.New("cbsgo: no bin has counts in every sample")
+	}
+	factors := make([]float64, len(samples))
+	for i, r := range logRatios {
+		sort.Float64s(r)
+		factors[i] = math.Exp(median(r))
+	}
+	return factors, nil
+}
+
+// Scale returns the tracks of the samples with the counts of every sample
+// divided by its size factor. The input tracks are not modified.
+func Scale(samples [][]*cbsgo.Track, factors []float64) ([][]*cbsgo.Track, error) {
+	if len(factors) != len(samples) {
+		return nil, fmt.Errorf("cbsgo: %d size factors for %d samples", len(factors), len(samples))
+	}
+	res := make([][]*cbsgo.Track, len(samples))
+	for i, sample := range samples {
+		if f := factors[i]; !(f > 0) || math.IsInf(f, 1) {
+			return nil, fmt.Errorf("cbsgo: sample %d has invalid size factor %v", i, f)
+		}
+		res[i] = make([]*cbsgo.Track, len(sample))
+		for k, t := range sample {
+			c := copyTrack(t)
+			for j := range c.Values {
+				if !masked(t, j) {
+					c.Values[j] /= factors[i]
+				}
+			}
+			res[i][k] = c
+		}
+	}
+	return res, nil
+}
+
+// Quantile returns the tracks of the samples quantile normalized, so that
+// the counts of every sample have the same distribution: the count of rank r
+// in a sample is replaced by the mean of the counts of rank r of all samples.
+// Tied counts all get the mean of the values of their ranks. Only bins
+// unmasked in every sample are normalized. The input tracks are not modified.
+func Quantile(samples [][]*cbsgo.Track) ([][]*cbsgo.Track, error) {
+	if err := checkBins(samples); err != nil {
+		return nil, err
+	}
+	res := make([][]*cbsgo.Track, len(samples))
+	for i, sample := range samples {
+		res[i] = make([]*cbsgo.Track, len(sample))
+		for k, t := range sample {
+			res[i][k] = copyTrack(t)
+		}
+	}
+	if len(samples) == 0 {
+		return res, nil
+	}
+
+	// bin locates a bin unmasked in every sample.
+	type bin struct{ track, index int }
+	var bins []bin
+	for k, t := range samples[0] {
+	next:
+		for j := range t.Values {
+			for _, sample := range samples {
+				if masked(sample[k], j) {
+					continue next
+				}
+			}
+			bins = append(bins, bin{k, j})
+		}
+	}
+
+	// order holds the bins of every sample by increasing count, and mean the
+	// mean count of every rank.
+	order := make([][]bin, len(samples))
+	mean := make([]float64, len(bins))
+	for i, sample := range samples {
+		order[i] = append([]bin(nil), bins...)
+		value := func(b bin) float64 { return sample[b.track].Values[b.index] }
+		sort.SliceStable(order[i], func(a, b int) bool { return value(order[i][a]) < value(order[i][b]) })
+		for r, b := range order[i] {
+			mean[r] += value(b) / float64(len(samples))
+		}
+	}
+	for i, sample := range samples {
+		value := func(b bin) float64 { return sample[b.track].Values[b.index] }
+		for r := 0; r < len(bins); {
+			// Ties span the ranks [r, end).
+			end, sum := r, 0.0
+			for ; end < len(bins) && value(order[i][end]) == value(order[i][r]); end++ {
+				sum += mean[end]
+			}
+			for _, b := range order[i][r:end] {
+				res[i][b.track].Values[b.index] = sum / float64(end-r)
+			}
+			r = end
+		}
+	}
+	return res, nil
+}
+
+// checkBins checks that all samples have the tracks and bins of the first.
+func checkBins(samples [][]*cbsgo.Track) error {
+	for i, sample := range samples[min(1, len(samples)):] {
+		if len(sample) != len(samples[0]) {
+			return fmt.Errorf("cbsgo: sample %d has %d tracks, expected %d", i+1, len(sample), len(samples[0]))
+		}
+		for k, t := range sample {
+			if ref := samples[0][k]; t.Chrom != ref.Chrom || t.Len() != ref.Len() {
+				return fmt.Errorf("cbsgo: track %s with %d bins of sample %d does not match track %s with %d bins",
+					t.Chrom, t.Len(), i+1, ref.Chrom, ref.Len())
+			}
+		}
+	}
+	return nil
+}
+
+// masked reports whether bin j of t is masked.
+func masked(t *cbsgo.Track, j int) bool {
+	return t.Mask != nil && t.Mask[j]
+}
+
+// copyTrack returns a copy of t with its own values, sharing the other
+// fields.
+func copyTrack(t *cbsgo.Track) *cbsgo.Track {
+	c := *t
+	c.Values = append([]float64(nil), t.Values...)
+	return &c
+}
@@ -0,0 +1,112 @@
+package normalize_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/normalize"
+)
+
+// samples returns two samples of two tracks, the second sequenced twice as
+// deep as the first, with a gain of the second track in the second sample.
+func samples() [][]*cbsgo.Track {
+	return [][]*cbsgo.Track{
+		{
+			{Chrom: "chr1", Values: []float64{10, 20, 30, 40}},
+			{Chrom: "chr2", Values: []float64{10, 0}},
+		},
+		{
+			{Chrom: "chr1", Values: []float64{20, 40, 60, 80}},
+			{Chrom: "chr2", Values: []float64{60, 5}, Mask: []bool{false, true}},
+		},
+	}
+}
+
+func TestTotalCountFactors(t *testing.T) {
+	factors, err := normalize.TotalCountFactors(samples())
+	if err != nil {
+		t.Fatalf("TotalCountFactors returned an unexpected error: %v", err)
+	}
+	// Totals of 110 and 260, whose geometric mean is sqrt(110*260).
+	g := math.Sqrt(110 * 260)
+	if expected := []float64{110 / g, 260 / g}; !near(factors, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, factors)
+	}
+
+	empty := [][]*cbsgo.Track{{{Chrom: "chr1", Values: []float64{0}}}}
+	if _, err := normalize.TotalCountFactors(empty); err == nil {
+		t.Errorf("Expected an error for a sample without counts")
+	}
+}
+
+func TestMedianOfRatiosFactors(t *testing.T) {
+	factors, err := normalize.MedianOfRatiosFactors(samples())
+	if err != nil {
+		t.Fatalf("MedianOfRatiosFactors returned an unexpected error: %v", err)
+	}
+	// The gain and the masked or empty bins do not affect the factors.
+	if expected := []float64{1 / math.Sqrt2, math.Sqrt2}; !near(factors, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, factors)
+	}
+
+	scaled, err := normalize.Scale(samples(), factors)
+	if err != nil {
+		t.Fatalf("Scale returned an unexpected error: %v", err)
+	}
+	if !near(scaled[0][0].Values, scaled[1][0].Values) {
+		t.Errorf("Expected equal scaled counts, got %v and %v", scaled[0][0].Values, scaled[1][0].Values)
+	}
+	if v := scaled[1][1].Values[1]; v != 5 {
+		t.Errorf("Expected the masked bin to be left unchanged, got %v", v)
+	}
+
+	disjoint := [][]*cbsgo.Track{
+		{{Chrom: "chr1", Values: []float64{0, 1}}},
+		{{Chrom: "chr1", Values: []float64{1, 0}}},
+	}
+	if _, err := normalize.MedianOfRatiosFactors(disjoint); err == nil {
+		t.Errorf("Expected an error without bins with counts in every sample")
+	}
+}
+
+func TestScaleInvalid(t *testing.T) {
+	if _, err := normalize.Scale(samples(), []float64{1}); err == nil {
+		t.Errorf("Expected an error for a missing factor")
+	}
+	if _, err := normalize.Scale(samples(), []float64{1, 0}); err == nil {
+		t.Errorf("Expected an error for a zero factor")
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	in := [][]*cbsgo.Track{
+		{{Chrom: "chr1", Values: []float64{5, 2, 3, 4}, Mask: []bool{false, false, false, true}}},
+		{{Chrom: "chr1", Values: []float64{4, 1, 4, 2}}},
+		{{Chrom: "chr1", Values: []float64{3, 4, 6, 8}}},
+	}
+	res, err := normalize.Quantile(in)
+	if err != nil {
+		t.Fatalf("Quantile returned an unexpected error: %v", err)
+	}
+	// The sorted counts of the first three bins are (2, 3, 5), (1, 4, 4) and
+	// (3, 4, 6), giving rank means of 2, 11/3 and 5. The tied counts of the
+	// second sample get the mean of 11/3 and 5.
+	expected := [][]float64{{5, 2, 11.0 / 3, 4}, {13.0 / 3, 2, 13.0 / 3, 2}, {2, 11.0 / 3, 5, 8}}
+	for i, e := range expected {
+		if !near(res[i][0].Values, e) {
+			t.Errorf("Sample %d: unexpected result.\nExpected: %v\nGot: %v", i, e, res[i][0].Values)
+		}
+	}
+	if in[0][0].Values[0] != 5 {
+		t.Errorf("Expected the input to be left unchanged")
+	}
+
+	mismatch := [][]*cbsgo.Track{
+		{{Chrom: "chr1", Values: []float64{1, 2}}},
+		{{Chrom: "chr2", Values: []float64{1, 2}}},
+	}
+	if _, err := normalize.Quantile(mismatch); err == nil {
+		t.Errorf("Expected an error for mismatched tracks")
+	}
+}
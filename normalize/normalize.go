@@ -0,0 +1,74 @@
+// Package normalize turns read counts into the log2 ratios that cbsgo
+// segments, correcting for differences in sequencing depth.
+package normalize
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// Log2Ratios returns tracks of the log2 ratios of the counts of sample to
+// those of control, median-centered over all bins so that the most common
+// copy number has a ratio of zero. Without a control, that is for a nil
+// control, the ratios are relative to the median count of sample.
+//
+// A control needs the same tracks and bins as sample. Bins without counts in
+// sample or control are masked. The input tracks are not modified.
+func Log2Ratios(sample, control []*cbsgo.Track) ([]*cbsgo.Track, error) {
+	if control != nil && len(control) != len(sample) {
+		return nil, fmt.Errorf("cbsgo: %d sample tracks but %d control tracks", len(sample), len(control))
+	}
+	res := make([]*cbsgo.Track, len(sample))
+	var ratios []float64
+	for i, t := range sample {
+		var c *cbsgo.Track
+		if control != nil {
+			c = control[i]
+			if c.Chrom != t.Chrom || c.Len() != t.Len() {
+				return nil, fmt.Errorf("cbsgo: control track %s with %d bins does not match sample track %s with %d bins",
+					c.Chrom, c.Len(), t.Chrom, t.Len())
+			}
+		}
+		r := &cbsgo.Track{Chrom: t.Chrom, Starts: t.Starts, Ends: t.Ends, Weights: t.Weights,
+			Values: make([]float64, t.Len()), Mask: make([]bool, t.Len())}
+		for j, v := range t.Values {
+			ref := 1.0
+			if c != nil {
+				ref = c.Values[j]
+			}
+			if v <= 0 || ref <= 0 || (t.Mask != nil && t.Mask[j]) || (c != nil && c.Mask != nil && c.Mask[j]) {
+				r.Mask[j] = true
+				continue
+			}
+			r.Values[j] = math.Log2(v / ref)
+			ratios = append(ratios, r.Values[j])
+		}
+		res[i] = r
+	}
+	if len(ratios) == 0 {
+		return res, nil
+	}
+
+	sort.Float64s(ratios)
+	m := median(ratios)
+	for _, r := range res {
+		for j := range r.Values {
+			if !r.Mask[j] {
+				r.Values[j] -= m
+			}
+		}
+	}
+	return res, nil
+}
+
+// median returns the median of sorted x.
+func median(x []float64) float64 {
+	n := len(x)
+	if n%2 == 1 {
+		return x[n/2]
+	}
+	return (x[n/2-1] + x[n/2]) / 2
+}
@@ -0,0 +1,68 @@
+package normalize_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/normalize"
+)
+
+// near reports whether x and y are equal up to rounding errors.
+func near(x, y []float64) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if math.Abs(x[i]-y[i]) > 1e-12 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLog2Ratios(t *testing.T) {
+	sample := []*cbsgo.Track{
+		{Chrom: "chr1", Values: []float64{100, 100, 200, 0}},
+		{Chrom: "chr2", Values: []float64{100, 50}},
+	}
+
+	got, err := normalize.Log2Ratios(sample, nil)
+	if err != nil {
+		t.Fatalf("Log2Ratios returned an unexpected error: %v", err)
+	}
+	if expected := []float64{0, 0, 1, 0}; !near(got[0].Values, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got[0].Values)
+	}
+	if expected := []bool{false, false, false, true}; !reflect.DeepEqual(got[0].Mask, expected) {
+		t.Errorf("Unexpected mask.\nExpected: %v\nGot: %v", expected, got[0].Mask)
+	}
+	if expected := []float64{0, -1}; !near(got[1].Values, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got[1].Values)
+	}
+
+	// A control with twice the depth and a gain in the first bin.
+	control := []*cbsgo.Track{
+		{Chrom: "chr1", Values: []float64{400, 200, 400, 200}},
+		{Chrom: "chr2", Values: []float64{200, 200}},
+	}
+	got, err = normalize.Log2Ratios(sample, control)
+	if err != nil {
+		t.Fatalf("Log2Ratios returned an unexpected error: %v", err)
+	}
+	if expected := []float64{-1, 0, 0, 0}; !reflect.DeepEqual(got[0].Values, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got[0].Values)
+	}
+	if expected := []float64{0, -1}; !near(got[1].Values, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got[1].Values)
+	}
+
+	if _, err := normalize.Log2Ratios(sample, control[:1]); err == nil {
+		t.Errorf("Expected an error for a missing control track")
+	}
+	control[1].Values = control[1].Values[:1]
+	if _, err := normalize.Log2Ratios(sample, control); err == nil {
+		t.Errorf("Expected an error for mismatched bins")
+	}
+}
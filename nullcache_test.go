@@ -0,0 +1,50 @@
+package cbsgo_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestRunNullCache(t *testing.T) {
+	cache := cbsgo.NewNullCache(1000, 1)
+
+	for _, x := range [][]float64{
+		{1, 1, 1, 3, 3, 2, 1, 2, 3, 300, 310, 321, 310, 299},
+		borderline(0.5),
+		borderline(0),
+	} {
+		expected, err := cbsgo.Run(x, cbsgo.WithSeed(42))
+		if err != nil {
+			t.Fatalf("Run returned an unexpected error: %v", err)
+		}
+		res, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithNullCache(cache))
+		if err != nil {
+			t.Fatalf("Run returned an unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(res, expected) {
+			t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected.Segments, res.Segments)
+		}
+	}
+}
+
+func TestNullCacheConcurrent(t *testing.T) {
+	cache := cbsgo.NewNullCache(200, 1)
+	done := make(chan *cbsgo.Result)
+	for i := 0; i < 8; i++ {
+		go func() {
+			res, err := cbsgo.Run(borderline(0.5), cbsgo.WithNullCache(cache))
+			if err != nil {
+				t.Errorf("Run returned an unexpected error: %v", err)
+			}
+			done <- res
+		}()
+	}
+	first := <-done
+	for i := 1; i < 8; i++ {
+		if res := <-done; !reflect.DeepEqual(res, first) {
+			t.Errorf("Concurrent runs differ.\nFirst: %v\nGot: %v", first.Segments, res.Segments)
+		}
+	}
+}
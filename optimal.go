@@ -0,0 +1,143 @@
+package cbsgo
+
+import (
+	"fmt"
+	"math"
+)
+
+// BestSegments returns the segmentation of x into k segments with the least
+// sum of squared residuals, found exactly by dynamic programming (segment
+// neighbourhood). Unlike Run, the result is deterministic and holds exactly k
+// segments, such as for the best 10-segment fit of a profile. The cost grows
+// with k times the square of the length of x, which suits profiles of up to a
+// few thousand bins.
+func BestSegments(x []float64, k int) ([]Segment, error) {
+	if k < 1 || k > len(x) {
+		return nil, fmt.Errorf("cbsgo: cannot split %d values into %d segments", len(x), k)
+	}
+	_, back := segmentNeighbourhood(x, k)
+	return binSegments(x, traceback(back, k, len(x))), nil
+}
+
+// PenalizedSegmentation is the segmentation minimizing the sum of squared
+// residuals plus a penalty per changepoint, for the penalties in
+// [MinPenalty, MaxPenalty].
+type PenalizedSegmentation struct {
+	Segments []Segment
+	// Cost is the sum of squared residuals of the segments.
+	Cost       float64
+	MinPenalty float64
+	MaxPenalty float64
+}
+
+// CROPS returns, in order of decreasing penalty, every segmentation of x with
+// at most maxSegments segments that is optimal for some penalty per
+// changepoint in [minPenalty, maxPenalty], in the spirit of the CROPS
+// algorithm of Haynes et al. (2017). Comparing the segmentations of the
+// penalty path helps choosing a penalty, and hence a number of segments. The
+// optimal segmentations of all numbers of segments are computed by
+// BestSegments, with the same cost.
+func CROPS(x []float64, minPenalty, maxPenalty float64, maxSegments int) ([]PenalizedSegmentation, error) {
+	if minPenalty < 0 || maxPenalty < minPenalty {
+		return nil, fmt.Errorf("cbsgo: invalid penalty range [%v, %v]", minPenalty, maxPenalty)
+	}
+	if maxSegments < 1 || maxSegments > len(x) {
+		return nil, fmt.Errorf("cbsgo: cannot split %d values into %d segments", len(x), maxSegments)
+	}
+	costs, back := segmentNeighbourhood(x, maxSegments)
+
+	// The optimal numbers of segments follow the lower convex hull of the
+	// costs as the penalty decreases.
+	k := 1
+	for j := 2; j <= maxSegments; j++ {
+		if costs[j]+maxPenalty*float64(j-1) < costs[k]+maxPenalty*float64(k-1) {
+			k = j
+		}
+	}
+	var res []PenalizedSegmentation
+	upper := maxPenalty
+	for {
+		lower, next := minPenalty, 0
+		for j := k + 1; j <= maxSegments; j++ {
+			if b := (costs[k] - costs[j]) / float64(j-k); b >= lower {
+				lower, next = b, j
+			}
+		}
+		res = append(res, PenalizedSegmentation{
+			Segments:   binSegments(x, traceback(back, k, len(x))),
+			Cost:       costs[k],
+			MinPenalty: lower,
+			MaxPenalty: upper,
+		})
+		if next == 0 || lower <= minPenalty {
+			return res, nil
+		}
+		k, upper = next, lower
+	}
+}
+
+// segmentNeighbourhood computes, for every number of segments k up to kmax,
+// the least sum of squared residuals of a segmentation of x into k segments,
+// costs[k], and the start of the last segment of the best segmentation of
+// x[:j] into k segments, back[k][j].
+func segmentNeighbourhood(x []float64, kmax int) ([]float64, [][]int) {
+	n := len(x)
+	s1 := make([]float64, n+1)
+	s2 := make([]float64, n+1)
+	for i, v := range x {
+		s1[i+1] = s1[i] + v
+		s2[i+1] = s2[i] + v*v
+	}
+	sse := func(i, j int) float64 {
+		d := s1[j] - s1[i]
+		return math.Max(0, s2[j]-s2[i]-d*d/float64(j-i))
+	}
+
+	costs := make([]float64, kmax+1)
+	back := make([][]int, kmax+1)
+	prev := make([]float64, n+1)
+	cur := make([]float64, n+1)
+	for j := 1; j <= n; j++ {
+		prev[j] = sse(0, j)
+	}
+	back[1] = make([]int, n+1)
+	costs[1] = prev[n]
+	for k := 2; k <= kmax; k++ {
+		back[k] = make([]int, n+1)
+		for j := k; j <= n; j++ {
+			best, arg := math.Inf(1), k-1
+			for i := k - 1; i < j; i++ {
+				if c := prev[i] + sse(i, j); c < best {
+					best, arg = c, i
+				}
+			}
+			cur[j], back[k][j] = best, arg
+		}
+		costs[k] = cur[n]
+		prev, cur = cur, prev
+	}
+	return costs, back
+}
+
+// traceback returns the bounds of the best segmentation of the first n values
+// into k segments from the back pointers of segmentNeighbourhood.
+func traceback(back [][]int, k, n int) [][2]int {
+	bounds := make([][2]int, k)
+	for ; k > 0; k-- {
+		start := back[k][n]
+		bounds[k-1] = [2]int{start, n}
+		n = start
+	}
+	return bounds
+}
+
+// binSegments converts the bounds of segments of x into segments.
+func binSegments(x []float64, bounds [][2]int) []Segment {
+	segments := make([]Segment, len(bounds))
+	for i, b := range bounds {
+		s := Segment{Start: b[0], End: b[1], BinStart: b[0], BinEnd: b[1]}
+		s.Mean, s.SD = meanSD(x[b[0]:b[1]], nil)
+		segments[i] = s
+	}
+	return segments
+}
@@ -0,0 +1,70 @@
+package cbsgo_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// bounds returns the bin bounds of segments.
+func bounds(segments []cbsgo.Segment) [][2]int {
+	var res [][2]int
+	for _, s := range segments {
+		res = append(res, [2]int{s.BinStart, s.BinEnd})
+	}
+	return res
+}
+
+func TestBestSegments(t *testing.T) {
+	x := []float64{0, 0.1, 0, 0.1, 5, 5.1, 5, 0, 0.1, 0, 2, 2.1, 2}
+	got, err := cbsgo.BestSegments(x, 4)
+	if err != nil {
+		t.Fatalf("BestSegments returned an unexpected error: %v", err)
+	}
+	expected := [][2]int{{0, 4}, {4, 7}, {7, 10}, {10, 13}}
+	if !reflect.DeepEqual(bounds(got), expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, bounds(got))
+	}
+	if got[1].Mean < 5 || got[1].Mean > 5.1 {
+		t.Errorf("Unexpected mean %v", got[1].Mean)
+	}
+
+	got, err = cbsgo.BestSegments(x, 2)
+	if err != nil {
+		t.Fatalf("BestSegments returned an unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("Expected 2 segments, got %v", got)
+	}
+	if _, err := cbsgo.BestSegments(x, 14); err == nil {
+		t.Errorf("Expected an error for too many segments")
+	}
+}
+
+func TestCROPS(t *testing.T) {
+	x := []float64{0, 0.1, 0, 0.1, 5, 5.1, 5, 0, 0.1, 0, 2, 2.1, 2}
+	path, err := cbsgo.CROPS(x, 0.01, 100, 6)
+	if err != nil {
+		t.Fatalf("CROPS returned an unexpected error: %v", err)
+	}
+	if len(path) < 3 {
+		t.Fatalf("Expected several segmentations, got %v", path)
+	}
+	for i, p := range path {
+		if p.MinPenalty > p.MaxPenalty || (i > 0 && p.MaxPenalty != path[i-1].MinPenalty) {
+			t.Errorf("Unexpected penalty range of segmentation %d: %+v", i, p)
+		}
+		if i > 0 && len(p.Segments) <= len(path[i-1].Segments) {
+			t.Errorf("Expected more segments for lower penalties, got %d after %d", len(p.Segments), len(path[i-1].Segments))
+		}
+	}
+	if path[0].MaxPenalty != 100 || path[len(path)-1].MinPenalty != 0.01 {
+		t.Errorf("Expected the path to cover the penalty range, got %+v", path)
+	}
+	for _, p := range path {
+		if len(p.Segments) == 4 && !reflect.DeepEqual(bounds(p.Segments), [][2]int{{0, 4}, {4, 7}, {7, 10}, {10, 13}}) {
+			t.Errorf("Unexpected 4-segment fit %v", bounds(p.Segments))
+		}
+	}
+}
@@ -0,0 +1,469 @@
+package cbsgo
+
+// Option configures a segmentation run started with Run.
+type Option func(*config)
+
+// config holds the settings of a segmentation run.
+type config struct {
+	shuffles int
+	alpha    float64
+	seed     int64
+
+	// adjustment adjusts alpha per test.
+	adjustment AlphaAdjustment
+
+	// schedule sets the number of shuffles by interval length, if set.
+	schedule ShuffleSchedule
+
+	// starts and ends hold the genomic extent of every bin, if known.
+	starts []int
+	ends   []int
+
+	// weights holds the bin weights, if set, in place of the bin widths.
+	weights []float64
+
+	// gapScale is the scale of the inter-marker distance penalty, 0 if disabled.
+	gapScale float64
+
+	// lengthScale is the scale of the segment length prior, 0 if disabled.
+	lengthScale float64
+
+	// consensus is the number of runs combined into a consensus segmentation.
+	consensus int
+
+	// snpPos and baf hold the B-allele frequencies of SNPs, if known.
+	snpPos []int
+	baf    []float64
+
+	// mosaicLevel is the confidence level of the cell fraction estimates,
+	// 0 if they are disabled.
+	mosaicLevel float64
+
+	// nulls replaces the permutations when set.
+	nulls *NullCache
+
+	// writer receives the segments instead of the result, if set.
+	writer func(Segment) error
+	// alert receives the changepoints confirmed while streaming, if set.
+	alert func(Alert)
+
+	// fit enables the goodness-of-fit measures.
+	fit bool
+
+	// calls holds the copy number state thresholds, if set.
+	calls *CallThresholds
+
+	// direction restricts the changes detected.
+	direction Direction
+	// tie chooses among equal extremes of the cumulative sums.
+	tie TieBreak
+
+	// breakpointLevel is the confidence level of the breakpoint intervals,
+	// 0 if the breakpoint details are disabled.
+	breakpointLevel float64
+
+	// workers bounds the number of chromosomes segmented concurrently by
+	// SegmentGenome, 0 for one per CPU.
+	workers int
+
+	// memoryLimit is the budget of WithMemoryLimit in bytes, 0 if unlimited.
+	memoryLimit int
+
+	// metrics receives the statistics of every run, if set.
+	metrics MetricsRecorder
+
+	// haar is the threshold of the Haar screen, 0 if disabled.
+	haar float64
+
+	// tail enables the extrapolated p-values of WithTailPValues.
+	tail bool
+
+	// smoothRegion, outlierSD and smoothSD configure the outlier smoothing of
+	// WithSmoothing, disabled if smoothRegion is 0.
+	smoothRegion        int
+	outlierSD, smoothSD float64
+	// transform is applied to the values before segmentation.
+	transform Transform
+
+	// minWidth is the minimum number of bins of the segments created by a
+	// split, 0 if unrestricted.
+	minWidth int
+
+	// undoSD is the threshold of WithUndoSD, 0 if disabled.
+	undoSD float64
+
+	// spikeSD is the threshold of WithSpikes, 0 if disabled.
+	spikeSD float64
+
+	// confirmAlpha is the significance level of WithConfirmation, 0 if
+	// disabled.
+	confirmAlpha float64
+
+	// shrink shrinks the segment means, see WithShrinkage.
+	shrink bool
+
+	// focalAmplitude, focalMin and focalMax configure the focal pass of
+	// WithFocal, disabled if focalMax is 0.
+	focalAmplitude     float64
+	focalMin, focalMax int
+
+	// hierarchyAlpha is the significance level of WithHierarchy, 0 if
+	// disabled.
+	hierarchyAlpha float64
+
+	// explain lists the tests in Result.Explain.
+	explain bool
+
+	// contentSeed derives the seed from the input, see WithContentSeed.
+	contentSeed bool
+
+	// err holds an invalid option, reported when the run starts.
+	err error
+
+	// strict rejects nonsensical settings, see WithStrictValidation.
+	strict bool
+
+	// profileLabels enables the profiler labels, with the sample identifier
+	// profileID and the chromosome chrom, set by Track.Segment.
+	profileLabels bool
+	profileID     string
+
+	// sample names the sample, from which SegmentGenome derives seeds.
+	sample string
+	chrom  string
+}
+
+// defaultConfig returns the recommended settings.
+func defaultConfig() *config {
+	return &config{
+		shuffles: 1000,
+		alpha:    0.05,
+	}
+}
+
+// newConfig returns the default settings modified by opts.
+func newConfig(opts []Option) *config {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithShuffles sets the number of permutations performed to determine the
+// significance of a changepoint. The default is 1000.
+func WithShuffles(n int) Option {
+	return func(c *config) {
+		c.shuffles = n
+	}
+}
+
+// WithShuffleSchedule sets the number of permutations of the test of every
+// interval by its length, with a schedule such as LinearShuffles. Short
+// intervals, where the test has little power anyway, can then be tested with
+// fewer permutations than long ones. Tests that find a significant split with
+// a p-value above half the significance level, close to the boundary,
+// continue up to the number of shuffles set with WithShuffles. The schedule
+// does not apply to tests using a null cache.
+func WithShuffleSchedule(s ShuffleSchedule) Option {
+	return func(c *config) {
+		c.schedule = s
+	}
+}
+
+// WithAlpha sets the p-value significance level. The default is 0.05.
+func WithAlpha(p float64) Option {
+	return func(c *config) {
+		c.alpha = p
+	}
+}
+
+// WithAlphaAdjustment adjusts the significance level of every test for the
+// multiple tests of a run, spending the significance level set with WithAlpha
+// over them, so that it bounds the probability of any false split in the run
+// rather than that of every split. The default is NoAdjustment.
+func WithAlphaAdjustment(a AlphaAdjustment) Option {
+	return func(c *config) {
+		c.adjustment = a
+	}
+}
+
+// WithSeed seeds the random source used for the permutations, making the
+// result reproducible. A zero seed, the default, selects a time-based seed.
+func WithSeed(seed int64) Option {
+	return func(c *config) {
+		c.seed = seed
+	}
+}
+
+// WithPositions sets the genomic extent [starts[i], ends[i]) of every bin.
+//
+// Without positions all bins are assumed to have the same width. With them,
+// the test statistic and the segment means are weighted by bin width, which
+// matters for targets of very different sizes such as exome capture regions,
+// and segment coordinates are reported as genomic positions rather than bin
+// indices.
+func WithPositions(starts, ends []int) Option {
+	return func(c *config) {
+		c.starts, c.ends = starts, ends
+	}
+}
+
+// WithWeights weights bin i by w[i] in the test statistic and the segment
+// means, for instance by the inverse variance of its value. The weights replace
+// the bin widths derived from WithPositions.
+func WithWeights(w []float64) Option {
+	return func(c *config) {
+		c.weights = w
+	}
+}
+
+// WithGapPenalty penalizes splits between markers separated by large genomic
+// gaps, in the spirit of PSCBS. It requires WithPositions.
+//
+// The statistic of a candidate split is multiplied by scale/(scale+gap) for
+// each of its breakpoints, where gap is the distance between the end of the
+// bin before and the start of the bin after the breakpoint. Breakpoints at gaps
+// much shorter than scale are hardly affected, while those at gaps much longer
+// than scale need correspondingly stronger evidence to be called.
+func WithGapPenalty(scale float64) Option {
+	return func(c *config) {
+		c.gapScale = scale
+	}
+}
+
+// WithLengthPrior favors longer segments, reducing over-segmentation of very
+// noisy profiles without a hard minimum width.
+//
+// The statistic of a candidate split is multiplied by 1-exp(-m/scale) for
+// each of the segments of m bins it creates, the probability that a segment
+// of an exponential length distribution with mean scale is shorter than m.
+// Splits into segments much longer than scale bins are hardly affected,
+// while those creating shorter segments need correspondingly stronger
+// evidence to be called.
+func WithLengthPrior(scale float64) Option {
+	return func(c *config) {
+		c.lengthScale = scale
+	}
+}
+
+// WithConsensus repeats the segmentation with the given number of different
+// seeds and reports the consensus of the runs.
+//
+// Because significance is assessed with random permutations, splits close to
+// the significance level may be called by some runs and not by others. In
+// consensus mode, Result.Breakpoints lists every breakpoint called by at least
+// one run together with the fraction of runs calling it, and Result.Segments
+// holds the segmentation formed by the breakpoints called by a majority of the
+// runs. The seeds of the runs are derived from the seed set with WithSeed.
+func WithConsensus(runs int) Option {
+	return func(c *config) {
+		c.consensus = runs
+	}
+}
+
+// WithBAF supplies the B-allele frequencies baf of heterozygous SNPs at the
+// coordinates pos, which must use the same coordinate system as the segments.
+// Each segment is then classified as LOH, allelically imbalanced, or balanced
+// by CallAllelic with DefaultAllelicThresholds.
+func WithBAF(pos []int, baf []float64) Option {
+	return func(c *config) {
+		c.snpPos, c.baf = pos, baf
+	}
+}
+
+// WithMosaicism estimates the fraction of cells carrying each gain or loss,
+// with confidence intervals at the given level, using EstimateMosaicism.
+// Segment means must be log2 ratios relative to the diploid state. Combine
+// with WithBAF to take allelic imbalance into account.
+func WithMosaicism(level float64) Option {
+	return func(c *config) {
+		c.mosaicLevel = level
+	}
+}
+
+// WithNullCache assesses significance against the cached null distributions
+// of c rather than by permutations, for bins of equal width. Sharing a cache
+// between the runs on many similar profiles avoids most of the permutation
+// cost. The number of shuffles is then that of the cache.
+func WithNullCache(c *NullCache) Option {
+	return func(cfg *config) {
+		cfg.nulls = c
+	}
+}
+
+// WithWriter writes the segments to w as soon as they are final, in order of
+// position, rather than collecting them in Result.Segments, which is then
+// empty. In consensus mode the segments are written once all runs completed.
+// Run does not close w.
+func WithWriter(w SegmentWriter) Option {
+	return func(c *config) {
+		c.writer = w.Write
+	}
+}
+
+// WithFit evaluates the fit of the segmentation with GoodnessOfFit, setting
+// the SSE and R2 fields of every segment and Result.Fit. Segment means and
+// sums of squares are weighted like the test statistic.
+func WithFit() Option {
+	return func(c *config) {
+		c.fit = true
+	}
+}
+
+// WithBreakpoints lists the breakpoints between the segments in
+// Result.Breakpoints, also outside of consensus mode, with their confidence
+// intervals at the given level (such as 0.95), the change in mean, and the
+// p-value of the split that introduced them.
+//
+// The confidence interval holds the positions where moving the breakpoint
+// increases the sum of squared residuals of the adjacent segments, relative
+// to their pooled variance, by less than the corresponding quantile of the
+// chi-squared distribution with one degree of freedom. In consensus mode the
+// p-values are not available and set to NaN.
+func WithBreakpoints(level float64) Option {
+	return func(c *config) {
+		c.breakpointLevel = level
+	}
+}
+
+// WithDirection restricts the segmentation to changes in the given direction,
+// with a one-sided test statistic that has more power when screening
+// specifically for amplifications or deletions. The default is TwoSided. A
+// null cache set with WithNullCache only applies to two-sided tests and is
+// otherwise ignored. Joint segmentation with RunJoint is always two-sided.
+func WithDirection(d Direction) Option {
+	return func(c *config) {
+		c.direction = d
+	}
+}
+
+// WithTieBreak sets the rule choosing the bounds of the best split among
+// equal extremes of the cumulative sums of the mean-centered values, which
+// occur with discrete or rounded data. The default, Leftmost, matches the
+// original implementation.
+func WithTieBreak(t TieBreak) Option {
+	return func(c *config) {
+		c.tie = t
+	}
+}
+
+// WithCalls calls the copy number state of every segment with CallStates and
+// the thresholds t, such as DefaultCallThresholds or those of a
+// SubclonalModel. Segment means must be log2 ratios.
+func WithCalls(t CallThresholds) Option {
+	return func(c *config) {
+		c.calls = &t
+	}
+}
+
+// WithWorkers bounds the number of chromosomes segmented concurrently by
+// SegmentGenome. The default is the number of CPUs usable by the process.
+func WithWorkers(n int) Option {
+	return func(c *config) {
+		c.workers = n
+	}
+}
+
+// WithMetrics reports the statistics of every run to m, such as to expose
+// them to a monitoring system when segmenting in a long-running service.
+func WithMetrics(m MetricsRecorder) Option {
+	return func(c *config) {
+		c.metrics = m
+	}
+}
+
+// WithContentSeed derives the seed of every run from a hash of its input
+// values, bin positions and weights, and of the options affecting the
+// segmentation, such as the significance level and the number of shuffles.
+// Runs on the same data with the same options then give the same result
+// without any seed to keep track of, while different data get independent
+// permutations. It replaces the seed set with WithSeed, also for the tracks
+// of SegmentGenome.
+func WithContentSeed() Option {
+	return func(c *config) {
+		c.contentSeed = true
+	}
+}
+
+// WithSample names the sample being segmented. SegmentGenome derives the
+// seeds of its tracks from the name, see DeriveSeed.
+func WithSample(name string) Option {
+	return func(c *config) {
+		c.sample = name
+	}
+}
+
+// WithProfileLabels labels the tests for changepoints with pprof labels, so
+// that CPU profiles of production runs attribute the time spent to samples and
+// chromosomes. The labels are "sample", set to the sample identifier id,
+// "chromosome", set for tracks segmented with Track.Segment, and "length", the
+// decade of the number of bins tested, such as "100-999".
+func WithProfileLabels(id string) Option {
+	return func(c *config) {
+		c.profileLabels = true
+		c.profileID = id
+	}
+}
+
+// WithTailPValues estimates the p-values of significant splits exceeded by
+// fewer than 10 permutations with TailPValue, extrapolating the tail of the
+// permutation distribution, rather than as the fraction of exceeding
+// permutations, which cannot go below 1/(shuffles+1). The p-values are
+// reported by WithBreakpoints and are then precise enough for multiple testing
+// corrections across thousands of tests without millions of shuffles.
+func WithTailPValues() Option {
+	return func(c *config) {
+		c.tail = true
+	}
+}
+
+// WithHaarScreen screens x for candidate breakpoints with HaarCandidates and
+// the given threshold, such as 4, before segmenting it. Intervals without a
+// candidate breakpoint are not tested, which saves most of the permutations
+// spent on long flat regions. Low thresholds keep the segmentation close to
+// that of the full search; higher ones are faster but may miss weak changes.
+func WithHaarScreen(threshold float64) Option {
+	return func(c *config) {
+		c.haar = threshold
+	}
+}
+
+// WithSmoothing pulls single outlying values towards their neighbors with
+// SmoothOutliers before segmenting, so that they do not form segments of
+// their own. Segment means are computed from the smoothed values.
+func WithSmoothing(region int, outlierSD, smoothSD float64) Option {
+	return func(c *config) {
+		c.smoothRegion, c.outlierSD, c.smoothSD = region, outlierSD, smoothSD
+	}
+}
+
+// WithMinWidth rejects splits creating a segment of fewer than n bins, like
+// min.width in DNAcopy. The default of 0 only rejects the changepoint
+// segments of fewer than 5 bins that the test cannot assess.
+func WithMinWidth(n int) Option {
+	return func(c *config) {
+		c.minWidth = n
+	}
+}
+
+// WithStrictValidation rejects settings that are valid but cannot produce a
+// meaningful result, which are otherwise accepted: a significance level
+// outside (0, 1), fewer shuffles than 1/alpha, too few to reach the
+// significance level, and a minimum width of 1, which rejects no split.
+func WithStrictValidation() Option {
+	return func(c *config) {
+		c.strict = true
+	}
+}
+
+// WithUndoSD merges adjacent segments whose means differ by less than sd
+// standard deviations of the values, smallest differences first, like
+// undo.splits = "sdundo" in DNAcopy. The standard deviation is estimated
+// by DiffMAD. With WithWriter, the segments are then written once the run
+// completed. It cannot be combined with WithConsensus.
+func WithUndoSD(sd float64) Option {
+	return func(c *config) {
+		c.undoSD = sd
+	}
+}
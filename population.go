@@ -0,0 +1,96 @@
+package cbsgo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// PopulationCNVs holds the germline CNVs of a population with their
+// frequencies, such as those of gnomAD-SV or the Database of Genomic
+// Variants, to recognize common germline CNVs among the segments of a sample
+// before clinical interpretation.
+type PopulationCNVs struct {
+	index *segmentIndex
+}
+
+// ReadPopulationCNVs reads population CNVs from a BED file holding the
+// frequency of every CNV, in [0, 1], in the name column. Track, browser and
+// comment lines are skipped.
+func ReadPopulationCNVs(r io.Reader) (*PopulationCNVs, error) {
+	dr, err := Decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	defer dr.Close()
+	var cnvs SegmentSet
+	sc := bufio.NewScanner(dr)
+	for line := 1; sc.Scan(); line++ {
+		if skipHeader(sc.Text()) {
+			continue
+		}
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("cbsgo: population CNV line %d: expected 4 fields, got %d", line, len(fields))
+		}
+		start, err1 := strconv.Atoi(fields[1])
+		end, err2 := strconv.Atoi(fields[2])
+		freq, err3 := strconv.ParseFloat(fields[3], 64)
+		if err := firstError(err1, err2, err3); err != nil {
+			return nil, fmt.Errorf("cbsgo: population CNV line %d: %w", line, err)
+		}
+		if end < start {
+			return nil, fmt.Errorf("cbsgo: population CNV line %d: end %d before start %d", line, end, start)
+		}
+		if !(freq >= 0 && freq <= 1) {
+			return nil, fmt.Errorf("cbsgo: population CNV line %d: frequency %v outside [0, 1]", line, freq)
+		}
+		cnvs = append(cnvs, Segment{Chrom: fields[0], Start: start, End: end, Mean: freq})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return &PopulationCNVs{index: newSegmentIndex(cnvs)}, nil
+}
+
+// Frequency returns the highest frequency of the population CNVs covering at
+// least the fraction minOverlap of s, or 0 if there are none, so that
+// segments spanning a common CNV and much more are not taken for it.
+func (p *PopulationCNVs) Frequency(s Segment, minOverlap float64) float64 {
+	var freq float64
+	for _, c := range p.index.overlapping(s) {
+		ov := min(s.End, c.End) - max(s.Start, c.Start)
+		if float64(ov) >= minOverlap*float64(s.Len()) {
+			freq = max(freq, c.Mean)
+		}
+	}
+	return freq
+}
+
+// Annotate returns copies of the segments with their PopulationFrequency set
+// by Frequency.
+func (p *PopulationCNVs) Annotate(segments []Segment, minOverlap float64) []Segment {
+	res := make([]Segment, len(segments))
+	for i, s := range segments {
+		s.PopulationFrequency = p.Frequency(s, minOverlap)
+		res[i] = s
+	}
+	return res
+}
+
+// Filter splits the segments into those that are kept and those matching a
+// common germline CNV, with a positive Frequency of at least minFrequency.
+// Neutral segments, called as such with CallStates, are always kept. Both
+// keep the order of the segments.
+func (p *PopulationCNVs) Filter(segments []Segment, minOverlap, minFrequency float64) (kept, common []Segment) {
+	for _, s := range segments {
+		if f := p.Frequency(s, minOverlap); s.State != Neutral && f > 0 && f >= minFrequency {
+			common = append(common, s)
+		} else {
+			kept = append(kept, s)
+		}
+	}
+	return kept, common
+}
@@ -0,0 +1,47 @@
+package cbsgo_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestPopulationCNVs(t *testing.T) {
+	resource := "#chrom\tstart\tend\tfrequency\nchr1\t1000\t2000\t0.2\nchr1\t1500\t1800\t0.6\nchr2\t0\t500\t0.001\n"
+	p, err := cbsgo.ReadPopulationCNVs(strings.NewReader(resource))
+	if err != nil {
+		t.Fatalf("ReadPopulationCNVs returned an unexpected error: %v", err)
+	}
+
+	segments := []cbsgo.Segment{
+		{Chrom: "chr1", Start: 0, End: 1000, State: cbsgo.Neutral},
+		{Chrom: "chr1", Start: 1000, End: 2000, State: cbsgo.Loss},
+		{Chrom: "chr1", Start: 2000, End: 3000, State: cbsgo.Neutral},
+		{Chrom: "chr1", Start: 3000, End: 10000, State: cbsgo.Gain},
+		{Chrom: "chr2", Start: 0, End: 400, State: cbsgo.Gain},
+	}
+	var got []float64
+	for _, s := range p.Annotate(segments, 0.5) {
+		got = append(got, s.PopulationFrequency)
+	}
+	// The CNV with a frequency of 0.6 covers less than half of the loss.
+	if expected := []float64{0, 0.2, 0, 0, 0.001}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+	}
+	if f := p.Frequency(segments[1], 0.3); f != 0.6 {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", 0.6, f)
+	}
+
+	kept, common := p.Filter(segments, 0.5, 0.01)
+	if !reflect.DeepEqual(common, segments[1:2]) || len(kept) != 4 {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", segments[1:2], common)
+	}
+
+	for _, invalid := range []string{"chr1\t0\t10\n", "chr1\t0\t10\t1.5\n", "chr1\t10\t0\t0.5\n", "chr1\t0\t10\tcommon\n"} {
+		if _, err := cbsgo.ReadPopulationCNVs(strings.NewReader(invalid)); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
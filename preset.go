@@ -0,0 +1,106 @@
+package cbsgo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Preset bundles the recommended settings for an assay. Select one with
+// WithPreset, and use BinSize when counting reads into bins.
+type Preset struct {
+	Name        string
+	Description string
+
+	// BinSize is the recommended bin size in base pairs, or 0 when the bins
+	// are given by the assay, such as capture targets or array probes.
+	BinSize int
+
+	Alpha    float64
+	Shuffles int
+
+	// SmoothRegion, OutlierSD and SmoothSD are the arguments of
+	// WithSmoothing, disabled if SmoothRegion is 0.
+	SmoothRegion int
+	OutlierSD    float64
+	SmoothSD     float64
+
+	// MinWidth is the argument of WithMinWidth and UndoSD that of
+	// WithUndoSD, disabled if 0.
+	MinWidth int
+	UndoSD   float64
+}
+
+// presets lists the built-in presets.
+var presets = []Preset{
+	{
+		Name:         "sWGS",
+		Description:  "shallow whole-genome sequencing with 50 kb bins",
+		BinSize:      50_000,
+		Alpha:        0.01,
+		Shuffles:     10000,
+		SmoothRegion: 10, OutlierSD: 4, SmoothSD: 2,
+		MinWidth: 3,
+		UndoSD:   1,
+	},
+	{
+		Name:         "WES",
+		Description:  "whole-exome sequencing, binned by capture target",
+		Alpha:        0.01,
+		Shuffles:     10000,
+		SmoothRegion: 10, OutlierSD: 4, SmoothSD: 2,
+		MinWidth: 5,
+		UndoSD:   2,
+	},
+	{
+		Name:         "aCGH",
+		Description:  "array comparative genomic hybridization, with the settings of DNAcopy",
+		Alpha:        0.01,
+		Shuffles:     10000,
+		SmoothRegion: 10, OutlierSD: 4, SmoothSD: 2,
+		MinWidth: 2,
+	},
+}
+
+// Presets returns the built-in presets.
+func Presets() []Preset {
+	return append([]Preset(nil), presets...)
+}
+
+// LookupPreset returns the built-in preset of the given name, ignoring case.
+func LookupPreset(name string) (Preset, error) {
+	names := make([]string, len(presets))
+	for i, p := range presets {
+		if strings.EqualFold(p.Name, name) {
+			return p, nil
+		}
+		names[i] = p.Name
+	}
+	return Preset{}, fmt.Errorf("cbsgo: unknown preset %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+// Options returns the options applying the settings of p.
+func (p Preset) Options() []Option {
+	return []Option{
+		WithAlpha(p.Alpha),
+		WithShuffles(p.Shuffles),
+		WithSmoothing(p.SmoothRegion, p.OutlierSD, p.SmoothSD),
+		WithMinWidth(p.MinWidth),
+		WithUndoSD(p.UndoSD),
+	}
+}
+
+// WithPreset applies the settings of the named built-in preset, such as
+// "sWGS". Options following it override its settings. An unknown name fails
+// the run.
+func WithPreset(name string) Option {
+	return func(c *config) {
+		p, err := LookupPreset(name)
+		if err != nil {
+			c.err = err
+			return
+		}
+		for _, opt := range p.Options() {
+			opt(c)
+		}
+	}
+}
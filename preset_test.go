@@ -0,0 +1,61 @@
+package cbsgo_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestLookupPreset(t *testing.T) {
+	for _, p := range cbsgo.Presets() {
+		got, err := cbsgo.LookupPreset(p.Name)
+		if err != nil {
+			t.Fatalf("LookupPreset returned an unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(got, p) {
+			t.Errorf("Unexpected result.\nExpected: %+v\nGot: %+v", p, got)
+		}
+	}
+	p, err := cbsgo.LookupPreset("swgs")
+	if err != nil || p.BinSize != 50_000 {
+		t.Errorf("Expected the sWGS preset with 50 kb bins, got %+v, %v", p, err)
+	}
+	if _, err := cbsgo.LookupPreset("nanopore"); err == nil {
+		t.Errorf("Expected an error for an unknown preset")
+	}
+}
+
+func TestWithPreset(t *testing.T) {
+	x := make([]float64, 200)
+	for i := range x {
+		x[i] = math.Sin(float64(i)) / 4
+		if i >= 80 && i < 120 {
+			x[i] += 1
+		}
+	}
+
+	p, err := cbsgo.LookupPreset("WES")
+	if err != nil {
+		t.Fatalf("LookupPreset returned an unexpected error: %v", err)
+	}
+	expected, err := cbsgo.Run(x, append(p.Options(), cbsgo.WithSeed(42))...)
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	got, err := cbsgo.Run(x, cbsgo.WithPreset("WES"), cbsgo.WithSeed(42))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+	}
+	if len(got.Segments) != 3 {
+		t.Errorf("Expected 3 segments, got %v", got.Segments)
+	}
+
+	if _, err := cbsgo.Run(x, cbsgo.WithPreset("nanopore")); err == nil {
+		t.Errorf("Expected an error for an unknown preset")
+	}
+}
@@ -0,0 +1,146 @@
+// Package promql queries the series of a Prometheus server over its HTTP
+// API, to detect level shifts in with timeseries.Detect. It depends on the
+// standard library only; the cbsprom module exposes the statistics of runs as
+// Prometheus metrics.
+package promql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattdsm/cbsgo/timeseries"
+)
+
+// Client queries the HTTP API of a Prometheus server for the series to
+// segment with timeseries.Detect.
+type Client struct {
+	// URL is the base URL of the server, such as "http://localhost:9090".
+	URL string
+	// Client is the client of the requests, or nil for http.DefaultClient.
+	Client *http.Client
+}
+
+// Series is a series of a query result.
+type Series struct {
+	// Labels identifies the series.
+	Labels map[string]string
+	Points []timeseries.Point
+}
+
+// Name returns the labels of the series in the notation of PromQL, such as
+// up{instance="a:9100",job="node"}, with the metric name first and the other
+// labels sorted.
+func (s Series) Name() string {
+	var labels []string
+	for k, v := range s.Labels {
+		if k != "__name__" {
+			labels = append(labels, k+"="+strconv.Quote(v))
+		}
+	}
+	slices.Sort(labels)
+	return s.Labels["__name__"] + "{" + strings.Join(labels, ",") + "}"
+}
+
+// queryResponse is the response of the HTTP API to a range query.
+type queryResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string    `json:"metric"`
+			Values [][2]json.RawMessage `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// QueryRange evaluates the PromQL query over the time range [start, end] at
+// the resolution step with the query_range endpoint, and returns the series
+// of the result in the order of the server. Missing samples leave gaps in the
+// series, which timeseries.Detect takes into account.
+func (c *Client) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) ([]Series, error) {
+	if step <= 0 {
+		return nil, fmt.Errorf("cbsgo: invalid query step %v", step)
+	}
+	form := url.Values{
+		"query": {query},
+		"start": {formatTime(start)},
+		"end":   {formatTime(end)},
+		"step":  {strconv.FormatFloat(step.Seconds(), 'f', -1, 64)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.URL, "/")+"/api/v1/query_range", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Failed queries are described in the body of the response.
+	var r queryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("cbsgo: querying Prometheus: unexpected status %s", resp.Status)
+		}
+		return nil, fmt.Errorf("cbsgo: decoding the Prometheus response: %w", err)
+	}
+	if r.Status != "success" {
+		return nil, fmt.Errorf("cbsgo: querying Prometheus: %s: %s", r.ErrorType, r.Error)
+	}
+	if r.Data.ResultType != "matrix" {
+		return nil, fmt.Errorf("cbsgo: unexpected Prometheus result type %q", r.Data.ResultType)
+	}
+
+	series := make([]Series, len(r.Data.Result))
+	for i, res := range r.Data.Result {
+		s := Series{Labels: res.Metric, Points: make([]timeseries.Point, len(res.Values))}
+		for j, v := range res.Values {
+			var (
+				ts    float64
+				value string
+			)
+			if err := firstError(json.Unmarshal(v[0], &ts), json.Unmarshal(v[1], &value)); err != nil {
+				return nil, fmt.Errorf("cbsgo: decoding sample %d of %s: %w", j, s.Name(), err)
+			}
+			x, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("cbsgo: decoding sample %d of %s: %w", j, s.Name(), err)
+			}
+			sec, frac := math.Modf(ts)
+			s.Points[j] = timeseries.Point{Time: time.Unix(int64(sec), int64(math.Round(frac*1e9))).UTC(), Value: x}
+		}
+		series[i] = s
+	}
+	return series, nil
+}
+
+// formatTime formats t as a Unix timestamp in seconds.
+func formatTime(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixMilli())/1000, 'f', -1, 64)
+}
+
+// firstError returns the first non-nil error of errs.
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package promql_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mattdsm/cbsgo/promql"
+	"github.com/mattdsm/cbsgo/timeseries"
+)
+
+func TestQueryRange(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query_range" || r.FormValue("query") != "up" || r.FormValue("start") != "1700000000" ||
+			r.FormValue("end") != "1700000060.5" || r.FormValue("step") != "30" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"unexpected request"}`))
+			return
+		}
+		w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[
+			{"metric":{"__name__":"up","job":"api","instance":"a:80"},"values":[[1700000000,"1"],[1700000030.5,"NaN"]]}]}}`))
+	}))
+	defer srv.Close()
+
+	c := &promql.Client{URL: srv.URL + "/"}
+	start := time.Unix(1700000000, 0)
+	series, err := c.QueryRange(context.Background(), "up", start, start.Add(60500*time.Millisecond), 30*time.Second)
+	if err != nil {
+		t.Fatalf("QueryRange returned an unexpected error: %v", err)
+	}
+	if len(series) != 1 {
+		t.Fatalf("Expected a single series, got %v", series)
+	}
+	if name := series[0].Name(); name != `up{instance="a:80",job="api"}` {
+		t.Errorf("Unexpected name %s", name)
+	}
+	points := series[0].Points
+	expected := []timeseries.Point{{Time: start.UTC(), Value: 1}, {Time: start.Add(30500 * time.Millisecond).UTC()}}
+	if len(points) != 2 || points[1].Value == points[1].Value {
+		t.Fatalf("Unexpected result.\nExpected: %v\nGot: %v", expected, points)
+	}
+	points[1].Value = 0
+	if !reflect.DeepEqual(points, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, points)
+	}
+
+	if _, err := c.QueryRange(context.Background(), "down", start, start.Add(time.Minute), 30*time.Second); err == nil {
+		t.Error("Expected an error")
+	}
+}
@@ -0,0 +1,261 @@
+package cbsgo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// RecurrenceOptions configures AnalyzeRecurrence.
+type RecurrenceOptions struct {
+	// Gain and Loss are the segment means above and below which a bin is
+	// considered gained or lost in a sample.
+	Gain float64
+	Loss float64
+
+	// Permutations is the number of permutations used to assess the
+	// significance of the alteration frequencies, and Alpha the genome-wide
+	// significance level.
+	Permutations int
+	Alpha        float64
+	// Seed seeds the permutations; 0 selects a time-based seed.
+	Seed int64
+}
+
+// DefaultRecurrenceOptions returns options suited to log2 ratios.
+func DefaultRecurrenceOptions() RecurrenceOptions {
+	return RecurrenceOptions{
+		Gain:         0.2,
+		Loss:         -0.2,
+		Permutations: 1000,
+		Alpha:        0.05,
+	}
+}
+
+// Recurrence holds the alteration frequencies of a cohort.
+type Recurrence struct {
+	// Bins is the common grid on which the samples are compared.
+	Bins SegmentSet
+	// States holds, for every sample and bin, +1 for a gain, -1 for a loss,
+	// and 0 otherwise.
+	States [][]int8
+	// Gain and Loss hold the fraction of samples with a gain or a loss of each bin.
+	Gain []float64
+	Loss []float64
+
+	// GainThreshold and LossThreshold are the frequencies from which a gain or
+	// a loss is significantly recurrent at the genome-wide level.
+	GainThreshold float64
+	LossThreshold float64
+	// Regions lists the significantly recurrent regions in bin order.
+	Regions []RecurrentRegion
+}
+
+// RecurrentRegion is a run of adjacent bins gained or lost significantly often.
+type RecurrentRegion struct {
+	Chrom string
+	Start int
+	End   int
+
+	// Gain is set for recurrent gains and unset for recurrent losses.
+	Gain bool
+	// Frequency is the highest alteration frequency of the bins of the region.
+	Frequency float64
+	// PValue is the genome-wide permutation p-value of Frequency.
+	PValue float64
+}
+
+// Bins tiles the genome with bins of the given size; the last bin of each
+// chromosome may be shorter. A size that is not positive gives no bins, which
+// the analyses taking bins reject.
+func (g Genome) Bins(size int) SegmentSet {
+	if size <= 0 {
+		return nil
+	}
+	var bins SegmentSet
+	for _, c := range g {
+		for start := 0; start < c.Length; start += size {
+			end := min(start+size, c.Length)
+			bins = append(bins, Segment{Chrom: c.Name, Start: start, End: end, BinStart: len(bins), BinEnd: len(bins) + 1})
+		}
+	}
+	return bins
+}
+
+// AnalyzeRecurrence computes per-bin gain and loss frequencies across the
+// segmentations of a cohort, one SegmentSet per sample, and identifies
+// recurrently altered regions.
+//
+// Each bin takes the state of the segment of a sample overlapping it most.
+// Significance is assessed genome-wide by circularly shifting the states of
+// every sample by a random offset, which preserves the size of the events,
+// and comparing the highest frequency over all bins to the observed ones.
+func AnalyzeRecurrence(samples []SegmentSet, bins SegmentSet, opts RecurrenceOptions) (*Recurrence, error) {
+	if len(samples) == 0 || len(bins) == 0 {
+		return nil, errors.New("cbsgo: recurrence analysis needs samples and bins")
+	}
+	if opts.Permutations < 1 {
+		return nil, fmt.Errorf("cbsgo: invalid number of permutations %d", opts.Permutations)
+	}
+
+	r := &Recurrence{Bins: bins, States: make([][]int8, len(samples))}
+	for i, set := range samples {
+		r.States[i] = binStates(set, bins, opts.Gain, opts.Loss)
+	}
+	r.Gain, r.Loss = frequencies(r.States, nil)
+
+	// Null distributions of the highest frequencies.
+	rng := newRand(opts.Seed)
+	maxGain := make([]float64, opts.Permutations)
+	maxLoss := make([]float64, opts.Permutations)
+	offsets := make([]int, len(samples))
+	for p := range maxGain {
+		for i := range offsets {
+			offsets[i] = rng.Intn(len(bins))
+		}
+		gain, loss := frequencies(r.States, offsets)
+		maxGain[p], maxLoss[p] = maxOf(gain), maxOf(loss)
+	}
+	sort.Float64s(maxGain)
+	sort.Float64s(maxLoss)
+	r.GainThreshold = upperQuantile(maxGain, opts.Alpha)
+	r.LossThreshold = upperQuantile(maxLoss, opts.Alpha)
+
+	r.Regions = append(r.regions(r.Gain, r.GainThreshold, true, maxGain), r.regions(r.Loss, r.LossThreshold, false, maxLoss)...)
+	sort.SliceStable(r.Regions, func(i, j int) bool {
+		a, b := r.Regions[i], r.Regions[j]
+		if a.Chrom != b.Chrom {
+			return chromLess(a.Chrom, b.Chrom)
+		}
+		return a.Start < b.Start
+	})
+	return r, nil
+}
+
+// regions returns the runs of adjacent bins with a frequency of at least
+// threshold.
+func (r *Recurrence) regions(freq []float64, threshold float64, gain bool, null []float64) []RecurrentRegion {
+	significant := func(f float64) bool { return f > 0 && f >= threshold }
+	var res []RecurrentRegion
+	for i := 0; i < len(freq); i++ {
+		if !significant(freq[i]) {
+			continue
+		}
+		region := RecurrentRegion{Chrom: r.Bins[i].Chrom, Start: r.Bins[i].Start, Gain: gain}
+		for ; i < len(freq) && significant(freq[i]) && r.Bins[i].Chrom == region.Chrom; i++ {
+			region.End = r.Bins[i].End
+			region.Frequency = max(region.Frequency, freq[i])
+		}
+		i--
+		// The fraction of permutations reaching the peak frequency anywhere.
+		exceed := len(null) - sort.SearchFloat64s(null, region.Frequency)
+		region.PValue = float64(1+exceed) / float64(1+len(null))
+		res = append(res, region)
+	}
+	return res
+}
+
+// WriteMatrix writes the bins as tab-separated values with a header, giving
+// for each bin its coordinates, the gain and loss frequencies, and the state
+// of every sample. names holds the sample names used in the header.
+func (r *Recurrence) WriteMatrix(w io.Writer, names []string) error {
+	if len(names) != len(r.States) {
+		return fmt.Errorf("cbsgo: got %d names for %d samples", len(names), len(r.States))
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprint(bw, "chrom\tstart\tend\tgain\tloss")
+	for _, name := range names {
+		fmt.Fprintf(bw, "\t%s", name)
+	}
+	fmt.Fprintln(bw)
+	for j, bin := range r.Bins {
+		fmt.Fprintf(bw, "%s\t%d\t%d\t%g\t%g", bin.Chrom, bin.Start, bin.End, r.Gain[j], r.Loss[j])
+		for _, states := range r.States {
+			fmt.Fprintf(bw, "\t%d", states[j])
+		}
+		fmt.Fprintln(bw)
+	}
+	return bw.Flush()
+}
+
+// WriteBED writes the recurrent regions in BED format, with the name column
+// holding "gain" or "loss" and the score column the peak frequency scaled to
+// [0, 1000].
+func (r *Recurrence) WriteBED(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, region := range r.Regions {
+		name := "loss"
+		if region.Gain {
+			name = "gain"
+		}
+		fmt.Fprintf(bw, "%s\t%d\t%d\t%s\t%d\n", region.Chrom, region.Start, region.End, name, int(1000*region.Frequency+0.5))
+	}
+	return bw.Flush()
+}
+
+// binStates returns the state of every bin according to the segments of set.
+func binStates(set SegmentSet, bins SegmentSet, gain, loss float64) []int8 {
+	idx := newSegmentIndex(set)
+	states := make([]int8, len(bins))
+	for j, bin := range bins {
+		best := -1
+		var mean float64
+		for _, s := range idx.overlapping(bin) {
+			if ov := min(s.End, bin.End) - max(s.Start, bin.Start); ov > best {
+				best, mean = ov, s.Mean
+			}
+		}
+		switch {
+		case best < 0:
+		case mean > gain:
+			states[j] = 1
+		case mean < loss:
+			states[j] = -1
+		}
+	}
+	return states
+}
+
+// frequencies returns the per-bin gain and loss frequencies, with the states
+// of sample i circularly shifted by offsets[i] unless offsets is nil.
+func frequencies(states [][]int8, offsets []int) ([]float64, []float64) {
+	n := len(states[0])
+	gain := make([]float64, n)
+	loss := make([]float64, n)
+	for i, s := range states {
+		shift := 0
+		if offsets != nil {
+			shift = offsets[i]
+		}
+		for j, v := range s {
+			k := (j + shift) % n
+			switch v {
+			case 1:
+				gain[k]++
+			case -1:
+				loss[k]++
+			}
+		}
+	}
+	for j := range gain {
+		gain[j] /= float64(len(states))
+		loss[j] /= float64(len(states))
+	}
+	return gain, loss
+}
+
+// upperQuantile returns the value exceeded by a fraction alpha of the sorted x.
+func upperQuantile(x []float64, alpha float64) float64 {
+	i := int(float64(len(x)) * (1 - alpha))
+	return x[min(i, len(x)-1)]
+}
+
+func maxOf(x []float64) float64 {
+	m := x[0]
+	for _, v := range x[1:] {
+		m = max(m, v)
+	}
+	return m
+}
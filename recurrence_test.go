@@ -0,0 +1,98 @@
+package cbsgo_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestGenomeBins(t *testing.T) {
+	genome := cbsgo.Genome{{Name: "chr1", Length: 250}, {Name: "chr2", Length: 100}}
+	bins := genome.Bins(100)
+	expected := [][2]int{{0, 100}, {100, 200}, {200, 250}, {0, 100}}
+	if len(bins) != len(expected) {
+		t.Fatalf("Expected %d bins, got %v", len(expected), bins)
+	}
+	for i, b := range bins {
+		if b.Start != expected[i][0] || b.End != expected[i][1] {
+			t.Errorf("Bin %d: expected %v, got %+v", i, expected[i], b)
+		}
+	}
+	for _, size := range []int{0, -100} {
+		if bins := genome.Bins(size); len(bins) != 0 {
+			t.Errorf("Size %d: expected no bins, got %v", size, bins)
+		}
+	}
+}
+
+func TestAnalyzeRecurrence(t *testing.T) {
+	genome := cbsgo.Genome{{Name: "chr1", Length: 1000}, {Name: "chr2", Length: 1000}}
+	bins := genome.Bins(100)
+
+	// Every sample gains chr1:200-400, and loses a different 100 bp of chr2.
+	var samples []cbsgo.SegmentSet
+	for i := 0; i < 10; i++ {
+		loss := 100 * i
+		samples = append(samples, cbsgo.SegmentSet{
+			{Chrom: "chr1", Start: 0, End: 200},
+			{Chrom: "chr1", Start: 200, End: 400, Mean: 0.6},
+			{Chrom: "chr1", Start: 400, End: 1000},
+			{Chrom: "chr2", Start: 0, End: loss},
+			{Chrom: "chr2", Start: loss, End: loss + 100, Mean: -1},
+			{Chrom: "chr2", Start: loss + 100, End: 1000},
+		})
+	}
+
+	opts := cbsgo.DefaultRecurrenceOptions()
+	opts.Seed = 1
+	r, err := cbsgo.AnalyzeRecurrence(samples, bins, opts)
+	if err != nil {
+		t.Fatalf("AnalyzeRecurrence returned an unexpected error: %v", err)
+	}
+
+	if r.Gain[2] != 1 || r.Gain[0] != 0 {
+		t.Errorf("Unexpected gain frequencies %v", r.Gain)
+	}
+	if r.Loss[10] != 0.1 {
+		t.Errorf("Unexpected loss frequencies %v", r.Loss)
+	}
+	if len(r.Regions) != 1 {
+		t.Fatalf("Expected a single recurrent region, got %+v", r.Regions)
+	}
+	region := r.Regions[0]
+	if region.Chrom != "chr1" || region.Start != 200 || region.End != 400 || !region.Gain || region.Frequency != 1 {
+		t.Errorf("Unexpected region %+v", region)
+	}
+	if region.PValue > 0.01 {
+		t.Errorf("Expected a significant region, got p = %v", region.PValue)
+	}
+
+	var bed bytes.Buffer
+	if err := r.WriteBED(&bed); err != nil {
+		t.Fatalf("WriteBED returned an unexpected error: %v", err)
+	}
+	if got := bed.String(); got != "chr1\t200\t400\tgain\t1000\n" {
+		t.Errorf("Unexpected BED output %q", got)
+	}
+
+	names := make([]string, len(samples))
+	for i := range names {
+		names[i] = string(rune('a' + i))
+	}
+	var matrix bytes.Buffer
+	if err := r.WriteMatrix(&matrix, names); err != nil {
+		t.Fatalf("WriteMatrix returned an unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(matrix.String()), "\n")
+	if len(lines) != len(bins)+1 {
+		t.Fatalf("Expected %d lines, got %d", len(bins)+1, len(lines))
+	}
+	if lines[0] != "chrom\tstart\tend\tgain\tloss\ta\tb\tc\td\te\tf\tg\th\ti\tj" {
+		t.Errorf("Unexpected header %q", lines[0])
+	}
+	if lines[3] != "chr1\t200\t300\t1\t0\t1\t1\t1\t1\t1\t1\t1\t1\t1\t1" {
+		t.Errorf("Unexpected line %q", lines[3])
+	}
+}
@@ -0,0 +1,36 @@
+package cbsgo
+
+import (
+	"fmt"
+	"math"
+)
+
+// Refit keeps the breakpoints of segments fixed and recomputes the segment
+// means and standard deviations from y, for instance to apply the breakpoints
+// of a tumor sample to a relapse sample so that both have the same boundaries.
+//
+// y must be measured on the same bins as the data the segments were derived
+// from. NaN values in y are treated as missing; the mean of a segment without
+// any value is NaN. The returned segments are copies of segments, with all
+// fields other than Mean and SD preserved.
+func Refit(segments []Segment, y []float64) ([]Segment, error) {
+	res := make([]Segment, len(segments))
+	for i, s := range segments {
+		if s.BinStart < 0 || s.BinEnd > len(y) || s.BinStart > s.BinEnd {
+			return nil, fmt.Errorf("cbsgo: segment %d spans bins [%d, %d) outside of %d values", i, s.BinStart, s.BinEnd, len(y))
+		}
+
+		values := make([]float64, 0, s.BinEnd-s.BinStart)
+		for _, v := range y[s.BinStart:s.BinEnd] {
+			if !math.IsNaN(v) {
+				values = append(values, v)
+			}
+		}
+		s.Mean, s.SD = math.NaN(), math.NaN()
+		if len(values) > 0 {
+			s.Mean, s.SD = meanSD(values, nil)
+		}
+		res[i] = s
+	}
+	return res, nil
+}
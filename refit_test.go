@@ -0,0 +1,44 @@
+package cbsgo_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestRefit(t *testing.T) {
+	tumor := []float64{1, 1, 1, 3, 3, 2, 1, 2, 3, 300, 310, 321, 310, 299}
+	relapse := []float64{2, 2, 2, 2, 2, 2, 2, 2, 4, 4, math.NaN(), 6, 6, 5}
+
+	res, err := cbsgo.Run(tumor, cbsgo.WithSeed(42))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	refit, err := cbsgo.Refit(res.Segments, relapse)
+	if err != nil {
+		t.Fatalf("Refit returned an unexpected error: %v", err)
+	}
+
+	if len(refit) != len(res.Segments) {
+		t.Fatalf("Expected %d segments, got %d", len(res.Segments), len(refit))
+	}
+	for i, s := range refit {
+		if s.BinStart != res.Segments[i].BinStart || s.BinEnd != res.Segments[i].BinEnd {
+			t.Errorf("Segment %d: breakpoints moved from %+v to %+v", i, res.Segments[i], s)
+		}
+	}
+	if s := refit[0]; s.Mean != 2 || s.SD != 0 {
+		t.Errorf("Expected mean 2 and SD 0 for the first segment, got %v and %v", s.Mean, s.SD)
+	}
+	if s := refit[1]; s.Mean != 5 || math.Abs(s.SD-1) > 1e-12 {
+		t.Errorf("Expected mean 5 and SD 1 for the second segment, got %v and %v", s.Mean, s.SD)
+	}
+}
+
+func TestRefitOutOfRange(t *testing.T) {
+	segments := []cbsgo.Segment{{BinStart: 0, BinEnd: 10}}
+	if _, err := cbsgo.Refit(segments, make([]float64, 5)); err == nil {
+		t.Errorf("Expected an error for a segment beyond the data")
+	}
+}
@@ -0,0 +1,77 @@
+package cbsgo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// MergeReplicates merges technical replicates of a track, measured on the
+// same bins, into a single track with more power than any of them.
+//
+// The value of every bin is the mean of the replicate values weighted by
+// their inverse variances, and its weight the inverse of the variance of
+// that mean, the sum of the inverse variances, so that Track.Segment weights
+// every bin by its precision. The weights of a replicate, if set, are the
+// inverse variances of its bins; otherwise the variance of the replicate is
+// estimated by DiffMAD. Masked bins of a replicate are left out, and bins
+// masked in every replicate are masked in the result.
+//
+// The merged track has the chromosome and positions of the first replicate.
+// As its weights replace the bin widths, segment means are not weighted by
+// bin width.
+func MergeReplicates(replicates []*Track) (*Track, error) {
+	if len(replicates) == 0 {
+		return nil, errors.New("cbsgo: no replicates to merge")
+	}
+	first := replicates[0]
+	n := first.Len()
+	precisions := make([][]float64, len(replicates))
+	for i, r := range replicates {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+		if r.Chrom != first.Chrom || r.Len() != n {
+			return nil, fmt.Errorf("cbsgo: replicate %d of track %s with %d bins does not match track %s with %d bins",
+				i, r.Chrom, r.Len(), first.Chrom, n)
+		}
+		if r.Weights != nil {
+			precisions[i] = r.Weights
+			continue
+		}
+		sd := DiffMAD(r.Compact().Values)
+		if !(sd > 0) {
+			return nil, fmt.Errorf("cbsgo: cannot estimate the variance of replicate %d of track %s", i, r.Chrom)
+		}
+		precisions[i] = make([]float64, n)
+		for j := range precisions[i] {
+			precisions[i][j] = 1 / (sd * sd)
+		}
+	}
+
+	res := &Track{Chrom: first.Chrom, Starts: first.Starts, Ends: first.Ends,
+		Values: make([]float64, n), Weights: make([]float64, n)}
+	for j := range n {
+		var sum, precision float64
+		for i, r := range replicates {
+			if r.Mask != nil && r.Mask[j] {
+				continue
+			}
+			p := precisions[i][j]
+			if !(p > 0) || math.IsInf(p, 1) {
+				return nil, fmt.Errorf("cbsgo: bin %d of replicate %d of track %s has invalid weight %v", j, i, r.Chrom, p)
+			}
+			sum += p * r.Values[j]
+			precision += p
+		}
+		if precision == 0 {
+			if res.Mask == nil {
+				res.Mask = make([]bool, n)
+			}
+			res.Mask[j] = true
+			continue
+		}
+		res.Values[j], res.Weights[j] = sum/precision, precision
+	}
+	return res, nil
+}
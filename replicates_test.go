@@ -0,0 +1,75 @@
+package cbsgo_test
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestMergeReplicates(t *testing.T) {
+	a := &cbsgo.Track{Chrom: "chr1", Values: []float64{1, 2, 3, 4}, Weights: []float64{1, 1, 1, 1}}
+	b := &cbsgo.Track{Chrom: "chr1", Values: []float64{3, 4, 5, 6}, Weights: []float64{3, 3, 3, 3}, Mask: []bool{1: true, 3: true}}
+	c := &cbsgo.Track{Chrom: "chr1", Values: []float64{0, 0, 0, 0}, Weights: []float64{1, 1, 1, 1}, Mask: []bool{3: true}}
+	a.Mask = []bool{3: true}
+
+	res, err := cbsgo.MergeReplicates([]*cbsgo.Track{a, b, c})
+	if err != nil {
+		t.Fatalf("MergeReplicates returned an unexpected error: %v", err)
+	}
+	// Bin 0: (1 + 3*3 + 0) / 5, bin 1: (2 + 0) / 2, bin 2: (3 + 3*5 + 0) / 5.
+	expected := &cbsgo.Track{Chrom: "chr1", Values: []float64{2, 1, 3.6, 0}, Weights: []float64{5, 2, 5, 0},
+		Mask: []bool{false, false, false, true}}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("Unexpected result.\nExpected: %+v\nGot: %+v", expected, res)
+	}
+
+	for name, replicates := range map[string][]*cbsgo.Track{
+		"none":     nil,
+		"length":   {a, {Chrom: "chr1", Values: []float64{1}}},
+		"chrom":    {a, {Chrom: "chr2", Values: []float64{1, 2, 3, 4}}},
+		"constant": {{Chrom: "chr1", Values: []float64{1, 1, 1, 1}}},
+		"weight":   {{Chrom: "chr1", Values: []float64{1, 2}, Weights: []float64{1, -1}}},
+	} {
+		if _, err := cbsgo.MergeReplicates(replicates); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestMergeReplicatesPower(t *testing.T) {
+	// A shift too small to be called in a single replicate.
+	rng := rand.New(rand.NewSource(6))
+	replicate := func(sd float64) *cbsgo.Track {
+		x := make([]float64, 200)
+		for i := range x {
+			x[i] = sd * rng.NormFloat64()
+			if i >= 100 {
+				x[i] += 0.4
+			}
+		}
+		return &cbsgo.Track{Chrom: "chr1", Values: x}
+	}
+	replicates := []*cbsgo.Track{replicate(1.2), replicate(1.2), replicate(1.2), replicate(1.2), replicate(5)}
+
+	merged, err := cbsgo.MergeReplicates(replicates)
+	if err != nil {
+		t.Fatalf("MergeReplicates returned an unexpected error: %v", err)
+	}
+	res, err := merged.Segment(cbsgo.WithSeed(42), cbsgo.WithAlpha(0.01))
+	if err != nil {
+		t.Fatalf("Segment returned an unexpected error: %v", err)
+	}
+	if len(res.Segments) != 2 || math.Abs(float64(res.Segments[1].BinStart-100)) > 5 {
+		t.Errorf("Expected a shift at bin 100, got %v", res.Segments)
+	}
+	single, err := replicates[0].Segment(cbsgo.WithSeed(42), cbsgo.WithAlpha(0.01))
+	if err != nil {
+		t.Fatalf("Segment returned an unexpected error: %v", err)
+	}
+	if len(single.Segments) != 1 {
+		t.Errorf("Expected no shift in a single replicate, got %v", single.Segments)
+	}
+}
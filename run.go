@@ -0,0 +1,539 @@
+package cbsgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"time"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// Result holds the outcome of a segmentation run.
+type Result struct {
+	// Segments lists the segments in order of position.
+	Segments []Segment
+
+	// Breakpoints lists the breakpoints observed across runs when consensus
+	// segmentation is enabled with WithConsensus, in order of position.
+	Breakpoints []Breakpoint
+
+	// Fit evaluates the fit of the segmentation when enabled with WithFit.
+	Fit *Fit
+
+	// Explain lists the tests for changepoints when enabled with
+	// WithExplain.
+	Explain []Candidate
+}
+
+// segmenter holds the state of a single segmentation run.
+type segmenter struct {
+	cfg *config
+	rng *rand.Rand
+
+	x []float64
+	// raw holds the values before WithTransform, which summarize the
+	// segments, or nil without transform.
+	raw []float64
+	// w holds the bin weights or widths, or nil when all bins weigh the same.
+	w []float64
+
+	// samples holds the samples of a joint segmentation, in which case x is
+	// the first sample, and scale the standard deviation of each sample.
+	samples [][]float64
+	scale   []float64
+
+	segments collector
+	buf      *scratch
+	// mean is the overall mean of x, needed by WithFit.
+	mean float64
+	// pvalues holds the p-values of the splits introducing breakpoints, by
+	// bin, when needed by WithBreakpoints.
+	pvalues map[int]float64
+	// emit is called for every segment as soon as it is added, if set.
+	emit func(start, end int) error
+
+	// ctx ends the run early when done, in which case interrupted holds its
+	// error and unexplored the start bins of the segments that were not tested
+	// for changepoints.
+	ctx         context.Context
+	interrupted error
+	unexplored  map[int]bool
+
+	// spikes holds the bins split off as spikes by WithSpikes.
+	spikes map[int]bool
+
+	// depth is the recursion depth of the interval under test, and tested
+	// the number of intervals tested, for WithAlphaAdjustment.
+	depth  int
+	tested int
+
+	// candidates holds the candidate breakpoints of the Haar screen, if
+	// enabled.
+	candidates []int
+
+	// explained lists the tests of the run, if enabled by WithExplain, and
+	// rejected holds the rejected split of every segment tested as a whole
+	// by its bounds.
+	explained []Candidate
+	rejected  map[[2]int]Candidate
+
+	// stats collects the statistics reported to the metrics recorder.
+	stats RunStats
+}
+
+// Run performs Circular Binary Segmentation on x, configured by opts.
+//
+// Segments are reported in order of position. Without WithPositions their
+// coordinates are bin indices; with it they are genomic positions, and the
+// bin indices are available in BinStart and BinEnd.
+//
+// Intervals whose values are all equal, up to a relative difference of 1e-10,
+// are never split and take no permutations, so that constant input yields a
+// single segment with a zero standard deviation.
+func Run(x []float64, opts ...Option) (*Result, error) {
+	return RunContext(context.Background(), x, opts...)
+}
+
+// RunContext is like Run, but stops early when ctx is done.
+//
+// The segments finalized before ctx ended are kept. Every part of x that was
+// not fully explored is reported as a single segment with Unexplored set, and
+// the partial result is returned together with the error of ctx. Consensus
+// runs are not resumable and return no result.
+func RunContext(ctx context.Context, x []float64, opts ...Option) (*Result, error) {
+	sg, err := newSegmenter(x, newConfig(opts))
+	if err != nil {
+		return nil, err
+	}
+	sg.ctx = ctx
+	return sg.run()
+}
+
+// run segments sg.x as configured, reporting the statistics of the run to the
+// metrics recorder, if set.
+func (sg *segmenter) run() (*Result, error) {
+	start := time.Now()
+	res, err := sg.segment()
+	if m := sg.cfg.metrics; m != nil {
+		sg.stats.Duration = time.Since(start)
+		sg.stats.Interrupted = sg.interrupted != nil
+		m.Record(sg.stats)
+	}
+	return res, err
+}
+
+// segment segments sg.x as configured.
+func (sg *segmenter) segment() (*Result, error) {
+	var res *Result
+	var err error
+	switch {
+	case sg.cfg.consensus > 1:
+		res, err = sg.consensus()
+	case sg.cfg.writer != nil && sg.cfg.undoSD == 0 && sg.cfg.spikeSD == 0 && sg.cfg.confirmAlpha == 0 && !sg.cfg.shrink:
+		return sg.stream()
+	default:
+		if sg.cfg.confirmAlpha != 0 {
+			err = sg.confirm()
+		} else {
+			err = sg.rsegment(0, len(sg.x))
+		}
+		if sg.cfg.undoSD != 0 && sg.interrupted == nil {
+			sg.segments.reset(sg.undo(sg.segments.segments()))
+		}
+		if sg.cfg.spikeSD != 0 && sg.interrupted == nil {
+			sg.segments.reset(sg.splitSpikes(sg.segments.segments()))
+		}
+		res = &Result{Segments: sg.summarize(sg.segments.segments())}
+	}
+	if err != nil {
+		return nil, err
+	}
+	res.Explain = sg.explained
+	if sg.cfg.shrink {
+		res.Segments = ShrinkMeans(res.Segments, DiffMAD(sg.values()))
+	}
+	if err := sg.annotate(res); err != nil {
+		return nil, err
+	}
+	sg.stats.Segments = len(res.Segments)
+	if sg.cfg.fit {
+		if _, res.Fit, err = fitSegments(res.Segments, sg.values(), sg.w, sg.mean); err != nil {
+			return nil, err
+		}
+	}
+	if sg.cfg.breakpointLevel != 0 {
+		if sg.cfg.consensus > 1 {
+			// The p-values of the individual runs do not apply.
+			sg.pvalues = nil
+			sg.describe(res.Breakpoints)
+		} else {
+			res.Breakpoints = sg.breakpointsOf(sg.segments.segments())
+		}
+	}
+	if sg.cfg.writer != nil {
+		for _, s := range res.Segments {
+			if err := sg.cfg.writer(s); err != nil {
+				return nil, err
+			}
+		}
+		res.Segments = nil
+	}
+	return res, sg.interrupted
+}
+
+// stream segments sg.x, writing every segment to the configured writer as
+// soon as it is final, and alerting of the changepoint before it.
+func (sg *segmenter) stream() (*Result, error) {
+	var prev *Segment
+	sg.emit = func(start, end int) error {
+		res := &Result{Segments: sg.summarize([][2]int{{start, end}})}
+		if err := sg.annotate(res); err != nil {
+			return err
+		}
+		sg.stats.Segments++
+		s := res.Segments[0]
+		if err := sg.cfg.writer(s); err != nil {
+			return err
+		}
+		if prev != nil && sg.cfg.alert != nil {
+			sg.cfg.alert(sg.newAlert(*prev, s))
+		}
+		prev = &s
+		return nil
+	}
+	if err := sg.rsegment(0, len(sg.x)); err != nil {
+		return nil, err
+	}
+	res := &Result{Explain: sg.explained}
+	if sg.cfg.fit {
+		var err error
+		if _, res.Fit, err = fitSegments(sg.summarize(sg.segments.segments()), sg.values(), sg.w, sg.mean); err != nil {
+			return nil, err
+		}
+	}
+	if sg.cfg.breakpointLevel != 0 {
+		res.Breakpoints = sg.breakpointsOf(sg.segments.segments())
+	}
+	return res, sg.interrupted
+}
+
+// annotate adds the optional per-segment annotations to res.
+func (sg *segmenter) annotate(res *Result) error {
+	if sg.cfg.baf != nil {
+		segments, err := CallAllelic(res.Segments, sg.cfg.snpPos, sg.cfg.baf, DefaultAllelicThresholds())
+		if err != nil {
+			return err
+		}
+		res.Segments = segments
+	}
+	if sg.cfg.mosaicLevel != 0 {
+		segments, err := EstimateMosaicism(res.Segments, sg.cfg.mosaicLevel)
+		if err != nil {
+			return err
+		}
+		res.Segments = segments
+	}
+	if sg.cfg.calls != nil {
+		segments, err := CallStates(res.Segments, *sg.cfg.calls)
+		if err != nil {
+			return err
+		}
+		res.Segments = segments
+	}
+	if sg.cfg.fit {
+		for i := range res.Segments {
+			fitSegment(&res.Segments[i], sg.values(), sg.w, sg.mean)
+		}
+	}
+	for i := range res.Segments {
+		focal, err := sg.focal(res.Segments[i])
+		if err != nil {
+			return err
+		}
+		res.Segments[i].Focal = focal
+		subsegments, err := sg.subsegments(res.Segments[i])
+		if err != nil {
+			return err
+		}
+		res.Segments[i].Subsegments = subsegments
+	}
+	return nil
+}
+
+// newSegmenter validates cfg against x and prepares a run.
+func newSegmenter(x []float64, cfg *config) (*segmenter, error) {
+	if cfg.err != nil {
+		return nil, cfg.err
+	}
+	if math.IsNaN(cfg.alpha) {
+		return nil, errors.New("cbsgo: the significance level is NaN")
+	}
+	if cfg.strict {
+		if err := cfg.validateStrict(); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.contentSeed {
+		// cfg may be shared, as by a Segmenter.
+		c := *cfg
+		c.seed = contentSeed(x, cfg)
+		cfg = &c
+	}
+	sg := &segmenter{cfg: cfg, rng: newRand(cfg.seed), x: x, buf: &scratch{}, ctx: context.Background()}
+	if cfg.smoothRegion < 0 || cfg.outlierSD < 0 || cfg.smoothSD < 0 {
+		return nil, fmt.Errorf("cbsgo: invalid smoothing region %d with outlier SD %v and smoothing SD %v",
+			cfg.smoothRegion, cfg.outlierSD, cfg.smoothSD)
+	}
+	if cfg.smoothRegion > 0 {
+		sg.x = SmoothOutliers(x, cfg.smoothRegion, cfg.outlierSD, cfg.smoothSD)
+	}
+	switch cfg.transform {
+	case NoTransform:
+	case RankTransform, NormalScores:
+		sg.raw, sg.x = sg.x, cfg.transform.Apply(sg.x)
+	default:
+		return nil, fmt.Errorf("cbsgo: invalid transform %d", cfg.transform)
+	}
+	if cfg.starts != nil || cfg.ends != nil {
+		w, err := binWidths(cfg.starts, cfg.ends, len(x))
+		if err != nil {
+			return nil, err
+		}
+		sg.w = w
+	}
+	if cfg.weights != nil {
+		if len(cfg.weights) != len(x) {
+			return nil, fmt.Errorf("cbsgo: got %d weights for %d values", len(cfg.weights), len(x))
+		}
+		for i, w := range cfg.weights {
+			if !(w > 0) || math.IsInf(w, 1) {
+				return nil, fmt.Errorf("cbsgo: bin %d has invalid weight %v", i, w)
+			}
+		}
+		sg.w = cfg.weights
+	}
+	if cfg.fit {
+		sg.mean = stat.Mean(sg.values(), sg.w)
+	}
+	if cfg.haar != 0 {
+		sg.candidates = HaarCandidates(sg.x, cfg.haar)
+	}
+	if cfg.calls != nil {
+		if err := cfg.calls.validate(); err != nil {
+			return nil, err
+		}
+	}
+	if l := cfg.breakpointLevel; l != 0 && (l < 0 || l >= 1) {
+		return nil, fmt.Errorf("cbsgo: invalid confidence level %v", l)
+	}
+	if err := cfg.checkMemory(len(x)); err != nil {
+		return nil, err
+	}
+	if cfg.minWidth < 0 {
+		return nil, fmt.Errorf("cbsgo: invalid minimum segment width %d", cfg.minWidth)
+	}
+	if cfg.undoSD < 0 {
+		return nil, fmt.Errorf("cbsgo: invalid undo threshold %v", cfg.undoSD)
+	}
+	if cfg.undoSD != 0 && cfg.consensus > 1 {
+		return nil, errors.New("cbsgo: WithUndoSD cannot be combined with WithConsensus")
+	}
+	if cfg.spikeSD < 0 {
+		return nil, fmt.Errorf("cbsgo: invalid spike threshold %v", cfg.spikeSD)
+	}
+	if cfg.spikeSD != 0 && cfg.consensus > 1 {
+		return nil, errors.New("cbsgo: WithSpikes cannot be combined with WithConsensus")
+	}
+	if a := cfg.confirmAlpha; a < 0 || a >= 1 {
+		return nil, fmt.Errorf("cbsgo: invalid confirmation significance level %v", a)
+	}
+	if cfg.confirmAlpha != 0 && cfg.consensus > 1 {
+		return nil, errors.New("cbsgo: WithConfirmation cannot be combined with WithConsensus")
+	}
+	if cfg.focalAmplitude < 0 || cfg.focalMin < 0 || cfg.focalMax < cfg.focalMin {
+		return nil, fmt.Errorf("cbsgo: invalid focal events of %d to %d bins with amplitude %v",
+			cfg.focalMin, cfg.focalMax, cfg.focalAmplitude)
+	}
+	if a := cfg.hierarchyAlpha; a < 0 || a >= 1 {
+		return nil, fmt.Errorf("cbsgo: invalid significance level %v of the sub-segments", a)
+	}
+	if cfg.lengthScale < 0 {
+		return nil, fmt.Errorf("cbsgo: invalid segment length scale %v", cfg.lengthScale)
+	}
+	if cfg.gapScale != 0 {
+		if cfg.starts == nil {
+			return nil, errors.New("cbsgo: the gap penalty requires bin positions")
+		}
+		if cfg.gapScale < 0 {
+			return nil, fmt.Errorf("cbsgo: invalid gap penalty scale %v", cfg.gapScale)
+		}
+	}
+	return sg, nil
+}
+
+// validateStrict checks the settings rejected by WithStrictValidation.
+func (c *config) validateStrict() error {
+	if !(c.alpha > 0 && c.alpha < 1) {
+		return fmt.Errorf("cbsgo: significance level %v is not between 0 and 1", c.alpha)
+	}
+	if float64(c.shuffles) < 1/c.alpha {
+		return fmt.Errorf("cbsgo: %d shuffles cannot reach the significance level %v, which takes at least %v",
+			c.shuffles, c.alpha, math.Ceil(1/c.alpha))
+	}
+	if c.minWidth == 1 {
+		return errors.New("cbsgo: a minimum segment width of 1 rejects no split, use 0 to disable it or at least 2")
+	}
+	return nil
+}
+
+// summarize converts segment bounds into segments.
+func (sg *segmenter) summarize(bounds [][2]int) []Segment {
+	segments := make([]Segment, len(bounds))
+	for i, b := range bounds {
+		s := Segment{Start: b[0], End: b[1], BinStart: b[0], BinEnd: b[1]}
+		if sg.cfg.starts != nil {
+			s.Start, s.End = sg.cfg.starts[b[0]], sg.cfg.ends[b[1]-1]
+		}
+		s.Unexplored = sg.unexplored[b[0]]
+		s.Spike = sg.spikes[b[0]]
+		if c, ok := sg.rejected[b]; ok {
+			s.BestSplit = &c
+		}
+
+		x := sg.values()[b[0]:b[1]]
+		var w []float64
+		if sg.w != nil {
+			w = sg.w[b[0]:b[1]]
+		}
+		s.Mean, s.SD = meanSD(x, w)
+		segments[i] = s
+	}
+	return segments
+}
+
+// values returns the values summarizing the segments: those before
+// WithTransform, if set.
+func (sg *segmenter) values() []float64 {
+	if sg.raw != nil {
+		return sg.raw
+	}
+	return sg.x
+}
+
+// undo merges the adjacent segments of bounds whose means differ by less than
+// the threshold of WithUndoSD, smallest differences first.
+func (sg *segmenter) undo(bounds [][2]int) [][2]int {
+	threshold := sg.cfg.undoSD * DiffMAD(sg.x)
+	mean := func(b [2]int) float64 {
+		var w []float64
+		if sg.w != nil {
+			w = sg.w[b[0]:b[1]]
+		}
+		return stat.Mean(sg.x[b[0]:b[1]], w)
+	}
+	means := make([]float64, len(bounds))
+	for i, b := range bounds {
+		means[i] = mean(b)
+	}
+	for len(bounds) > 1 {
+		best, diff := 0, math.Inf(1)
+		for i := 1; i < len(bounds); i++ {
+			if d := math.Abs(means[i] - means[i-1]); d < diff {
+				best, diff = i, d
+			}
+		}
+		if diff >= threshold {
+			break
+		}
+		bounds[best-1][1] = bounds[best][1]
+		means[best-1] = mean(bounds[best-1])
+		bounds = slices.Delete(bounds, best, best+1)
+		means = slices.Delete(means, best, best+1)
+	}
+	return bounds
+}
+
+// meanSD returns the mean and standard deviation of x, weighted by w unless w
+// is nil. The standard deviation of a single value is zero.
+func meanSD(x, w []float64) (float64, float64) {
+	if len(x) < 2 {
+		return stat.Mean(x, w), 0
+	}
+	mean, variance := stat.MeanVariance(x, w)
+	return mean, math.Sqrt(variance)
+}
+
+// position returns the coordinate of the start of bin i.
+func (sg *segmenter) position(i int) int {
+	if sg.cfg.starts == nil {
+		return i
+	}
+	if i == len(sg.x) {
+		return sg.cfg.ends[i-1]
+	}
+	return sg.cfg.starts[i]
+}
+
+// gapPenalty returns the factor applied to the statistic of a split of the
+// bins [start, end) at the bins s and e, as configured by WithGapPenalty.
+func (sg *segmenter) gapPenalty(start, end, s, e int) float64 {
+	scale := sg.cfg.gapScale
+	if scale == 0 {
+		return 1
+	}
+	f := 1.0
+	for _, b := range [2]int{s, e} {
+		if b <= start || b >= end {
+			continue
+		}
+		gap := float64(sg.cfg.starts[b] - sg.cfg.ends[b-1])
+		f *= scale / (scale + gap)
+	}
+	return f
+}
+
+// lengthPenalty returns the factor applied to the statistic of a split of n
+// bins at the bins s and e, as configured by WithLengthPrior.
+func (sg *segmenter) lengthPenalty(n, s, e int) float64 {
+	scale := sg.cfg.lengthScale
+	if scale == 0 {
+		return 1
+	}
+	f := 1.0
+	for _, m := range [3]int{s, e - s, n - e} {
+		if m > 0 {
+			f *= -math.Expm1(-float64(m) / scale)
+		}
+	}
+	return f
+}
+
+// binWidths validates the bin positions and returns the bin widths.
+func binWidths(starts, ends []int, n int) ([]float64, error) {
+	if len(starts) != n || len(ends) != n {
+		return nil, fmt.Errorf("cbsgo: got %d starts and %d ends for %d values", len(starts), len(ends), n)
+	}
+	w := make([]float64, n)
+	for i := range w {
+		if ends[i] <= starts[i] {
+			return nil, fmt.Errorf("cbsgo: bin %d has empty extent [%d, %d)", i, starts[i], ends[i])
+		}
+		if i > 0 && starts[i] < ends[i-1] {
+			return nil, fmt.Errorf("cbsgo: bin %d at %d overlaps the previous bin", i, starts[i])
+		}
+		w[i] = float64(ends[i] - starts[i])
+	}
+	return w, nil
+}
+
+// newRand returns the random source for seed; 0 selects a time-based seed.
+func newRand(seed int64) *rand.Rand {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return rand.New(rand.NewSource(seed))
+}
@@ -0,0 +1,426 @@
+package cbsgo_test
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestRun(t *testing.T) {
+	steps := []float64{1, 1, 1, 3, 3, 2, 1, 2, 3, 300, 310, 321, 310, 299}
+
+	res, err := cbsgo.Run(steps, cbsgo.WithSeed(42))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+
+	expected := [][2]int{{0, 8}, {8, 14}}
+	if len(res.Segments) != len(expected) {
+		t.Fatalf("Unexpected result.\nExpected: %v\nGot: %v", expected, res.Segments)
+	}
+	for i, s := range res.Segments {
+		if s.Start != expected[i][0] || s.End != expected[i][1] || s.BinStart != s.Start || s.BinEnd != s.End {
+			t.Errorf("Segment %d: unexpected bounds %+v, expected %v", i, s, expected[i])
+		}
+	}
+	if m := res.Segments[0].Mean; m != 1.75 {
+		t.Errorf("Expected a mean of 1.75 for the first segment, got %v", m)
+	}
+}
+
+func TestRunConstant(t *testing.T) {
+	for name, x := range map[string][]float64{
+		"equal":          {2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2},
+		"rounding error": {0.3, 0.3, 0.3, 0.3, 0.3, 0.3, 0.1 + 0.2, 0.1 + 0.2, 0.1 + 0.2, 0.1 + 0.2, 0.1 + 0.2, 0.1 + 0.2},
+	} {
+		var r recorder
+		res, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithMetrics(&r))
+		if err != nil {
+			t.Fatalf("%s: Run returned an unexpected error: %v", name, err)
+		}
+		if len(res.Segments) != 1 || res.Segments[0].SD > 1e-15 {
+			t.Errorf("%s: expected a single constant segment, got %v", name, res.Segments)
+		}
+		if p := r.stats[0].Permutations; p != 0 {
+			t.Errorf("%s: expected no permutations, got %d", name, p)
+		}
+	}
+}
+
+func TestRunSeed(t *testing.T) {
+	x := []float64{0, 0.4, 0.1, 0.3, 0.2, 0.7, 0.9, 0.6, 0.8, 0.5, 0.1, 0.3, 0.2, 0.4}
+
+	a, err := cbsgo.Run(x, cbsgo.WithSeed(7), cbsgo.WithShuffles(200))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	b, err := cbsgo.Run(x, cbsgo.WithSeed(7), cbsgo.WithShuffles(200))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("Runs with the same seed differ.\nFirst: %v\nSecond: %v", a, b)
+	}
+}
+
+func TestRunPositions(t *testing.T) {
+	steps := []float64{1, 1, 1, 3, 3, 2, 1, 2, 3, 300, 310, 321, 310, 299}
+
+	// Bins of equal width must reproduce the unweighted result.
+	starts := make([]int, len(steps))
+	ends := make([]int, len(steps))
+	for i := range steps {
+		starts[i], ends[i] = 1000+100*i, 1000+100*(i+1)
+	}
+
+	plain, err := cbsgo.Run(steps, cbsgo.WithSeed(42))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	res, err := cbsgo.Run(steps, cbsgo.WithSeed(42), cbsgo.WithPositions(starts, ends))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if len(res.Segments) != len(plain.Segments) {
+		t.Fatalf("Unexpected result.\nExpected: %v\nGot: %v", plain.Segments, res.Segments)
+	}
+	for i, s := range res.Segments {
+		p := plain.Segments[i]
+		if s.BinStart != p.BinStart || s.BinEnd != p.BinEnd {
+			t.Errorf("Segment %d: expected bins [%d, %d), got [%d, %d)", i, p.BinStart, p.BinEnd, s.BinStart, s.BinEnd)
+		}
+		if s.Start != starts[s.BinStart] || s.End != ends[s.BinEnd-1] {
+			t.Errorf("Segment %d: expected positions [%d, %d), got [%d, %d)",
+				i, starts[s.BinStart], ends[s.BinEnd-1], s.Start, s.End)
+		}
+	}
+}
+
+func TestRunPositionsWeightedMean(t *testing.T) {
+	x := []float64{1, 2, 1, 2, 1, 2, 1, 2}
+	starts := []int{0, 10, 40, 50, 80, 90, 120, 130}
+	ends := []int{10, 40, 50, 80, 90, 120, 130, 160}
+
+	res, err := cbsgo.Run(x, cbsgo.WithSeed(1), cbsgo.WithPositions(starts, ends))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if len(res.Segments) != 1 {
+		t.Fatalf("Expected a single segment, got %v", res.Segments)
+	}
+	// Bins holding 2 are three times as wide as those holding 1.
+	if m := res.Segments[0].Mean; m != 1.75 {
+		t.Errorf("Expected a length-weighted mean of 1.75, got %v", m)
+	}
+}
+
+func TestRunPositionsInvalid(t *testing.T) {
+	x := []float64{1, 2, 3}
+	tests := map[string][2][]int{
+		"length":  {{0, 10}, {10, 20}},
+		"empty":   {{0, 10, 20}, {10, 10, 30}},
+		"overlap": {{0, 10, 15}, {10, 20, 30}},
+	}
+	for name, pos := range tests {
+		if _, err := cbsgo.Run(x, cbsgo.WithPositions(pos[0], pos[1])); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRunGapPenalty(t *testing.T) {
+	pattern := []float64{0, 0.3, -0.2, 0.1, -0.3, 0.2}
+	profile := func(shift float64) []float64 {
+		x := make([]float64, 40)
+		for i := range x {
+			x[i] = pattern[i%len(pattern)]
+			if i >= 20 {
+				x[i] += shift
+			}
+		}
+		return x
+	}
+
+	// Point markers every kilobase, with a 10 Mb gap where the split happens.
+	starts := make([]int, 40)
+	ends := make([]int, 40)
+	for i := range starts {
+		starts[i] = 1000 * i
+		if i >= 19 {
+			starts[i] += 10_000_000
+		}
+		ends[i] = starts[i] + 1
+	}
+
+	tests := []struct {
+		name     string
+		shift    float64
+		opts     []cbsgo.Option
+		segments int
+	}{
+		{name: "moderate shift", shift: 0.25, segments: 2},
+		{name: "moderate shift at gap", shift: 0.25, opts: []cbsgo.Option{cbsgo.WithGapPenalty(5_000_000)}, segments: 1},
+		{name: "strong shift at gap", shift: 1000, opts: []cbsgo.Option{cbsgo.WithGapPenalty(5_000_000)}, segments: 2},
+	}
+	for _, tt := range tests {
+		opts := append([]cbsgo.Option{cbsgo.WithSeed(42), cbsgo.WithPositions(starts, ends)}, tt.opts...)
+		res, err := cbsgo.Run(profile(tt.shift), opts...)
+		if err != nil {
+			t.Fatalf("%s: Run returned an unexpected error: %v", tt.name, err)
+		}
+		if len(res.Segments) != tt.segments {
+			t.Errorf("%s: expected %d segments, got %v", tt.name, tt.segments, res.Segments)
+		}
+	}
+
+	if _, err := cbsgo.Run(profile(1), cbsgo.WithGapPenalty(10_000)); err == nil {
+		t.Errorf("Expected an error when using the gap penalty without positions")
+	}
+}
+
+func TestRunLengthPrior(t *testing.T) {
+	pattern := []float64{0, 0.3, -0.2, 0.1, -0.3, 0.2}
+	profile := func(start, end int) []float64 {
+		x := make([]float64, 120)
+		for i := range x {
+			x[i] = pattern[i%len(pattern)]
+			if i >= start && i < end {
+				x[i] += 1
+			}
+		}
+		return x
+	}
+
+	tests := []struct {
+		name       string
+		start, end int
+		opts       []cbsgo.Option
+		segments   int
+	}{
+		{name: "short event", start: 50, end: 57, segments: 3},
+		{name: "short event with prior", start: 50, end: 57, opts: []cbsgo.Option{cbsgo.WithLengthPrior(100)}, segments: 1},
+		{name: "long event with prior", start: 40, end: 80, opts: []cbsgo.Option{cbsgo.WithLengthPrior(10)}, segments: 3},
+	}
+	for _, tt := range tests {
+		res, err := cbsgo.Run(profile(tt.start, tt.end), append([]cbsgo.Option{cbsgo.WithSeed(42)}, tt.opts...)...)
+		if err != nil {
+			t.Fatalf("%s: Run returned an unexpected error: %v", tt.name, err)
+		}
+		if len(res.Segments) != tt.segments {
+			t.Errorf("%s: expected %d segments, got %v", tt.name, tt.segments, res.Segments)
+		}
+	}
+
+	if _, err := cbsgo.Run(profile(0, 0), cbsgo.WithLengthPrior(-1)); err == nil {
+		t.Errorf("Expected an error for a negative length scale")
+	}
+}
+
+// countdown is a context that ends after its Err method was called a given
+// number of times, making interruptions reproducible.
+type countdown struct {
+	context.Context
+	calls int
+}
+
+func (c *countdown) Err() error {
+	if c.calls--; c.calls < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestRunContext(t *testing.T) {
+	x := make([]float64, 300)
+	for i := range x {
+		x[i] = math.Sin(float64(i)) / 4
+		if i >= 100 && i < 200 {
+			x[i] += 3
+		}
+	}
+
+	full, err := cbsgo.RunContext(context.Background(), x, cbsgo.WithSeed(42))
+	if err != nil {
+		t.Fatalf("RunContext returned an unexpected error: %v", err)
+	}
+
+	for calls := 0; calls < 40; calls++ {
+		res, err := cbsgo.RunContext(&countdown{context.Background(), calls}, x, cbsgo.WithSeed(42))
+		if err == nil {
+			if !reflect.DeepEqual(res, full) {
+				t.Errorf("%d calls: unexpected result.\nExpected: %v\nGot: %v", calls, full, res)
+			}
+			continue
+		}
+		if err != context.Canceled || res == nil {
+			t.Fatalf("%d calls: expected a partial result, got %v, %v", calls, res, err)
+		}
+
+		// The partial segments tile the profile, and those that were explored
+		// are final.
+		end := 0
+		unexplored := 0
+		for _, s := range res.Segments {
+			if s.BinStart != end {
+				t.Fatalf("%d calls: segments do not tile the profile: %v", calls, res.Segments)
+			}
+			end = s.BinEnd
+			if s.Unexplored {
+				unexplored++
+				continue
+			}
+			found := false
+			for _, f := range full.Segments {
+				found = found || (f.BinStart == s.BinStart && f.BinEnd == s.BinEnd)
+			}
+			if !found {
+				t.Errorf("%d calls: explored segment %+v is not final", calls, s)
+			}
+		}
+		if end != len(x) || unexplored == 0 {
+			t.Errorf("%d calls: unexpected partial result %v", calls, res.Segments)
+		}
+	}
+}
+
+func TestWithProfileLabels(t *testing.T) {
+	track := &cbsgo.Track{Chrom: "chr1", Values: []float64{1, 1, 1, 3, 3, 2, 1, 2, 3, 300, 310, 321, 310, 299, 1, 2, 1, 2, 1, 1}}
+	expected, err := track.Segment(cbsgo.WithSeed(42))
+	if err != nil {
+		t.Fatalf("Segment returned an unexpected error: %v", err)
+	}
+	got, err := track.Segment(cbsgo.WithSeed(42), cbsgo.WithProfileLabels("sample1"))
+	if err != nil {
+		t.Fatalf("Segment returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+	}
+}
+
+func TestRunMinWidth(t *testing.T) {
+	pattern := []float64{0, 0.3, -0.2, 0.1, -0.3, 0.2}
+	x := make([]float64, 120)
+	for i := range x {
+		x[i] = pattern[i%len(pattern)]
+		if i >= 50 && i < 57 {
+			x[i] += 3
+		}
+	}
+
+	for width, segments := range map[int]int{0: 3, 9: 3, 10: 1} {
+		res, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithMinWidth(width))
+		if err != nil {
+			t.Fatalf("Run returned an unexpected error: %v", err)
+		}
+		if len(res.Segments) != segments {
+			t.Errorf("Minimum width %d: expected %d segments, got %v", width, segments, res.Segments)
+		}
+	}
+
+	if _, err := cbsgo.Run(x, cbsgo.WithMinWidth(-1)); err == nil {
+		t.Errorf("Expected an error for a negative minimum width")
+	}
+}
+
+func TestRunUndoSD(t *testing.T) {
+	pattern := []float64{0, 0.3, -0.2, 0.1, -0.3, 0.2}
+	x := make([]float64, 120)
+	for i := range x {
+		x[i] = pattern[i%len(pattern)]
+		switch {
+		case i >= 40 && i < 80:
+			x[i] += 0.6
+		case i >= 80:
+			x[i] += 3
+		}
+	}
+
+	for sd, segments := range map[float64]int{0: 3, 0.5: 3, 3: 2, 20: 1} {
+		var c collector
+		res, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithUndoSD(sd))
+		if err != nil {
+			t.Fatalf("Run returned an unexpected error: %v", err)
+		}
+		if len(res.Segments) != segments {
+			t.Errorf("Undo SD %v: expected %d segments, got %v", sd, segments, res.Segments)
+		}
+		if _, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithUndoSD(sd), cbsgo.WithWriter(&c)); err != nil {
+			t.Fatalf("Run returned an unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(c.segments, res.Segments) {
+			t.Errorf("Undo SD %v: unexpected written segments.\nExpected: %v\nGot: %v", sd, res.Segments, c.segments)
+		}
+	}
+
+	for name, opts := range map[string][]cbsgo.Option{
+		"negative":  {cbsgo.WithUndoSD(-1)},
+		"consensus": {cbsgo.WithUndoSD(1), cbsgo.WithConsensus(3)},
+	} {
+		if _, err := cbsgo.Run(x, opts...); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRunSmoothing(t *testing.T) {
+	pattern := []float64{0, 0.3, -0.2, 0.1, -0.3, 0.2}
+	x := make([]float64, 60)
+	for i := range x {
+		x[i] = pattern[i%len(pattern)]
+	}
+	x[30] = 50
+
+	res, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithSmoothing(10, 4, 2))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if len(res.Segments) != 1 || math.Abs(res.Segments[0].Mean) > 0.1 {
+		t.Errorf("Expected a single segment unaffected by the outlier, got %v", res.Segments)
+	}
+	if x[30] != 50 {
+		t.Errorf("Expected the input to be left unchanged")
+	}
+
+	if _, err := cbsgo.Run(x, cbsgo.WithSmoothing(-1, 4, 2)); err == nil {
+		t.Errorf("Expected an error for a negative smoothing region")
+	}
+}
+
+func TestRunStrictValidation(t *testing.T) {
+	steps := []float64{1, 1, 1, 3, 3, 2, 1, 2, 3, 300, 310, 321, 310, 299}
+	for name, opts := range map[string][]cbsgo.Option{
+		"alpha 0":     {cbsgo.WithAlpha(0)},
+		"alpha 1":     {cbsgo.WithAlpha(1)},
+		"shuffles":    {cbsgo.WithAlpha(0.001), cbsgo.WithShuffles(999)},
+		"min width 1": {cbsgo.WithMinWidth(1)},
+	} {
+		// The settings are accepted, however meaningless, unless strict.
+		if _, err := cbsgo.Run(steps, append(opts, cbsgo.WithSeed(42))...); err != nil {
+			t.Errorf("%s: Run returned an unexpected error: %v", name, err)
+		}
+		if _, err := cbsgo.Run(steps, append(opts, cbsgo.WithSeed(42), cbsgo.WithStrictValidation())...); err == nil {
+			t.Errorf("%s: expected an error with strict validation", name)
+		}
+	}
+
+	if _, err := cbsgo.Run(steps, cbsgo.WithAlpha(math.NaN())); err == nil {
+		t.Errorf("Expected an error for a NaN significance level")
+	}
+
+	opts := []cbsgo.Option{cbsgo.WithSeed(42), cbsgo.WithAlpha(0.001), cbsgo.WithShuffles(1000), cbsgo.WithMinWidth(2)}
+	expected, err := cbsgo.Run(steps, opts...)
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	got, err := cbsgo.Run(steps, append(opts, cbsgo.WithStrictValidation())...)
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error with strict validation: %v", err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+	}
+}
@@ -0,0 +1,17 @@
+package cbsgo
+
+// ShuffleSchedule returns the number of permutations of the test of an
+// interval of n bins. See WithShuffleSchedule.
+type ShuffleSchedule func(n int) int
+
+// LinearShuffles returns a schedule growing linearly from lo permutations for
+// the shortest intervals to hi permutations for intervals of at least length
+// bins.
+func LinearShuffles(lo, hi, length int) ShuffleSchedule {
+	return func(n int) int {
+		if n >= length {
+			return hi
+		}
+		return lo + (hi-lo)*n/length
+	}
+}
@@ -0,0 +1,47 @@
+package cbsgo_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestLinearShuffles(t *testing.T) {
+	s := cbsgo.LinearShuffles(100, 1000, 1000)
+	var got []int
+	for _, n := range []int{0, 100, 500, 1000, 5000} {
+		got = append(got, s(n))
+	}
+	expected := []int{100, 190, 550, 1000, 1000}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+	}
+}
+
+func TestWithShuffleSchedule(t *testing.T) {
+	x := make([]float64, 400)
+	for i := range x {
+		x[i] = float64(i%7) / 10
+		if i >= 150 && i < 250 {
+			x[i] += 3
+		}
+	}
+	var full, scheduled recorder
+	expected, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithMetrics(&full))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	got, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithMetrics(&scheduled),
+		cbsgo.WithShuffleSchedule(cbsgo.LinearShuffles(50, 1000, 10000)))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got.Segments, expected.Segments) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected.Segments, got.Segments)
+	}
+	if scheduled.stats[0].Permutations >= full.stats[0].Permutations {
+		t.Errorf("Expected fewer permutations with the schedule, got %d and %d",
+			scheduled.stats[0].Permutations, full.stats[0].Permutations)
+	}
+}
@@ -0,0 +1,72 @@
+package cbsgo
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+)
+
+// DeriveSeed returns the seed of the track of chromosome chrom of a sample
+// in a run seeded with seed, as used by SegmentGenome: seed XOR an FNV-1a
+// hash of the sample and chromosome names. Because the seed of a track only
+// depends on its names, segmenting a single track with
+//
+//	track.Segment(WithSeed(DeriveSeed(seed, sample, track.Chrom)))
+//
+// reproduces its segments in the full run. The derived seed is never 0,
+// which would select a time-based seed.
+func DeriveSeed(seed int64, sample, chrom string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(sample))
+	h.Write([]byte{0})
+	h.Write([]byte(chrom))
+	derived := seed ^ int64(h.Sum64())
+	if derived == 0 {
+		return 1
+	}
+	return derived
+}
+
+// contentSeed returns a seed derived from a hash of x and of the settings of
+// cfg that affect the segmentation, for WithContentSeed.
+func contentSeed(x []float64, cfg *config) int64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	write := func(v uint64) {
+		binary.LittleEndian.PutUint64(buf[:], v)
+		h.Write(buf[:])
+	}
+	floats := func(s []float64) {
+		write(uint64(len(s)))
+		for _, v := range s {
+			write(math.Float64bits(v))
+		}
+	}
+	ints := func(s []int) {
+		write(uint64(len(s)))
+		for _, v := range s {
+			write(uint64(v))
+		}
+	}
+	floats(x)
+	ints(cfg.starts)
+	ints(cfg.ends)
+	floats(cfg.weights)
+	ints([]int{cfg.shuffles, int(cfg.adjustment), cfg.consensus, int(cfg.direction), int(cfg.tie),
+		cfg.smoothRegion, cfg.minWidth})
+	floats([]float64{cfg.alpha, cfg.gapScale, cfg.lengthScale, cfg.haar, cfg.outlierSD, cfg.smoothSD, cfg.undoSD})
+	// Options added later only count when set, so that seeds do not change.
+	if cfg.transform != NoTransform {
+		write(uint64(cfg.transform))
+	}
+	if cfg.spikeSD != 0 {
+		write(math.Float64bits(cfg.spikeSD))
+	}
+	if cfg.confirmAlpha != 0 {
+		write(math.Float64bits(cfg.confirmAlpha))
+	}
+	if seed := int64(h.Sum64()); seed != 0 {
+		return seed
+	}
+	return 1
+}
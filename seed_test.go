@@ -0,0 +1,58 @@
+package cbsgo_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestDeriveSeed(t *testing.T) {
+	seeds := map[int64]bool{}
+	for _, seed := range []int64{0, 1, 42} {
+		for _, sample := range []string{"", "s1", "s2"} {
+			for _, chrom := range []string{"chr1", "chr2", "1"} {
+				seeds[cbsgo.DeriveSeed(seed, sample, chrom)] = true
+			}
+		}
+	}
+	if len(seeds) != 27 || seeds[0] {
+		t.Errorf("Expected 27 distinct non-zero seeds, got %v", seeds)
+	}
+	if cbsgo.DeriveSeed(1, "s", "chr1") != cbsgo.DeriveSeed(1, "s", "chr1") {
+		t.Errorf("Expected equal seeds for equal arguments")
+	}
+}
+
+func TestWithContentSeed(t *testing.T) {
+	// The p-values of the breakpoints depend on the permutations.
+	opts := []cbsgo.Option{cbsgo.WithContentSeed(), cbsgo.WithShuffles(200), cbsgo.WithBreakpoints(0.95)}
+	x := borderline(0.3)
+	first, err := cbsgo.Run(x, opts...)
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	second, err := cbsgo.NewSegmenter(opts...).Segment(x)
+	if err != nil {
+		t.Fatalf("Segment returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Runs on the same data differ.\nFirst: %v\nSecond: %v", first, second)
+	}
+
+	// The seed depends on the options, and replaces the one of WithSeed.
+	other, err := cbsgo.Run(x, append(opts, cbsgo.WithShuffles(201), cbsgo.WithSeed(1))...)
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if reflect.DeepEqual(other.Breakpoints, first.Breakpoints) {
+		t.Errorf("Expected different permutations for different options, got %v", other.Breakpoints)
+	}
+	seeded, err := cbsgo.Run(x, append(opts, cbsgo.WithSeed(1))...)
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(seeded, first) {
+		t.Errorf("Expected the seed to be ignored.\nExpected: %v\nGot: %v", first, seeded)
+	}
+}
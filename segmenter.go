@@ -0,0 +1,47 @@
+package cbsgo
+
+import (
+	"context"
+	"sync"
+)
+
+// Segmenter segments many profiles with the same options, such as in a server
+// segmenting thousands of samples. It keeps the scratch buffers of finished
+// runs for reuse by later ones, avoiding most of the per-call allocations of
+// Run. Its methods are safe for concurrent use.
+//
+// The options are shared by all runs: a seed set with WithSeed seeds every run
+// alike, and a cache set with WithNullCache is shared by all of them. Options
+// carrying per-profile data, such as WithPositions, apply to every profile and
+// require them all to have the same bins.
+type Segmenter struct {
+	cfg  *config
+	bufs sync.Pool
+}
+
+// NewSegmenter returns a Segmenter configured by opts.
+func NewSegmenter(opts ...Option) *Segmenter {
+	return &Segmenter{
+		cfg:  newConfig(opts),
+		bufs: sync.Pool{New: func() any { return &scratch{} }},
+	}
+}
+
+// Segment performs Circular Binary Segmentation on x, like Run.
+func (s *Segmenter) Segment(x []float64) (*Result, error) {
+	return s.SegmentContext(context.Background(), x)
+}
+
+// SegmentContext is like Segment, but stops early when ctx is done, like
+// RunContext.
+func (s *Segmenter) SegmentContext(ctx context.Context, x []float64) (*Result, error) {
+	sg, err := newSegmenter(x, s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	sg.ctx = ctx
+	buf := s.bufs.Get().(*scratch)
+	defer s.bufs.Put(buf)
+	sg.buf = buf
+	return sg.run()
+}
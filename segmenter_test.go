@@ -0,0 +1,74 @@
+package cbsgo_test
+
+import (
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestSegmenter(t *testing.T) {
+	profiles := make([][]float64, 16)
+	for i := range profiles {
+		x := make([]float64, 200)
+		for j := range x {
+			x[j] = math.Sin(float64(i*j)) / 4
+			if j >= 20*(i%5+1) && j < 150 {
+				x[j] += float64(i%3 + 1)
+			}
+		}
+		profiles[i] = x
+	}
+
+	opts := []cbsgo.Option{cbsgo.WithSeed(42), cbsgo.WithShuffles(200)}
+	expected := make([]*cbsgo.Result, len(profiles))
+	for i, x := range profiles {
+		res, err := cbsgo.Run(x, opts...)
+		if err != nil {
+			t.Fatalf("Run returned an unexpected error: %v", err)
+		}
+		expected[i] = res
+	}
+
+	s := cbsgo.NewSegmenter(opts...)
+	got := make([]*cbsgo.Result, len(profiles))
+	errs := make([]error, len(profiles))
+	var wg sync.WaitGroup
+	for rep := 0; rep < 3; rep++ {
+		for i, x := range profiles {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				got[i], errs[i] = s.Segment(x)
+			}()
+		}
+		wg.Wait()
+		for i := range profiles {
+			if errs[i] != nil {
+				t.Fatalf("Segment returned an unexpected error: %v", errs[i])
+			}
+			if !reflect.DeepEqual(got[i], expected[i]) {
+				t.Errorf("Profile %d: unexpected result.\nExpected: %v\nGot: %v", i, expected[i], got[i])
+			}
+		}
+	}
+}
+
+func BenchmarkSegmenter(b *testing.B) {
+	x := make([]float64, 5000)
+	for i := range x {
+		x[i] = math.Sin(float64(i))
+		if i > 2500 {
+			x[i]++
+		}
+	}
+	s := cbsgo.NewSegmenter(cbsgo.WithSeed(1), cbsgo.WithShuffles(100))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.Segment(x); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
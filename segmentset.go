@@ -0,0 +1,320 @@
+package cbsgo
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Segment is a half-open interval [Start, End) on chromosome Chrom together
+// with summary statistics of the values it covers.
+type Segment struct {
+	Chrom string
+	Start int
+	End   int
+
+	// BinStart and BinEnd delimit the bins covered by the segment, [BinStart,
+	// BinEnd). They equal Start and End unless the bins have genomic
+	// positions.
+	BinStart int
+	BinEnd   int
+
+	Mean float64
+	SD   float64
+
+	// SNPs is the number of SNPs with a B-allele frequency in the segment,
+	// BAF their median mirrored B-allele frequency, and Allelic the allelic
+	// state called from them. See CallAllelic.
+	SNPs    int
+	BAF     float64
+	Allelic AllelicState
+
+	// CellFraction is the estimated fraction of cells carrying the copy
+	// number change of the segment, within the confidence interval
+	// [CellFractionLower, CellFractionUpper]. See EstimateMosaicism.
+	CellFraction      float64
+	CellFractionLower float64
+	CellFractionUpper float64
+
+	// State is the copy number state called from the mean. See CallStates.
+	State CopyNumberState
+
+	// SSE is the sum of squared residuals of the segment, and R2 the fraction
+	// of its sum of squared deviations from the overall mean explained by the
+	// segment mean. See GoodnessOfFit.
+	SSE float64
+	R2  float64
+
+	// Cytoband is the span of chromosome bands covered by the segment. See
+	// Cytobands.Annotate.
+	Cytoband string
+
+	// PopulationFrequency is the frequency of the common germline CNV
+	// matching the segment, or 0 if there is none. See
+	// PopulationCNVs.Annotate.
+	PopulationFrequency float64
+
+	// Unexplored is set when the run ended before the segment was tested for
+	// further changepoints. See RunContext.
+	Unexplored bool
+
+	// Spike is set for the segments of a single bin split off their segment
+	// by WithSpikes.
+	Spike bool
+
+	// BestSplit is the best split of the segment, tested and rejected for
+	// the reason given by its Decision, such as to tune WithAlpha and
+	// WithMinWidth. It is set by WithExplain, except for segments that were
+	// not tested as a whole, such as the arcs of splits and segments merged
+	// by WithUndoSD.
+	BestSplit *Candidate
+
+	// Focal lists the focal events within the segment. See WithFocal.
+	Focal []FocalEvent
+
+	// Subsegments lists the segments found within the segment at a relaxed
+	// significance level. See WithHierarchy.
+	Subsegments []Segment
+}
+
+// Len returns the length of the segment.
+func (s Segment) Len() int {
+	return s.End - s.Start
+}
+
+// clip returns a copy of s restricted to [start, end). The bins follow when
+// they equal the coordinates; otherwise the bins covered are unknown, and
+// BinStart and BinEnd are zeroed.
+func (s Segment) clip(start, end int) Segment {
+	if s.BinStart == s.Start && s.BinEnd == s.End {
+		s.BinStart, s.BinEnd = start, end
+	} else {
+		s.BinStart, s.BinEnd = 0, 0
+	}
+	s.Start, s.End = start, end
+	return s
+}
+
+// SegmentSet is a collection of segments, such as the output of a caller for
+// one sample or a list of blacklisted regions.
+//
+// The set operations below never modify their operands and always return a
+// new set sorted by chromosome and start.
+type SegmentSet []Segment
+
+// Chromosome is a named chromosome of a given length.
+type Chromosome struct {
+	Name   string
+	Length int
+}
+
+// Genome lists the chromosomes of a reference genome in reporting order.
+type Genome []Chromosome
+
+// NewSegmentSet builds a SegmentSet from the intervals returned by CBS for the
+// values x measured on chromosome chrom, filling in the segment means.
+func NewSegmentSet(chrom string, x []float64, intervals [][2]int) SegmentSet {
+	set := make(SegmentSet, len(intervals))
+	for i, iv := range intervals {
+		sum := 0.0
+		for _, v := range x[iv[0]:iv[1]] {
+			sum += v
+		}
+		set[i] = Segment{
+			Chrom:    chrom,
+			Start:    iv[0],
+			End:      iv[1],
+			BinStart: iv[0],
+			BinEnd:   iv[1],
+			Mean:     sum / float64(iv[1]-iv[0]),
+		}
+	}
+	return set
+}
+
+// Sorted returns a copy of s sorted by chromosome, start and end.
+// Chromosomes are ordered naturally, so that chr2 comes before chr10.
+func (s SegmentSet) Sorted() SegmentSet {
+	res := make(SegmentSet, len(s))
+	copy(res, s)
+	sort.SliceStable(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.Chrom != b.Chrom {
+			return chromLess(a.Chrom, b.Chrom)
+		}
+		if a.Start != b.Start {
+			return a.Start < b.Start
+		}
+		return a.End < b.End
+	})
+	return res
+}
+
+// Intersect returns the regions where segments of s overlap segments of other.
+//
+// A pair of segments only contributes when the overlap covers at least the
+// fraction minOverlap of both segments (reciprocal overlap); use 0 to accept any
+// overlap. The resulting segments keep the other fields of the segment from s,
+// except for the bins of segments with genomic positions, which are zeroed.
+func (s SegmentSet) Intersect(other SegmentSet, minOverlap float64) SegmentSet {
+	idx := newSegmentIndex(other)
+	var res SegmentSet
+	for _, a := range s.Sorted() {
+		for _, b := range idx.overlapping(a) {
+			start, end := max(a.Start, b.Start), min(a.End, b.End)
+			ov := float64(end - start)
+			if ov < minOverlap*float64(a.Len()) || ov < minOverlap*float64(b.Len()) {
+				continue
+			}
+			res = append(res, a.clip(start, end))
+		}
+	}
+	return res.Sorted()
+}
+
+// Union returns the regions covered by s, other, or both. Overlapping and
+// book-ended segments are merged; the mean of a merged segment is the
+// length-weighted mean of its parts, and its SD and state are zeroed. Its
+// bins follow its coordinates when those of all its parts equal theirs, and
+// are zeroed otherwise, like those of Subtract. Its other fields are those of
+// its first part.
+func (s SegmentSet) Union(other SegmentSet) SegmentSet {
+	all := make(SegmentSet, 0, len(s)+len(other))
+	all = append(append(all, s...), other...)
+	return all.merged()
+}
+
+// Subtract returns the parts of the segments of s that are not covered by any
+// segment of other, for instance to remove blacklisted regions. The resulting
+// segments keep the other fields of the segment from s, except for the bins of
+// segments with genomic positions, which are zeroed.
+func (s SegmentSet) Subtract(other SegmentSet) SegmentSet {
+	idx := newSegmentIndex(other.merged())
+	var res SegmentSet
+	for _, a := range s.Sorted() {
+		start := a.Start
+		for _, b := range idx.overlapping(a) {
+			if b.Start > start {
+				res = append(res, a.clip(start, b.Start))
+			}
+			start = max(start, b.End)
+		}
+		if start < a.End {
+			res = append(res, a.clip(start, a.End))
+		}
+	}
+	return res
+}
+
+// Complement returns the regions of genome not covered by any segment of s,
+// in genome order. Segments on chromosomes missing from genome are ignored.
+// The means of the returned segments are zero.
+func (s SegmentSet) Complement(genome Genome) SegmentSet {
+	byChrom := make(map[string]SegmentSet)
+	for _, seg := range s.merged() {
+		byChrom[seg.Chrom] = append(byChrom[seg.Chrom], seg)
+	}
+
+	var res SegmentSet
+	for _, c := range genome {
+		start := 0
+		for _, seg := range byChrom[c.Name] {
+			if end := min(seg.Start, c.Length); start < end {
+				res = append(res, Segment{Chrom: c.Name, Start: start, End: end})
+			}
+			start = max(start, seg.End)
+		}
+		if start < c.Length {
+			res = append(res, Segment{Chrom: c.Name, Start: start, End: c.Length})
+		}
+	}
+	return res
+}
+
+// merged returns s sorted with overlapping and book-ended segments merged,
+// as described by Union.
+func (s SegmentSet) merged() SegmentSet {
+	var res SegmentSet
+	var sum, weight float64
+	flush := func() {
+		if n := len(res); n > 0 && weight > 0 {
+			res[n-1].Mean = sum / weight
+		}
+	}
+	for _, seg := range s.Sorted() {
+		if n := len(res); n > 0 && res[n-1].Chrom == seg.Chrom && seg.Start <= res[n-1].End {
+			last := &res[n-1]
+			binned := last.BinStart == last.Start && last.BinEnd == last.End && seg.BinStart == seg.Start && seg.BinEnd == seg.End
+			last.End = max(last.End, seg.End)
+			if binned {
+				last.BinEnd = last.End
+			} else {
+				last.BinStart, last.BinEnd = 0, 0
+			}
+			last.SD, last.State = 0, StateUnknown
+		} else {
+			flush()
+			res = append(res, seg)
+			sum, weight = 0, 0
+		}
+		sum += seg.Mean * float64(seg.Len())
+		weight += float64(seg.Len())
+	}
+	flush()
+	return res
+}
+
+// segmentIndex answers overlap queries against a fixed set of segments.
+type segmentIndex struct {
+	byChrom map[string]SegmentSet
+	// maxEnd holds, per chromosome, the running maximum of the segment ends.
+	maxEnd map[string][]int
+}
+
+func newSegmentIndex(s SegmentSet) *segmentIndex {
+	idx := &segmentIndex{byChrom: make(map[string]SegmentSet), maxEnd: make(map[string][]int)}
+	for _, seg := range s.Sorted() {
+		idx.byChrom[seg.Chrom] = append(idx.byChrom[seg.Chrom], seg)
+		ends := idx.maxEnd[seg.Chrom]
+		end := seg.End
+		if n := len(ends); n > 0 {
+			end = max(end, ends[n-1])
+		}
+		idx.maxEnd[seg.Chrom] = append(ends, end)
+	}
+	return idx
+}
+
+// overlapping returns the indexed segments overlapping seg, sorted by start.
+func (idx *segmentIndex) overlapping(seg Segment) SegmentSet {
+	segs, ends := idx.byChrom[seg.Chrom], idx.maxEnd[seg.Chrom]
+	lo := sort.SearchInts(ends, seg.Start+1)
+	hi := sort.Search(len(segs), func(i int) bool { return segs[i].Start >= seg.End })
+	var res SegmentSet
+	for i := lo; i < hi; i++ {
+		if segs[i].End > seg.Start {
+			res = append(res, segs[i])
+		}
+	}
+	return res
+}
+
+// chromLess orders chromosome names naturally: an optional "chr" prefix is
+// ignored, numbered chromosomes come first in numeric order, and the others
+// follow in lexical order.
+func chromLess(a, b string) bool {
+	ta, tb := strings.TrimPrefix(a, "chr"), strings.TrimPrefix(b, "chr")
+	na, errA := strconv.Atoi(ta)
+	nb, errB := strconv.Atoi(tb)
+	switch {
+	case errA == nil && errB == nil && na != nb:
+		return na < nb
+	case errA == nil && errB != nil:
+		return true
+	case errA != nil && errB == nil:
+		return false
+	case ta != tb:
+		return ta < tb
+	}
+	return a < b
+}
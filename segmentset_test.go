@@ -0,0 +1,187 @@
+package cbsgo_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestNewSegmentSet(t *testing.T) {
+	x := []float64{1, 1, 3, 3, 3, 5}
+	res := cbsgo.NewSegmentSet("chr1", x, [][2]int{{0, 2}, {2, 5}, {5, 6}})
+	expected := cbsgo.SegmentSet{
+		{Chrom: "chr1", Start: 0, End: 2, BinStart: 0, BinEnd: 2, Mean: 1},
+		{Chrom: "chr1", Start: 2, End: 5, BinStart: 2, BinEnd: 5, Mean: 3},
+		{Chrom: "chr1", Start: 5, End: 6, BinStart: 5, BinEnd: 6, Mean: 5},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, res)
+	}
+}
+
+func TestSegmentSetSorted(t *testing.T) {
+	set := cbsgo.SegmentSet{
+		{Chrom: "chrX", Start: 0, End: 10},
+		{Chrom: "chr10", Start: 0, End: 10},
+		{Chrom: "chr2", Start: 50, End: 60},
+		{Chrom: "chr2", Start: 0, End: 10},
+	}
+	expected := cbsgo.SegmentSet{
+		{Chrom: "chr2", Start: 0, End: 10},
+		{Chrom: "chr2", Start: 50, End: 60},
+		{Chrom: "chr10", Start: 0, End: 10},
+		{Chrom: "chrX", Start: 0, End: 10},
+	}
+	if res := set.Sorted(); !reflect.DeepEqual(res, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, res)
+	}
+}
+
+func TestSegmentSetIntersect(t *testing.T) {
+	a := cbsgo.SegmentSet{
+		{Chrom: "chr1", Start: 0, End: 100, Mean: 1},
+		{Chrom: "chr1", Start: 200, End: 300, Mean: 2},
+		{Chrom: "chr2", Start: 0, End: 100, Mean: 3},
+	}
+	b := cbsgo.SegmentSet{
+		{Chrom: "chr1", Start: 0, End: 1000},
+		{Chrom: "chr1", Start: 250, End: 260},
+		{Chrom: "chr2", Start: 10, End: 100},
+	}
+
+	tests := []struct {
+		name       string
+		minOverlap float64
+		expected   cbsgo.SegmentSet
+	}{
+		{
+			name:       "any overlap",
+			minOverlap: 0,
+			expected: cbsgo.SegmentSet{
+				{Chrom: "chr1", Start: 0, End: 100, Mean: 1},
+				{Chrom: "chr1", Start: 200, End: 300, Mean: 2},
+				{Chrom: "chr1", Start: 250, End: 260, Mean: 2},
+				{Chrom: "chr2", Start: 10, End: 100, Mean: 3},
+			},
+		},
+		{
+			name:       "reciprocal 50%",
+			minOverlap: 0.5,
+			expected: cbsgo.SegmentSet{
+				{Chrom: "chr2", Start: 10, End: 100, Mean: 3},
+			},
+		},
+	}
+	for _, tt := range tests {
+		if res := a.Intersect(b, tt.minOverlap); !reflect.DeepEqual(res, tt.expected) {
+			t.Errorf("%s: unexpected result.\nExpected: %v\nGot: %v", tt.name, tt.expected, res)
+		}
+	}
+}
+
+func TestSegmentSetUnion(t *testing.T) {
+	a := cbsgo.SegmentSet{
+		{Chrom: "chr1", Start: 0, End: 10, Mean: 1},
+		{Chrom: "chr1", Start: 30, End: 40, Mean: 1},
+	}
+	b := cbsgo.SegmentSet{
+		{Chrom: "chr1", Start: 10, End: 20, Mean: 3},
+		{Chrom: "chr2", Start: 0, End: 10, Mean: 5},
+	}
+	expected := cbsgo.SegmentSet{
+		{Chrom: "chr1", Start: 0, End: 20, Mean: 2},
+		{Chrom: "chr1", Start: 30, End: 40, Mean: 1},
+		{Chrom: "chr2", Start: 0, End: 10, Mean: 5},
+	}
+	if res := a.Union(b); !reflect.DeepEqual(res, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, res)
+	}
+
+	// Bins equal to the coordinates follow the merge, while those of genomic
+	// positions no longer tell the bins covered. The SD and state of the
+	// parts no longer describe the merged segment.
+	binned := cbsgo.SegmentSet{
+		{Chrom: "chr1", End: 10, BinEnd: 10, Mean: 1, SD: 0.5, State: cbsgo.Gain},
+		{Chrom: "chr2", End: 100, BinEnd: 10, Mean: 1, SD: 0.5, State: cbsgo.Gain},
+	}
+	other := cbsgo.SegmentSet{
+		{Chrom: "chr1", Start: 5, End: 50, BinStart: 5, BinEnd: 50, Mean: 1},
+		{Chrom: "chr2", Start: 100, End: 200, BinStart: 10, BinEnd: 20, Mean: 1},
+		{Chrom: "chr3", Start: 100, End: 200, BinStart: 10, BinEnd: 20, Mean: 1, SD: 0.5},
+	}
+	expected = cbsgo.SegmentSet{
+		{Chrom: "chr1", End: 50, BinEnd: 50, Mean: 1},
+		{Chrom: "chr2", End: 200, Mean: 1},
+		{Chrom: "chr3", Start: 100, End: 200, BinStart: 10, BinEnd: 20, Mean: 1, SD: 0.5},
+	}
+	if res := binned.Union(other); !reflect.DeepEqual(res, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, res)
+	}
+}
+
+func TestSegmentSetSubtract(t *testing.T) {
+	a := cbsgo.SegmentSet{
+		{Chrom: "chr1", Start: 0, End: 100, Mean: 1},
+		{Chrom: "chr2", Start: 0, End: 100, Mean: 2},
+	}
+	blacklist := cbsgo.SegmentSet{
+		{Chrom: "chr1", Start: 10, End: 20},
+		{Chrom: "chr1", Start: 15, End: 30},
+		{Chrom: "chr1", Start: 90, End: 200},
+		{Chrom: "chr3", Start: 0, End: 100},
+	}
+	expected := cbsgo.SegmentSet{
+		{Chrom: "chr1", Start: 0, End: 10, Mean: 1},
+		{Chrom: "chr1", Start: 30, End: 90, Mean: 1},
+		{Chrom: "chr2", Start: 0, End: 100, Mean: 2},
+	}
+	if res := a.Subtract(blacklist); !reflect.DeepEqual(res, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, res)
+	}
+
+	// Bins equal to the coordinates are clipped with them, while those of
+	// genomic positions no longer tell the bins covered.
+	a = cbsgo.SegmentSet{
+		{Chrom: "chr1", Start: 0, End: 100, BinStart: 0, BinEnd: 100},
+		{Chrom: "chr2", Start: 1000, End: 2000, BinStart: 5, BinEnd: 15},
+	}
+	blacklist = cbsgo.SegmentSet{
+		{Chrom: "chr1", Start: 40, End: 50},
+		{Chrom: "chr2", Start: 1500, End: 1600},
+	}
+	expected = cbsgo.SegmentSet{
+		{Chrom: "chr1", Start: 0, End: 40, BinStart: 0, BinEnd: 40},
+		{Chrom: "chr1", Start: 50, End: 100, BinStart: 50, BinEnd: 100},
+		{Chrom: "chr2", Start: 1000, End: 1500},
+		{Chrom: "chr2", Start: 1600, End: 2000},
+	}
+	if res := a.Subtract(blacklist); !reflect.DeepEqual(res, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, res)
+	}
+}
+
+func TestSegmentSetComplement(t *testing.T) {
+	genome := cbsgo.Genome{{Name: "chr1", Length: 100}, {Name: "chr2", Length: 50}}
+	set := cbsgo.SegmentSet{
+		{Chrom: "chr1", Start: 10, End: 20},
+		{Chrom: "chr1", Start: 15, End: 40},
+		{Chrom: "chr1", Start: 90, End: 100},
+		{Chrom: "chrUn", Start: 0, End: 10},
+	}
+	expected := cbsgo.SegmentSet{
+		{Chrom: "chr1", Start: 0, End: 10},
+		{Chrom: "chr1", Start: 40, End: 90},
+		{Chrom: "chr2", Start: 0, End: 50},
+	}
+	if res := set.Complement(genome); !reflect.DeepEqual(res, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, res)
+	}
+
+	// Segments beyond the end of the chromosome leave it uncovered.
+	beyond := cbsgo.SegmentSet{{Chrom: "chr2", Start: 150, End: 160}, {Chrom: "chr2", Start: 170, End: 180}}
+	expected = cbsgo.SegmentSet{{Chrom: "chr1", Start: 0, End: 100}, {Chrom: "chr2", Start: 0, End: 50}}
+	if res := beyond.Complement(genome); !reflect.DeepEqual(res, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, res)
+	}
+}
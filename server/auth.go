@@ -0,0 +1,88 @@
+package server
+
+import (
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// errUnauthorized rejects the requests without a valid API key.
+var errUnauthorized = errors.New("cbsgo: missing or invalid API key")
+
+// Authenticator identifies the client of a request, such as by an API key
+// or a token checked by an identity provider, or returns an error to reject
+// the request, which is answered with 401.
+type Authenticator func(*http.Request) (client string, err error)
+
+// APIKeys returns an Authenticator accepting the requests bearing one of
+// the keys of the map keys, in an Authorization header "Bearer <key>" or an
+// X-API-Key header, as the client the key maps to. Several keys may map to
+// the same client, so that keys can be rotated.
+func APIKeys(keys map[string]string) Authenticator {
+	// Keys are looked up by their hashes, so that the time taken does not
+	// tell how much of a key is right.
+	clients := make(map[[sha256.Size]byte]string, len(keys))
+	for key, client := range keys {
+		clients[sha256.Sum256([]byte(key))] = client
+	}
+	return func(r *http.Request) (string, error) {
+		key := r.Header.Get("X-API-Key")
+		if auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			key = auth
+		}
+		client, ok := clients[sha256.Sum256([]byte(key))]
+		if key == "" || !ok {
+			return "", errUnauthorized
+		}
+		return client, nil
+	}
+}
+
+// Usage accounts for the jobs of a client.
+type Usage struct {
+	// Jobs is the number of jobs accepted, and Rejected that of jobs
+	// rejected by the limits of the queue.
+	Jobs     int `json:"jobs"`
+	Rejected int `json:"rejected"`
+	// Failed is the number of jobs that failed, and Canceled that of jobs
+	// canceled by a shutdown.
+	Failed   int `json:"failed"`
+	Canceled int `json:"canceled"`
+	// Cached is the number of jobs answered by an identical earlier job,
+	// which Jobs does not count.
+	Cached int `json:"cached"`
+	// Bins is the number of bins segmented, and Time the time spent
+	// segmenting them, by the jobs that ran.
+	Bins int           `json:"bins"`
+	Time time.Duration `json:"time_ns"`
+}
+
+// Usage returns the usage of every client since the server started.
+func (s *Server) Usage() map[string]Usage {
+	return s.q.usageOf()
+}
+
+// client identifies the client of r, or writes the response rejecting r.
+func (s *Server) client(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if s.cfg.Auth == nil {
+		return s.cfg.Client(r), true
+	}
+	client, err := s.cfg.Auth(r)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, err)
+		return "", false
+	}
+	return client, true
+}
+
+// usage handles GET /usage.
+func (s *Server) usage(w http.ResponseWriter, r *http.Request) {
+	client, ok := s.client(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.Usage()[client])
+}
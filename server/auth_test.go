@@ -0,0 +1,85 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo/server"
+)
+
+// do sends a request to srv with the given headers, and returns the
+// response status and decodes its body into v.
+func do(t *testing.T, srv *httptest.Server, method, path, body string, header map[string]string, v any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, h := range header {
+		req.Header.Set(k, h)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s returned an unexpected error: %v", method, err)
+	}
+	defer resp.Body.Close()
+	if v != nil {
+		json.NewDecoder(resp.Body).Decode(v)
+	}
+	return resp.StatusCode
+}
+
+func TestAPIKeys(t *testing.T) {
+	cfg := server.DefaultConfig()
+	cfg.Auth = server.APIKeys(map[string]string{"k1": "lab1", "k1b": "lab1", "k2": "lab2"})
+	s, err := server.New(cfg)
+	if err != nil {
+		t.Fatalf("New returned an unexpected error: %v", err)
+	}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	for _, header := range []map[string]string{
+		nil,
+		{"X-API-Key": "k3"},
+		{"Authorization": "Bearer "},
+		{"Authorization": "Basic k1"},
+	} {
+		if code := do(t, srv, "POST", "/jobs", stepJob(""), header, nil); code != http.StatusUnauthorized {
+			t.Errorf("%v: expected status 401, got %d", header, code)
+		}
+	}
+
+	var st jobStatus
+	if code := do(t, srv, "POST", "/jobs", stepJob(""), map[string]string{"Authorization": "Bearer k1"}, &st); code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d", code)
+	}
+	// The job is seen by its client, with any of its keys, and by no other.
+	for key, expected := range map[string]int{"k1b": http.StatusOK, "k2": http.StatusNotFound, "k3": http.StatusUnauthorized} {
+		if code := do(t, srv, "GET", "/jobs/"+st.ID, "", map[string]string{"X-API-Key": key}, nil); code != expected {
+			t.Errorf("%s: expected status %d, got %d", key, expected, code)
+		}
+	}
+	for deadline := 0; st.Status != server.Done && deadline < 2000; deadline++ {
+		do(t, srv, "GET", "/jobs/"+st.ID, "", map[string]string{"X-API-Key": "k1"}, &st)
+	}
+	if st.Status != server.Done {
+		t.Fatalf("Expected the job to complete, got %+v", st)
+	}
+	do(t, srv, "POST", "/jobs", `{"tracks": []}`, map[string]string{"X-API-Key": "k2"}, nil)
+
+	var u server.Usage
+	if code := do(t, srv, "GET", "/usage", "", map[string]string{"X-API-Key": "k1"}, &u); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if u.Jobs != 1 || u.Bins != 100 || u.Failed != 0 || u.Time <= 0 {
+		t.Errorf("Unexpected result.\nExpected: 1 job of 100 bins\nGot: %+v", u)
+	}
+	// Invalid jobs are not accounted for.
+	if usage := s.Usage(); len(usage) != 1 || usage["lab1"] != u {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", map[string]server.Usage{"lab1": u}, usage)
+	}
+}
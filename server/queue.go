@@ -0,0 +1,256 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// Status is the state of a job.
+type Status string
+
+const (
+	Queued   Status = "queued"
+	Running  Status = "running"
+	Done     Status = "done"
+	Failed   Status = "failed"
+	Canceled Status = "canceled"
+)
+
+var (
+	// errQueueFull rejects jobs while the queue holds Config.QueueSize jobs.
+	errQueueFull = errors.New("cbsgo: the job queue is full")
+	// errClientQueue rejects the jobs of a client holding
+	// Config.ClientQueue queued jobs.
+	errClientQueue = errors.New("cbsgo: too many queued jobs of the client")
+	// errShutdown rejects jobs once the server is shutting down.
+	errShutdown = errors.New("cbsgo: the server is shutting down")
+)
+
+// job is a segmentation submitted to the server.
+type job struct {
+	id     string
+	client string
+	// key identifies the jobs of the client with the same tracks and
+	// options, and so the same result. It is empty for the jobs with a
+	// time-based seed, whose results differ.
+	key    string
+	tracks []*cbsgo.Track
+	opts   []cbsgo.Option
+
+	// The fields below are guarded by the mutex of the queue.
+	status   Status
+	err      error
+	res      *cbsgo.GenomeResult
+	finished time.Time
+}
+
+// queue holds the jobs and segments them on a fixed number of workers,
+// running at most Config.ClientLimit jobs of a client at once: workers take
+// the first queued job whose client is under its limit, so that the jobs of
+// a busy client do not hold up those of others.
+type queue struct {
+	cfg Config
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []*job
+	jobs    map[string]*job
+	// keys maps the key of a job to the latest job with that key.
+	keys map[string]*job
+	// running and queued count the jobs of every client.
+	running map[string]int
+	queued  map[string]int
+	closing bool
+	usage   map[string]Usage
+
+	// ctx is the context of the segmentations, canceled when a shutdown
+	// runs out of time.
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// newQueue returns a queue configured by cfg and starts its workers.
+func newQueue(cfg Config) *queue {
+	q := &queue{
+		cfg:     cfg,
+		jobs:    make(map[string]*job),
+		keys:    make(map[string]*job),
+		running: make(map[string]int),
+		queued:  make(map[string]int),
+		usage:   make(map[string]Usage),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	q.ctx, q.cancel = context.WithCancel(context.Background())
+	for range cfg.Workers {
+		q.wg.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+// submit queues j, and returns its state and position in the queue. If a
+// job with the key of j is kept and did not fail or was not canceled, that
+// job is returned instead, since it gives the same result. Jobs without a
+// key are always queued.
+func (q *queue) submit(j *job) (job, int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	u := q.usage[j.client]
+	defer func() { q.usage[j.client] = u }()
+	q.forget(time.Now().Add(-q.cfg.Retention))
+	if p, ok := q.keys[j.key]; ok && p.status != Failed && p.status != Canceled {
+		u.Cached++
+		return *p, q.position(p), nil
+	}
+	var err error
+	switch {
+	case q.closing:
+		err = errShutdown
+	case len(q.pending) >= q.cfg.QueueSize:
+		err = errQueueFull
+	case q.cfg.ClientQueue > 0 && q.queued[j.client] >= q.cfg.ClientQueue:
+		err = errClientQueue
+	}
+	if err != nil {
+		u.Rejected++
+		return job{}, 0, err
+	}
+	u.Jobs++
+	j.status = Queued
+	q.pending = append(q.pending, j)
+	q.queued[j.client]++
+	q.jobs[j.id] = j
+	if j.key != "" {
+		q.keys[j.key] = j
+	}
+	q.cond.Broadcast()
+	return *j, len(q.pending), nil
+}
+
+// forget drops the jobs finished before t. q.mu must be held.
+func (q *queue) forget(t time.Time) {
+	for id, j := range q.jobs {
+		if !j.finished.IsZero() && j.finished.Before(t) {
+			delete(q.jobs, id)
+			if q.keys[j.key] == j {
+				delete(q.keys, j.key)
+			}
+		}
+	}
+}
+
+// status returns the state of the job id and, if queued, its position: the
+// number of jobs before it in the queue, plus one.
+func (q *queue) status(id string) (j job, position int, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	p, ok := q.jobs[id]
+	if !ok {
+		return job{}, 0, false
+	}
+	return *p, q.position(p), true
+}
+
+// position returns the number of jobs before j in the queue plus one, or 0
+// if j is not queued. q.mu must be held.
+func (q *queue) position(j *job) int {
+	if j.status != Queued {
+		return 0
+	}
+	return slices.Index(q.pending, j) + 1
+}
+
+// next waits for a job to run and takes it from the queue, or returns nil
+// once the queue is closing.
+func (q *queue) next() *job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for !q.closing {
+		i := slices.IndexFunc(q.pending, func(j *job) bool {
+			return q.cfg.ClientLimit == 0 || q.running[j.client] < q.cfg.ClientLimit
+		})
+		if i >= 0 {
+			j := q.pending[i]
+			q.pending = slices.Delete(q.pending, i, i+1)
+			q.queued[j.client]--
+			q.running[j.client]++
+			j.status = Running
+			return j
+		}
+		q.cond.Wait()
+	}
+	return nil
+}
+
+// work runs jobs until the queue is closing.
+func (q *queue) work() {
+	defer q.wg.Done()
+	for j := q.next(); j != nil; j = q.next() {
+		start := time.Now()
+		res, err := cbsgo.SegmentGenome(q.ctx, j.tracks, j.opts...)
+		q.mu.Lock()
+		j.res, j.err, j.finished = res, err, time.Now()
+		j.status = Done
+		u := q.usage[j.client]
+		if err != nil {
+			j.status = Failed
+			u.Failed++
+		}
+		for _, t := range j.tracks {
+			u.Bins += len(t.Values)
+		}
+		u.Time += j.finished.Sub(start)
+		q.usage[j.client] = u
+		j.tracks = nil
+		q.running[j.client]--
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	}
+}
+
+// shutdown rejects new jobs, cancels the queued ones and waits for those
+// running to complete, or cancels them when ctx ends first.
+func (q *queue) shutdown(ctx context.Context) error {
+	defer q.cancel()
+	q.mu.Lock()
+	q.closing = true
+	now := time.Now()
+	for _, j := range q.pending {
+		j.status, j.err, j.finished, j.tracks = Canceled, errShutdown, now, nil
+		u := q.usage[j.client]
+		u.Canceled++
+		q.usage[j.client] = u
+	}
+	q.pending = nil
+	clear(q.queued)
+	q.cond.Broadcast()
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// usageOf returns a copy of the usage of every client.
+func (q *queue) usageOf() map[string]Usage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return maps.Clone(q.usage)
+}
@@ -0,0 +1,131 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/server"
+)
+
+// gate is a cbsgo.MetricsRecorder holding the runs until released, to keep
+// the workers of a server busy.
+type gate chan struct{}
+
+func (g gate) Record(cbsgo.RunStats) {
+	<-g
+}
+
+func TestServerQueue(t *testing.T) {
+	cfg := server.Config{
+		Workers:     2,
+		QueueSize:   3,
+		ClientLimit: 1,
+		ClientQueue: 2,
+		Retention:   time.Hour,
+		Client:      func(r *http.Request) string { return r.Header.Get("X-Client") },
+	}
+	// The jobs have different seeds, so that none is answered by another.
+	g := make(gate)
+	s, err := server.New(cfg, cbsgo.WithMetrics(g))
+	if err != nil {
+		t.Fatalf("New returned an unexpected error: %v", err)
+	}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	_, a1 := submit(t, srv, "a", stepJob(`, "seed": 1`))
+	poll(t, srv, a1.ID, server.Running)
+	// The second job of a waits for the first, while that of b runs.
+	_, a2 := submit(t, srv, "a", stepJob(`, "seed": 2`))
+	_, b1 := submit(t, srv, "b", stepJob(`, "seed": 3`))
+	poll(t, srv, b1.ID, server.Running)
+	if st := poll(t, srv, a2.ID, server.Queued); st.Position != 1 {
+		t.Errorf("Expected the job at position 1, got %+v", st)
+	}
+	_, a3 := submit(t, srv, "a", stepJob(`, "seed": 4`))
+	if a3.Position != 2 {
+		t.Errorf("Expected the job at position 2, got %+v", a3)
+	}
+	if code, _ := submit(t, srv, "a", stepJob(`, "seed": 5`)); code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429 for a client with too many queued jobs, got %d", code)
+	}
+	if code, _ := submit(t, srv, "b", stepJob(`, "seed": 6`)); code != http.StatusAccepted {
+		t.Errorf("Expected status 202, got %d", code)
+	}
+	if code, _ := submit(t, srv, "c", stepJob(`, "seed": 7`)); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 for a full queue, got %d", code)
+	}
+
+	// Shutting down cancels the queued jobs and completes the running ones.
+	done := make(chan error)
+	go func() { done <- s.Shutdown(context.Background()) }()
+	poll(t, srv, a2.ID, server.Canceled)
+	if code, _ := submit(t, srv, "c", stepJob(`, "seed": 8`)); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 while shutting down, got %d", code)
+	}
+	close(g)
+	if err := <-done; err != nil {
+		t.Errorf("Shutdown returned an unexpected error: %v", err)
+	}
+	for _, id := range []string{a1.ID, b1.ID} {
+		if st := poll(t, srv, id, server.Done, server.Failed); st.Status != server.Done {
+			t.Errorf("Expected the running job to complete, got %+v", st)
+		}
+	}
+	if st := poll(t, srv, a3.ID, server.Canceled, server.Done); st.Status != server.Canceled || st.Error == "" {
+		t.Errorf("Expected the queued job to be canceled, got %+v", st)
+	}
+}
+
+func TestServerCache(t *testing.T) {
+	cfg := server.DefaultConfig()
+	cfg.Client = func(r *http.Request) string { return r.Header.Get("X-Client") }
+	g := make(gate)
+	s, err := server.New(cfg, cbsgo.WithMetrics(g))
+	if err != nil {
+		t.Fatalf("New returned an unexpected error: %v", err)
+	}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	_, a1 := submit(t, srv, "a", stepJob(""))
+	poll(t, srv, a1.ID, server.Running)
+	// A retry is answered by the running job, the default seed being set.
+	for _, body := range []string{stepJob(""), stepJob(`, "seed": 1`)} {
+		if code, st := submit(t, srv, "a", body); code != http.StatusAccepted || st.ID != a1.ID || st.Status != server.Running {
+			t.Errorf("Expected the running job %s, got %d, %+v", a1.ID, code, st)
+		}
+	}
+	// Other jobs, or the same of another client, are segmented anew.
+	for client, body := range map[string]string{"a": stepJob(`, "seed": 2`), "b": stepJob("")} {
+		if _, st := submit(t, srv, client, body); st.ID == "" || st.ID == a1.ID {
+			t.Errorf("%s: expected a new job, got %+v", client, st)
+		}
+	}
+	close(g)
+	done := poll(t, srv, a1.ID, server.Done, server.Failed)
+	code, st := submit(t, srv, "a", stepJob(""))
+	if code != http.StatusOK || st.ID != a1.ID || st.Status != server.Done || len(st.Segments) != len(done.Segments) {
+		t.Errorf("Expected the done job %+v, got %d, %+v", done, code, st)
+	}
+
+	// Jobs with a time-based seed are run again.
+	_, t1 := submit(t, srv, "a", stepJob(`, "seed": 0`))
+	if _, t2 := submit(t, srv, "a", stepJob(`, "seed": 0`)); t1.ID == "" || t2.ID == "" || t2.ID == t1.ID {
+		t.Errorf("Expected new jobs for a time-based seed, got %+v and %+v", t1, t2)
+	}
+
+	// Failed jobs are run again.
+	_, f1 := submit(t, srv, "a", stepJob(`, "min_width": -1`))
+	poll(t, srv, f1.ID, server.Failed)
+	if _, f2 := submit(t, srv, "a", stepJob(`, "min_width": -1`)); f2.ID == f1.ID {
+		t.Errorf("Expected a new job for a failed one, got %+v", f2)
+	}
+	if u := s.Usage()["a"]; u.Jobs != 6 || u.Cached != 3 {
+		t.Errorf("Unexpected result.\nExpected: 6 jobs and 3 cached\nGot: %+v", u)
+	}
+}
@@ -0,0 +1,380 @@
+// Package server serves segmentations over HTTP, as a service shared by the
+// pipelines and users of a lab. Jobs are submitted as JSON, queued, and
+// segmented by a fixed number of workers, while clients poll for their
+// results:
+//
+//	POST /jobs       submits a job, answering 202 with its id and position
+//	GET  /jobs/{id}  returns the status of a job, and its segments once done
+//	GET  /usage      returns the Usage of the client
+//
+// A job is an object with the fields tracks, an array of objects with the
+// fields chrom, starts, ends and values of cbsgo.Track, and the optional
+// fields sample, preset, alpha, shuffles, seed, min_width, undo_sd and
+// transform, named like the parameters of cbsgo.Manifest. The seed defaults
+// to 1, so that repeated jobs give the same result.
+//
+// The status of a job is an object with the fields id, status (queued,
+// running, done, failed or canceled), position while queued, error if
+// failed, and segments, an array of the objects written by
+// cbsgo.JSONLWriter, once done. Errors are answered with an object holding
+// the field error: 400 for invalid jobs and jobs over the limits of Config,
+// 404 for unknown ones, 429 when the
+// client has too many jobs queued, and 503 when the queue is full or the
+// server is shutting down.
+//
+// A job identical to an earlier one of the client, with the same tracks and
+// options, is answered with the status of the earlier job while it is kept,
+// unless it failed or was canceled, rather than segmented again: with 200
+// and its segments once done, or 202 while it is queued or running. Workflow
+// engines retrying a submission so get their result at once. Jobs with a
+// seed of 0, which selects a time-based seed, are always segmented anew.
+//
+// With Config.Auth set, such as to APIKeys, requests are answered only for
+// authenticated clients, with 401 otherwise, and clients only see their own
+// jobs, so that a deployment can be shared safely.
+//
+// Only the JSON API over HTTP is served.
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// maxRequestBytes bounds the size of a job submitted.
+const maxRequestBytes = 256 << 20
+
+// Config configures a Server.
+type Config struct {
+	// Workers is the number of jobs segmented concurrently.
+	Workers int
+	// QueueSize bounds the number of jobs waiting for a worker.
+	QueueSize int
+	// ClientLimit bounds the number of jobs of a client segmented
+	// concurrently, and ClientQueue the number of its jobs waiting. 0 sets
+	// no limit.
+	ClientLimit int
+	ClientQueue int
+	// Retention is how long the results of finished jobs are kept.
+	Retention time.Duration
+	// MaxShuffles bounds the permutations of the tests of a job, and MaxBins
+	// the bins of its tracks together, so that a single job cannot hold a
+	// worker for long. 0 sets no limit.
+	MaxShuffles int
+	MaxBins     int
+
+	// Client identifies the client of a request, by default by its remote
+	// host, unless Auth is set.
+	Client func(*http.Request) string
+	// Auth authenticates the clients of the requests, if set.
+	Auth Authenticator
+}
+
+// DefaultConfig returns a configuration segmenting a job per CPU, queueing
+// up to 100 jobs, without limits per client, keeping results for an hour,
+// and accepting jobs of up to 100000 permutations and 10 million bins.
+func DefaultConfig() Config {
+	return Config{
+		Workers:     runtime.GOMAXPROCS(0),
+		QueueSize:   100,
+		Retention:   time.Hour,
+		MaxShuffles: 100000,
+		MaxBins:     10_000_000,
+	}
+}
+
+// Server is an http.Handler serving segmentations.
+type Server struct {
+	cfg  Config
+	opts []cbsgo.Option
+	q    *queue
+	mux  *http.ServeMux
+}
+
+// New returns a server configured by cfg, and starts its workers. The
+// options opts apply to every job, before those of the job, such as to set
+// cbsgo.WithMemoryLimit.
+func New(cfg Config, opts ...cbsgo.Option) (*Server, error) {
+	if cfg.Workers < 1 || cfg.QueueSize < 1 || cfg.ClientLimit < 0 || cfg.ClientQueue < 0 || cfg.Retention < 0 ||
+		cfg.MaxShuffles < 0 || cfg.MaxBins < 0 {
+		return nil, fmt.Errorf("cbsgo: invalid server configuration %+v", cfg)
+	}
+	if cfg.Client == nil {
+		cfg.Client = remoteHost
+	}
+	s := &Server{cfg: cfg, opts: opts, q: newQueue(cfg), mux: http.NewServeMux()}
+	s.mux.HandleFunc("POST /jobs", s.submit)
+	s.mux.HandleFunc("GET /jobs/{id}", s.status)
+	s.mux.HandleFunc("GET /usage", s.usage)
+	return s, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Shutdown rejects new jobs, cancels the queued ones and waits for the
+// running ones to complete. If ctx ends first, the running jobs are
+// canceled, failing with the error of ctx, which Shutdown returns. Shut
+// down the http.Server afterwards, so that clients can still fetch the
+// results of the jobs completed meanwhile.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.q.shutdown(ctx)
+}
+
+// remoteHost returns the host of the remote address of r.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// jobRequest is the JSON object of a job.
+type jobRequest struct {
+	Tracks    []jobTrack `json:"tracks"`
+	Sample    string     `json:"sample"`
+	Preset    string     `json:"preset"`
+	Alpha     *float64   `json:"alpha"`
+	Shuffles  *int       `json:"shuffles"`
+	Seed      *int64     `json:"seed"`
+	MinWidth  int        `json:"min_width"`
+	UndoSD    float64    `json:"undo_sd"`
+	Transform string     `json:"transform"`
+}
+
+// jobTrack is the JSON object of a track.
+type jobTrack struct {
+	Chrom  string    `json:"chrom"`
+	Starts []int     `json:"starts"`
+	Ends   []int     `json:"ends"`
+	Values []float64 `json:"values"`
+}
+
+// options returns the options of the job requested by req.
+func (req *jobRequest) options() ([]cbsgo.Option, error) {
+	var opts []cbsgo.Option
+	if req.Preset != "" {
+		if _, err := cbsgo.LookupPreset(req.Preset); err != nil {
+			return nil, err
+		}
+		opts = append(opts, cbsgo.WithPreset(req.Preset))
+	}
+	opts = append(opts, cbsgo.WithSeed(*req.Seed), cbsgo.WithSample(req.Sample))
+	if req.Alpha != nil {
+		opts = append(opts, cbsgo.WithAlpha(*req.Alpha))
+	}
+	if req.Shuffles != nil {
+		opts = append(opts, cbsgo.WithShuffles(*req.Shuffles))
+	}
+	if req.MinWidth != 0 {
+		opts = append(opts, cbsgo.WithMinWidth(req.MinWidth))
+	}
+	if req.UndoSD != 0 {
+		opts = append(opts, cbsgo.WithUndoSD(req.UndoSD))
+	}
+	if req.Transform != "" {
+		t, err := cbsgo.ParseTransform(req.Transform)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, cbsgo.WithTransform(t))
+	}
+	return opts, nil
+}
+
+// jobStatus is the JSON object of the status of a job.
+type jobStatus struct {
+	ID       string            `json:"id"`
+	Status   Status            `json:"status"`
+	Position int               `json:"position,omitempty"`
+	Error    string            `json:"error,omitempty"`
+	Segments []json.RawMessage `json:"segments,omitempty"`
+}
+
+// submit handles POST /jobs.
+func (s *Server) submit(w http.ResponseWriter, r *http.Request) {
+	client, ok := s.client(w, r)
+	if !ok {
+		return
+	}
+	var req jobRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("cbsgo: invalid job: %w", err))
+		return
+	}
+	if req.Seed == nil {
+		seed := int64(1)
+		req.Seed = &seed
+	}
+	opts, err := req.options()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(req.Tracks) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("cbsgo: the job has no tracks"))
+		return
+	}
+	tracks := make([]*cbsgo.Track, len(req.Tracks))
+	bins := 0
+	for i, t := range req.Tracks {
+		tracks[i] = &cbsgo.Track{Chrom: t.Chrom, Starts: t.Starts, Ends: t.Ends, Values: t.Values}
+		if err := tracks[i].Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		bins += len(t.Values)
+	}
+	opts = append(slices.Clone(s.opts), opts...)
+	if n := shuffles(opts); s.cfg.MaxShuffles > 0 && n > s.cfg.MaxShuffles {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("cbsgo: %d permutations are over the limit of %d", n, s.cfg.MaxShuffles))
+		return
+	}
+	if s.cfg.MaxBins > 0 && bins > s.cfg.MaxBins {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("cbsgo: %d bins are over the limit of %d", bins, s.cfg.MaxBins))
+		return
+	}
+
+	var key string
+	if *req.Seed != 0 {
+		if key, err = req.key(client); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	j := &job{id: newID(), client: client, key: key, tracks: tracks, opts: opts}
+	sj, position, err := s.q.submit(j)
+	switch {
+	case errors.Is(err, errClientQueue):
+		writeError(w, http.StatusTooManyRequests, err)
+		return
+	case err != nil:
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	st, err := newJobStatus(sj, position)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Location", "/jobs/"+sj.id)
+	code := http.StatusAccepted
+	if sj.status == Done {
+		code = http.StatusOK
+	}
+	writeJSON(w, code, st)
+}
+
+// shuffles returns the number of permutations of the runs configured by
+// opts.
+func shuffles(opts []cbsgo.Option) int {
+	n, _ := strconv.Atoi(cbsgo.NewManifest(opts...).Parameters["shuffles"])
+	return n
+}
+
+// key returns the key of the job requested by req of the given client: a
+// hash of the client and the request, with its defaults set.
+func (req *jobRequest) key(client string) (string, error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(client))
+	h.Write([]byte{0})
+	h.Write(b)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// status handles GET /jobs/{id}.
+func (s *Server) status(w http.ResponseWriter, r *http.Request) {
+	client, ok := s.client(w, r)
+	if !ok {
+		return
+	}
+	j, position, ok := s.q.status(r.PathValue("id"))
+	if !ok || s.cfg.Auth != nil && j.client != client {
+		writeError(w, http.StatusNotFound, errors.New("cbsgo: unknown job"))
+		return
+	}
+	st, err := newJobStatus(j, position)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, st)
+}
+
+// newJobStatus returns the status of j, at the given position in the queue.
+func newJobStatus(j job, position int) (jobStatus, error) {
+	st := jobStatus{ID: j.id, Status: j.status, Position: position}
+	if j.err != nil {
+		st.Error = j.err.Error()
+	}
+	if j.status == Done {
+		var err error
+		if st.Segments, err = encodeSegments(j.res.Segments); err != nil {
+			return jobStatus{}, err
+		}
+	}
+	return st, nil
+}
+
+// encodeSegments returns the objects of the segments written by a
+// JSONLWriter.
+func encodeSegments(segments []cbsgo.Segment) ([]json.RawMessage, error) {
+	var b bytes.Buffer
+	jw := cbsgo.NewJSONLWriter(&b, "")
+	for _, seg := range segments {
+		if err := jw.Write(seg); err != nil {
+			return nil, err
+		}
+	}
+	res := make([]json.RawMessage, 0, len(segments))
+	for _, line := range bytes.Split(bytes.TrimSuffix(b.Bytes(), []byte("\n")), []byte("\n")) {
+		if len(line) > 0 {
+			res = append(res, json.RawMessage(line))
+		}
+	}
+	return res, nil
+}
+
+// newID returns a random job id.
+func newID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// writeJSON writes v as the JSON body of a response with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes err as the JSON body of a response with the given
+// status.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
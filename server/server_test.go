@@ -0,0 +1,153 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattdsm/cbsgo/server"
+)
+
+// jobStatus is the status of a job, as returned by the server.
+type jobStatus struct {
+	ID       string           `json:"id"`
+	Status   server.Status    `json:"status"`
+	Position int              `json:"position"`
+	Error    string           `json:"error"`
+	Segments []map[string]any `json:"segments"`
+}
+
+// stepJob returns a job of a track with a step at its middle.
+func stepJob(extra string) string {
+	rng := rand.New(rand.NewSource(1))
+	values := make([]string, 100)
+	for i := range values {
+		v := 0.1 * rng.NormFloat64()
+		if i >= 50 {
+			v++
+		}
+		values[i] = fmt.Sprint(v)
+	}
+	return `{"tracks": [{"chrom": "chr1", "values": [` + strings.Join(values, ",") + `]}], "shuffles": 1000` + extra + `}`
+}
+
+// submit submits the job body to srv as the given client, and returns the
+// HTTP status and the status of the job.
+func submit(t *testing.T, srv *httptest.Server, client, body string) (int, jobStatus) {
+	t.Helper()
+	req, err := http.NewRequest("POST", srv.URL+"/jobs", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Client", client)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST returned an unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	var st jobStatus
+	json.NewDecoder(resp.Body).Decode(&st)
+	return resp.StatusCode, st
+}
+
+// poll returns the status of the job id once it is in one of the states
+// wanted.
+func poll(t *testing.T, srv *httptest.Server, id string, wanted ...server.Status) jobStatus {
+	t.Helper()
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		resp, err := http.Get(srv.URL + "/jobs/" + id)
+		if err != nil {
+			t.Fatalf("GET returned an unexpected error: %v", err)
+		}
+		var st jobStatus
+		err = json.NewDecoder(resp.Body).Decode(&st)
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("Unexpected response %d, %v", resp.StatusCode, err)
+		}
+		for _, s := range wanted {
+			if st.Status == s {
+				return st
+			}
+		}
+	}
+	t.Fatalf("Job %s never reached %v", id, wanted)
+	return jobStatus{}
+}
+
+func TestServer(t *testing.T) {
+	s, err := server.New(server.DefaultConfig())
+	if err != nil {
+		t.Fatalf("New returned an unexpected error: %v", err)
+	}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	code, st := submit(t, srv, "", stepJob(`, "sample": "s1"`))
+	if code != http.StatusAccepted || st.ID == "" || st.Status != server.Queued || st.Position != 1 {
+		t.Fatalf("Unexpected response %d, %+v", code, st)
+	}
+	st = poll(t, srv, st.ID, server.Done, server.Failed)
+	// Like those of cbsgo.Run, the split may be placed a bin early.
+	if st.Status != server.Done || len(st.Segments) != 2 || st.Segments[1]["bin_start"] != 50.0 && st.Segments[1]["bin_start"] != 49.0 {
+		t.Errorf("Expected 2 segments split at 50, got %+v", st)
+	}
+
+	for name, body := range map[string]string{
+		"syntax":    "{",
+		"unknown":   stepJob(`, "alfa": 0.1`),
+		"tracks":    `{"tracks": []}`,
+		"track":     `{"tracks": [{"chrom": "chr1", "starts": [0], "values": [1, 2]}]}`,
+		"transform": stepJob(`, "transform": "log"`),
+		"preset":    stepJob(`, "preset": "nanopore"`),
+	} {
+		if code, _ := submit(t, srv, "", body); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", name, code)
+		}
+	}
+	if code, _ := submit(t, srv, "", stepJob(`, "alpha": 2`)); code != http.StatusAccepted {
+		t.Errorf("Expected status 202, got %d", code)
+	}
+	resp, err := http.Get(srv.URL + "/jobs/unknown")
+	if err != nil {
+		t.Fatalf("GET returned an unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", resp.StatusCode)
+	}
+
+	// Jobs are bounded in permutations, also those of presets, and in bins.
+	cfg := server.DefaultConfig()
+	cfg.MaxShuffles, cfg.MaxBins = 5000, 150
+	limited, err := server.New(cfg)
+	if err != nil {
+		t.Fatalf("New returned an unexpected error: %v", err)
+	}
+	defer limited.Shutdown(context.Background())
+	lsrv := httptest.NewServer(limited)
+	defer lsrv.Close()
+	if code, _ := submit(t, lsrv, "", stepJob("")); code != http.StatusAccepted {
+		t.Errorf("Expected status 202, got %d", code)
+	}
+	zeros := strings.Repeat("0,", 99) + "0"
+	for name, body := range map[string]string{
+		"shuffles": stepJob(`, "shuffles": 10000`),
+		"preset":   strings.Replace(stepJob(`, "preset": "WES"`), `, "shuffles": 1000`, "", 1),
+		"bins":     `{"tracks": [{"chrom": "chr1", "values": [` + zeros + `]}, {"chrom": "chr2", "values": [` + zeros + `]}]}`,
+	} {
+		if code, _ := submit(t, lsrv, "", body); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", name, code)
+		}
+	}
+
+	if _, err := server.New(server.Config{}); err == nil {
+		t.Error("Expected an error for a server without workers")
+	}
+}
@@ -0,0 +1,102 @@
+package cbsgo
+
+import (
+	"sort"
+	"strings"
+)
+
+// Sex is the chromosomal sex of a sample.
+type Sex int
+
+const (
+	// SexUnknown is reported when the sex cannot be inferred.
+	SexUnknown Sex = iota
+	// Female samples have two copies of X and no Y.
+	Female
+	// Male samples have one copy of X and one of Y.
+	Male
+)
+
+// String returns a short name of the sex.
+func (s Sex) String() string {
+	switch s {
+	case Female:
+		return "female"
+	case Male:
+		return "male"
+	}
+	return "unknown"
+}
+
+// InferSex infers the sex of a sample from the log2 ratios of its tracks,
+// measured against a diploid baseline for every chromosome, as when the
+// reference is built from female samples. A single copy of X lowers its median
+// log2 ratio by about one relative to the autosomes. The sex is unknown when
+// the tracks cover no X chromosome or no autosome.
+func InferSex(tracks []*Track) Sex {
+	var auto, x []float64
+	for _, t := range tracks {
+		switch sexChrom(t.Chrom) {
+		case "X":
+			x = append(x, t.Compact().Values...)
+		case "":
+			auto = append(auto, t.Compact().Values...)
+		}
+	}
+	if len(auto) == 0 || len(x) == 0 {
+		return SexUnknown
+	}
+	sort.Float64s(auto)
+	sort.Float64s(x)
+	if median(x)-median(auto) < -0.5 {
+		return Male
+	}
+	return Female
+}
+
+// AdjustForSex adjusts the log2 ratios of the sex chromosomes of a sample of
+// the given sex, measured against a diploid baseline, to the expected copy
+// number of the sample, so that the single X of male samples is not reported
+// as a loss. For male samples the values of X and Y are raised by one; for
+// female samples, Y, which is not expected to be present, is masked. Other
+// tracks, and all tracks of samples of unknown sex, are returned unchanged.
+//
+// The pseudoautosomal regions of X are diploid in male samples too and may
+// appear as gains after the adjustment; mask them beforehand if needed.
+func AdjustForSex(tracks []*Track, sex Sex) []*Track {
+	res := make([]*Track, len(tracks))
+	for i, t := range tracks {
+		res[i] = t
+		chrom := sexChrom(t.Chrom)
+		switch {
+		case chrom == "" || sex == SexUnknown:
+		case sex == Male:
+			c := *t
+			c.Values = make([]float64, len(t.Values))
+			for j, v := range t.Values {
+				c.Values[j] = v + 1
+			}
+			res[i] = &c
+		case chrom == "Y":
+			c := *t
+			c.Mask = make([]bool, len(t.Values))
+			for j := range c.Mask {
+				c.Mask[j] = true
+			}
+			res[i] = &c
+		}
+	}
+	return res
+}
+
+// sexChrom returns "X" or "Y" for the sex chromosomes, with or without a
+// "chr" prefix, and "" for other chromosomes.
+func sexChrom(name string) string {
+	switch strings.ToUpper(strings.TrimPrefix(name, "chr")) {
+	case "X", "23":
+		return "X"
+	case "Y", "24":
+		return "Y"
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package cbsgo_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// sample returns the tracks of a sample with a single X when male.
+func sample(male bool) []*cbsgo.Track {
+	noise := func(n int, offset float64) []float64 {
+		x := make([]float64, n)
+		for i := range x {
+			x[i] = math.Sin(float64(i))/10 + offset
+		}
+		return x
+	}
+	xOffset := 0.0
+	if male {
+		xOffset = -1
+	}
+	return []*cbsgo.Track{
+		{Chrom: "chr1", Values: noise(100, 0)},
+		{Chrom: "chr2", Values: noise(100, 0)},
+		{Chrom: "chrX", Values: noise(50, xOffset)},
+		{Chrom: "chrY", Values: noise(10, xOffset)},
+	}
+}
+
+func TestInferSex(t *testing.T) {
+	if got := cbsgo.InferSex(sample(true)); got != cbsgo.Male {
+		t.Errorf("Expected male, got %v", got)
+	}
+	if got := cbsgo.InferSex(sample(false)); got != cbsgo.Female {
+		t.Errorf("Expected female, got %v", got)
+	}
+	if got := cbsgo.InferSex(sample(true)[:2]); got != cbsgo.SexUnknown {
+		t.Errorf("Expected an unknown sex without X, got %v", got)
+	}
+}
+
+func TestAdjustForSex(t *testing.T) {
+	male := sample(true)
+	adjusted := cbsgo.AdjustForSex(male, cbsgo.Male)
+	if male[2].Values[0] != math.Sin(0)/10-1 {
+		t.Fatalf("AdjustForSex modified its input")
+	}
+	if adjusted[0] != male[0] {
+		t.Errorf("Expected autosomes to be shared")
+	}
+	for _, track := range adjusted[2:] {
+		res, err := track.Segment(cbsgo.WithSeed(42), cbsgo.WithCalls(cbsgo.DefaultCallThresholds()))
+		if err != nil {
+			t.Fatalf("Segment returned an unexpected error: %v", err)
+		}
+		for _, s := range res.Segments {
+			if s.State != cbsgo.Neutral {
+				t.Errorf("%s: expected a neutral segment, got %+v", track.Chrom, s)
+			}
+		}
+	}
+
+	female := cbsgo.AdjustForSex(sample(false), cbsgo.Female)
+	if y := female[3]; y.Compact().Len() != 0 {
+		t.Errorf("Expected Y to be masked in female samples")
+	}
+}
@@ -0,0 +1,173 @@
+package cbsgo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Shard describes the segmentation of a range of bins of a track, to be run
+// independently of the other shards of a genome, for instance on another
+// machine. Shards and their results hold exported fields only and can be
+// serialized, such as with encoding/gob.
+type Shard struct {
+	Chrom string
+	// BinStart and BinEnd delimit the bins of the track, [BinStart, BinEnd).
+	BinStart int
+	BinEnd   int
+	// Seed seeds the segmentation of the shard.
+	Seed int64
+}
+
+// ShardResult is the segmentation of a shard.
+type ShardResult struct {
+	Shard Shard
+	// Segments lists the segments of the shard, with bin indices, and without
+	// bin positions coordinates, referring to the bins of the whole track.
+	Segments []Segment
+
+	// Head holds the bins from the start of the shard to the end of its
+	// first segment, and Tail those from the start of its last segment to
+	// the end of the shard, which MergeShards segments again across the
+	// boundaries between shards.
+	Head *Track
+	Tail *Track
+}
+
+// PlanShards splits every track into shards of at most maxBins bins of
+// similar size, in track order. The seed of every shard is derived from seed.
+//
+// Shards longer than a few hundred bins keep the boundary reconciliation of
+// MergeShards local; shorter shards lose power to detect events longer than a
+// shard.
+func PlanShards(tracks []*Track, maxBins int, seed int64) ([]Shard, error) {
+	if maxBins < 1 {
+		return nil, fmt.Errorf("cbsgo: invalid shard size %d", maxBins)
+	}
+	seeds := newRand(seed)
+	var shards []Shard
+	for _, t := range tracks {
+		n := t.Len()
+		k := (n + maxBins - 1) / maxBins
+		for i := range k {
+			shards = append(shards, Shard{
+				Chrom:    t.Chrom,
+				BinStart: i * n / k,
+				BinEnd:   (i + 1) * n / k,
+				Seed:     seeds.Int63() | 1,
+			})
+		}
+	}
+	return shards, nil
+}
+
+// Run segments the bins of the shard in track, which must be the track it was
+// planned for, configured by opts like Track.Segment. The seed of the shard
+// replaces any seed set by opts, and segments are not written to a writer set
+// with WithWriter.
+func (s Shard) Run(ctx context.Context, track *Track, opts ...Option) (*ShardResult, error) {
+	if track.Chrom != s.Chrom || s.BinStart < 0 || s.BinEnd > track.Len() || s.BinStart >= s.BinEnd {
+		return nil, fmt.Errorf("cbsgo: shard %s:%d-%d does not match track %s of %d bins", s.Chrom, s.BinStart, s.BinEnd, track.Chrom, track.Len())
+	}
+	if err := track.Validate(); err != nil {
+		return nil, err
+	}
+	bins := track.slice(s.BinStart, s.BinEnd)
+	segments, err := segmentShard(ctx, bins, s.BinStart, s.Seed, opts)
+	if err != nil {
+		return nil, err
+	}
+	res := &ShardResult{Shard: s, Segments: segments, Head: bins, Tail: bins}
+	if n := len(segments); n > 0 {
+		res.Head = bins.slice(0, segments[0].BinEnd-s.BinStart)
+		res.Tail = bins.slice(segments[n-1].BinStart-s.BinStart, bins.Len())
+	}
+	return res, nil
+}
+
+// segmentShard segments the bins of a track starting at bin offset with the
+// given seed, returning segments relative to the whole track.
+func segmentShard(ctx context.Context, bins *Track, offset int, seed int64, opts []Option) ([]Segment, error) {
+	opts = append(opts[:len(opts):len(opts)], func(c *config) {
+		c.seed, c.writer = seed, nil
+	})
+	res, err := bins.SegmentContext(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range res.Segments {
+		seg := &res.Segments[i]
+		seg.BinStart += offset
+		seg.BinEnd += offset
+		if bins.Starts == nil {
+			seg.Start, seg.End = seg.BinStart, seg.BinEnd
+		}
+	}
+	return res.Segments, nil
+}
+
+// MergeShards merges the results of the shards of a genome, in any order,
+// into the segments of every track, in order of first appearance of the
+// tracks. The shards of every track must cover it without gaps, from bin 0.
+//
+// Shard boundaries are not breakpoints in themselves: the last segment of
+// every shard and the first of the next are segmented again together,
+// configured by opts as for Shard.Run, and replaced by the result, so that a
+// segment crossing a boundary is reported whole.
+func MergeShards(ctx context.Context, results []*ShardResult, opts ...Option) (SegmentSet, error) {
+	var chroms []string
+	byChrom := make(map[string][]*ShardResult)
+	for _, r := range results {
+		if byChrom[r.Shard.Chrom] == nil {
+			chroms = append(chroms, r.Shard.Chrom)
+		}
+		byChrom[r.Shard.Chrom] = append(byChrom[r.Shard.Chrom], r)
+	}
+
+	var merged SegmentSet
+	for _, chrom := range chroms {
+		rs := byChrom[chrom]
+		sort.Slice(rs, func(i, j int) bool { return rs[i].Shard.BinStart < rs[j].Shard.BinStart })
+		if rs[0].Shard.BinStart != 0 {
+			return nil, fmt.Errorf("cbsgo: shards of %s do not cover bins 0 to %d", chrom, rs[0].Shard.BinStart)
+		}
+		segments := append([]Segment{}, rs[0].Segments...)
+		tail := rs[0].Tail
+		tailStart := rs[0].Shard.BinEnd - tail.Len()
+		for i, r := range rs[1:] {
+			if r.Shard.BinStart != rs[i].Shard.BinEnd {
+				return nil, fmt.Errorf("cbsgo: shards of %s do not cover bins %d to %d", chrom, rs[i].Shard.BinEnd, r.Shard.BinStart)
+			}
+			headEnd := r.Shard.BinStart + r.Head.Len()
+			bins := tail.concat(r.Head)
+			joined, err := segmentShard(ctx, bins, tailStart, r.Shard.Seed, opts)
+			if err != nil {
+				return nil, err
+			}
+
+			// Replace the segments of the tail and the head by those of the
+			// joined bins.
+			n := len(segments)
+			for n > 0 && segments[n-1].BinStart >= tailStart {
+				n--
+			}
+			segments = append(segments[:n], joined...)
+			rest := 0
+			for rest < len(r.Segments) && r.Segments[rest].BinStart < headEnd {
+				rest++
+			}
+			if rest < len(r.Segments) {
+				segments = append(segments, r.Segments[rest:]...)
+				tail = r.Tail
+				tailStart = r.Shard.BinEnd - tail.Len()
+			} else if k := len(joined); k > 0 {
+				tail = bins.slice(joined[k-1].BinStart-tailStart, bins.Len())
+				tailStart = joined[k-1].BinStart
+			} else {
+				tail = bins
+			}
+		}
+		merged = append(merged, segments...)
+	}
+	return merged, nil
+}
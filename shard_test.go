@@ -0,0 +1,80 @@
+package cbsgo_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"math"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestShards(t *testing.T) {
+	x := make([]float64, 300)
+	for i := range x {
+		x[i] = math.Sin(float64(i)) / 5
+		if i >= 80 && i < 130 {
+			x[i] += 2
+		}
+	}
+	track := &cbsgo.Track{Chrom: "chr1", Values: x, Mask: []bool{99: true, 299: false}}
+
+	shards, err := cbsgo.PlanShards([]*cbsgo.Track{track}, 100, 42)
+	if err != nil {
+		t.Fatalf("PlanShards returned an unexpected error: %v", err)
+	}
+	if len(shards) != 3 || shards[1].BinStart != 100 || shards[1].BinEnd != 200 {
+		t.Fatalf("Unexpected shards %v", shards)
+	}
+
+	// Run the shards in reverse order, passing them and their results
+	// through gob as to remote workers.
+	var results []*cbsgo.ShardResult
+	for i := len(shards) - 1; i >= 0; i-- {
+		var shard cbsgo.Shard
+		roundTrip(t, shards[i], &shard)
+		res, err := shard.Run(context.Background(), track)
+		if err != nil {
+			t.Fatalf("Run returned an unexpected error: %v", err)
+		}
+		var r cbsgo.ShardResult
+		roundTrip(t, res, &r)
+		results = append(results, &r)
+	}
+
+	segments, err := cbsgo.MergeShards(context.Background(), results)
+	if err != nil {
+		t.Fatalf("MergeShards returned an unexpected error: %v", err)
+	}
+	expected := [][2]int{{0, 80}, {80, 130}, {130, 300}}
+	if len(segments) != len(expected) {
+		t.Fatalf("Expected %d segments, got %v", len(expected), segments)
+	}
+	for i, s := range segments {
+		if s.Chrom != "chr1" || abs(s.BinStart-expected[i][0]) > 1 || abs(s.BinEnd-expected[i][1]) > 1 {
+			t.Errorf("Unexpected segment %d.\nExpected: %v\nGot: %+v", i, expected[i], s)
+		}
+	}
+
+	if _, err := cbsgo.MergeShards(context.Background(), results[2:]); err != nil {
+		t.Errorf("MergeShards returned an unexpected error for a single shard: %v", err)
+	}
+	if _, err := cbsgo.MergeShards(context.Background(), []*cbsgo.ShardResult{results[0], results[2]}); err == nil {
+		t.Errorf("Expected an error for shards with a gap")
+	}
+	if _, err := cbsgo.MergeShards(context.Background(), results[:2]); err == nil {
+		t.Errorf("Expected an error for shards missing the start of the track")
+	}
+}
+
+// roundTrip encodes v with gob and decodes it into ptr.
+func roundTrip(t *testing.T, v, ptr any) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		t.Fatalf("Encode returned an unexpected error: %v", err)
+	}
+	if err := gob.NewDecoder(&buf).Decode(ptr); err != nil {
+		t.Fatalf("Decode returned an unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package cbsgo
+
+import "slices"
+
+// ShrinkMeans returns a copy of segments with every mean shrunk towards the
+// global mean by empirical Bayes, given the standard deviation of the noise
+// of a single bin, such as estimated by DiffMAD.
+//
+// The segment means are modeled as drawn from a normal distribution around
+// the global mean, the mean of the segment means weighted by their numbers
+// of bins, with a variance estimated by the method of moments from their
+// spread beyond the noise. Every mean is then replaced by its posterior
+// mean, which weights it against the global mean by their precisions: the
+// means of long segments hardly move, while those of segments of a few
+// bins, which vary wildly in noisy data, move most. Segments are of a
+// single profile, with bins in [BinStart, BinEnd). Segments without bins,
+// such as those of Subtract with genomic positions, are left unchanged.
+func ShrinkMeans(segments []Segment, noiseSD float64) []Segment {
+	res := slices.Clone(segments)
+	var global, bins float64
+	binned := 0
+	for _, s := range res {
+		if s.BinEnd > s.BinStart {
+			n := float64(s.BinEnd - s.BinStart)
+			global += n * s.Mean
+			bins += n
+			binned++
+		}
+	}
+	if binned < 2 || !(noiseSD > 0) {
+		return res
+	}
+	global /= bins
+	noise := noiseSD * noiseSD
+
+	// The expected squared deviation of a segment mean from the global mean
+	// is the variance of the means plus that of the noise of the segment.
+	var variance float64
+	for _, s := range res {
+		if s.BinEnd > s.BinStart {
+			d := s.Mean - global
+			variance += d*d - noise/float64(s.BinEnd-s.BinStart)
+		}
+	}
+	variance = max(variance/float64(binned-1), 0)
+
+	for i := range res {
+		s := &res[i]
+		if s.BinEnd <= s.BinStart {
+			continue
+		}
+		sampling := noise / float64(s.BinEnd-s.BinStart)
+		s.Mean -= sampling / (sampling + variance) * (s.Mean - global)
+	}
+	return res
+}
+
+// WithShrinkage shrinks the segment means towards the global mean with
+// ShrinkMeans and the noise estimated by DiffMAD, before the other
+// per-segment annotations, such as the states of WithCalls, are computed.
+// With WithWriter, the segments are then written once the run completed.
+func WithShrinkage() Option {
+	return func(c *config) {
+		c.shrink = true
+	}
+}
@@ -0,0 +1,97 @@
+package cbsgo_test
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestShrinkMeans(t *testing.T) {
+	segments := []cbsgo.Segment{
+		{BinEnd: 100, Mean: 0},
+		{BinStart: 100, BinEnd: 105, Mean: 3},
+		{BinStart: 105, BinEnd: 200, Mean: 0},
+		{BinStart: 200, BinEnd: 300, Mean: 2},
+	}
+	input := append([]cbsgo.Segment(nil), segments...)
+	got := cbsgo.ShrinkMeans(segments, 2)
+	if !reflect.DeepEqual(segments, input) {
+		t.Errorf("Expected the input to be left unchanged, got %v", segments)
+	}
+
+	global := (5*3 + 100*2) / 300.0
+	for i, s := range got {
+		moved, d := math.Abs(s.Mean-segments[i].Mean), math.Abs(segments[i].Mean-global)
+		if moved > d || math.Abs(s.Mean-global) > d {
+			t.Errorf("Expected segment %d to move towards %v, got %v", i, global, s.Mean)
+		}
+		// Short segments move much further, relative to their distance.
+		if long := s.BinEnd-s.BinStart >= 95; long && moved > 0.05*d || !long && moved < 0.2*d {
+			t.Errorf("Unexpected shrinkage of segment %d from %v to %v", i, segments[i].Mean, s.Mean)
+		}
+	}
+
+	// Means within the noise all shrink to the global mean.
+	flat := cbsgo.ShrinkMeans([]cbsgo.Segment{{BinEnd: 10, Mean: 0.1}, {BinStart: 10, BinEnd: 20, Mean: -0.1}}, 1)
+	for _, s := range flat {
+		if math.Abs(s.Mean) > 1e-12 {
+			t.Errorf("Expected the global mean 0, got %v", s.Mean)
+		}
+	}
+	if got := cbsgo.ShrinkMeans(segments, 0); !reflect.DeepEqual(got, segments) {
+		t.Errorf("Expected no shrinkage without noise, got %v", got)
+	}
+}
+
+func TestShrinkMeansWithoutBins(t *testing.T) {
+	segments := []cbsgo.Segment{
+		{Start: 0, End: 1000, BinEnd: 10, Mean: 0.1},
+		{Start: 1000, End: 1500, Mean: 5},
+		{Start: 1500, End: 2500, BinStart: 10, BinEnd: 20, Mean: -0.1},
+	}
+	got := cbsgo.ShrinkMeans(segments, 1)
+	if !reflect.DeepEqual(got[1], segments[1]) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", segments[1], got[1])
+	}
+	for _, i := range []int{0, 2} {
+		if math.Abs(got[i].Mean) > 1e-12 {
+			t.Errorf("Expected the global mean 0, got %v", got[i].Mean)
+		}
+	}
+
+	// A single segment with bins is left unchanged.
+	if got := cbsgo.ShrinkMeans(segments[:2], 1); !reflect.DeepEqual(got, segments[:2]) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", segments[:2], got)
+	}
+}
+
+func TestWithShrinkage(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	x := make([]float64, 300)
+	for i := range x {
+		x[i] = rng.NormFloat64()
+		if i >= 150 && i < 158 {
+			x[i] += 4
+		}
+	}
+	plain, err := cbsgo.Run(x, cbsgo.WithSeed(42))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	var c collector
+	res, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithShrinkage(), cbsgo.WithWriter(&c))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	written := c.segments
+	if len(res.Segments) != 0 || len(written) != len(plain.Segments) || len(written) < 3 {
+		t.Fatalf("Expected the segments %v to be written, got %v", plain.Segments, written)
+	}
+	expected := cbsgo.ShrinkMeans(plain.Segments, cbsgo.DiffMAD(x))
+	if !reflect.DeepEqual(written, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, written)
+	}
+}
@@ -0,0 +1,135 @@
+package cbsgo
+
+import (
+	"errors"
+	"math"
+)
+
+// Simulation describes synthetic profiles resembling those of an assay, for
+// tuning the options of Run on data with known changes. See
+// DefaultSimulation.
+type Simulation struct {
+	Chrom string
+	// Bins is the number of bins, and BinSize their size in base pairs.
+	Bins    int
+	BinSize int
+
+	// NoiseSD is the standard deviation of the Gaussian noise around the
+	// signal. Autocorrelation is the lag-one correlation of the noise, as
+	// from GC or mappability waves, with the overall standard deviation kept
+	// at NoiseSD. A fraction OutlierRate of the bins is moved by a further
+	// OutlierSD standard deviations.
+	NoiseSD         float64
+	Autocorrelation float64
+	OutlierRate     float64
+	OutlierSD       float64
+
+	// Events is the number of copy number changes. Their lengths in bins are
+	// drawn log-uniformly from [MinLength, MaxLength], and their levels
+	// uniformly from Levels. Later events overwrite earlier ones where they
+	// overlap, which produces nested changes.
+	Events    int
+	MinLength int
+	MaxLength int
+	Levels    []float64
+}
+
+// DefaultSimulation returns a simulation of 10000 bins of 10 kb with moderate
+// noise and events from focal to arm level.
+func DefaultSimulation() Simulation {
+	return Simulation{
+		Chrom:     "chr1",
+		Bins:      10000,
+		BinSize:   10000,
+		NoiseSD:   0.2,
+		OutlierSD: 5,
+		Events:    5,
+		MinLength: 10,
+		MaxLength: 2000,
+		Levels:    []float64{-1, -0.4, 0.3, 0.6, 1.2},
+	}
+}
+
+// Validate checks that the parameters of the simulation are consistent.
+func (s Simulation) Validate() error {
+	switch {
+	case s.Bins < 1 || s.BinSize < 1:
+		return errors.New("cbsgo: simulation needs at least one bin of positive size")
+	case s.NoiseSD < 0 || s.OutlierSD < 0:
+		return errors.New("cbsgo: simulation noise must not be negative")
+	case s.Autocorrelation < 0 || s.Autocorrelation >= 1:
+		return errors.New("cbsgo: simulation autocorrelation must be in [0, 1)")
+	case s.OutlierRate < 0 || s.OutlierRate > 1:
+		return errors.New("cbsgo: simulation outlier rate must be in [0, 1]")
+	case s.Events < 0:
+		return errors.New("cbsgo: simulation needs a non-negative number of events")
+	case s.Events > 0 && (s.MinLength < 1 || s.MaxLength < s.MinLength):
+		return errors.New("cbsgo: simulation event lengths must satisfy 1 <= min <= max")
+	case s.Events > 0 && len(s.Levels) == 0:
+		return errors.New("cbsgo: simulation needs event levels")
+	}
+	return nil
+}
+
+// Generate returns a simulated track with bin positions, and the truth: the
+// segments of constant signal, with their means holding the signal level.
+// The same simulation and seed always give the same result; a seed of 0
+// selects a time-based seed.
+func (s Simulation) Generate(seed int64) (*Track, SegmentSet, error) {
+	if err := s.Validate(); err != nil {
+		return nil, nil, err
+	}
+	rng := newRand(seed)
+
+	signal := make([]float64, s.Bins)
+	for range s.Events {
+		length := min(s.MinLength, s.Bins)
+		if s.MaxLength > s.MinLength {
+			length = int(float64(s.MinLength) * math.Exp(rng.Float64()*math.Log(float64(s.MaxLength)/float64(s.MinLength))))
+			length = min(length, s.Bins)
+		}
+		start := rng.Intn(s.Bins - length + 1)
+		level := s.Levels[rng.Intn(len(s.Levels))]
+		for i := start; i < start+length; i++ {
+			signal[i] = level
+		}
+	}
+
+	t := &Track{
+		Chrom:  s.Chrom,
+		Starts: make([]int, s.Bins),
+		Ends:   make([]int, s.Bins),
+		Values: make([]float64, s.Bins),
+	}
+	// An AR(1) process with unit variance.
+	innovation := math.Sqrt(1 - s.Autocorrelation*s.Autocorrelation)
+	noise := rng.NormFloat64()
+	for i := range t.Values {
+		if i > 0 {
+			noise = s.Autocorrelation*noise + innovation*rng.NormFloat64()
+		}
+		t.Starts[i], t.Ends[i] = i*s.BinSize, (i+1)*s.BinSize
+		t.Values[i] = signal[i] + s.NoiseSD*noise
+		if s.OutlierRate > 0 && rng.Float64() < s.OutlierRate {
+			t.Values[i] += s.OutlierSD * s.NoiseSD * rng.NormFloat64()
+		}
+	}
+
+	var truth SegmentSet
+	start := 0
+	for i := 1; i <= s.Bins; i++ {
+		if i < s.Bins && signal[i] == signal[start] {
+			continue
+		}
+		truth = append(truth, Segment{
+			Chrom:    s.Chrom,
+			Start:    t.Starts[start],
+			End:      t.Ends[i-1],
+			BinStart: start,
+			BinEnd:   i,
+			Mean:     signal[start],
+		})
+		start = i
+	}
+	return t, truth, nil
+}
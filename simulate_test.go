@@ -0,0 +1,70 @@
+package cbsgo_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestSimulation(t *testing.T) {
+	sim := cbsgo.DefaultSimulation()
+	sim.Bins = 2000
+
+	track, truth, err := sim.Generate(3)
+	if err != nil {
+		t.Fatalf("Generate returned an unexpected error: %v", err)
+	}
+	if err := track.Validate(); err != nil || track.Len() != sim.Bins {
+		t.Fatalf("Unexpected track of %d bins: %v", track.Len(), err)
+	}
+	if err := cbsgo.ValidateSegmentation(truth, sim.Bins); err != nil {
+		t.Fatalf("Invalid truth: %v", err)
+	}
+	if len(truth) < 2 {
+		t.Errorf("Expected events in the truth, got %v", truth)
+	}
+	for _, s := range truth {
+		if s.Start != s.BinStart*sim.BinSize || s.End != s.BinEnd*sim.BinSize {
+			t.Errorf("Unexpected positions of %+v", s)
+		}
+	}
+
+	again, _, err := sim.Generate(3)
+	if err != nil {
+		t.Fatalf("Generate returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(track, again) {
+		t.Errorf("Simulations with the same seed differ")
+	}
+
+	// Without noise, the values are the signal.
+	sim.NoiseSD = 0
+	track, truth, err = sim.Generate(3)
+	if err != nil {
+		t.Fatalf("Generate returned an unexpected error: %v", err)
+	}
+	for _, s := range truth {
+		for _, v := range track.Values[s.BinStart:s.BinEnd] {
+			if v != s.Mean {
+				t.Fatalf("Unexpected value %v in %+v", v, s)
+			}
+		}
+	}
+}
+
+func TestSimulationInvalid(t *testing.T) {
+	for name, modify := range map[string]func(*cbsgo.Simulation){
+		"bins":            func(s *cbsgo.Simulation) { s.Bins = 0 },
+		"noise":           func(s *cbsgo.Simulation) { s.NoiseSD = -1 },
+		"autocorrelation": func(s *cbsgo.Simulation) { s.Autocorrelation = 1 },
+		"lengths":         func(s *cbsgo.Simulation) { s.MinLength = 100; s.MaxLength = 10 },
+		"levels":          func(s *cbsgo.Simulation) { s.Levels = nil },
+	} {
+		sim := cbsgo.DefaultSimulation()
+		modify(&sim)
+		if _, _, err := sim.Generate(1); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
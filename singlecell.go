@@ -0,0 +1,115 @@
+package cbsgo
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// CellMatrix is the result of SegmentCells.
+type CellMatrix struct {
+	// Cells holds the segmentation of every cell.
+	Cells []*Result
+	// Segments holds the segments shared by all cells, delimited by the
+	// recurrent breakpoints of the cells. Their means are not set.
+	Segments []Segment
+	// Means is the cells × segments matrix of segment means.
+	Means [][]float64
+}
+
+// SegmentCells segments the copy number profiles of many cells of a
+// single-cell DNA sequencing experiment, all measured on the same bins, and
+// summarizes them as a cells × segments matrix.
+//
+// Cells are segmented concurrently and share a NullCache, which makes
+// segmenting hundreds of cells affordable; a cache supplied with
+// WithNullCache is used, otherwise one is created with the configured number
+// of shuffles and seed. The breakpoints of all cells are then clustered, with
+// breakpoints at most tolerance bins apart joining the same cluster, and the
+// clusters supported by at least minCells cells delimit the shared segments.
+func SegmentCells(cells [][]float64, tolerance, minCells int, opts ...Option) (*CellMatrix, error) {
+	if len(cells) == 0 {
+		return nil, errors.New("cbsgo: no cells to segment")
+	}
+	n := len(cells[0])
+	for i, x := range cells {
+		if len(x) != n {
+			return nil, fmt.Errorf("cbsgo: cell %d has %d bins, cell 0 has %d", i, len(x), n)
+		}
+	}
+	cfg := newConfig(opts)
+	if cfg.nulls == nil {
+		opts = append(opts[:len(opts):len(opts)], WithNullCache(NewNullCache(cfg.shuffles, cfg.seed)))
+	}
+
+	m := &CellMatrix{Cells: make([]*Result, len(cells))}
+	errs := make([]error, len(cells))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(runtime.GOMAXPROCS(0), len(cells)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				m.Cells[i], errs[i] = Run(cells[i], opts...)
+			}
+		}()
+	}
+	for i := range cells {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	// Shared segments from the recurrent breakpoints.
+	var calls []breakpointCall
+	for i, res := range m.Cells {
+		calls = append(calls, resultCalls(strconv.Itoa(i), res)...)
+	}
+	segment := func(start, end int) Segment {
+		s := Segment{Start: start, End: end, BinStart: start, BinEnd: end}
+		if cfg.starts != nil {
+			s.Start, s.End = cfg.starts[start], cfg.ends[end-1]
+		}
+		return s
+	}
+	start := 0
+	for _, cluster := range clusterBreakpoints(calls, tolerance) {
+		if len(cluster.sources()) < minCells {
+			continue
+		}
+		median := cluster[(len(cluster)-1)/2]
+		m.Segments = append(m.Segments, segment(start, median.bin))
+		start = median.bin
+	}
+	if start < n {
+		m.Segments = append(m.Segments, segment(start, n))
+	}
+
+	m.Means = make([][]float64, len(cells))
+	for i, x := range cells {
+		m.Means[i] = make([]float64, len(m.Segments))
+		for k, s := range m.Segments {
+			m.Means[i][k], _ = meanSD(x[s.BinStart:s.BinEnd], nil)
+		}
+	}
+	return m, nil
+}
+
+// Cluster groups the cells into k clusters by their copy number profiles,
+// using average-linkage hierarchical clustering of the Euclidean distances
+// between the profiles, in which every segment counts once per bin. It
+// returns the cluster of every cell; clusters are numbered in order of their
+// first cell.
+func (m *CellMatrix) Cluster(k int) []int {
+	w := make([]float64, len(m.Segments))
+	for i, s := range m.Segments {
+		w[i] = float64(s.BinEnd - s.BinStart)
+	}
+	return cutTree(averageLinkage(euclidean(m.Means, w)), len(m.Means), k)
+}
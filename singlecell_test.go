@@ -0,0 +1,74 @@
+package cbsgo_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// clones returns the profiles of 12 cells: 6 with a gain of bins 20-39 and 6
+// with a loss of bins 40-59.
+func clones() [][]float64 {
+	pattern := []float64{0, 0.3, -0.2, 0.1, -0.3, 0.2}
+	cells := make([][]float64, 12)
+	for c := range cells {
+		x := make([]float64, 80)
+		for i := range x {
+			x[i] = pattern[(i+c)%len(pattern)]
+			switch {
+			case c < 6 && i >= 20 && i < 40:
+				x[i] += 1.5
+			case c >= 6 && i >= 40 && i < 60:
+				x[i] -= 1.5
+			}
+		}
+		cells[c] = x
+	}
+	return cells
+}
+
+func TestSegmentCells(t *testing.T) {
+	cells := clones()
+
+	m, err := cbsgo.SegmentCells(cells, 2, 3, cbsgo.WithSeed(1))
+	if err != nil {
+		t.Fatalf("SegmentCells returned an unexpected error: %v", err)
+	}
+	if len(m.Cells) != len(cells) || len(m.Means) != len(cells) {
+		t.Fatalf("Expected results for %d cells, got %d and %d", len(cells), len(m.Cells), len(m.Means))
+	}
+
+	var bounds [][2]int
+	for _, s := range m.Segments {
+		bounds = append(bounds, [2]int{s.BinStart, s.BinEnd})
+	}
+	if len(bounds) != 4 || bounds[0][0] != 0 || bounds[3][1] != 80 {
+		t.Fatalf("Expected 4 shared segments covering all bins, got %v", bounds)
+	}
+	for i, row := range m.Means {
+		if len(row) != len(m.Segments) {
+			t.Fatalf("Cell %d: expected %d means, got %d", i, len(m.Segments), len(row))
+		}
+	}
+	if m.Means[0][1] < 1 || m.Means[11][2] > -1 {
+		t.Errorf("Expected a gain in cell 0 and a loss in cell 11, got %v and %v", m.Means[0], m.Means[11])
+	}
+
+	expected := []int{0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1}
+	if labels := m.Cluster(2); !reflect.DeepEqual(labels, expected) {
+		t.Errorf("Unexpected clusters.\nExpected: %v\nGot: %v", expected, labels)
+	}
+	if labels := m.Cluster(1); !reflect.DeepEqual(labels, make([]int, len(cells))) {
+		t.Errorf("Expected a single cluster, got %v", labels)
+	}
+}
+
+func TestSegmentCellsInvalid(t *testing.T) {
+	if _, err := cbsgo.SegmentCells(nil, 1, 1); err == nil {
+		t.Errorf("Expected an error without cells")
+	}
+	if _, err := cbsgo.SegmentCells([][]float64{{1, 2}, {1}}, 1, 1); err == nil {
+		t.Errorf("Expected an error for cells with different numbers of bins")
+	}
+}
@@ -0,0 +1,49 @@
+package cbsgo
+
+import (
+	"math"
+	"sort"
+)
+
+// SmoothOutliers returns a copy of x in which single outlying values are
+// pulled towards their neighbors, as done by smooth.CNA in DNAcopy.
+//
+// A value is an outlier when it lies more than outlierSD standard deviations
+// above the largest or below the smallest of the region values on either side
+// of it. It is then replaced by the median of the 2*region+1 values centered
+// on it, plus or minus smoothSD standard deviations. The standard deviation is
+// estimated by DiffMAD.
+// DNAcopy uses a region of 10 with outlierSD 4 and smoothSD 2.
+func SmoothOutliers(x []float64, region int, outlierSD, smoothSD float64) []float64 {
+	res := make([]float64, len(x))
+	copy(res, x)
+	if len(x) < 3 || region < 1 {
+		return res
+	}
+
+	sd := DiffMAD(x)
+	window := make([]float64, 0, 2*region+1)
+	for i, v := range x {
+		lo, hi := max(0, i-region), min(len(x), i+region+1)
+		nbMax, nbMin := math.Inf(-1), math.Inf(1)
+		for j := lo; j < hi; j++ {
+			if j != i {
+				nbMax, nbMin = math.Max(nbMax, x[j]), math.Min(nbMin, x[j])
+			}
+		}
+
+		var sign float64
+		switch {
+		case v > nbMax+outlierSD*sd:
+			sign = 1
+		case v < nbMin-outlierSD*sd:
+			sign = -1
+		default:
+			continue
+		}
+		window = append(window[:0], x[lo:hi]...)
+		sort.Float64s(window)
+		res[i] = median(window) + sign*smoothSD*sd
+	}
+	return res
+}
@@ -0,0 +1,32 @@
+package cbsgo_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestSmoothOutliers(t *testing.T) {
+	x := make([]float64, 40)
+	for i := range x {
+		x[i] = 0.1 * math.Sin(float64(i))
+		if i >= 20 {
+			x[i] += 2
+		}
+	}
+	x[10] = 5
+
+	res := cbsgo.SmoothOutliers(x, 10, 4, 2)
+	if x[10] != 5 {
+		t.Fatalf("SmoothOutliers modified its input")
+	}
+	if res[10] >= 1 {
+		t.Errorf("Outlier not smoothed: got %v", res[10])
+	}
+	for i := range x {
+		if i != 10 && res[i] != x[i] {
+			t.Errorf("Unexpected change at %d.\nExpected: %v\nGot: %v", i, x[i], res[i])
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package cbsgo
+
+import (
+	"math"
+	"slices"
+)
+
+// WithSpikes reports the isolated bins deviating from the median of their
+// segment by at least minSD times the noise of the profile, estimated by
+// DiffMAD, as segments of a single bin with Spike set, such as the
+// single-target deletions of panel data. CBS does not split off arcs of
+// fewer than 5 bins, nor segments narrower than the width of WithMinWidth,
+// so that such bins are otherwise absorbed by their segment. A bin is
+// isolated when neither of its neighbours in the segment deviates as much.
+//
+// Spikes are split off after WithUndoSD merges segments, so that they are
+// kept. WithSpikes cannot be combined with WithConsensus, and segments are
+// written by WithWriter once the run is complete.
+func WithSpikes(minSD float64) Option {
+	return func(c *config) {
+		c.spikeSD = minSD
+	}
+}
+
+// splitSpikes splits the spikes of WithSpikes off the segments of bounds,
+// recording them in sg.spikes.
+func (sg *segmenter) splitSpikes(bounds [][2]int) [][2]int {
+	threshold := sg.cfg.spikeSD * DiffMAD(sg.x)
+	if threshold == 0 {
+		return bounds
+	}
+	var res [][2]int
+	for _, b := range bounds {
+		x := sg.x[b[0]:b[1]]
+		center := median(slices.Sorted(slices.Values(x)))
+		deviates := func(i int) bool {
+			return i >= 0 && i < len(x) && math.Abs(x[i]-center) >= threshold
+		}
+		start := b[0]
+		for i := range x {
+			if len(x) < 2 || !deviates(i) || deviates(i-1) || deviates(i+1) {
+				continue
+			}
+			bin := b[0] + i
+			if start < bin {
+				res = append(res, [2]int{start, bin})
+			}
+			res = append(res, [2]int{bin, bin + 1})
+			if sg.spikes == nil {
+				sg.spikes = make(map[int]bool)
+			}
+			sg.spikes[bin] = true
+			start = bin + 1
+		}
+		if start < b[1] {
+			res = append(res, [2]int{start, b[1]})
+		}
+	}
+	return res
+}
@@ -0,0 +1,67 @@
+package cbsgo_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestWithSpikes(t *testing.T) {
+	// Noisy values with a deletion of a single bin at 100, a deletion of two
+	// bins at 150 and a gain from 200.
+	rng := rand.New(rand.NewSource(3))
+	x := make([]float64, 300)
+	for i := range x {
+		x[i] = 0.2 * rng.NormFloat64()
+		switch {
+		case i == 100:
+			x[i] -= 3
+		case i == 150 || i == 151:
+			x[i] -= 3
+		case i >= 200:
+			x[i]++
+		}
+	}
+
+	res, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithMinWidth(5))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	for _, s := range res.Segments {
+		if s.Spike || s.BinEnd-s.BinStart < 5 {
+			t.Errorf("Expected no spikes without WithSpikes, got %+v", s)
+		}
+	}
+
+	res, err = cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithMinWidth(5), cbsgo.WithSpikes(5))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	var spikes []cbsgo.Segment
+	for _, s := range res.Segments {
+		if s.Spike {
+			spikes = append(spikes, s)
+		}
+	}
+	if len(spikes) != 1 || spikes[0].BinStart != 100 || spikes[0].BinEnd != 101 || spikes[0].Mean != x[100] || spikes[0].SD != 0 {
+		t.Fatalf("Expected a single spike at bin 100, got %+v", spikes)
+	}
+	if n := len(res.Segments); n < 4 || res.Segments[0].BinStart != 0 || res.Segments[n-1].BinEnd != len(x) {
+		t.Errorf("Expected the spike to split its segment, got %v", res.Segments)
+	}
+	for i := 1; i < len(res.Segments); i++ {
+		if res.Segments[i].BinStart != res.Segments[i-1].BinEnd {
+			t.Errorf("Expected adjacent segments, got %v", res.Segments)
+		}
+	}
+
+	for _, opts := range [][]cbsgo.Option{
+		{cbsgo.WithSpikes(-1)},
+		{cbsgo.WithSpikes(5), cbsgo.WithConsensus(3)},
+	} {
+		if _, err := cbsgo.Run(x, opts...); err == nil {
+			t.Error("Expected an error for invalid spike settings")
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package cbsgo
+
+import (
+	"fmt"
+)
+
+// Stability assesses how robust the breakpoints of the segmentation of x are
+// to perturbations of the data, with a leave-block-out jackknife.
+//
+// x is first segmented as by Run, with the same options, including WithUndoSD,
+// WithSpikes, WithConfirm and WithConsensus. Then, for each of the replicates, a random
+// block of the given number of consecutive bins is held out, the remaining
+// bins are segmented again, and the breakpoints are mapped back onto the
+// original bins. The returned Result holds the segments of the full data and,
+// for each of its breakpoints, the fraction of replicates in which a breakpoint
+// reappears within tolerance bins. Replicates holding out the bins on both
+// sides of a breakpoint are not counted for it.
+//
+// The blocks and the seeds of the replicates are derived from the seed set with
+// WithSeed. As Stability returns the segments, it does not support WithWriter.
+func Stability(x []float64, replicates, block, tolerance int, opts ...Option) (*Result, error) {
+	if block < 1 || block >= len(x) {
+		return nil, fmt.Errorf("cbsgo: invalid block size %d for %d values", block, len(x))
+	}
+	cfg := newConfig(opts)
+	if cfg.writer != nil {
+		return nil, fmt.Errorf("cbsgo: Stability does not support WithWriter")
+	}
+	seed := cfg.seed
+	if cfg.contentSeed {
+		seed = contentSeed(x, cfg)
+	}
+	rng := newRand(seed)
+
+	sg, err := newSegmenter(x, cfg)
+	if err != nil {
+		return nil, err
+	}
+	res, err := sg.segment()
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Segments) < 2 {
+		return res, nil
+	}
+
+	hits := make([]int, len(res.Segments)-1)
+	trials := make([]int, len(res.Segments)-1)
+	for r := 0; r < replicates; r++ {
+		start := rng.Intn(len(x) - block + 1)
+		end := start + block
+
+		rcfg := *cfg
+		rcfg.seed = rng.Int63() | 1
+		rcfg.breakpointLevel, rcfg.fit = 0, false
+		if cfg.starts != nil {
+			rcfg.starts = holdOut(cfg.starts, start, end)
+			rcfg.ends = holdOut(cfg.ends, start, end)
+		}
+		if cfg.weights != nil {
+			rcfg.weights = holdOut(cfg.weights, start, end)
+		}
+		rsg, err := newSegmenter(holdOut(x, start, end), &rcfg)
+		if err != nil {
+			return nil, err
+		}
+		rres, err := rsg.segment()
+		if err != nil {
+			return nil, err
+		}
+
+		var found []int
+		for _, s := range rres.Segments[1:] {
+			b := s.BinStart
+			if b >= start {
+				b += block
+			}
+			found = append(found, b)
+		}
+
+		for i, s := range res.Segments[1:] {
+			bin := s.BinStart
+			if bin > start && bin < end {
+				continue
+			}
+			trials[i]++
+			for _, b := range found {
+				if abs(b-bin) <= tolerance {
+					hits[i]++
+					break
+				}
+			}
+		}
+	}
+
+	// Keep the breakpoints described by WithBreakpoints, if any.
+	if len(res.Breakpoints) != len(hits) {
+		res.Breakpoints = make([]Breakpoint, len(hits))
+		for i, s := range res.Segments[1:] {
+			res.Breakpoints[i] = Breakpoint{Bin: s.BinStart, Position: sg.position(s.BinStart)}
+		}
+	}
+	for i := range hits {
+		bp := &res.Breakpoints[i]
+		if trials[i] > 0 {
+			bp.Support = float64(hits[i]) / float64(trials[i])
+		}
+		bp.Unstable = hits[i] < trials[i]
+	}
+	return res, nil
+}
+
+// holdOut returns a copy of s without the elements [start, end).
+func holdOut[T any](s []T, start, end int) []T {
+	res := make([]T, 0, len(s)-(end-start))
+	return append(append(res, s[:start]...), s[end:]...)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,82 @@
+package cbsgo_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestStability(t *testing.T) {
+	res, err := cbsgo.Stability(borderline(0.28), 20, 6, 2, cbsgo.WithSeed(5), cbsgo.WithShuffles(200))
+	if err != nil {
+		t.Fatalf("Stability returned an unexpected error: %v", err)
+	}
+	if len(res.Segments) != 3 || len(res.Breakpoints) != 2 {
+		t.Fatalf("Expected 3 segments and 2 breakpoints, got %v and %v", res.Segments, res.Breakpoints)
+	}
+
+	weak, strong := res.Breakpoints[0], res.Breakpoints[1]
+	if weak.Bin != 18 || strong.Bin != 39 {
+		t.Errorf("Expected breakpoints at bins 18 and 39, got %v", res.Breakpoints)
+	}
+	if strong.Support != 1 || strong.Unstable {
+		t.Errorf("Expected the strong breakpoint to be stable, got %+v", strong)
+	}
+	if weak.Support <= 0 || weak.Support >= 1 || !weak.Unstable {
+		t.Errorf("Expected the weak breakpoint to be unstable, got %+v", weak)
+	}
+}
+
+func TestStabilityWeights(t *testing.T) {
+	x := borderline(0.28)
+	w := make([]float64, len(x))
+	for i := range w {
+		w[i] = 1
+	}
+	want, err := cbsgo.Stability(x, 20, 6, 2, cbsgo.WithSeed(5), cbsgo.WithShuffles(200))
+	if err != nil {
+		t.Fatalf("Stability returned an unexpected error: %v", err)
+	}
+	got, err := cbsgo.Stability(x, 20, 6, 2, cbsgo.WithSeed(5), cbsgo.WithShuffles(200), cbsgo.WithWeights(w))
+	if err != nil {
+		t.Fatalf("Stability returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got.Breakpoints, want.Breakpoints) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", want.Breakpoints, got.Breakpoints)
+	}
+}
+
+func TestStabilityUndoSD(t *testing.T) {
+	opts := []cbsgo.Option{cbsgo.WithSeed(5), cbsgo.WithShuffles(200), cbsgo.WithUndoSD(3)}
+	want, err := cbsgo.Run(borderline(0.28), opts...)
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	res, err := cbsgo.Stability(borderline(0.28), 20, 6, 2, opts...)
+	if err != nil {
+		t.Fatalf("Stability returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(res.Segments, want.Segments) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", want.Segments, res.Segments)
+	}
+	if len(res.Breakpoints) != 1 || res.Breakpoints[0].Bin != 39 || res.Breakpoints[0].Support != 1 {
+		t.Errorf("Expected a stable breakpoint at bin 39, got %+v", res.Breakpoints)
+	}
+}
+
+func TestStabilityWriter(t *testing.T) {
+	var c collector
+	if _, err := cbsgo.Stability(borderline(0.28), 20, 6, 2, cbsgo.WithWriter(&c)); err == nil {
+		t.Errorf("Expected an error for WithWriter")
+	}
+}
+
+func TestStabilityInvalidBlock(t *testing.T) {
+	x := []float64{1, 2, 3}
+	for _, block := range []int{0, 3} {
+		if _, err := cbsgo.Stability(x, 10, block, 1); err == nil {
+			t.Errorf("Expected an error for a block of %d bins", block)
+		}
+	}
+}
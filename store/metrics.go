@@ -0,0 +1,46 @@
+package store
+
+import (
+	"github.com/mattdsm/cbsgo"
+)
+
+// Metrics returns quality metrics of a segmentation, for storing with
+// SampleWriter.SetMetric:
+//
+//	segments          number of segments
+//	bins              number of bins covered by the segments
+//	altered_fraction  fraction of the segmented length called as a loss or gain
+//	gained_fraction   fraction of the segmented length called as a gain
+//	lost_fraction     fraction of the segmented length called as a loss
+//	breakpoints       number of boundaries between segments of a chromosome
+//	wgii              weighted genome instability index
+//	sse               sum of squared residuals, when evaluated with cbsgo.WithFit
+func Metrics(res *cbsgo.GenomeResult) map[string]float64 {
+	m := map[string]float64{"segments": float64(len(res.Segments))}
+	var bins, length float64
+	for _, s := range res.Segments {
+		bins += float64(s.BinEnd - s.BinStart)
+		length += float64(s.Len())
+	}
+	m["bins"] = bins
+	if length > 0 {
+		a := cbsgo.SummarizeAlterations(res.Segments)
+		m["altered_fraction"] = a.Altered()
+		m["gained_fraction"] = a.Gained
+		m["lost_fraction"] = a.Lost
+		m["breakpoints"] = float64(a.Breakpoints)
+		m["wgii"] = a.WGII
+	}
+	fitted := false
+	var sse float64
+	for _, r := range res.Tracks {
+		if r != nil && r.Fit != nil {
+			fitted = true
+			sse += r.Fit.SSE
+		}
+	}
+	if fitted {
+		m["sse"] = sse
+	}
+	return m
+}
@@ -0,0 +1,183 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// migrations holds the schema of the PostgreSQL tables, one step per
+// version. Steps are only ever appended, so that databases at any version can
+// be migrated to the latest one.
+var migrations = []string{
+	// Version 1.
+	`CREATE TABLE cbsgo_runs (
+		id TEXT PRIMARY KEY,
+		sample TEXT NOT NULL,
+		started TIMESTAMPTZ NOT NULL,
+		duration_ms BIGINT NOT NULL,
+		parameters JSONB NOT NULL DEFAULT '{}',
+		version TEXT NOT NULL DEFAULT '',
+		generation BIGINT NOT NULL DEFAULT 1
+	);
+	CREATE INDEX cbsgo_runs_sample ON cbsgo_runs (sample);
+	CREATE TABLE cbsgo_segments (
+		run_id TEXT NOT NULL REFERENCES cbsgo_runs (id) ON DELETE CASCADE,
+		chrom TEXT NOT NULL,
+		chrom_start BIGINT NOT NULL,
+		chrom_end BIGINT NOT NULL,
+		bin_start BIGINT NOT NULL,
+		bin_end BIGINT NOT NULL,
+		mean DOUBLE PRECISION,
+		sd DOUBLE PRECISION,
+		state TEXT NOT NULL,
+		cytoband TEXT NOT NULL,
+		generation BIGINT NOT NULL,
+		PRIMARY KEY (run_id, chrom, chrom_start)
+	);
+	CREATE INDEX cbsgo_segments_region ON cbsgo_segments (chrom, chrom_start, chrom_end)`,
+}
+
+// migrationLock is the key of the advisory lock serializing migrations.
+const migrationLock = 0x636273676f
+
+// Run describes a segmentation run stored with Postgres.WriteRun.
+type Run struct {
+	// ID identifies the run; writing a run with the same ID again updates it.
+	ID     string
+	Sample string
+
+	Started  time.Time
+	Duration time.Duration
+	// Parameters holds the settings of the run, such as the significance
+	// level, stored as a JSONB object.
+	Parameters map[string]any
+	// Version is the version of the software that produced the run.
+	Version string
+}
+
+// Postgres writes runs and their segments into PostgreSQL tables, for labs
+// keeping their copy number calls in a central database. It works with any
+// database/sql driver for PostgreSQL, such as github.com/jackc/pgx/v5/stdlib,
+// which the caller imports.
+//
+// The tables are cbsgo_runs, with one row per run, and cbsgo_segments, with
+// one row per segment of a run, keyed by run, chromosome and start. They are
+// created and migrated by OpenPostgres; the applied versions are recorded in
+// cbsgo_schema_migrations.
+type Postgres struct {
+	db *sql.DB
+}
+
+// OpenPostgres returns a Postgres writing into db, migrating the schema to
+// the latest version if needed. Concurrent migrations are serialized with an
+// advisory lock.
+func OpenPostgres(ctx context.Context, db *sql.DB) (*Postgres, error) {
+	if err := migrate(ctx, db); err != nil {
+		return nil, fmt.Errorf("cbsgo: migrating the result tables: %w", err)
+	}
+	return &Postgres{db: db}, nil
+}
+
+// migrate applies the migrations that db lacks in a single transaction.
+func migrate(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLock); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS cbsgo_schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return err
+	}
+	var version int
+	if err := tx.QueryRowContext(ctx, `SELECT coalesce(max(version), 0) FROM cbsgo_schema_migrations`).Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("schema version %d is newer than the supported version %d", version, len(migrations))
+	}
+	for v := version + 1; v <= len(migrations); v++ {
+		if _, err := tx.ExecContext(ctx, migrations[v-1]); err != nil {
+			return fmt.Errorf("version %d: %w", v, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO cbsgo_schema_migrations (version) VALUES ($1)`, v); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// WriteRun upserts a run and its segments in a single transaction. Segments
+// previously stored for the run that are not in segments are removed, so that
+// writing a run again leaves exactly its latest segments.
+func (p *Postgres) WriteRun(ctx context.Context, run Run, segments []cbsgo.Segment) error {
+	params := run.Parameters
+	if params == nil {
+		params = map[string]any{}
+	}
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("cbsgo: run %s: %w", run.ID, err)
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var generation int64
+	err = tx.QueryRowContext(ctx, `INSERT INTO cbsgo_runs (id, sample, started, duration_ms, parameters, version)
+		VALUES ($1, $2, $3, $4, $5::jsonb, $6)
+		ON CONFLICT (id) DO UPDATE SET
+			sample = EXCLUDED.sample,
+			started = EXCLUDED.started,
+			duration_ms = EXCLUDED.duration_ms,
+			parameters = EXCLUDED.parameters,
+			version = EXCLUDED.version,
+			generation = cbsgo_runs.generation + 1
+		RETURNING generation`,
+		run.ID, run.Sample, run.Started.UTC(), run.Duration.Milliseconds(), string(paramsJSON), run.Version,
+	).Scan(&generation)
+	if err != nil {
+		return fmt.Errorf("cbsgo: storing run %s: %w", run.ID, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO cbsgo_segments
+		(run_id, chrom, chrom_start, chrom_end, bin_start, bin_end, mean, sd, state, cytoband, generation)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (run_id, chrom, chrom_start) DO UPDATE SET
+			chrom_end = EXCLUDED.chrom_end,
+			bin_start = EXCLUDED.bin_start,
+			bin_end = EXCLUDED.bin_end,
+			mean = EXCLUDED.mean,
+			sd = EXCLUDED.sd,
+			state = EXCLUDED.state,
+			cytoband = EXCLUDED.cytoband,
+			generation = EXCLUDED.generation`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, s := range segments {
+		if _, err := stmt.ExecContext(ctx, run.ID, s.Chrom, s.Start, s.End, s.BinStart, s.BinEnd,
+			nullable(s.Mean), nullable(s.SD), s.State.String(), s.Cytoband, generation); err != nil {
+			return fmt.Errorf("cbsgo: storing a segment of run %s: %w", run.ID, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM cbsgo_segments WHERE run_id = $1 AND generation < $2`,
+		run.ID, generation); err != nil {
+		return fmt.Errorf("cbsgo: storing run %s: %w", run.ID, err)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,152 @@
+//go:build postgres
+
+package store_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/store"
+)
+
+// openPostgres connects to the server of the CBSGO_POSTGRES_DSN environment
+// variable, such as "postgres://localhost/cbsgo_test", in a schema of its own
+// that is dropped when the test ends. It skips the test without the variable.
+// The tests run with "go test -tags postgres" once github.com/jackc/pgx/v5 is
+// required by the module.
+func openPostgres(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("CBSGO_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("CBSGO_POSTGRES_DSN is not set")
+	}
+	ctx := context.Background()
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A single connection keeps the search path of the schema.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	schema := fmt.Sprintf("cbsgo_test_%d", time.Now().UnixNano())
+	if _, err := db.ExecContext(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.ExecContext(ctx, "DROP SCHEMA "+schema+" CASCADE") })
+	if _, err := db.ExecContext(ctx, "SET search_path TO "+schema); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestPostgresLiveMigrate(t *testing.T) {
+	ctx := context.Background()
+	db := openPostgres(t)
+
+	for range 2 {
+		if _, err := store.OpenPostgres(ctx, db); err != nil {
+			t.Fatalf("OpenPostgres returned an unexpected error: %v", err)
+		}
+	}
+	var versions, latest int
+	if err := db.QueryRowContext(ctx, `SELECT count(*), max(version) FROM cbsgo_schema_migrations`).Scan(&versions, &latest); err != nil {
+		t.Fatal(err)
+	}
+	if versions != latest {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", latest, versions)
+	}
+
+	// A database migrated by a newer version is refused.
+	if _, err := db.ExecContext(ctx, `INSERT INTO cbsgo_schema_migrations (version) VALUES ($1)`, latest+1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.OpenPostgres(ctx, db); err == nil {
+		t.Errorf("Expected an error for a newer schema")
+	}
+}
+
+func TestPostgresLiveWriteRun(t *testing.T) {
+	ctx := context.Background()
+	db := openPostgres(t)
+	p, err := store.OpenPostgres(ctx, db)
+	if err != nil {
+		t.Fatalf("OpenPostgres returned an unexpected error: %v", err)
+	}
+
+	run := store.Run{
+		ID:         "run1",
+		Sample:     "s1",
+		Started:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Duration:   1500 * time.Millisecond,
+		Parameters: map[string]any{"alpha": 0.01},
+		Version:    "v1",
+	}
+	segments := []cbsgo.Segment{
+		{Chrom: "chr1", End: 100, BinEnd: 10, Mean: 0.1},
+		{Chrom: "chr1", Start: 100, End: 200, BinStart: 10, BinEnd: 20, Mean: 0.5},
+	}
+	if err := p.WriteRun(ctx, run, segments); err != nil {
+		t.Fatalf("WriteRun returned an unexpected error: %v", err)
+	}
+
+	// Writing the run again updates it and leaves only its latest segments.
+	run.Sample = "s2"
+	run.Parameters = map[string]any{"alpha": 0.05}
+	segments = []cbsgo.Segment{{Chrom: "chr1", End: 200, BinEnd: 20, Mean: 0.3}}
+	if err := p.WriteRun(ctx, run, segments); err != nil {
+		t.Fatalf("WriteRun returned an unexpected error: %v", err)
+	}
+
+	var sample string
+	var alpha float64
+	var generation, durationMS int64
+	if err := db.QueryRowContext(ctx, `SELECT sample, (parameters->>'alpha')::float8, generation, duration_ms
+		FROM cbsgo_runs WHERE id = $1`, run.ID).Scan(&sample, &alpha, &generation, &durationMS); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := []any{sample, alpha, generation, durationMS}, []any{"s2", 0.05, int64(2), int64(1500)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", want, got)
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT chrom, chrom_start, chrom_end, bin_start, bin_end, mean
+		FROM cbsgo_segments WHERE run_id = $1 ORDER BY chrom_start`, run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []cbsgo.Segment
+	for rows.Next() {
+		var s cbsgo.Segment
+		if err := rows.Scan(&s.Chrom, &s.Start, &s.End, &s.BinStart, &s.BinEnd, &s.Mean); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, s)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, segments) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", segments, got)
+	}
+
+	// Deleting the run removes its segments.
+	if _, err := db.ExecContext(ctx, `DELETE FROM cbsgo_runs WHERE id = $1`, run.ID); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := db.QueryRowContext(ctx, `SELECT count(*) FROM cbsgo_segments`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", 0, n)
+	}
+}
@@ -0,0 +1,182 @@
+package store_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/store"
+)
+
+// recorder is a database/sql driver recording the statements it executes,
+// standing in for PostgreSQL. It tracks the applied schema version and the
+// generation of runs to answer the queries of store.Postgres.
+type recorder struct {
+	mu         sync.Mutex
+	statements []string
+	version    int64
+	generation int64
+}
+
+func (r *recorder) Open(string) (driver.Conn, error) {
+	return conn{r}, nil
+}
+
+// executed returns the recorded statements that contain s.
+func (r *recorder) executed(s string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var res []string
+	for _, stmt := range r.statements {
+		if strings.Contains(stmt, s) {
+			res = append(res, stmt)
+		}
+	}
+	return res
+}
+
+type conn struct {
+	r *recorder
+}
+
+func (c conn) Prepare(query string) (driver.Stmt, error) { return stmt{c.r, query}, nil }
+func (c conn) Close() error                              { return nil }
+func (c conn) Begin() (driver.Tx, error)                 { return tx{}, nil }
+
+type tx struct{}
+
+func (tx) Commit() error   { return nil }
+func (tx) Rollback() error { return nil }
+
+type stmt struct {
+	r     *recorder
+	query string
+}
+
+func (s stmt) Close() error  { return nil }
+func (s stmt) NumInput() int { return -1 }
+
+func (s stmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+	s.r.statements = append(s.r.statements, s.query)
+	if strings.HasPrefix(s.query, "INSERT INTO cbsgo_schema_migrations") {
+		s.r.version = args[0].(int64)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s stmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+	s.r.statements = append(s.r.statements, s.query)
+	switch {
+	case strings.Contains(s.query, "max(version)"):
+		return &rows{values: []driver.Value{s.r.version}}, nil
+	case strings.Contains(s.query, "RETURNING generation"):
+		s.r.generation++
+		return &rows{values: []driver.Value{s.r.generation}}, nil
+	}
+	return &rows{}, nil
+}
+
+// rows holds a single row of a single column, if any.
+type rows struct {
+	values []driver.Value
+}
+
+func (r *rows) Columns() []string { return []string{"value"} }
+func (r *rows) Close() error      { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+func openRecorder(t *testing.T) (*sql.DB, *recorder) {
+	t.Helper()
+	r := new(recorder)
+	name := "recorder-" + t.Name()
+	sql.Register(name, r)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, r
+}
+
+func TestPostgresMigrate(t *testing.T) {
+	ctx := context.Background()
+	db, r := openRecorder(t)
+
+	if _, err := store.OpenPostgres(ctx, db); err != nil {
+		t.Fatalf("OpenPostgres returned an unexpected error: %v", err)
+	}
+	if n := len(r.executed("CREATE TABLE cbsgo_runs")); n != 1 {
+		t.Errorf("Expected the tables to be created once, got %d", n)
+	}
+	if n := len(r.executed("pg_advisory_xact_lock")); n != 1 {
+		t.Errorf("Expected the migration to be locked, got %d locks", n)
+	}
+
+	// A migrated database is left alone.
+	if _, err := store.OpenPostgres(ctx, db); err != nil {
+		t.Fatalf("OpenPostgres returned an unexpected error: %v", err)
+	}
+	if n := len(r.executed("CREATE TABLE cbsgo_runs")); n != 1 {
+		t.Errorf("Expected the tables to be created once, got %d", n)
+	}
+
+	// A database migrated by a newer version is refused.
+	r.version = 100
+	if _, err := store.OpenPostgres(ctx, db); err == nil {
+		t.Errorf("Expected an error for a newer schema")
+	}
+}
+
+func TestPostgresWriteRun(t *testing.T) {
+	ctx := context.Background()
+	db, r := openRecorder(t)
+	p, err := store.OpenPostgres(ctx, db)
+	if err != nil {
+		t.Fatalf("OpenPostgres returned an unexpected error: %v", err)
+	}
+
+	run := store.Run{
+		ID:         "run1",
+		Sample:     "s1",
+		Started:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Duration:   1500 * time.Millisecond,
+		Parameters: map[string]any{"alpha": 0.01},
+	}
+	segments := []cbsgo.Segment{{Chrom: "chr1", End: 100, Mean: 0.1}, {Chrom: "chr1", Start: 100, End: 200, Mean: 0.5}}
+	for range 2 {
+		if err := p.WriteRun(ctx, run, segments); err != nil {
+			t.Fatalf("WriteRun returned an unexpected error: %v", err)
+		}
+	}
+	if n := len(r.executed("ON CONFLICT (id) DO UPDATE")); n != 2 {
+		t.Errorf("Expected 2 run upserts, got %d", n)
+	}
+	if n := len(r.executed("ON CONFLICT (run_id, chrom, chrom_start) DO UPDATE")); n != 4 {
+		t.Errorf("Expected 4 segment upserts, got %d", n)
+	}
+	if n := len(r.executed("DELETE FROM cbsgo_segments WHERE run_id = $1 AND generation < $2")); n != 2 {
+		t.Errorf("Expected stale segments to be removed after every write, got %d", n)
+	}
+
+	run.Parameters = map[string]any{"invalid": make(chan int)}
+	if err := p.WriteRun(ctx, run, segments); err == nil {
+		t.Errorf("Expected an error for parameters that are not JSON")
+	}
+}
@@ -0,0 +1,182 @@
+// Package store writes segmentation results into SQL databases, so that
+// cohorts can be queried with SQL rather than by parsing result files.
+//
+// A Store works with any database/sql driver for SQLite or DuckDB, such as
+// github.com/mattn/go-sqlite3 or github.com/marcboeker/go-duckdb, which the
+// caller imports. Open creates the following tables if needed:
+//
+//	samples   one row per sample
+//	  name          TEXT     sample name, the primary key
+//	  created       TEXT     time of the last write, RFC 3339
+//
+//	segments  one row per segment
+//	  sample        TEXT     sample name
+//	  chrom         TEXT     chromosome
+//	  chrom_start   INTEGER  zero-based start position
+//	  chrom_end     INTEGER  end position, exclusive
+//	  bin_start     INTEGER  first bin
+//	  bin_end       INTEGER  last bin, exclusive
+//	  mean          DOUBLE   segment mean
+//	  sd            DOUBLE   standard deviation of the segment values
+//	  state         TEXT     copy number state, see cbsgo.CopyNumberState
+//	  cytoband      TEXT     span of chromosome bands, if annotated
+//
+//	qc        one row per quality metric of a sample
+//	  sample        TEXT     sample name
+//	  metric        TEXT     metric name, see Metrics
+//	  value         DOUBLE   metric value
+//
+// For example, the segments called as gains in at least 10 samples:
+//
+//	SELECT chrom, chrom_start, chrom_end, count(*) FROM segments
+//	WHERE state = 'gain' GROUP BY 1, 2, 3 HAVING count(*) >= 10
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// schema creates the tables in SQLite and DuckDB. Values are DOUBLE, 8 bytes
+// in both, where REAL is a 4-byte float in DuckDB.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS samples (
+		name TEXT PRIMARY KEY,
+		created TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS segments (
+		sample TEXT NOT NULL,
+		chrom TEXT NOT NULL,
+		chrom_start INTEGER NOT NULL,
+		chrom_end INTEGER NOT NULL,
+		bin_start INTEGER NOT NULL,
+		bin_end INTEGER NOT NULL,
+		mean DOUBLE,
+		sd DOUBLE,
+		state TEXT NOT NULL,
+		cytoband TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS segments_sample ON segments (sample)`,
+	`CREATE TABLE IF NOT EXISTS qc (
+		sample TEXT NOT NULL,
+		metric TEXT NOT NULL,
+		value DOUBLE,
+		PRIMARY KEY (sample, metric)
+	)`,
+}
+
+// Store writes results into a database.
+type Store struct {
+	db *sql.DB
+}
+
+// Open returns a Store writing into db, creating the tables if needed.
+func Open(ctx context.Context, db *sql.DB) (*Store, error) {
+	for _, stmt := range schema {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return nil, fmt.Errorf("cbsgo: creating the result tables: %w", err)
+		}
+	}
+	return &Store{db: db}, nil
+}
+
+// Write stores the segments and quality metrics of a sample, replacing any
+// results previously stored for it.
+func (s *Store) Write(ctx context.Context, sample string, segments []cbsgo.Segment, qc map[string]float64) error {
+	w, err := s.Writer(ctx, sample)
+	if err != nil {
+		return err
+	}
+	for _, seg := range segments {
+		if err := w.Write(seg); err != nil {
+			w.Abort()
+			return err
+		}
+	}
+	for name, v := range qc {
+		if err := w.SetMetric(name, v); err != nil {
+			w.Abort()
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// Writer returns a writer storing the results of a sample in a single
+// transaction, which replaces any results previously stored for it once the
+// writer is closed. It implements cbsgo.SegmentWriter, for use with
+// cbsgo.WithWriter.
+func (s *Store) Writer(ctx context.Context, sample string) (*SampleWriter, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	w := &SampleWriter{ctx: ctx, tx: tx, sample: sample}
+	for _, stmt := range []string{
+		`DELETE FROM segments WHERE sample = ?`,
+		`DELETE FROM qc WHERE sample = ?`,
+		`DELETE FROM samples WHERE name = ?`,
+	} {
+		if _, err := tx.ExecContext(ctx, stmt, sample); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("cbsgo: storing sample %s: %w", sample, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO samples (name, created) VALUES (?, ?)`,
+		sample, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("cbsgo: storing sample %s: %w", sample, err)
+	}
+	return w, nil
+}
+
+// SampleWriter stores the results of a sample. See Store.Writer.
+type SampleWriter struct {
+	ctx    context.Context
+	tx     *sql.Tx
+	sample string
+}
+
+// Write stores a segment.
+func (w *SampleWriter) Write(s cbsgo.Segment) error {
+	_, err := w.tx.ExecContext(w.ctx, `INSERT INTO segments
+		(sample, chrom, chrom_start, chrom_end, bin_start, bin_end, mean, sd, state, cytoband)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		w.sample, s.Chrom, s.Start, s.End, s.BinStart, s.BinEnd, nullable(s.Mean), nullable(s.SD), s.State.String(), s.Cytoband)
+	if err != nil {
+		return fmt.Errorf("cbsgo: storing a segment of sample %s: %w", w.sample, err)
+	}
+	return nil
+}
+
+// SetMetric stores a quality metric.
+func (w *SampleWriter) SetMetric(name string, value float64) error {
+	_, err := w.tx.ExecContext(w.ctx, `INSERT INTO qc (sample, metric, value) VALUES (?, ?, ?)`,
+		w.sample, name, nullable(value))
+	if err != nil {
+		return fmt.Errorf("cbsgo: storing metric %s of sample %s: %w", name, w.sample, err)
+	}
+	return nil
+}
+
+// Close commits the results.
+func (w *SampleWriter) Close() error {
+	return w.tx.Commit()
+}
+
+// Abort discards the results, keeping those previously stored.
+func (w *SampleWriter) Abort() error {
+	return w.tx.Rollback()
+}
+
+// nullable returns v, or nil for NaN, which databases store as NULL.
+func nullable(v float64) any {
+	if v != v {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,144 @@
+//go:build cgo
+
+package store_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/store"
+)
+
+// openDB opens a new SQLite database.
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "results.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	s, err := store.Open(ctx, db)
+	if err != nil {
+		t.Fatalf("Open returned an unexpected error: %v", err)
+	}
+
+	segments := []cbsgo.Segment{
+		{Chrom: "chr1", Start: 0, End: 100, BinStart: 0, BinEnd: 10, Mean: 0.01, SD: 0.2, State: cbsgo.Neutral},
+		{Chrom: "chr1", Start: 100, End: 150, BinStart: 10, BinEnd: 15, Mean: 0.6, SD: math.NaN(), State: cbsgo.Gain, Cytoband: "1p36.33"},
+	}
+	if err := s.Write(ctx, "s1", segments, map[string]float64{"segments": 2}); err != nil {
+		t.Fatalf("Write returned an unexpected error: %v", err)
+	}
+	if err := s.Write(ctx, "s2", segments[:1], nil); err != nil {
+		t.Fatalf("Write returned an unexpected error: %v", err)
+	}
+	// Writing a sample again replaces its results, and opening the store
+	// again keeps them.
+	if err := s.Write(ctx, "s2", segments[1:], nil); err != nil {
+		t.Fatalf("Write returned an unexpected error: %v", err)
+	}
+	if _, err := store.Open(ctx, db); err != nil {
+		t.Fatalf("Open returned an unexpected error: %v", err)
+	}
+
+	rows, err := db.Query(`SELECT sample, chrom, chrom_start, chrom_end, bin_end, mean, sd, state, cytoband
+		FROM segments ORDER BY sample, chrom_start`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var sample, chrom, state, cytoband string
+		var start, end, binEnd int
+		var mean, sd sql.NullFloat64
+		if err := rows.Scan(&sample, &chrom, &start, &end, &binEnd, &mean, &sd, &state, &cytoband); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmtRow(sample, chrom, start, end, binEnd, mean, sd, state, cytoband))
+	}
+	expected := []string{
+		"s1 chr1 0 100 10 0.01 0.2 neutral ",
+		"s1 chr1 100 150 15 0.6 NULL gain 1p36.33",
+		"s2 chr1 100 150 15 0.6 NULL gain 1p36.33",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %q\nGot: %q", expected, got)
+	}
+
+	var n int
+	var value float64
+	if err := db.QueryRow(`SELECT count(*) FROM samples`).Scan(&n); err != nil || n != 2 {
+		t.Errorf("Expected 2 samples, got %d, %v", n, err)
+	}
+	if err := db.QueryRow(`SELECT value FROM qc WHERE sample = 's1' AND metric = 'segments'`).Scan(&value); err != nil || value != 2 {
+		t.Errorf("Expected the segments metric, got %v, %v", value, err)
+	}
+}
+
+func TestSampleWriterAbort(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	s, err := store.Open(ctx, db)
+	if err != nil {
+		t.Fatalf("Open returned an unexpected error: %v", err)
+	}
+	if err := s.Write(ctx, "s1", []cbsgo.Segment{{Chrom: "chr1", End: 10}}, nil); err != nil {
+		t.Fatalf("Write returned an unexpected error: %v", err)
+	}
+
+	w, err := s.Writer(ctx, "s1")
+	if err != nil {
+		t.Fatalf("Writer returned an unexpected error: %v", err)
+	}
+	w.Write(cbsgo.Segment{Chrom: "chr2", End: 10})
+	w.Abort()
+
+	var chrom string
+	if err := db.QueryRow(`SELECT chrom FROM segments WHERE sample = 's1'`).Scan(&chrom); err != nil || chrom != "chr1" {
+		t.Errorf("Expected the previous results to be kept, got %q, %v", chrom, err)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	res := &cbsgo.GenomeResult{
+		Segments: cbsgo.SegmentSet{
+			{Start: 0, End: 300, BinEnd: 3, State: cbsgo.Neutral},
+			{Start: 300, End: 400, BinStart: 3, BinEnd: 4, State: cbsgo.Loss},
+		},
+		Tracks: []*cbsgo.Result{{Fit: &cbsgo.Fit{SSE: 1.5}}},
+	}
+	expected := map[string]float64{
+		"segments": 2, "bins": 4, "altered_fraction": 0.25, "gained_fraction": 0, "lost_fraction": 0.25,
+		"breakpoints": 1, "wgii": 0.25, "sse": 1.5,
+	}
+	if got := store.Metrics(res); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+	}
+}
+
+// fmtRow formats a row of the segments table.
+func fmtRow(sample, chrom string, start, end, binEnd int, mean, sd sql.NullFloat64, state, cytoband string) string {
+	f := func(v sql.NullFloat64) string {
+		if !v.Valid {
+			return "NULL"
+		}
+		return strconv.FormatFloat(v.Float64, 'g', -1, 64)
+	}
+	return fmt.Sprintf("%s %s %d %d %d %s %s %s %s", sample, chrom, start, end, binEnd, f(mean), f(sd), state, cytoband)
+}
@@ -0,0 +1,219 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattdsm/cbsgo/timeseries"
+)
+
+// NATSConn is a connection to a NATS server speaking the core NATS
+// protocol, enough to consume samples and publish events without TLS or
+// authentication. Kafka is not supported, as its protocol needs a client
+// library.
+type NATSConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	// mu serializes the writes, as PONG replies are sent while reading.
+	mu  sync.Mutex
+	sid int
+}
+
+// DialNATS connects to the NATS server at address, such as
+// "nats://localhost:4222" or "localhost:4222".
+func DialNATS(ctx context.Context, address string) (*NATSConn, error) {
+	if u, err := url.Parse(address); err == nil && u.Scheme == "nats" {
+		address = u.Host
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	c := &NATSConn{conn: conn, r: bufio.NewReader(conn)}
+	line, err := c.r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return nil, fmt.Errorf("cbsgo: %s is not a NATS server", address)
+	}
+	if err := c.write("CONNECT {\"verbose\":false,\"pedantic\":false,\"name\":\"cbsgo\"}\r\n"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// write writes the protocol line s, followed by the payloads, each ended by
+// CRLF.
+func (c *NATSConn) write(s string, payload ...[]byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var buf bytes.Buffer
+	buf.WriteString(s)
+	for _, p := range payload {
+		buf.Write(p)
+		buf.WriteString("\r\n")
+	}
+	_, err := c.conn.Write(buf.Bytes())
+	return err
+}
+
+// Subscribe subscribes to the messages of subject, which may hold
+// wildcards, such as "metrics.>".
+func (c *NATSConn) Subscribe(subject string) error {
+	c.sid++
+	return c.write(fmt.Sprintf("SUB %s %d\r\n", subject, c.sid))
+}
+
+// Publish publishes data to subject.
+func (c *NATSConn) Publish(subject string, data []byte) error {
+	return c.write(fmt.Sprintf("PUB %s %d\r\n", subject, len(data)), data)
+}
+
+// Next returns the next message of the subscriptions, answering the pings
+// of the server on the way.
+func (c *NATSConn) Next() (subject string, data []byte, err error) {
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return "", nil, err
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "PING":
+			if err := c.write("PONG\r\n"); err != nil {
+				return "", nil, err
+			}
+		case "-ERR":
+			return "", nil, fmt.Errorf("cbsgo: NATS server error: %s", strings.TrimSpace(line[4:]))
+		case "MSG":
+			// MSG <subject> <sid> [reply-to] <size>
+			if len(fields) < 4 || len(fields) > 5 {
+				return "", nil, fmt.Errorf("cbsgo: invalid NATS message %q", strings.TrimSpace(line))
+			}
+			size, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil || size < 0 {
+				return "", nil, fmt.Errorf("cbsgo: invalid NATS message %q", strings.TrimSpace(line))
+			}
+			data := make([]byte, size+2)
+			if _, err := io.ReadFull(c.r, data); err != nil {
+				return "", nil, err
+			}
+			return fields[1], data[:size], nil
+		}
+	}
+}
+
+// Close closes the connection.
+func (c *NATSConn) Close() error {
+	return c.conn.Close()
+}
+
+// sample is the JSON form of a sample.
+type sample struct {
+	Series string     `json:"series"`
+	Time   *time.Time `json:"time"`
+	Value  *float64   `json:"value"`
+}
+
+// ParseSample parses a message holding a sample: either a number, or a JSON
+// object with a numeric "value", an optional RFC 3339 "time" and an optional
+// "series" ID. Samples without time are taken at now, and those without
+// series ID belong to the series named by the subject.
+func ParseSample(subject string, data []byte, now time.Time) (series string, p timeseries.Point, err error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var s sample
+		if err := json.Unmarshal(data, &s); err != nil {
+			return "", p, fmt.Errorf("cbsgo: invalid sample: %w", err)
+		}
+		if s.Value == nil {
+			return "", p, errors.New("cbsgo: sample without value")
+		}
+		series, p = subject, timeseries.Point{Time: now, Value: *s.Value}
+		if s.Series != "" {
+			series = s.Series
+		}
+		if s.Time != nil {
+			p.Time = *s.Time
+		}
+		return series, p, nil
+	}
+	v, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return "", p, fmt.Errorf("cbsgo: invalid sample: %w", err)
+	}
+	return subject, timeseries.Point{Time: now, Value: v}, nil
+}
+
+// ConsumeNATS subscribes c to subject, adds the samples of its messages to
+// m, and publishes every event as JSON to the subject publish, until ctx is
+// done or the connection fails. Messages that do not parse with ParseSample
+// are skipped, so that a misbehaving producer does not stop the monitoring of
+// the other series.
+//
+// The series without messages for the idle time of m are dropped when
+// the next message arrives, checked at most once per idle time, so that
+// series are kept for up to twice the idle time after their last message.
+func ConsumeNATS(ctx context.Context, c *NATSConn, m *Monitor, subject, publish string) error {
+	if err := c.Subscribe(subject); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { c.conn.SetReadDeadline(time.Now()) })
+	defer stop()
+	// seen holds the time of the last message of every series, by the clock
+	// of the consumer, as samples may carry the times of a replay.
+	seen := make(map[string]time.Time)
+	swept := time.Now()
+	for {
+		subj, data, err := c.Next()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		now := time.Now()
+		if idle := m.o.Idle; idle > 0 && now.Sub(swept) >= idle {
+			for series, last := range seen {
+				if now.Sub(last) >= idle {
+					m.Forget(series)
+					delete(seen, series)
+				}
+			}
+			swept = now
+		}
+		series, p, err := ParseSample(subj, data, now)
+		if err != nil {
+			continue
+		}
+		seen[series] = now
+		events, err := m.Add(ctx, series, p)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			b, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			if err := c.Publish(publish, b); err != nil {
+				return err
+			}
+		}
+	}
+}
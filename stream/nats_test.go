@@ -0,0 +1,187 @@
+package stream_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/stream"
+	"github.com/mattdsm/cbsgo/timeseries"
+)
+
+func TestParseSample(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := now.Add(-time.Minute)
+	tests := []struct {
+		data   string
+		series string
+		p      timeseries.Point
+	}{
+		{"1.5\r\n", "metrics.latency", timeseries.Point{Time: now, Value: 1.5}},
+		{`{"value":2}`, "metrics.latency", timeseries.Point{Time: now, Value: 2}},
+		{`{"series":"api","time":"2025-12-31T23:59:00Z","value":3}`, "api", timeseries.Point{Time: at, Value: 3}},
+	}
+	for _, tt := range tests {
+		series, p, err := stream.ParseSample("metrics.latency", []byte(tt.data), now)
+		if err != nil {
+			t.Fatalf("%s: ParseSample returned an unexpected error: %v", tt.data, err)
+		}
+		if series != tt.series || !p.Time.Equal(tt.p.Time) || p.Value != tt.p.Value {
+			t.Errorf("%s: unexpected result.\nExpected: %s %v\nGot: %s %v", tt.data, tt.series, tt.p, series, p)
+		}
+	}
+	for _, data := range []string{"", "abc", `{"time":"2025-12-31T23:59:00Z"}`, `{"value":"1"}`} {
+		if _, _, err := stream.ParseSample("metrics.latency", []byte(data), now); err == nil {
+			t.Errorf("%q: expected an error", data)
+		}
+	}
+}
+
+// natsServer serves a single connection like a NATS server: it expects the
+// subscription of the client, pings it, sends it the messages, pausing for
+// 100ms at the empty ones, and returns the lines the client sends afterwards
+// on lines.
+func natsServer(t *testing.T, messages []string) (address string, lines chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen returned an unexpected error: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	lines = make(chan string, 100)
+	go func() {
+		defer close(lines)
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		fmt.Fprint(conn, "INFO {\"server_id\":\"test\"}\r\n")
+		for range 2 {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			lines <- strings.TrimSpace(line)
+		}
+		fmt.Fprint(conn, "PING\r\n")
+		for _, m := range messages {
+			if m == "" {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			fmt.Fprintf(conn, "MSG metrics.latency 1 %d\r\n%s\r\n", len(m), m)
+		}
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			lines <- strings.TrimSpace(line)
+		}
+	}()
+	return l.Addr().String(), lines
+}
+
+func TestConsumeNATS(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	messages := []string{"abc"}
+	for i := range 60 {
+		v := 10 + float64(i%3)
+		if i >= 30 {
+			v += 20
+		}
+		messages = append(messages, fmt.Sprintf(`{"time":%q,"value":%g}`, start.Add(time.Duration(i)*time.Minute).Format(time.RFC3339), v))
+	}
+	address, lines := natsServer(t, messages)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c, err := stream.DialNATS(ctx, "nats://"+address)
+	if err != nil {
+		t.Fatalf("DialNATS returned an unexpected error: %v", err)
+	}
+	defer c.Close()
+	m, err := stream.NewMonitor(stream.MonitorOptions{Window: 100, Hold: 10}, cbsgo.WithSeed(1), cbsgo.WithShuffles(1000))
+	if err != nil {
+		t.Fatalf("NewMonitor returned an unexpected error: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- stream.ConsumeNATS(ctx, c, m, "metrics.>", "cbs.changepoints") }()
+
+	var got []string
+	for line := range lines {
+		got = append(got, line)
+		if len(got) == 5 {
+			break
+		}
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("ConsumeNATS returned an unexpected error: %v", err)
+	}
+	if len(got) != 5 || !strings.HasPrefix(got[0], "CONNECT ") || got[1] != "SUB metrics.> 1" || got[2] != "PONG" || !strings.HasPrefix(got[3], "PUB cbs.changepoints ") {
+		t.Fatalf("Unexpected protocol lines %q", got)
+	}
+	var e stream.Event
+	if err := json.Unmarshal([]byte(got[4]), &e); err != nil {
+		t.Fatalf("Unexpected event %q: %v", got[4], err)
+	}
+	// Changes may be placed a sample early.
+	if d := e.Time.Sub(start); e.Series != "metrics.latency" || d != 29*time.Minute && d != 30*time.Minute || e.Magnitude < 15 {
+		t.Errorf("Unexpected event %+v", e)
+	}
+}
+
+func TestConsumeNATSIdle(t *testing.T) {
+	// Series a stops before series b starts and shifts.
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var messages []string
+	for i := range 5 {
+		messages = append(messages, fmt.Sprintf(`{"series":"a","time":%q,"value":1}`, start.Add(time.Duration(i)*time.Minute).Format(time.RFC3339)))
+	}
+	messages = append(messages, "")
+	for i := range 60 {
+		v := 10 + float64(i%3)
+		if i >= 30 {
+			v += 20
+		}
+		messages = append(messages, fmt.Sprintf(`{"series":"b","time":%q,"value":%g}`, start.Add(time.Duration(i)*time.Minute).Format(time.RFC3339), v))
+	}
+	address, lines := natsServer(t, messages)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c, err := stream.DialNATS(ctx, address)
+	if err != nil {
+		t.Fatalf("DialNATS returned an unexpected error: %v", err)
+	}
+	defer c.Close()
+	m, err := stream.NewMonitor(stream.MonitorOptions{Window: 100, Hold: 10, Idle: 50 * time.Millisecond}, cbsgo.WithSeed(1), cbsgo.WithShuffles(1000))
+	if err != nil {
+		t.Fatalf("NewMonitor returned an unexpected error: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- stream.ConsumeNATS(ctx, c, m, "metrics.>", "cbs.changepoints") }()
+
+	// The event of b follows the samples of both series.
+	for line := range lines {
+		if strings.HasPrefix(line, "PUB ") {
+			break
+		}
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("ConsumeNATS returned an unexpected error: %v", err)
+	}
+	if n := m.Len(); n != 1 {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", 1, n)
+	}
+}
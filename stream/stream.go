@@ -0,0 +1,167 @@
+// Package stream detects level shifts in many series as their samples
+// arrive, such as metrics consumed from a message broker, and reports every
+// shift once as an event.
+//
+// The samples of every series are kept in a window, which is segmented with
+// timeseries.Detect whenever a sample arrives. A changepoint is confirmed
+// once enough samples at the new level followed it, and the window then
+// restarts at the new level, so that every shift is reported once.
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/timeseries"
+)
+
+// Event reports a confirmed level shift of a series.
+type Event struct {
+	Series string `json:"series"`
+	// Time is the time of the first sample at the new level, and Previous
+	// that of the last sample at the old level.
+	Time     time.Time `json:"time"`
+	Previous time.Time `json:"previous"`
+	// From and To are the levels before and after the shift, and Magnitude
+	// the difference To - From.
+	From      float64 `json:"from"`
+	To        float64 `json:"to"`
+	Magnitude float64 `json:"magnitude"`
+}
+
+// MonitorOptions configures a Monitor.
+type MonitorOptions struct {
+	// Window is the number of samples kept per series, and Hold the number
+	// of samples at the new level confirming a level shift.
+	Window int
+	Hold   int
+	// MinMagnitude is the smallest absolute magnitude of the level shifts
+	// reported.
+	MinMagnitude float64
+	// Idle is the time after which ConsumeNATS drops the window of a series
+	// without new samples, so that series that stop reporting, such as those
+	// of replaced instances, do not accumulate. 0 keeps every series.
+	Idle time.Duration
+
+	// Series configures the segmentation of the windows. With seasonal
+	// patterns to remove, the windows are segmented only once they span
+	// two of the longest periods, which Window samples taken every Interval
+	// must allow for.
+	Series   timeseries.Options
+	Interval time.Duration
+}
+
+// DefaultMonitorOptions returns options keeping 1000 samples per series,
+// confirming level shifts of any magnitude after 10 samples and dropping the
+// series idle for an hour.
+func DefaultMonitorOptions() MonitorOptions {
+	return MonitorOptions{Window: 1000, Hold: 10, Idle: time.Hour}
+}
+
+// Monitor keeps the windows of the series. It is not safe for concurrent
+// use.
+type Monitor struct {
+	o    MonitorOptions
+	opts []cbsgo.Option
+	// span is the least time spanned by the windows segmented.
+	span   time.Duration
+	series map[string][]timeseries.Point
+}
+
+// NewMonitor returns a monitor of series segmented with timeseries.Detect
+// configured by o and opts.
+//
+// As the windows are segmented again whenever a sample arrives, the same
+// data are tested many times, and a significance level lower than usual,
+// set with cbsgo.WithAlpha, avoids spurious shifts, while fewer
+// permutations than the default, set with cbsgo.WithShuffles, keep up with
+// busier streams.
+func NewMonitor(o MonitorOptions, opts ...cbsgo.Option) (*Monitor, error) {
+	if o.Hold < 1 || o.Window < 2*o.Hold {
+		return nil, errors.New("cbsgo: the window must hold at least twice the confirming samples")
+	}
+	if !(o.MinMagnitude >= 0) {
+		return nil, fmt.Errorf("cbsgo: invalid minimum magnitude %v", o.MinMagnitude)
+	}
+	if o.Idle < 0 {
+		return nil, fmt.Errorf("cbsgo: negative idle time %v", o.Idle)
+	}
+	m := &Monitor{o: o, opts: opts, series: make(map[string][]timeseries.Point)}
+	for _, period := range o.Series.Seasons {
+		m.span = max(m.span, 2*period)
+	}
+	if m.span > 0 {
+		if o.Interval <= 0 {
+			return nil, errors.New("cbsgo: seasonal patterns need the sampling interval")
+		}
+		if span := time.Duration(o.Window-1) * o.Interval; span < m.span {
+			return nil, fmt.Errorf("cbsgo: a window of %d samples every %v spans %v, less than the %v of two seasonal periods", o.Window, o.Interval, span, m.span)
+		}
+	}
+	return m, nil
+}
+
+// Add adds a sample to the named series, and returns the level shifts it
+// confirms. Samples not following the last one of their series are dropped.
+func (m *Monitor) Add(ctx context.Context, series string, p timeseries.Point) ([]Event, error) {
+	points := m.series[series]
+	if n := len(points); n > 0 && !p.Time.After(points[n-1].Time) {
+		return nil, nil
+	}
+	points = append(points, p)
+	if len(points) > m.o.Window {
+		points = append(points[:0], points[len(points)-m.o.Window:]...)
+	}
+	defer func() { m.series[series] = points }()
+
+	var events []Event
+	for len(points) >= 2*m.o.Hold && points[len(points)-1].Time.Sub(points[0].Time) >= m.span {
+		res, err := timeseries.Detect(ctx, points, m.o.Series, m.opts...)
+		if err != nil {
+			return events, err
+		}
+		// Shifts need Hold samples on either side, so that those just after
+		// a reported shift, which the window restarts at, are not taken for
+		// another one.
+		var (
+			c  timeseries.Changepoint
+			ok bool
+		)
+		i := 0
+		for _, cp := range res.Changepoints {
+			i = slices.IndexFunc(points, func(q timeseries.Point) bool { return q.Time.Equal(cp.Time) })
+			if i >= m.o.Hold && math.Abs(cp.To-cp.From) >= m.o.MinMagnitude {
+				c, ok = cp, true
+				break
+			}
+		}
+		if !ok || len(points)-i < m.o.Hold {
+			break
+		}
+		events = append(events, Event{
+			Series:    series,
+			Time:      c.Time,
+			Previous:  c.Previous,
+			From:      c.From,
+			To:        c.To,
+			Magnitude: c.To - c.From,
+		})
+		points = append(points[:0], points[i:]...)
+	}
+	return events, nil
+}
+
+// Forget drops the window of the named series.
+func (m *Monitor) Forget(series string) {
+	delete(m.series, series)
+}
+
+// Len returns the number of series whose windows are kept.
+func (m *Monitor) Len() int {
+	return len(m.series)
+}
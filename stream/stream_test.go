@@ -0,0 +1,76 @@
+package stream_test
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/stream"
+	"github.com/mattdsm/cbsgo/timeseries"
+)
+
+func TestMonitor(t *testing.T) {
+	o := stream.MonitorOptions{Window: 100, Hold: 20, MinMagnitude: 1}
+	m, err := stream.NewMonitor(o, cbsgo.WithSeed(1), cbsgo.WithAlpha(0.001), cbsgo.WithShuffles(1000))
+	if err != nil {
+		t.Fatalf("NewMonitor returned an unexpected error: %v", err)
+	}
+	// Series a steps up at the 80th sample, b is stable.
+	rng := rand.New(rand.NewSource(1))
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var events []stream.Event
+	for i := range 150 {
+		at := start.Add(time.Duration(i) * time.Minute)
+		for _, series := range []string{"a", "b"} {
+			v := 10 + rng.NormFloat64()
+			if series == "a" && i >= 80 {
+				v += 5
+			}
+			e, err := m.Add(context.Background(), series, timeseries.Point{Time: at, Value: v})
+			if err != nil {
+				t.Fatalf("Add returned an unexpected error: %v", err)
+			}
+			events = append(events, e...)
+		}
+	}
+	if len(events) != 1 {
+		t.Fatalf("Expected a single event, got %+v", events)
+	}
+	// Shifts are placed within a few samples, by the noise of the samples
+	// around them.
+	e := events[0]
+	if change := start.Add(80 * time.Minute); e.Series != "a" || e.Time.Sub(change).Abs() > 3*time.Minute {
+		t.Errorf("Unexpected event %+v", e)
+	}
+	if math.Abs(e.Magnitude-5) > 1 || e.Magnitude != e.To-e.From {
+		t.Errorf("Unexpected magnitude of event %+v", e)
+	}
+
+	// Samples out of order are dropped.
+	if e, err := m.Add(context.Background(), "a", timeseries.Point{Time: start, Value: 100}); err != nil || e != nil {
+		t.Errorf("Unexpected result %v, %v", e, err)
+	}
+}
+
+func TestNewMonitorInvalid(t *testing.T) {
+	for _, o := range []stream.MonitorOptions{
+		{Window: 10},
+		{Window: 10, Hold: 6},
+		{Window: 10, Hold: 5, MinMagnitude: -1},
+		{Window: 10, Hold: 5, Idle: -time.Second},
+		// Seasons need the interval, and a window spanning two periods.
+		{Window: 100, Hold: 5, Series: timeseries.Options{Seasons: []time.Duration{time.Hour}}},
+		{Window: 100, Hold: 5, Series: timeseries.Options{Seasons: []time.Duration{time.Hour, 24 * time.Hour}}, Interval: time.Minute},
+	} {
+		if _, err := stream.NewMonitor(o); err == nil {
+			t.Errorf("Expected an error for %+v", o)
+		}
+	}
+	o := stream.MonitorOptions{Window: 121, Hold: 5, Series: timeseries.Options{Seasons: []time.Duration{time.Hour}}, Interval: time.Minute}
+	if _, err := stream.NewMonitor(o); err != nil {
+		t.Errorf("NewMonitor returned an unexpected error: %v", err)
+	}
+}
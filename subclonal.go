@@ -0,0 +1,153 @@
+package cbsgo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// SubclonalModel decomposes segment log2 ratios and B-allele frequencies into
+// clonal and subclonal copy number states of a tumor sample of known purity
+// and ploidy.
+//
+// The expected log2 ratio of a segment with total copy number n in all tumor
+// cells is log2((p*n + 2*(1-p)) / (p*P + 2*(1-p))), where p is the purity and
+// P the ploidy, and its expected mirrored BAF, for a major copy number a, is
+// (p*a + 1-p) / (p*n + 2*(1-p)).
+type SubclonalModel struct {
+	// Purity is the fraction of tumor cells in the sample, in (0, 1].
+	Purity float64
+	// Ploidy is the average copy number of the tumor cells.
+	Ploidy float64
+
+	// Tolerance is the largest distance between the copy number implied by
+	// a segment and the closest integer for the segment to be called clonal.
+	// Zero selects 0.1.
+	Tolerance float64
+	// Bandwidth is the largest gap between the cancer cell fractions of two
+	// subclonal segments assigned to the same subclone. Zero selects 0.1.
+	Bandwidth float64
+}
+
+// CopyNumberCall is the copy number state of a segment.
+type CopyNumberCall struct {
+	// CopyNumber is the continuous total copy number implied by the log2 ratio.
+	CopyNumber float64
+
+	// Total and Minor are the total and minor copy numbers of the clonal
+	// state, shared by all tumor cells for clonal segments and by the
+	// cells outside the subclone for subclonal ones. Minor is -1 when the
+	// segment has no B-allele frequency.
+	Total int
+	Minor int
+
+	// Subclonal is set for segments that are a mixture of two states. Then
+	// SubclonalTotal is the total copy number in the subclone, CCF the
+	// fraction of cancer cells in the subclone, and Subclone the index of
+	// the subclone in Subclonality.Subclones. For clonal segments, CCF is 1
+	// and Subclone -1.
+	Subclonal      bool
+	SubclonalTotal int
+	CCF            float64
+	Subclone       int
+}
+
+// Subclone is a group of subclonal segments with similar cancer cell fractions.
+type Subclone struct {
+	// CCF is the mean cancer cell fraction of the segments, weighted by their
+	// number of bins.
+	CCF float64
+	// Segments is the number of segments in the subclone.
+	Segments int
+}
+
+// Subclonality is the result of SubclonalModel.Decompose.
+type Subclonality struct {
+	// Calls holds the copy number state of each segment.
+	Calls []CopyNumberCall
+	// Subclones lists the subclones in order of decreasing cancer cell fraction.
+	Subclones []Subclone
+}
+
+// Decompose calls the copy number state of every segment, whose means must be
+// log2 ratios. The B-allele frequencies of the segments, as set by
+// CallAllelic, are used for the minor copy numbers when available.
+//
+// A segment is called clonal when its implied copy number is close to an
+// integer. Otherwise it is modelled as a mixture of the two closest integer
+// states, the one closest to the ploidy being the state of the cells outside
+// the subclone, and the fraction of cancer cells in the subclone follows from
+// the position of the copy number between the two states. Subclonal segments
+// are then clustered by cancer cell fraction into subclones.
+func (m SubclonalModel) Decompose(segments []Segment) (*Subclonality, error) {
+	if m.Purity <= 0 || m.Purity > 1 {
+		return nil, fmt.Errorf("cbsgo: invalid purity %v", m.Purity)
+	}
+	if m.Ploidy <= 0 {
+		return nil, fmt.Errorf("cbsgo: invalid ploidy %v", m.Ploidy)
+	}
+	tolerance, bandwidth := m.Tolerance, m.Bandwidth
+	if tolerance == 0 {
+		tolerance = 0.1
+	}
+	if bandwidth == 0 {
+		bandwidth = 0.1
+	}
+
+	p := m.Purity
+	normal := 2 * (1 - p)
+	baseline := math.Round(m.Ploidy)
+
+	res := &Subclonality{Calls: make([]CopyNumberCall, len(segments))}
+	var subclonal []int
+	for i, s := range segments {
+		n := math.Max(0, ((p*m.Ploidy+normal)*math.Exp2(s.Mean)-normal)/p)
+		c := CopyNumberCall{CopyNumber: n, Minor: -1, CCF: 1, Subclone: -1}
+
+		if rounded := math.Round(n); math.Abs(n-rounded) <= tolerance {
+			c.Total = int(rounded)
+		} else {
+			lo, hi := math.Floor(n), math.Ceil(n)
+			background, event := lo, hi
+			if math.Abs(hi-baseline) < math.Abs(lo-baseline) {
+				background, event = hi, lo
+			}
+			c.Total, c.SubclonalTotal = int(background), int(event)
+			c.Subclonal = true
+			c.CCF = math.Abs(n - background)
+			subclonal = append(subclonal, i)
+		}
+
+		if s.SNPs > 0 && !math.IsNaN(s.BAF) {
+			major := (s.BAF*(p*n+normal) - (1 - p)) / p
+			minor := math.Round(n - major)
+			c.Minor = int(math.Max(0, math.Min(minor, float64(c.Total/2))))
+		}
+		res.Calls[i] = c
+	}
+
+	// Cluster the subclonal segments by cancer cell fraction, in decreasing order.
+	sort.SliceStable(subclonal, func(a, b int) bool {
+		return res.Calls[subclonal[a]].CCF > res.Calls[subclonal[b]].CCF
+	})
+	var sum, weight float64
+	for k, i := range subclonal {
+		c := &res.Calls[i]
+		if k == 0 || res.Calls[subclonal[k-1]].CCF-c.CCF > bandwidth {
+			if len(res.Subclones) > 0 {
+				res.Subclones[len(res.Subclones)-1].CCF = sum / weight
+			}
+			res.Subclones = append(res.Subclones, Subclone{})
+			sum, weight = 0, 0
+		}
+		w := float64(max(1, segments[i].BinEnd-segments[i].BinStart))
+		sum += w * c.CCF
+		weight += w
+		c.Subclone = len(res.Subclones) - 1
+		res.Subclones[c.Subclone].Segments++
+	}
+	if len(res.Subclones) > 0 {
+		res.Subclones[len(res.Subclones)-1].CCF = sum / weight
+	}
+	return res, nil
+}
@@ -0,0 +1,70 @@
+package cbsgo_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestSubclonalModelDecompose(t *testing.T) {
+	const purity = 0.8
+	lr := func(n float64) float64 { return math.Log2((purity*n + 2*(1-purity)) / 2) }
+
+	segments := []cbsgo.Segment{
+		{BinStart: 0, BinEnd: 10, Mean: lr(2), SNPs: 10, BAF: 0.5},
+		{BinStart: 10, BinEnd: 20, Mean: lr(3), BAF: math.NaN()},
+		{BinStart: 20, BinEnd: 30, Mean: lr(1), SNPs: 10, BAF: 1 / 1.2},
+		{BinStart: 30, BinEnd: 40, Mean: lr(2.4), BAF: math.NaN()},
+		{BinStart: 40, BinEnd: 50, Mean: lr(1.55), BAF: math.NaN()},
+		{BinStart: 50, BinEnd: 60, Mean: lr(2.38), BAF: math.NaN()},
+		{BinStart: 60, BinEnd: 70, Mean: lr(2.7), BAF: math.NaN()},
+	}
+
+	res, err := cbsgo.SubclonalModel{Purity: purity, Ploidy: 2}.Decompose(segments)
+	if err != nil {
+		t.Fatalf("Decompose returned an unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		total, minor, subclonalTotal int
+		ccf                          float64
+		subclone                     int
+	}{
+		{2, 1, 0, 1, -1},
+		{3, -1, 0, 1, -1},
+		{1, 0, 0, 1, -1},
+		{2, -1, 3, 0.4, 1},
+		{2, -1, 1, 0.45, 1},
+		{2, -1, 3, 0.38, 1},
+		{2, -1, 3, 0.7, 0},
+	}
+	for i, e := range expected {
+		c := res.Calls[i]
+		if c.Total != e.total || c.Minor != e.minor || c.Subclone != e.subclone ||
+			(e.subclone >= 0 && c.SubclonalTotal != e.subclonalTotal) || math.Abs(c.CCF-e.ccf) > 1e-9 {
+			t.Errorf("Segment %d: expected %+v, got %+v", i, e, c)
+		}
+		if c.Subclonal != (e.subclone >= 0) {
+			t.Errorf("Segment %d: unexpected subclonal flag %v", i, c.Subclonal)
+		}
+	}
+
+	if len(res.Subclones) != 2 {
+		t.Fatalf("Expected 2 subclones, got %v", res.Subclones)
+	}
+	if s := res.Subclones[0]; math.Abs(s.CCF-0.7) > 1e-9 || s.Segments != 1 {
+		t.Errorf("Unexpected first subclone %+v", s)
+	}
+	if s := res.Subclones[1]; math.Abs(s.CCF-0.41) > 1e-9 || s.Segments != 3 {
+		t.Errorf("Unexpected second subclone %+v", s)
+	}
+}
+
+func TestSubclonalModelInvalid(t *testing.T) {
+	for _, m := range []cbsgo.SubclonalModel{{Purity: 0, Ploidy: 2}, {Purity: 1.2, Ploidy: 2}, {Purity: 0.5}} {
+		if _, err := m.Decompose(nil); err == nil {
+			t.Errorf("Expected an error for %+v", m)
+		}
+	}
+}
@@ -0,0 +1,441 @@
+package cbsgo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TabixReader reads the records overlapping a region from a bgzip-compressed,
+// tabix-indexed tab-separated file, such as a bedGraph file indexed with
+// "tabix -p bed", without decompressing the rest of the file.
+type TabixReader struct {
+	data *bgzfReader
+	idx  *tabixIndex
+}
+
+// tabixIndex is the content of a .tbi file.
+type tabixIndex struct {
+	// zeroBased is set when the start column holds zero-based coordinates.
+	zeroBased     bool
+	seq, beg, end int
+	meta          byte
+	names         []string
+	refs          map[string]*tabixRef
+}
+
+// tabixRef is the index of the records of one chromosome.
+type tabixRef struct {
+	bins   map[uint32][]tabixChunk
+	linear []uint64
+}
+
+// tabixChunk is a range [beg, end) of virtual offsets in the data file.
+type tabixChunk struct {
+	beg, end uint64
+}
+
+// tabixShift is the size in bits of the windows of the linear index.
+const tabixShift = 14
+
+// NewTabixReader returns a reader of the bgzip-compressed data, indexed by
+// the tabix index read from index.
+func NewTabixReader(data io.ReadSeeker, index io.Reader) (*TabixReader, error) {
+	idx, err := readTabixIndex(index)
+	if err != nil {
+		return nil, err
+	}
+	return &TabixReader{data: &bgzfReader{r: data}, idx: idx}, nil
+}
+
+// Chromosomes returns the names of the indexed chromosomes, in file order.
+func (t *TabixReader) Chromosomes() []string {
+	return append([]string(nil), t.idx.names...)
+}
+
+// Lines returns the records overlapping [start, end) on chrom, in file order.
+// Coordinates are zero-based and half-open, whatever the convention of the
+// file.
+func (t *TabixReader) Lines(chrom string, start, end int) ([]string, error) {
+	var lines []string
+	err := t.query(chrom, start, end, func(line string, _ []string, _, _ int) error {
+		lines = append(lines, line)
+		return nil
+	})
+	return lines, err
+}
+
+// Track reads the records overlapping [start, end) on chrom into a track,
+// taking the values from the one-based column valueCol, which is 4 for
+// bedGraph files.
+func (t *TabixReader) Track(chrom string, start, end, valueCol int) (*Track, error) {
+	track := &Track{Chrom: chrom, Starts: []int{}, Ends: []int{}}
+	err := t.query(chrom, start, end, func(_ string, fields []string, beg, end int) error {
+		if valueCol < 1 || valueCol > len(fields) {
+			return fmt.Errorf("cbsgo: record has no column %d", valueCol)
+		}
+		v, err := strconv.ParseFloat(fields[valueCol-1], 64)
+		if err != nil {
+			return err
+		}
+		track.Starts = append(track.Starts, beg)
+		track.Ends = append(track.Ends, end)
+		track.Values = append(track.Values, v)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return track, nil
+}
+
+// query calls fn for every record overlapping [start, end) on chrom, with the
+// fields and the zero-based, half-open extent of the record.
+func (t *TabixReader) query(chrom string, start, end int, fn func(line string, fields []string, beg, end int) error) error {
+	ref := t.idx.refs[chrom]
+	if ref == nil {
+		return nil
+	}
+	for _, c := range ref.chunks(start, end) {
+		if err := t.data.seek(c.beg); err != nil {
+			return err
+		}
+		for t.data.offset() < c.end {
+			line, err := t.data.readLine()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if line == "" || line[0] == t.idx.meta {
+				continue
+			}
+			fields := strings.Split(line, "\t")
+			seq, beg, stop, err := t.idx.parse(fields)
+			if err != nil {
+				return err
+			}
+			if seq != chrom || beg >= end || stop <= start {
+				continue
+			}
+			if err := fn(line, fields, beg, stop); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parse returns the chromosome and the zero-based, half-open extent of the
+// record with the given fields.
+func (idx *tabixIndex) parse(fields []string) (string, int, int, error) {
+	if len(fields) < max(idx.seq, idx.beg, idx.end) {
+		return "", 0, 0, fmt.Errorf("cbsgo: record has %d columns", len(fields))
+	}
+	beg, err := strconv.Atoi(fields[idx.beg-1])
+	if err != nil {
+		return "", 0, 0, err
+	}
+	if !idx.zeroBased {
+		beg--
+	}
+	end := beg + 1
+	if idx.end > 0 {
+		if end, err = strconv.Atoi(fields[idx.end-1]); err != nil {
+			return "", 0, 0, err
+		}
+	}
+	return fields[idx.seq-1], beg, end, nil
+}
+
+// chunks returns the sorted, merged chunks that may hold records overlapping
+// [start, end).
+func (ref *tabixRef) chunks(start, end int) []tabixChunk {
+	start, end = max(start, 0), max(end, start+1)
+	var minOff uint64
+	if w := start >> tabixShift; len(ref.linear) > 0 {
+		minOff = ref.linear[min(w, len(ref.linear)-1)]
+	}
+	var chunks []tabixChunk
+	for _, bin := range regionBins(start, end) {
+		for _, c := range ref.bins[bin] {
+			if c.end > minOff {
+				chunks = append(chunks, c)
+			}
+		}
+	}
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].beg < chunks[j].beg })
+
+	var res []tabixChunk
+	for _, c := range chunks {
+		if n := len(res); n > 0 && c.beg <= res[n-1].end {
+			res[n-1].end = max(res[n-1].end, c.end)
+			continue
+		}
+		res = append(res, c)
+	}
+	return res
+}
+
+// regionBins returns the bins of the binning scheme of tabix that may hold
+// records overlapping [start, end).
+func regionBins(start, end int) []uint32 {
+	end--
+	bins := []uint32{0}
+	for _, level := range []struct {
+		offset uint32
+		shift  int
+	}{{1, 26}, {9, 23}, {73, 20}, {585, 17}, {4681, 14}} {
+		for k := start >> level.shift; k <= end>>level.shift; k++ {
+			bins = append(bins, level.offset+uint32(k))
+		}
+	}
+	return bins
+}
+
+// readTabixIndex reads a .tbi file.
+func readTabixIndex(r io.Reader) (*tabixIndex, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, []byte("TBI\x01")) {
+		return nil, errors.New("cbsgo: not a tabix index")
+	}
+	br := &binReader{data: data[4:]}
+
+	var header [8]int32
+	for i := range header {
+		header[i] = br.int32()
+	}
+	idx := &tabixIndex{
+		zeroBased: header[1]&0x10000 != 0,
+		seq:       int(header[2]),
+		beg:       int(header[3]),
+		end:       int(header[4]),
+		meta:      byte(header[5]),
+		refs:      make(map[string]*tabixRef),
+	}
+	if header[1]&0xffff != 0 {
+		return nil, fmt.Errorf("cbsgo: unsupported tabix format %d", header[1]&0xffff)
+	}
+	names := br.bytes(int(header[7]))
+	for _, name := range bytes.Split(bytes.TrimSuffix(names, []byte{0}), []byte{0}) {
+		if len(name) > 0 {
+			idx.names = append(idx.names, string(name))
+		}
+	}
+	if len(idx.names) != int(header[0]) {
+		return nil, errors.New("cbsgo: corrupt tabix index")
+	}
+
+	for _, name := range idx.names {
+		ref := &tabixRef{bins: make(map[uint32][]tabixChunk)}
+		for i, n := 0, br.count(8); i < n && br.err == nil; i++ {
+			bin := br.uint32()
+			chunks := make([]tabixChunk, br.count(16))
+			for j := range chunks {
+				chunks[j] = tabixChunk{br.uint64(), br.uint64()}
+			}
+			ref.bins[bin] = chunks
+		}
+		ref.linear = make([]uint64, br.count(8))
+		for i := range ref.linear {
+			ref.linear[i] = br.uint64()
+		}
+		idx.refs[name] = ref
+	}
+	if br.err != nil {
+		return nil, errors.New("cbsgo: truncated tabix index")
+	}
+	return idx, nil
+}
+
+// binReader decodes little-endian integers, recording whether data ran out.
+type binReader struct {
+	data []byte
+	err  error
+}
+
+func (br *binReader) bytes(n int) []byte {
+	if n < 0 || n > len(br.data) {
+		br.err, br.data = io.ErrUnexpectedEOF, nil
+		return make([]byte, 8)[:min(max(n, 0), 8)]
+	}
+	b := br.data[:n]
+	br.data = br.data[n:]
+	return b
+}
+
+// count reads the number of the following elements of the given size.
+func (br *binReader) count(size int) int {
+	n := int(br.int32())
+	if n < 0 || n*size > len(br.data) {
+		br.err, br.data = io.ErrUnexpectedEOF, nil
+		return 0
+	}
+	return n
+}
+
+func (br *binReader) int32() int32   { return int32(binary.LittleEndian.Uint32(br.bytes(4))) }
+func (br *binReader) uint32() uint32 { return binary.LittleEndian.Uint32(br.bytes(4)) }
+func (br *binReader) uint64() uint64 { return binary.LittleEndian.Uint64(br.bytes(8)) }
+
+// TabixWriter writes records sorted by position as a bgzip-compressed file
+// together with its tabix index, so that the output can be queried by region
+// with tabix, htslib-based viewers, or TabixReader.
+type TabixWriter struct {
+	data  *bgzfWriter
+	index io.Writer
+
+	names []string
+	refs  map[string]*tabixRef
+	// last holds the chromosome and start of the last record.
+	lastChrom string
+	lastStart int
+}
+
+// NewTabixWriter returns a writer of the compressed records to data and of
+// their index, in the format of "tabix -p bed", to index when it is closed.
+func NewTabixWriter(data, index io.Writer) *TabixWriter {
+	return &TabixWriter{data: &bgzfWriter{w: data}, index: index, refs: make(map[string]*tabixRef)}
+}
+
+// WriteRecord writes the line holding a record covering [start, end) on
+// chrom, whose first three columns must be chrom, start and end. The records
+// of a chromosome must be written together, in order of start.
+func (t *TabixWriter) WriteRecord(chrom string, start, end int, line string) error {
+	ref := t.refs[chrom]
+	switch {
+	case end <= start || start < 0:
+		return fmt.Errorf("cbsgo: invalid record extent [%d, %d)", start, end)
+	case ref != nil && chrom != t.lastChrom:
+		return fmt.Errorf("cbsgo: records on %s are not contiguous", chrom)
+	case ref != nil && start < t.lastStart:
+		return fmt.Errorf("cbsgo: records on %s are not sorted by start", chrom)
+	}
+	if ref == nil {
+		ref = &tabixRef{bins: make(map[uint32][]tabixChunk)}
+		t.refs[chrom] = ref
+		t.names = append(t.names, chrom)
+	}
+	t.lastChrom, t.lastStart = chrom, start
+
+	line = strings.TrimSuffix(line, "\n") + "\n"
+	if err := t.data.reserve(len(line)); err != nil {
+		return err
+	}
+	beg := t.data.offset()
+	if _, err := io.WriteString(t.data, line); err != nil {
+		return err
+	}
+	ref.add(start, end, tabixChunk{beg, t.data.offset()})
+	return nil
+}
+
+// Close completes the compressed file and writes the index. It does not close
+// the underlying writers.
+func (t *TabixWriter) Close() error {
+	if err := t.data.Close(); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("TBI\x01")
+	var names []byte
+	for _, name := range t.names {
+		names = append(append(names, name...), 0)
+	}
+	for _, v := range []int32{int32(len(t.names)), 0x10000, 1, 2, 3, '#', 0, int32(len(names))} {
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+	buf.Write(names)
+	for _, name := range t.names {
+		ref := t.refs[name]
+		bins := make([]uint32, 0, len(ref.bins))
+		for bin := range ref.bins {
+			bins = append(bins, bin)
+		}
+		sort.Slice(bins, func(i, j int) bool { return bins[i] < bins[j] })
+
+		binary.Write(&buf, binary.LittleEndian, int32(len(bins)))
+		for _, bin := range bins {
+			chunks := ref.bins[bin]
+			binary.Write(&buf, binary.LittleEndian, bin)
+			binary.Write(&buf, binary.LittleEndian, int32(len(chunks)))
+			for _, c := range chunks {
+				binary.Write(&buf, binary.LittleEndian, [2]uint64{c.beg, c.end})
+			}
+		}
+		binary.Write(&buf, binary.LittleEndian, int32(len(ref.linear)))
+		binary.Write(&buf, binary.LittleEndian, ref.linear)
+	}
+
+	zw := &bgzfWriter{w: t.index}
+	if _, err := zw.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// add indexes the record covering [start, end) stored at c.
+func (ref *tabixRef) add(start, end int, c tabixChunk) {
+	bin := regionBin(start, end)
+	chunks := ref.bins[bin]
+	if n := len(chunks); n > 0 && chunks[n-1].end == c.beg {
+		chunks[n-1].end = c.end
+	} else {
+		ref.bins[bin] = append(chunks, c)
+	}
+
+	// Every window of the linear index holds the offset of the first record
+	// overlapping it, or of an earlier one if there is none.
+	last := (end - 1) >> tabixShift
+	for w := len(ref.linear); w <= last; w++ {
+		off := c.beg
+		if w < start>>tabixShift && w > 0 {
+			off = ref.linear[w-1]
+		}
+		ref.linear = append(ref.linear, off)
+	}
+}
+
+// regionBin returns the smallest bin of the binning scheme of tabix that
+// contains [start, end).
+func regionBin(start, end int) uint32 {
+	end--
+	for _, level := range []struct {
+		offset uint32
+		shift  int
+	}{{4681, 14}, {585, 17}, {73, 20}, {9, 23}, {1, 26}} {
+		if start>>level.shift == end>>level.shift {
+			return level.offset + uint32(start>>level.shift)
+		}
+	}
+	return 0
+}
+
+// WriteBedGraphTabix writes the segment means in bedGraph format, like
+// WriteBedGraph, as a bgzip-compressed file to data and its tabix index to
+// index. The segments are sorted by chromosome and start first.
+func WriteBedGraphTabix(data, index io.Writer, segments []Segment) error {
+	t := NewTabixWriter(data, index)
+	for _, s := range SegmentSet(segments).Sorted() {
+		line := fmt.Sprintf("%s\t%d\t%d\t%g", s.Chrom, s.Start, s.End, s.Mean)
+		if err := t.WriteRecord(s.Chrom, s.Start, s.End, line); err != nil {
+			return err
+		}
+	}
+	return t.Close()
+}
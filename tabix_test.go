@@ -0,0 +1,185 @@
+package cbsgo_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// openTabix opens testdata/sample.bedgraph.gz, which holds 1 kb bins on chr1
+// and sparser bins on chr2, spread over many BGZF blocks.
+func openTabix(t *testing.T) *cbsgo.TabixReader {
+	t.Helper()
+	data, err := os.Open("testdata/sample.bedgraph.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { data.Close() })
+	index, err := os.Open("testdata/sample.bedgraph.gz.tbi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	r, err := cbsgo.NewTabixReader(data, index)
+	if err != nil {
+		t.Fatalf("NewTabixReader returned an unexpected error: %v", err)
+	}
+	return r
+}
+
+// scanBedGraph returns the records of testdata/sample.bedgraph.gz overlapping
+// a region by reading the whole file.
+func scanBedGraph(t *testing.T, chrom string, start, end int) []string {
+	t.Helper()
+	f, err := os.Open("testdata/sample.bedgraph.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		fields := strings.Split(line, "\t")
+		if fields[0] != chrom {
+			continue
+		}
+		s, _ := strconv.Atoi(fields[1])
+		e, _ := strconv.Atoi(fields[2])
+		if s < end && e > start {
+			res = append(res, line)
+		}
+	}
+	return res
+}
+
+func TestTabixLines(t *testing.T) {
+	r := openTabix(t)
+	if got, expected := r.Chromosomes(), []string{"chr1", "chr2"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected chromosomes.\nExpected: %v\nGot: %v", expected, got)
+	}
+
+	for _, q := range []struct {
+		chrom      string
+		start, end int
+	}{
+		{"chr1", 50000, 60500},
+		{"chr1", 0, 1},
+		{"chr1", 199500, 1 << 29},
+		{"chr1", 0, 1 << 29},
+		{"chr2", 12000, 14000},
+		{"chr2", 0, 1 << 29},
+	} {
+		got, err := r.Lines(q.chrom, q.start, q.end)
+		if err != nil {
+			t.Fatalf("Lines returned an unexpected error: %v", err)
+		}
+		expected := scanBedGraph(t, q.chrom, q.start, q.end)
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s:%d-%d: unexpected result.\nExpected: %v\nGot: %v", q.chrom, q.start, q.end, expected, got)
+		}
+	}
+
+	if got, err := r.Lines("chr3", 0, 1000); err != nil || got != nil {
+		t.Errorf("Expected no records on an unindexed chromosome, got %v, %v", got, err)
+	}
+}
+
+func TestTabixTrack(t *testing.T) {
+	r := openTabix(t)
+	track, err := r.Track("chr1", 60000, 140000, 4)
+	if err != nil {
+		t.Fatalf("Track returned an unexpected error: %v", err)
+	}
+	if track.Len() != 80 || track.Starts[0] != 60000 || track.Ends[79] != 140000 {
+		t.Fatalf("Unexpected track of %d bins from %v to %v", track.Len(), track.Starts[0], track.Ends[track.Len()-1])
+	}
+
+	// The region holds a gain from 80 kb to 120 kb.
+	res, err := track.Segment(cbsgo.WithSeed(42))
+	if err != nil {
+		t.Fatalf("Segment returned an unexpected error: %v", err)
+	}
+	if len(res.Segments) != 3 || res.Segments[1].Mean < 1 {
+		t.Errorf("Unexpected segments %+v", res.Segments)
+	}
+}
+
+func TestWriteBedGraphTabix(t *testing.T) {
+	// Enough segments to fill several BGZF blocks, written out of order.
+	var segments []cbsgo.Segment
+	for _, chrom := range []string{"chr2", "chr10", "chr1"} {
+		for i := 0; i < 5000; i++ {
+			segments = append(segments, cbsgo.Segment{Chrom: chrom, Start: 500 * i, End: 500*i + 700, Mean: float64(i%9) / 8})
+		}
+	}
+
+	var data, index bytes.Buffer
+	if err := cbsgo.WriteBedGraphTabix(&data, &index, segments); err != nil {
+		t.Fatalf("WriteBedGraphTabix returned an unexpected error: %v", err)
+	}
+
+	// The data is plain gzip to tools unaware of BGZF.
+	zr, err := gzip.NewReader(bytes.NewReader(data.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected bytes.Buffer
+	if err := cbsgo.WriteBedGraph(&expected, cbsgo.SegmentSet(segments).Sorted()); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plain, expected.Bytes()) {
+		t.Fatalf("Decompressed data differs from WriteBedGraph output")
+	}
+
+	r, err := cbsgo.NewTabixReader(bytes.NewReader(data.Bytes()), &index)
+	if err != nil {
+		t.Fatalf("NewTabixReader returned an unexpected error: %v", err)
+	}
+	if got, want := r.Chromosomes(), []string{"chr1", "chr2", "chr10"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected chromosomes.\nExpected: %v\nGot: %v", want, got)
+	}
+	track, err := r.Track("chr10", 1_000_000, 1_100_000, 4)
+	if err != nil {
+		t.Fatalf("Track returned an unexpected error: %v", err)
+	}
+	// Segments starting from 999_500 to 1_099_500 overlap the region.
+	if track.Len() != 201 || track.Starts[0] != 999_500 || track.Values[0] != float64(1999%9)/8 {
+		t.Errorf("Unexpected track of %d bins", track.Len())
+	}
+}
+
+func TestTabixWriterOrder(t *testing.T) {
+	var data, index bytes.Buffer
+	w := cbsgo.NewTabixWriter(&data, &index)
+	if err := w.WriteRecord("chr1", 100, 200, "chr1\t100\t200"); err != nil {
+		t.Fatalf("WriteRecord returned an unexpected error: %v", err)
+	}
+	if err := w.WriteRecord("chr1", 50, 200, "chr1\t50\t200"); err == nil {
+		t.Errorf("Expected an error for an unsorted record")
+	}
+	if err := w.WriteRecord("chr2", 0, 10, "chr2\t0\t10"); err != nil {
+		t.Fatalf("WriteRecord returned an unexpected error: %v", err)
+	}
+	if err := w.WriteRecord("chr1", 300, 400, "chr1\t300\t400"); err == nil {
+		t.Errorf("Expected an error for a non-contiguous chromosome")
+	}
+}
@@ -0,0 +1,56 @@
+package cbsgo
+
+import (
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// tailExceedances is the number of permutations exceeding the statistic below
+// which WithTailPValues extrapolates the p-value.
+const tailExceedances = 10
+
+// TailPValue estimates the probability that a draw from the null
+// distribution sampled by null exceeds t, also beyond the largest draw, by
+// fitting a generalized Pareto distribution to the largest 10% of the draws,
+// as proposed by Knijnenburg et al. (2009). It returns 0 when fewer than 50
+// draws are available, and the empirical fraction of exceeding draws when t
+// lies below the tail. null is sorted in place.
+func TailPValue(null []float64, t float64) float64 {
+	n := len(null)
+	if n < 50 {
+		return 0
+	}
+	sort.Float64s(null)
+	k := n / 10
+	u := null[n-k-1]
+	if t <= u {
+		exceed := n - sort.SearchFloat64s(null, t)
+		return float64(exceed) / float64(n)
+	}
+
+	excess := make([]float64, k)
+	for i, v := range null[n-k:] {
+		excess[i] = v - u
+	}
+	// Method of moments estimates of the shape xi and scale sigma. Negative
+	// shapes imply a bounded null distribution; the exponential tail used
+	// instead keeps the extrapolation conservative.
+	mean, variance := stat.MeanVariance(excess, nil)
+	if variance == 0 {
+		return 0
+	}
+	ratio := mean * mean / variance
+	xi := (1 - ratio) / 2
+	sigma := mean * (ratio + 1) / 2
+
+	y := t - u
+	var tail float64
+	if xi < 1e-6 {
+		tail = math.Exp(-y / mean)
+	} else {
+		tail = math.Pow(1+xi*y/sigma, -1/xi)
+	}
+	return float64(k) / float64(n) * tail
+}
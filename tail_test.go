@@ -0,0 +1,49 @@
+package cbsgo_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestTailPValue(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	null := make([]float64, 10000)
+	for i := range null {
+		null[i] = rng.ExpFloat64()
+	}
+	for _, x := range []float64{1, 5, 10, 20} {
+		expected := math.Exp(-x)
+		got := cbsgo.TailPValue(null, x)
+		if got < expected/10 || got > expected*10 {
+			t.Errorf("%v: expected about %g, got %g", x, expected, got)
+		}
+	}
+	if got := cbsgo.TailPValue(null[:10], 1); got != 0 {
+		t.Errorf("Expected 0 for too few draws, got %v", got)
+	}
+}
+
+func TestWithTailPValues(t *testing.T) {
+	x := make([]float64, 200)
+	for i := range x {
+		x[i] = math.Sin(float64(i)) / 5
+		if i >= 80 && i < 120 {
+			x[i] += 3
+		}
+	}
+	res, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithBreakpoints(0.95), cbsgo.WithTailPValues())
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if len(res.Breakpoints) == 0 {
+		t.Fatalf("Expected breakpoints")
+	}
+	for _, b := range res.Breakpoints {
+		if !(b.PValue < 1.0/1001) {
+			t.Errorf("Expected a p-value below the permutation resolution, got %+v", b)
+		}
+	}
+}
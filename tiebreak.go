@@ -0,0 +1,72 @@
+package cbsgo
+
+// TieBreak chooses among equal extremes of the cumulative sums delimiting
+// the best split. See WithTieBreak.
+//
+// Extremes are equal when they compare equal as floating-point numbers;
+// cumulative sums are computed in a fixed order, so that the choice does not
+// depend on the architecture for data exactly representable in binary, such
+// as integer counts.
+type TieBreak int
+
+const (
+	// Leftmost chooses the first of the equal extremes.
+	Leftmost TieBreak = iota
+	// Rightmost chooses the last of the equal extremes.
+	Rightmost
+	// Midpoint chooses the middle of the equal extremes, the left one of the
+	// two middle ones for an even number.
+	Midpoint
+)
+
+// String returns the name of the rule.
+func (t TieBreak) String() string {
+	switch t {
+	case Rightmost:
+		return "rightmost"
+	case Midpoint:
+		return "midpoint"
+	}
+	return "leftmost"
+}
+
+// maxIdx returns the index of the maximum of y, chosen among ties by t.
+func (t TieBreak) maxIdx(y []float64) int {
+	return t.extreme(y, func(a, b float64) bool { return a > b })
+}
+
+// minIdx returns the index of the minimum of y, chosen among ties by t.
+func (t TieBreak) minIdx(y []float64) int {
+	return t.extreme(y, func(a, b float64) bool { return a < b })
+}
+
+// extreme returns the index of the extreme of y by better, chosen among ties
+// by t.
+func (t TieBreak) extreme(y []float64, better func(a, b float64) bool) int {
+	best, first, last, count := 0, 0, 0, 1
+	for i := 1; i < len(y); i++ {
+		switch {
+		case better(y[i], y[best]):
+			best, first, last, count = i, i, i, 1
+		case y[i] == y[best]:
+			last = i
+			count++
+		}
+	}
+	switch t {
+	case Rightmost:
+		return last
+	case Midpoint:
+		// The (count+1)/2-th of the ties, found again.
+		k := (count - 1) / 2
+		for i := first; ; i++ {
+			if y[i] == y[best] {
+				if k == 0 {
+					return i
+				}
+				k--
+			}
+		}
+	}
+	return first
+}
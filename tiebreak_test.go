@@ -0,0 +1,31 @@
+package cbsgo_test
+
+import (
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestWithTieBreak(t *testing.T) {
+	// The cumulative sums of the mean-centered values reach their maximum at
+	// bins 9 to 13, over the run of values equal to the mean.
+	var x []float64
+	for _, run := range []struct {
+		value float64
+		n     int
+	}{{2, 10}, {1, 4}, {0, 10}} {
+		for range run.n {
+			x = append(x, run.value)
+		}
+	}
+
+	for tie, expected := range map[cbsgo.TieBreak]int{cbsgo.Leftmost: 9, cbsgo.Rightmost: 13, cbsgo.Midpoint: 11} {
+		res, err := cbsgo.Run(x, cbsgo.WithSeed(42), cbsgo.WithTieBreak(tie))
+		if err != nil {
+			t.Fatalf("%v: Run returned an unexpected error: %v", tie, err)
+		}
+		if got := res.Segments[0].End; got != expected {
+			t.Errorf("%v: expected the first segment to end at %d, got %v", tie, expected, res.Segments)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+package timeseries
+
+// Exported for the tests of the timeseries_test package.
+var PositionUnit = positionUnit
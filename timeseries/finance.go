@@ -0,0 +1,101 @@
+package timeseries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// LogReturns returns the log returns of a price series, which must be in
+// order of time: log(p[i] / p[i-1]) at the time of every price but the
+// first. Prices must be positive; NaN prices give NaN returns, and the
+// return after them spans the gap.
+func LogReturns(prices []Point) ([]Point, error) {
+	if err := checkOrder(prices); err != nil {
+		return nil, err
+	}
+	var (
+		returns []Point
+		prev    = math.NaN()
+	)
+	for i, p := range prices {
+		if math.IsNaN(p.Value) {
+			if i > 0 {
+				returns = append(returns, Point{Time: p.Time, Value: math.NaN()})
+			}
+			continue
+		}
+		if !(p.Value > 0) {
+			return nil, fmt.Errorf("cbsgo: price %d at %v is %v, not positive", i, p.Time, p.Value)
+		}
+		if i > 0 {
+			returns = append(returns, Point{Time: p.Time, Value: math.Log(p.Value / prev)})
+		}
+		prev = p.Value
+	}
+	return returns, nil
+}
+
+// ScaleVolatility returns the returns divided by their volatility, the
+// standard deviation of the window returns before them, so that changes of
+// their mean, such as the drift of a trend, stand out of periods of
+// different volatility. The first returns, with fewer than window returns
+// before them, are scaled by the volatility of the first window returns.
+// NaN returns are kept and do not count.
+func ScaleVolatility(returns []Point, window int) ([]Point, error) {
+	if window < 2 {
+		return nil, fmt.Errorf("cbsgo: invalid volatility window %d", window)
+	}
+	var valid []float64
+	for _, p := range returns {
+		if !math.IsNaN(p.Value) {
+			valid = append(valid, p.Value)
+		}
+	}
+	if len(valid) < window {
+		return nil, fmt.Errorf("cbsgo: %d returns, fewer than the volatility window of %d", len(valid), window)
+	}
+	res := slices.Clone(returns)
+	k := 0
+	for i, p := range returns {
+		if math.IsNaN(p.Value) {
+			continue
+		}
+		lo := max(k-window, 0)
+		var s cbsgo.RunningStats
+		for _, v := range valid[lo : lo+window] {
+			s.Add(v)
+		}
+		if sd := s.SD(); sd > 0 {
+			res[i].Value = p.Value / sd
+		}
+		k++
+	}
+	return res, nil
+}
+
+// DetectRegimes detects the volatility regimes of a price series, such as
+// the calm and turbulent periods of a market: it segments the absolute log
+// returns of the prices with Detect, configured by o and opts, replacing them
+// by their ranks with cbsgo.RankTransform to be robust to their heavy tails.
+// The levels of the result are mean absolute log returns, about 0.8 times
+// the volatility of the returns of each regime. For changes of the drift
+// instead, segment the log returns scaled with ScaleVolatility.
+func DetectRegimes(ctx context.Context, prices []Point, o Options, opts ...cbsgo.Option) (*Result, error) {
+	returns, err := LogReturns(prices)
+	if err != nil {
+		return nil, err
+	}
+	if len(returns) == 0 {
+		return nil, errors.New("cbsgo: no returns to segment")
+	}
+	for i, r := range returns {
+		returns[i].Value = math.Abs(r.Value)
+	}
+	opts = append(slices.Clone(opts), cbsgo.WithTransform(cbsgo.RankTransform))
+	return Detect(ctx, returns, o, opts...)
+}
@@ -0,0 +1,92 @@
+package timeseries_test
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/timeseries"
+)
+
+func TestLogReturns(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := func(i int) time.Time { return start.AddDate(0, 0, i) }
+	prices := []timeseries.Point{{day(0), 100}, {day(1), 110}, {day(2), math.NaN()}, {day(3), 99}}
+	got, err := timeseries.LogReturns(prices)
+	if err != nil {
+		t.Fatalf("LogReturns returned an unexpected error: %v", err)
+	}
+	expected := []timeseries.Point{{day(1), math.Log(1.1)}, {day(2), math.NaN()}, {day(3), math.Log(0.9)}}
+	if len(got) != len(expected) {
+		t.Fatalf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+	}
+	for i := range got {
+		if !got[i].Time.Equal(expected[i].Time) || math.Abs(got[i].Value-expected[i].Value) > 1e-12 && !math.IsNaN(expected[i].Value) ||
+			math.IsNaN(got[i].Value) != math.IsNaN(expected[i].Value) {
+			t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+		}
+	}
+
+	if _, err := timeseries.LogReturns([]timeseries.Point{{day(0), 100}, {day(1), 0}}); err == nil {
+		t.Error("Expected an error for a price of 0")
+	}
+}
+
+func TestScaleVolatility(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var returns []timeseries.Point
+	for i, v := range []float64{1, -1, 1, -1, 4, -4, 4} {
+		returns = append(returns, timeseries.Point{Time: start.AddDate(0, 0, i), Value: v})
+	}
+	got, err := timeseries.ScaleVolatility(returns, 4)
+	if err != nil {
+		t.Fatalf("ScaleVolatility returned an unexpected error: %v", err)
+	}
+	// The first five returns are scaled by the deviation of the first four,
+	// and the others by those of the four before them.
+	sd := math.Sqrt(4.0 / 3)
+	expected := []float64{1 / sd, -1 / sd, 1 / sd, -1 / sd, 4 / sd, -4 / math.Sqrt(16.75/3), 4 / math.Sqrt(34.0/3)}
+	for i, p := range got {
+		if math.Abs(p.Value-expected[i]) > 1e-12 {
+			t.Errorf("Unexpected return %d.\nExpected: %v\nGot: %v", i, expected[i], p.Value)
+		}
+	}
+	if _, err := timeseries.ScaleVolatility(returns, 10); err == nil {
+		t.Error("Expected an error for a window longer than the returns")
+	}
+}
+
+func TestDetectRegimes(t *testing.T) {
+	// Daily prices whose volatility quadruples after 300 days.
+	rng := rand.New(rand.NewSource(1))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	price := 100.0
+	var prices []timeseries.Point
+	for i := range 600 {
+		vol := 0.01
+		if i > 300 {
+			vol = 0.04
+		}
+		price *= math.Exp(vol * rng.NormFloat64())
+		prices = append(prices, timeseries.Point{Time: start.AddDate(0, 0, i), Value: price})
+	}
+
+	res, err := timeseries.DetectRegimes(context.Background(), prices, timeseries.Options{}, cbsgo.WithSeed(1))
+	if err != nil {
+		t.Fatalf("DetectRegimes returned an unexpected error: %v", err)
+	}
+	if len(res.Changepoints) != 1 {
+		t.Fatalf("Expected a single changepoint, got %+v", res.Changepoints)
+	}
+	c := res.Changepoints[0]
+	if d := c.Time.Sub(start.AddDate(0, 0, 301)).Abs(); d > 5*24*time.Hour {
+		t.Errorf("Unexpected changepoint at %v", c.Time)
+	}
+	// Mean absolute returns are about 0.8 times the volatility.
+	if math.Abs(c.From-0.008) > 0.002 || math.Abs(c.To-0.032) > 0.006 {
+		t.Errorf("Unexpected levels %v and %v", c.From, c.To)
+	}
+}
@@ -0,0 +1,109 @@
+package timeseries
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+// Deseasonalize returns a copy of the points, which must be in order of
+// time, with the seasonal pattern of the given period removed, such as the
+// daily or weekly cycle of a metric, so that it is not taken for changes of
+// level. The series must span at least two periods.
+//
+// The pattern is estimated robustly, in the manner of STL: the local level
+// of every sample is the median of the samples within half a period on
+// either side, and the seasonal component of a phase of the period, taken in
+// slots of the typical sampling interval, the median difference of the
+// samples of that phase from their local level. Changes of level hardly
+// affect these medians, and are kept in the returned series. Phases are
+// measured from the Unix epoch, so that daily periods align with days in
+// UTC. NaN values are kept and do not count.
+func Deseasonalize(points []Point, period time.Duration) ([]Point, error) {
+	if period <= 0 {
+		return nil, fmt.Errorf("cbsgo: invalid period %v", period)
+	}
+	if err := checkOrder(points); err != nil {
+		return nil, err
+	}
+	res := slices.Clone(points)
+	var valid []Point
+	for _, p := range points {
+		if !math.IsNaN(p.Value) {
+			valid = append(valid, p)
+		}
+	}
+	if len(valid) < 2 || valid[len(valid)-1].Time.Sub(valid[0].Time) < 2*period {
+		return nil, fmt.Errorf("cbsgo: the series spans less than two periods of %v", period)
+	}
+	slot := min(typicalInterval(valid), period)
+	slots := int((period + slot - 1) / slot)
+	phase := func(t time.Time) int {
+		return int((time.Duration(t.UnixNano())%period + period) % period / slot)
+	}
+
+	// The differences from the local level, by phase.
+	deviations := make([][]float64, slots)
+	window := make([]float64, 0, len(valid))
+	lo, hi := 0, 0
+	for _, p := range valid {
+		for valid[lo].Time.Before(p.Time.Add(-period / 2)) {
+			lo++
+		}
+		for hi < len(valid) && !valid[hi].Time.After(p.Time.Add(period/2)) {
+			hi++
+		}
+		window = window[:0]
+		for _, q := range valid[lo:hi] {
+			window = append(window, q.Value)
+		}
+		k := phase(p.Time)
+		deviations[k] = append(deviations[k], p.Value-median(window))
+	}
+	seasonal := make([]float64, slots)
+	for k, d := range deviations {
+		if len(d) > 0 {
+			seasonal[k] = median(d)
+		}
+	}
+	for i, p := range res {
+		res[i].Value = p.Value - seasonal[phase(p.Time)]
+	}
+	return res, nil
+}
+
+// checkOrder returns an error if the points are not in strictly increasing
+// order of time.
+func checkOrder(points []Point) error {
+	for i := 1; i < len(points); i++ {
+		if !points[i].Time.After(points[i-1].Time) {
+			return fmt.Errorf("cbsgo: sample %d at %v does not follow sample %d at %v", i, points[i].Time, i-1, points[i-1].Time)
+		}
+	}
+	return nil
+}
+
+// typicalInterval returns the median interval between the points, or a
+// second for a single point.
+func typicalInterval(points []Point) time.Duration {
+	if len(points) < 2 {
+		return time.Second
+	}
+	intervals := make([]time.Duration, len(points)-1)
+	for i := range intervals {
+		intervals[i] = points[i+1].Time.Sub(points[i].Time)
+	}
+	slices.Sort(intervals)
+	return intervals[len(intervals)/2]
+}
+
+// median returns the median of x, which it sorts.
+func median(x []float64) float64 {
+	slices.Sort(x)
+	n := len(x)
+	if n%2 == 1 {
+		return x[n/2]
+	}
+	return (x[n/2-1] + x[n/2]) / 2
+}
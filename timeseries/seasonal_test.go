@@ -0,0 +1,84 @@
+package timeseries_test
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/timeseries"
+)
+
+// daily returns hourly samples over two weeks of a level following a daily
+// cycle of amplitude 10, which steps up by 5 at the change time.
+func daily(start, change time.Time) []timeseries.Point {
+	rng := rand.New(rand.NewSource(3))
+	var points []timeseries.Point
+	for t := start; t.Before(start.Add(14 * 24 * time.Hour)); t = t.Add(time.Hour) {
+		v := 50 + 10*math.Sin(2*math.Pi*float64(t.Hour())/24) + rng.NormFloat64()
+		if !t.Before(change) {
+			v += 5
+		}
+		points = append(points, timeseries.Point{Time: t, Value: v})
+	}
+	return points
+}
+
+func TestDeseasonalize(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	change := start.Add(10 * 24 * time.Hour)
+	points := daily(start, change)
+	points[5].Value = math.NaN()
+
+	res, err := timeseries.Deseasonalize(points, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Deseasonalize returned an unexpected error: %v", err)
+	}
+	if !math.IsNaN(res[5].Value) {
+		t.Errorf("Expected NaN, got %v", res[5].Value)
+	}
+	// The cycle is gone but the step is kept.
+	for i, p := range res {
+		expected := 50.0
+		if !p.Time.Before(change) {
+			expected = 55
+		}
+		if i != 5 && (!p.Time.Equal(points[i].Time) || math.Abs(p.Value-expected) > 4) {
+			t.Errorf("Unexpected sample %d: %+v", i, p)
+		}
+	}
+
+	for _, points := range [][]timeseries.Point{points[:47], {points[1], points[0]}} {
+		if _, err := timeseries.Deseasonalize(points, 24*time.Hour); err == nil {
+			t.Errorf("Expected an error for %d points", len(points))
+		}
+	}
+}
+
+func TestDetectSeasons(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	change := start.Add(10 * 24 * time.Hour)
+	points := daily(start, change)
+
+	res, err := timeseries.Detect(context.Background(), points, timeseries.Options{}, cbsgo.WithSeed(1))
+	if err != nil {
+		t.Fatalf("Detect returned an unexpected error: %v", err)
+	}
+	if len(res.Changepoints) < 2 {
+		t.Errorf("Expected the cycle to give spurious changepoints, got %+v", res.Changepoints)
+	}
+
+	res, err = timeseries.Detect(context.Background(), points, timeseries.Options{Seasons: []time.Duration{24 * time.Hour}}, cbsgo.WithSeed(1))
+	if err != nil {
+		t.Fatalf("Detect returned an unexpected error: %v", err)
+	}
+	if len(res.Changepoints) != 1 {
+		t.Fatalf("Expected a single changepoint, got %+v", res.Changepoints)
+	}
+	// Changes may be placed a sample early.
+	if c := res.Changepoints[0]; c.Time.Sub(change).Abs() > time.Hour || math.Abs(c.To-c.From-5) > 1 {
+		t.Errorf("Unexpected changepoint %+v for a change at %v", c, change)
+	}
+}
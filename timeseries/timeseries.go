@@ -0,0 +1,171 @@
+// Package timeseries detects changes in the level of time series, such as
+// the latency or error rate of a service, with cbsgo.
+//
+// The samples of a series may be irregularly spaced. Every sample holds its
+// value until the next one, up to the typical sampling interval, so that
+// segment means are weighted by time, and a change across a gap in the
+// series, such as during an outage of the collector, needs stronger evidence
+// the longer the gap is.
+//
+// Seasonal patterns can be removed before segmentation, and price series
+// turned into returns, to detect regime changes of markets.
+package timeseries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// Point is a sample of a series.
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Segment is a period of the series at a stable level.
+type Segment struct {
+	// Start and End are the times of the first and last sample of the
+	// segment.
+	Start, End time.Time
+	// Samples is the number of samples of the segment.
+	Samples int
+
+	Mean float64
+	SD   float64
+}
+
+// Changepoint is a change of the level of the series.
+type Changepoint struct {
+	// Time is the time of the first sample at the new level, and Previous
+	// that of the last sample at the old level; the change happened in
+	// between.
+	Time, Previous time.Time
+	// From and To are the levels before and after the change.
+	From, To float64
+}
+
+// Result is the segmentation of a series.
+type Result struct {
+	Segments     []Segment
+	Changepoints []Changepoint
+}
+
+// Options configures Detect.
+type Options struct {
+	// GapScale is the scale of the penalty of changes across gaps, with
+	// the meaning of the scale of cbsgo.WithGapPenalty: a change across a
+	// gap of GapScale beyond the typical sampling interval needs about
+	// twice the evidence. 0 selects 10 typical sampling intervals.
+	GapScale time.Duration
+
+	// Seasons lists the periods of the seasonal patterns removed with
+	// Deseasonalize before segmentation, such as 24 * time.Hour and
+	// 7 * 24 * time.Hour for the daily and weekly cycles of a metric, in
+	// that order. The levels of the result are those of the deseasonalized
+	// series.
+	Seasons []time.Duration
+}
+
+// Detect segments the series of points, which must be in order of time, with
+// cbsgo.RunContext configured by opts. Samples with a NaN value are left out,
+// leaving a gap.
+//
+// The typical sampling interval is the median interval between samples.
+// Changes are reported at the times of the samples, and their significance
+// level and the other settings of the segmentation are set by opts, except
+// for positions and weights.
+func Detect(ctx context.Context, points []Point, o Options, opts ...cbsgo.Option) (*Result, error) {
+	points = slices.DeleteFunc(slices.Clone(points), func(p Point) bool { return math.IsNaN(p.Value) })
+	if len(points) == 0 {
+		return nil, errors.New("cbsgo: no samples to segment")
+	}
+	if o.GapScale < 0 {
+		return nil, fmt.Errorf("cbsgo: negative gap scale %v", o.GapScale)
+	}
+	if err := checkOrder(points); err != nil {
+		return nil, err
+	}
+	for _, period := range o.Seasons {
+		var err error
+		if points, err = Deseasonalize(points, period); err != nil {
+			return nil, err
+		}
+	}
+	typical := typicalInterval(points)
+	scale := o.GapScale
+	if scale == 0 {
+		scale = 10 * typical
+	}
+
+	// Positions are in units from the first sample.
+	shortest := typical
+	for i := 1; i < len(points); i++ {
+		shortest = min(shortest, points[i].Time.Sub(points[i-1].Time))
+	}
+	unit, err := positionUnit(points[len(points)-1].Time.Sub(points[0].Time)+typical, shortest, math.MaxInt)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]float64, len(points))
+	starts := make([]int, len(points))
+	ends := make([]int, len(points))
+	for i, p := range points {
+		values[i] = p.Value
+		starts[i] = int(p.Time.Sub(points[0].Time) / unit)
+		width := typical
+		if i+1 < len(points) {
+			width = min(width, points[i+1].Time.Sub(p.Time))
+		}
+		ends[i] = starts[i] + int(width/unit)
+	}
+	opts = append(slices.Clone(opts), cbsgo.WithPositions(starts, ends), cbsgo.WithGapPenalty(float64(scale)/float64(unit)))
+	res, err := cbsgo.RunContext(ctx, values, opts...)
+	if res == nil {
+		return nil, err
+	}
+
+	out := &Result{Segments: make([]Segment, len(res.Segments))}
+	for i, s := range res.Segments {
+		out.Segments[i] = Segment{
+			Start:   points[s.BinStart].Time,
+			End:     points[s.BinEnd-1].Time,
+			Samples: s.BinEnd - s.BinStart,
+			Mean:    s.Mean,
+			SD:      s.SD,
+		}
+		if i > 0 {
+			prev := out.Segments[i-1]
+			out.Changepoints = append(out.Changepoints, Changepoint{
+				Time:     out.Segments[i].Start,
+				Previous: prev.End,
+				From:     prev.Mean,
+				To:       s.Mean,
+			})
+		}
+	}
+	return out, err
+}
+
+// positionUnit returns the finest unit of nanoseconds, microseconds,
+// milliseconds and seconds in which span is at most maxInt, the largest int,
+// so that positions do not overflow where int has 32 bits. It returns an
+// error if the unit is coarser than the shortest interval between samples,
+// which would get the same position.
+func positionUnit(span, shortest time.Duration, maxInt int64) (time.Duration, error) {
+	for _, unit := range []time.Duration{time.Nanosecond, time.Microsecond, time.Millisecond, time.Second} {
+		if int64(span/unit) > maxInt {
+			continue
+		}
+		if shortest < unit {
+			return 0, fmt.Errorf("cbsgo: series of %v with samples %v apart are too long to segment", span, shortest)
+		}
+		return unit, nil
+	}
+	return 0, fmt.Errorf("cbsgo: series of %v are too long to segment", span)
+}
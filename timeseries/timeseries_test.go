@@ -0,0 +1,117 @@
+package timeseries_test
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/mattdsm/cbsgo"
+	"github.com/mattdsm/cbsgo/timeseries"
+)
+
+// series returns samples every 10 s, jittered by up to 2 s, of a level that
+// steps from 100 to 130 at the change time.
+func series(rng *rand.Rand, start, change time.Time, n int) []timeseries.Point {
+	points := make([]timeseries.Point, n)
+	for i := range points {
+		t := start.Add(time.Duration(i)*10*time.Second + time.Duration(rng.Intn(2000))*time.Millisecond)
+		v := 100 + 5*rng.NormFloat64()
+		if !t.Before(change) {
+			v += 30
+		}
+		points[i] = timeseries.Point{Time: t, Value: v}
+	}
+	return points
+}
+
+func TestDetect(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	change := start.Add(30 * time.Minute)
+	points := series(rng, start, change, 360)
+	points[100].Value = math.NaN()
+
+	res, err := timeseries.Detect(context.Background(), points, timeseries.Options{}, cbsgo.WithSeed(1))
+	if err != nil {
+		t.Fatalf("Detect returned an unexpected error: %v", err)
+	}
+	if len(res.Changepoints) != 1 || len(res.Segments) != 2 {
+		t.Fatalf("Expected a single changepoint, got %+v", res)
+	}
+	c := res.Changepoints[0]
+	// Changes may be placed a sample early.
+	if c.Time.Sub(change) > 20*time.Second || change.Sub(c.Time) > 20*time.Second || !c.Previous.Before(c.Time) {
+		t.Errorf("Unexpected changepoint between %v and %v for a change at %v", c.Previous, c.Time, change)
+	}
+	if math.Abs(c.From-100) > 2 || math.Abs(c.To-130) > 2 {
+		t.Errorf("Unexpected levels %v and %v", c.From, c.To)
+	}
+	if s := res.Segments[0]; !s.Start.Equal(points[0].Time) || (s.Samples != 178 && s.Samples != 179) {
+		t.Errorf("Unexpected first segment %+v", s)
+	}
+}
+
+func TestDetectGap(t *testing.T) {
+	// The level steps up across a gap of an hour.
+	rng := rand.New(rand.NewSource(2))
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var points []timeseries.Point
+	for i := range 200 {
+		t := start.Add(time.Duration(i) * 10 * time.Second)
+		v := 100 + 5*rng.NormFloat64()
+		if i >= 100 {
+			t = t.Add(time.Hour)
+			v += 10
+		}
+		points = append(points, timeseries.Point{Time: t, Value: v})
+	}
+	res, err := timeseries.Detect(context.Background(), points, timeseries.Options{}, cbsgo.WithSeed(1))
+	if err != nil {
+		t.Fatalf("Detect returned an unexpected error: %v", err)
+	}
+	if len(res.Changepoints) != 1 {
+		t.Fatalf("Expected a single changepoint, got %+v", res.Changepoints)
+	}
+	// Changes may be placed a sample early.
+	if c := res.Changepoints[0]; c.Time.Before(points[99].Time) || c.Time.After(points[100].Time) {
+		t.Errorf("Unexpected changepoint between %v and %v for a change at %v", c.Previous, c.Time, points[100].Time)
+	}
+}
+
+func TestDetectInvalid(t *testing.T) {
+	now := time.Now()
+	for name, points := range map[string][]timeseries.Point{
+		"empty":     nil,
+		"unordered": {{Time: now, Value: 1}, {Time: now.Add(-time.Second), Value: 2}},
+		"duplicate": {{Time: now, Value: 1}, {Time: now, Value: 2}},
+	} {
+		if _, err := timeseries.Detect(context.Background(), points, timeseries.Options{}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestPositionUnit(t *testing.T) {
+	const maxInt32 = 1<<31 - 1
+	day := 24 * time.Hour
+	for _, tt := range []struct {
+		span, shortest time.Duration
+		maxInt         int64
+		expected       time.Duration
+	}{
+		{day, time.Minute, math.MaxInt64, time.Nanosecond},
+		{time.Second, time.Millisecond, maxInt32, time.Nanosecond},
+		{day, time.Minute, maxInt32, time.Millisecond},
+		{365 * day, time.Hour, maxInt32, time.Second},
+		// Samples closer than the unit, and spans too long for seconds.
+		{day, time.Microsecond, maxInt32, 0},
+		{100 * 365 * day, time.Hour, maxInt32, 0},
+	} {
+		got, err := timeseries.PositionUnit(tt.span, tt.shortest, tt.maxInt)
+		if got != tt.expected || (err != nil) != (tt.expected == 0) {
+			t.Errorf("%v, %v: unexpected result.\nExpected: %v\nGot: %v, %v", tt.span, tt.shortest, tt.expected, got, err)
+		}
+	}
+}
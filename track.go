@@ -0,0 +1,248 @@
+package cbsgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Track holds the values measured along one chromosome, such as the log2
+// ratios of a sample. Readers produce tracks, normalizers transform them, and
+// Segment segments them.
+type Track struct {
+	Chrom string
+
+	// Starts and Ends hold the genomic extent [Starts[i], Ends[i]) of every
+	// bin, or are nil when the positions are unknown.
+	Starts []int
+	Ends   []int
+
+	Values []float64
+
+	// Weights holds optional bin weights; see WithWeights.
+	Weights []float64
+
+	// Mask optionally marks bins to leave out, such as blacklisted regions
+	// or bins without coverage.
+	Mask []bool
+}
+
+// Len returns the number of bins of the track.
+func (t *Track) Len() int {
+	return len(t.Values)
+}
+
+// Validate checks that the fields of the track are consistent.
+func (t *Track) Validate() error {
+	n := len(t.Values)
+	if (t.Starts == nil) != (t.Ends == nil) {
+		return errors.New("cbsgo: track has starts without ends or ends without starts")
+	}
+	for _, f := range []struct {
+		name string
+		len  int
+	}{{"starts", len(t.Starts)}, {"ends", len(t.Ends)}, {"weights", len(t.Weights)}, {"mask", len(t.Mask)}} {
+		if f.len != 0 && f.len != n {
+			return fmt.Errorf("cbsgo: track %s has %d %s for %d values", t.Chrom, f.len, f.name, n)
+		}
+	}
+	return nil
+}
+
+// Compact returns a copy of the track without the masked bins. Its fields
+// are shared with t when no bin is masked.
+func (t *Track) Compact() *Track {
+	res, _ := t.compact()
+	return res
+}
+
+// compact returns the track without the masked bins together with the
+// original indices of the remaining bins, nil when no bin is masked.
+func (t *Track) compact() (*Track, []int) {
+	masked := false
+	for _, m := range t.Mask {
+		masked = masked || m
+	}
+	if !masked {
+		res := *t
+		res.Mask = nil
+		return &res, nil
+	}
+
+	res := &Track{Chrom: t.Chrom}
+	var idx []int
+	res.Values, idx = compact(t.Values, func(i int) bool { return t.Mask[i] })
+	res.Starts = gather(t.Starts, idx)
+	res.Ends = gather(t.Ends, idx)
+	res.Weights = gather(t.Weights, idx)
+	return res, idx
+}
+
+// gather returns the elements of s at idx, or nil if s is nil.
+func gather[T any](s []T, idx []int) []T {
+	if s == nil {
+		return nil
+	}
+	res := make([]T, len(idx))
+	for i, j := range idx {
+		res[i] = s[j]
+	}
+	return res
+}
+
+// slice returns the bins [lo, hi) of the track, sharing its fields.
+func (t *Track) slice(lo, hi int) *Track {
+	res := &Track{Chrom: t.Chrom, Values: t.Values[lo:hi]}
+	if t.Starts != nil {
+		res.Starts, res.Ends = t.Starts[lo:hi], t.Ends[lo:hi]
+	}
+	if t.Weights != nil {
+		res.Weights = t.Weights[lo:hi]
+	}
+	if t.Mask != nil {
+		res.Mask = t.Mask[lo:hi]
+	}
+	return res
+}
+
+// concat returns the bins of t followed by those of u, which must have the
+// same fields set.
+func (t *Track) concat(u *Track) *Track {
+	join := func(a, b []float64) []float64 {
+		if a == nil && b == nil {
+			return nil
+		}
+		return append(append([]float64{}, a...), b...)
+	}
+	res := &Track{Chrom: t.Chrom, Values: join(t.Values, u.Values), Weights: join(t.Weights, u.Weights)}
+	if t.Starts != nil {
+		res.Starts = append(append([]int{}, t.Starts...), u.Starts...)
+		res.Ends = append(append([]int{}, t.Ends...), u.Ends...)
+	}
+	if t.Mask != nil || u.Mask != nil {
+		res.Mask = make([]bool, res.Len())
+		copy(res.Mask, t.Mask)
+		copy(res.Mask[t.Len():], u.Mask)
+	}
+	return res
+}
+
+// Options returns the options describing the bin positions and weights of
+// the track, to be passed to Run together with its values.
+func (t *Track) Options() []Option {
+	var opts []Option
+	if t.Starts != nil {
+		opts = append(opts, WithPositions(t.Starts, t.Ends))
+	}
+	if t.Weights != nil {
+		opts = append(opts, WithWeights(t.Weights))
+	}
+	return opts
+}
+
+// Segment segments the track with Run, configured by opts on top of the
+// positions and weights of the track. Masked bins are left out, so that runs
+// of masked bins between two segments are covered by neither. The segments
+// are labeled with the chromosome of the track, and their bin indices, like
+// those of the breakpoints, refer to the bins of the track.
+func (t *Track) Segment(opts ...Option) (*Result, error) {
+	return t.SegmentContext(context.Background(), opts...)
+}
+
+// SegmentContext is like Segment, but stops early when ctx is done, like
+// RunContext.
+func (t *Track) SegmentContext(ctx context.Context, opts ...Option) (*Result, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	c, idx := t.compact()
+	if c.Len() == 0 {
+		return &Result{}, nil
+	}
+	// Segments streamed to a writer, and alerts, are relabeled on their way out.
+	relabel := func(c *config) {
+		c.chrom = t.Chrom
+		if write := c.writer; write != nil {
+			c.writer = func(s Segment) error {
+				t.relabel(&s, idx)
+				return write(s)
+			}
+		}
+		if alert := c.alert; alert != nil {
+			c.alert = func(a Alert) {
+				a.Chrom = t.Chrom
+				if idx != nil {
+					a.Bin = idx[a.Bin]
+					if t.Starts == nil {
+						a.Position = a.Bin
+					}
+				}
+				alert(a)
+			}
+		}
+	}
+	res, err := RunContext(ctx, c.Values, append(append(c.Options(), opts...), relabel)...)
+	if res == nil {
+		return nil, err
+	}
+
+	for i := range res.Segments {
+		t.relabel(&res.Segments[i], idx)
+	}
+	for i := range res.Explain {
+		t.relabelCandidate(&res.Explain[i], idx)
+	}
+	for i := range res.Breakpoints {
+		b := &res.Breakpoints[i]
+		b.Chrom = t.Chrom
+		if idx != nil {
+			b.Bin = idx[b.Bin]
+			if t.Starts == nil {
+				b.Position, b.Lower, b.Upper = b.Bin, idx[b.Lower], idx[b.Upper]
+			}
+		}
+	}
+	return res, err
+}
+
+// relabel labels a segment of the bins idx of t with the chromosome of t
+// and the indices of the bins in t.
+func (t *Track) relabel(s *Segment, idx []int) {
+	s.Chrom = t.Chrom
+	if s.BestSplit != nil {
+		t.relabelCandidate(s.BestSplit, idx)
+	}
+	for i := range s.Subsegments {
+		t.relabel(&s.Subsegments[i], idx)
+	}
+	for i := range s.Focal {
+		f := &s.Focal[i]
+		f.Chrom = t.Chrom
+		if idx != nil {
+			f.BinStart, f.BinEnd = idx[f.BinStart], idx[f.BinEnd-1]+1
+			if t.Starts == nil {
+				f.Start, f.End = f.BinStart, f.BinEnd
+			}
+		}
+	}
+	if idx != nil {
+		s.BinStart, s.BinEnd = idx[s.BinStart], idx[s.BinEnd-1]+1
+		if t.Starts == nil {
+			s.Start, s.End = s.BinStart, s.BinEnd
+		}
+	}
+}
+
+// relabelCandidate labels a candidate split of the bins idx of t like
+// relabel.
+func (t *Track) relabelCandidate(c *Candidate, idx []int) {
+	c.Chrom = t.Chrom
+	if idx != nil {
+		c.Start, c.End = idx[c.Start], idx[c.End-1]+1
+		if c.SplitEnd > c.SplitStart {
+			c.SplitStart, c.SplitEnd = idx[c.SplitStart], idx[c.SplitEnd-1]+1
+		} else {
+			c.SplitStart, c.SplitEnd = c.Start, c.Start
+		}
+	}
+}
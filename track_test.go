@@ -0,0 +1,85 @@
+package cbsgo_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestTrackSegment(t *testing.T) {
+	steps := []float64{1, 1, 1, 3, 3, 2, 1, 2, 3, 300, 310, 321, 310, 299}
+	expected, err := cbsgo.CBS(steps, 1000, 0.05, 42)
+	if err != nil {
+		t.Fatalf("CBS function returned an unexpected error: %v", err)
+	}
+
+	// Masked outliers are left out of the segmentation.
+	values := append([]float64{}, steps[:4]...)
+	values = append(values, 1000, -1000)
+	values = append(values, steps[4:]...)
+	mask := make([]bool, len(values))
+	mask[4], mask[5] = true, true
+
+	track := &cbsgo.Track{Chrom: "chr1", Values: values, Mask: mask}
+	res, err := track.Segment(cbsgo.WithShuffles(1000), cbsgo.WithAlpha(0.05), cbsgo.WithSeed(42))
+	if err != nil {
+		t.Fatalf("Segment returned an unexpected error: %v", err)
+	}
+	if len(res.Segments) != len(expected) {
+		t.Fatalf("Unexpected result.\nExpected: %v\nGot: %v", expected, res.Segments)
+	}
+	for i, s := range res.Segments {
+		want := expected[i]
+		for j, b := range want {
+			if b > 4 {
+				want[j] = b + 2
+			}
+		}
+		if s.Chrom != "chr1" || s.BinStart != want[0] || s.BinEnd != want[1] || s.Start != s.BinStart || s.End != s.BinEnd {
+			t.Errorf("Segment %d: unexpected segment %+v, expected bins %v", i, s, want)
+		}
+	}
+}
+
+func TestTrackWeights(t *testing.T) {
+	x := []float64{1, 1, 1, 3, 3, 2, 1, 2, 3, 300, 310, 321, 310, 299}
+	starts := make([]int, len(x))
+	ends := make([]int, len(x))
+	weights := make([]float64, len(x))
+	for i := range x {
+		starts[i], ends[i] = 100*i, 100*i+10*(i%3+1)
+		weights[i] = 1
+	}
+
+	// Unit weights override the bin widths, as if the bins had equal width.
+	track := &cbsgo.Track{Chrom: "chr2", Starts: starts, Ends: ends, Values: x, Weights: weights}
+	res, err := track.Segment(cbsgo.WithSeed(42))
+	if err != nil {
+		t.Fatalf("Segment returned an unexpected error: %v", err)
+	}
+	plain, err := cbsgo.Run(x, cbsgo.WithSeed(42))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	for i := range plain.Segments {
+		p := &plain.Segments[i]
+		p.Chrom, p.Start, p.End = "chr2", starts[p.BinStart], ends[p.BinEnd-1]
+	}
+	if !reflect.DeepEqual(res.Segments, plain.Segments) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", plain.Segments, res.Segments)
+	}
+}
+
+func TestTrackValidate(t *testing.T) {
+	for name, track := range map[string]*cbsgo.Track{
+		"starts only":  {Values: []float64{1, 2}, Starts: []int{0, 10}},
+		"short ends":   {Values: []float64{1, 2}, Starts: []int{0, 10}, Ends: []int{10}},
+		"long mask":    {Values: []float64{1, 2}, Mask: []bool{false, false, true}},
+		"zero weights": {Values: []float64{1, 2}, Weights: []float64{1, 0}},
+	} {
+		if _, err := track.Segment(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
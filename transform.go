@@ -0,0 +1,96 @@
+package cbsgo
+
+import (
+	"fmt"
+	"sort"
+
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// Transform is a monotone transform of the values applied before
+// segmentation. See WithTransform.
+type Transform int
+
+const (
+	// NoTransform segments the values as they are.
+	NoTransform Transform = iota
+	// RankTransform replaces every value by its rank, scaled to (0, 1) as
+	// (rank - 0.5) / n, with tied values sharing their average rank.
+	RankTransform
+	// NormalScores replaces every value by the standard normal quantile of
+	// its scaled rank, a quantile normalization to the normal distribution
+	// also known as the rank-based inverse normal transform.
+	NormalScores
+)
+
+// String returns the name of the transform.
+func (t Transform) String() string {
+	switch t {
+	case RankTransform:
+		return "rank"
+	case NormalScores:
+		return "normal-scores"
+	}
+	return "none"
+}
+
+// ParseTransform returns the transform named "none", "rank" or
+// "normal-scores".
+func ParseTransform(name string) (Transform, error) {
+	for _, t := range []Transform{NoTransform, RankTransform, NormalScores} {
+		if name == t.String() {
+			return t, nil
+		}
+	}
+	return NoTransform, fmt.Errorf("cbsgo: unknown transform %q", name)
+}
+
+// Apply returns the transformed values of x. NaN values are kept, and do not
+// count in the ranks of the others.
+func (t Transform) Apply(x []float64) []float64 {
+	res := make([]float64, len(x))
+	copy(res, x)
+	if t == NoTransform {
+		return res
+	}
+	var idx []int
+	for i, v := range x {
+		if v == v {
+			idx = append(idx, i)
+		}
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return x[idx[a]] < x[idx[b]] })
+	n := float64(len(idx))
+	for i := 0; i < len(idx); {
+		j := i + 1
+		for j < len(idx) && x[idx[j]] == x[idx[i]] {
+			j++
+		}
+		// Ties share the average of the ranks i+1 to j.
+		u := (float64(i+j+1)/2 - 0.5) / n
+		if t == NormalScores {
+			u = distuv.UnitNormal.Quantile(u)
+		}
+		for _, k := range idx[i:j] {
+			res[k] = u
+		}
+		i = j
+	}
+	return res
+}
+
+// WithTransform segments the values transformed by t, such as ranks or
+// normal scores, which makes the test statistic, which assumes Gaussian
+// noise, robust to heavy-tailed data such as latencies or financial returns:
+// a few extreme values then no longer form segments of their own or mask the
+// changes of the others.
+//
+// Segments are summarized by the means and standard deviations of the
+// original values, like the changes in mean of their breakpoints and their
+// goodness of fit, while WithUndoSD and the focal and hierarchical passes
+// work on the transformed values. RunJoint does not support transforms.
+func WithTransform(t Transform) Option {
+	return func(c *config) {
+		c.transform = t
+	}
+}
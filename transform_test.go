@@ -0,0 +1,83 @@
+package cbsgo_test
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+	"gonum.org/v1/gonum/stat"
+)
+
+func TestTransformApply(t *testing.T) {
+	x := []float64{3, math.NaN(), 1, 3, 2}
+	got := cbsgo.RankTransform.Apply(x)
+	expected := []float64{0.75, math.NaN(), 0.125, 0.75, 0.375}
+	for i := range x {
+		if got[i] != expected[i] && !(math.IsNaN(got[i]) && math.IsNaN(expected[i])) {
+			t.Fatalf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+		}
+	}
+
+	got = cbsgo.NormalScores.Apply([]float64{10, -5, 1000})
+	if got[1] != -got[2] || got[0] != 0 || !(got[2] > 0.9 && got[2] < 1) {
+		t.Errorf("Unexpected normal scores %v", got)
+	}
+	if got := cbsgo.NoTransform.Apply(x[2:]); !reflect.DeepEqual(got, x[2:]) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", x[2:], got)
+	}
+}
+
+func TestParseTransform(t *testing.T) {
+	for _, tr := range []cbsgo.Transform{cbsgo.NoTransform, cbsgo.RankTransform, cbsgo.NormalScores} {
+		if got, err := cbsgo.ParseTransform(tr.String()); err != nil || got != tr {
+			t.Errorf("Unexpected result %v, %v for %v", got, err, tr)
+		}
+	}
+	if _, err := cbsgo.ParseTransform("log"); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestWithTransform(t *testing.T) {
+	// Cauchy noise, with a level shift halfway.
+	rng := rand.New(rand.NewSource(1))
+	x := make([]float64, 400)
+	for i := range x {
+		x[i] = math.Tan(math.Pi * (rng.Float64() - 0.5))
+		if i >= 200 {
+			x[i] += 5
+		}
+	}
+	res, err := cbsgo.Run(x, cbsgo.WithSeed(1))
+	if err != nil {
+		t.Fatalf("Run returned an unexpected error: %v", err)
+	}
+	if len(res.Segments) == 2 {
+		t.Errorf("Expected the outliers to mask the shift or form segments, got %v", res.Segments)
+	}
+
+	for _, tr := range []cbsgo.Transform{cbsgo.RankTransform, cbsgo.NormalScores} {
+		res, err := cbsgo.Run(x, cbsgo.WithSeed(1), cbsgo.WithTransform(tr), cbsgo.WithFit())
+		if err != nil {
+			t.Fatalf("%v: Run returned an unexpected error: %v", tr, err)
+		}
+		if len(res.Segments) != 2 || abs(res.Segments[1].BinStart-200) > 3 {
+			t.Fatalf("%v: expected a split near 200, got %v", tr, res.Segments)
+		}
+		// Segments are summarized by the original values.
+		for _, s := range res.Segments {
+			if mean := stat.Mean(x[s.BinStart:s.BinEnd], nil); math.Abs(s.Mean-mean) > 1e-9 {
+				t.Errorf("%v: unexpected mean %v of segment %v, expected %v", tr, s.Mean, s, mean)
+			}
+		}
+	}
+
+	if _, err := cbsgo.RunJoint([][]float64{x, x}, cbsgo.WithTransform(cbsgo.RankTransform)); err == nil {
+		t.Error("Expected an error for a joint segmentation")
+	}
+	if _, err := cbsgo.Run(x, cbsgo.WithTransform(cbsgo.Transform(5))); err == nil {
+		t.Error("Expected an error for an invalid transform")
+	}
+}
@@ -0,0 +1,167 @@
+package cbsgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// Inheritance is the origin of a copy number change of a child.
+type Inheritance int
+
+const (
+	// NotAltered is reported for segments of the child without a copy
+	// number change.
+	NotAltered Inheritance = iota
+	// Maternal, Paternal and Biparental are reported for changes carried by
+	// the mother, the father or both.
+	Maternal
+	Paternal
+	Biparental
+	// DeNovo is reported for changes carried by neither parent, whose
+	// values differ significantly from those of the child.
+	DeNovo
+	// Ambiguous is reported for changes carried by neither parent, but
+	// whose values do not differ significantly from those of the child in
+	// both parents, such as short changes.
+	Ambiguous
+)
+
+// String returns a short name of the inheritance.
+func (i Inheritance) String() string {
+	switch i {
+	case NotAltered:
+		return "not-altered"
+	case Maternal:
+		return "maternal"
+	case Paternal:
+		return "paternal"
+	case Biparental:
+		return "biparental"
+	case DeNovo:
+		return "de-novo"
+	case Ambiguous:
+		return "ambiguous"
+	}
+	return "unknown"
+}
+
+// TrioOptions configures SegmentTrio.
+type TrioOptions struct {
+	// Threshold is the smallest absolute segment mean of a copy number
+	// change, in the child and in a parent carrying it.
+	Threshold float64
+	// Alpha is the significance level of the tests of the differences
+	// between the child and its parents.
+	Alpha float64
+}
+
+// DefaultTrioOptions returns options suited to log2 ratios: changes of at
+// least 0.2 and a significance level of 1%.
+func DefaultTrioOptions() TrioOptions {
+	return TrioOptions{Threshold: 0.2, Alpha: 0.01}
+}
+
+// TrioSegment is a segment of a child with the values of its parents on the
+// bins of the segment.
+type TrioSegment struct {
+	Segment
+
+	// Mother and Father are the means of the parents.
+	Mother, Father float64
+	// MotherP and FatherP are the p-values of Welch's t-test of the
+	// difference between the means of the child and of the parents, NaN
+	// when the segment has fewer than two bins.
+	MotherP, FatherP float64
+
+	Inheritance Inheritance
+}
+
+// SegmentTrio segments the track of a child with Track.Segment, configured
+// by opts, and classifies the inheritance of the change of every segment by
+// comparing the child to its parents, whose tracks must have the same bins.
+//
+// A segment whose absolute mean is at least t.Threshold is altered, and a
+// parent carries its change when the mean of the parent on the bins of the
+// segment is beyond t.Threshold in the same direction. A change carried by
+// neither parent is de novo when both parents differ significantly from the
+// child at level t.Alpha, and ambiguous otherwise. Bins masked in any of the
+// tracks are left out of the comparison.
+func SegmentTrio(ctx context.Context, child, mother, father *Track, t TrioOptions, opts ...Option) ([]TrioSegment, error) {
+	if child.Len() != mother.Len() || child.Len() != father.Len() {
+		return nil, fmt.Errorf("cbsgo: trio tracks have %d, %d and %d bins", child.Len(), mother.Len(), father.Len())
+	}
+	if !(t.Threshold > 0) || !(t.Alpha > 0 && t.Alpha < 1) {
+		return nil, errors.New("cbsgo: trio threshold must be positive and alpha in (0, 1)")
+	}
+	res, err := child.SegmentContext(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	masked := func(i int) bool {
+		for _, tr := range [3]*Track{child, mother, father} {
+			if tr.Mask != nil && tr.Mask[i] || math.IsNaN(tr.Values[i]) {
+				return true
+			}
+		}
+		return false
+	}
+	trio := make([]TrioSegment, len(res.Segments))
+	for k, s := range res.Segments {
+		var x, m, f []float64
+		for i := s.BinStart; i < s.BinEnd; i++ {
+			if !masked(i) {
+				x, m, f = append(x, child.Values[i]), append(m, mother.Values[i]), append(f, father.Values[i])
+			}
+		}
+		ts := TrioSegment{Segment: s, Mother: stat.Mean(m, nil), Father: stat.Mean(f, nil), MotherP: welch(x, m), FatherP: welch(x, f)}
+		ts.Inheritance = t.classify(ts)
+		trio[k] = ts
+	}
+	return trio, nil
+}
+
+// classify returns the inheritance of the change of s.
+func (t TrioOptions) classify(s TrioSegment) Inheritance {
+	sign := math.Copysign(1, s.Mean)
+	if math.Abs(s.Mean) < t.Threshold {
+		return NotAltered
+	}
+	maternal, paternal := sign*s.Mother >= t.Threshold, sign*s.Father >= t.Threshold
+	switch {
+	case maternal && paternal:
+		return Biparental
+	case maternal:
+		return Maternal
+	case paternal:
+		return Paternal
+	case s.MotherP < t.Alpha && s.FatherP < t.Alpha:
+		return DeNovo
+	}
+	return Ambiguous
+}
+
+// welch returns the two-sided p-value of Welch's t-test of the difference
+// between the means of x and y, or NaN if either has fewer than two values.
+func welch(x, y []float64) float64 {
+	if len(x) < 2 || len(y) < 2 {
+		return math.NaN()
+	}
+	mx, vx := stat.MeanVariance(x, nil)
+	my, vy := stat.MeanVariance(y, nil)
+	sx, sy := vx/float64(len(x)), vy/float64(len(y))
+	if sx+sy == 0 {
+		if mx == my {
+			return 1
+		}
+		return 0
+	}
+	tstat := (mx - my) / math.Sqrt(sx+sy)
+	df := (sx + sy) * (sx + sy) / (sx*sx/float64(len(x)-1) + sy*sy/float64(len(y)-1))
+	return 2 * distuv.StudentsT{Mu: 0, Sigma: 1, Nu: df}.CDF(-math.Abs(tstat))
+}
@@ -0,0 +1,84 @@
+package cbsgo_test
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestSegmentTrio(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	track := func(events map[int]float64) *cbsgo.Track {
+		x := make([]float64, 700)
+		for i := range x {
+			x[i] = 0.1 * rng.NormFloat64()
+			for start, level := range events {
+				if i >= start && i < start+60 {
+					x[i] += level
+				}
+			}
+		}
+		return &cbsgo.Track{Chrom: "chr1", Values: x}
+	}
+	for _, tt := range []struct {
+		name                  string
+		child, mother, father map[int]float64
+		expected              map[int]cbsgo.Inheritance
+	}{
+		{
+			name:     "maternal and de novo",
+			child:    map[int]float64{100: 0.6, 300: -0.8},
+			mother:   map[int]float64{100: 0.6},
+			expected: map[int]cbsgo.Inheritance{100: cbsgo.Maternal, 300: cbsgo.DeNovo},
+		},
+		{
+			name:     "biparental",
+			child:    map[int]float64{300: 0.6},
+			mother:   map[int]float64{300: 0.6},
+			father:   map[int]float64{300: 0.6},
+			expected: map[int]cbsgo.Inheritance{300: cbsgo.Biparental},
+		},
+	} {
+		child, mother, father := track(tt.child), track(tt.mother), track(tt.father)
+		trio, err := cbsgo.SegmentTrio(context.Background(), child, mother, father, cbsgo.DefaultTrioOptions(), cbsgo.WithSeed(1))
+		if err != nil {
+			t.Fatalf("%s: SegmentTrio returned an unexpected error: %v", tt.name, err)
+		}
+		// Segments are matched to the events starting within 10 bins.
+		got := make(map[int]cbsgo.Inheritance)
+		for _, s := range trio {
+			if s.Inheritance == cbsgo.NotAltered {
+				continue
+			}
+			start := s.BinStart
+			for event := range tt.expected {
+				if abs(start-event) <= 10 {
+					start = event
+				}
+			}
+			got[start] = s.Inheritance
+			if s.Inheritance == cbsgo.DeNovo && (s.MotherP > 1e-6 || s.FatherP > 1e-6 || s.Mother < -0.1 || s.Father < -0.1) {
+				t.Errorf("%s: unexpected statistics of the de novo loss %+v", tt.name, s)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: unexpected result.\nExpected: %v\nGot: %v", tt.name, tt.expected, got)
+		}
+	}
+
+	x := &cbsgo.Track{Values: make([]float64, 10)}
+	if _, err := cbsgo.SegmentTrio(context.Background(), x, x, &cbsgo.Track{Values: []float64{1}}, cbsgo.DefaultTrioOptions()); err == nil {
+		t.Errorf("Expected an error for tracks of different lengths")
+	}
+}
+
+func TestInheritanceString(t *testing.T) {
+	for i, expected := range []string{"not-altered", "maternal", "paternal", "biparental", "de-novo", "ambiguous", "unknown"} {
+		if got := cbsgo.Inheritance(i).String(); got != expected {
+			t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, got)
+		}
+	}
+}
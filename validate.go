@@ -0,0 +1,33 @@
+package cbsgo
+
+import "fmt"
+
+// ValidateSegmentation checks that segments form a segmentation of n bins,
+// as returned by Run: every segment covers at least one bin, the segments
+// are ordered, and they cover the bins [0, n) without gaps or overlaps.
+func ValidateSegmentation(segments []Segment, n int) error {
+	if n == 0 && len(segments) == 0 {
+		return nil
+	}
+	if len(segments) == 0 {
+		return fmt.Errorf("cbsgo: no segments for %d bins", n)
+	}
+	next := 0
+	for i, s := range segments {
+		switch {
+		case s.BinStart != next:
+			return fmt.Errorf("cbsgo: segment %d starts at bin %d, expected %d", i, s.BinStart, next)
+		case s.BinEnd <= s.BinStart:
+			return fmt.Errorf("cbsgo: segment %d has empty bins [%d, %d)", i, s.BinStart, s.BinEnd)
+		case s.End < s.Start:
+			return fmt.Errorf("cbsgo: segment %d has invalid extent [%d, %d)", i, s.Start, s.End)
+		case i > 0 && s.Start < segments[i-1].End:
+			return fmt.Errorf("cbsgo: segment %d at %d overlaps the previous segment", i, s.Start)
+		}
+		next = s.BinEnd
+	}
+	if next != n {
+		return fmt.Errorf("cbsgo: segments end at bin %d, expected %d", next, n)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package cbsgo_test
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestValidateSegmentation(t *testing.T) {
+	valid := []cbsgo.Segment{{Start: 0, End: 3, BinStart: 0, BinEnd: 3}, {Start: 3, End: 5, BinStart: 3, BinEnd: 5}}
+	if err := cbsgo.ValidateSegmentation(valid, 5); err != nil {
+		t.Errorf("ValidateSegmentation returned an unexpected error: %v", err)
+	}
+	if err := cbsgo.ValidateSegmentation(nil, 0); err != nil {
+		t.Errorf("ValidateSegmentation returned an unexpected error: %v", err)
+	}
+	for name, tt := range map[string]struct {
+		segments []cbsgo.Segment
+		n        int
+	}{
+		"short":    {valid, 6},
+		"none":     {nil, 5},
+		"gap":      {[]cbsgo.Segment{valid[0], {Start: 4, End: 5, BinStart: 4, BinEnd: 5}}, 5},
+		"empty":    {[]cbsgo.Segment{valid[0], {Start: 3, End: 3, BinStart: 3, BinEnd: 3}, valid[1]}, 5},
+		"overlap":  {[]cbsgo.Segment{valid[0], {Start: 2, End: 5, BinStart: 3, BinEnd: 5}}, 5},
+		"reversed": {[]cbsgo.Segment{valid[1], valid[0]}, 5},
+	} {
+		if err := cbsgo.ValidateSegmentation(tt.segments, tt.n); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// floats decodes data into float64 values, 8 bytes each.
+func floats(data []byte) []float64 {
+	x := make([]float64, len(data)/8)
+	for i := range x {
+		x[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:]))
+	}
+	return x
+}
+
+// fuzzSeeds adds seed inputs shared by the fuzz targets.
+func fuzzSeeds(f *testing.F) {
+	var steps []byte
+	for _, v := range []float64{1, 1, 1, 3, 3, 2, 1, 2, 3, 300, 310, 321, 310, 299, 1, 2, 1, 2, 1, 1} {
+		steps = binary.LittleEndian.AppendUint64(steps, math.Float64bits(v))
+	}
+	f.Add(steps, uint8(50), uint8(0))
+	f.Add([]byte{}, uint8(10), uint8(1))
+	f.Add(steps[:8], uint8(10), uint8(2))
+}
+
+func FuzzRun(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte, shuffles, mode uint8) {
+		x := floats(data)
+		opts := []cbsgo.Option{cbsgo.WithSeed(1), cbsgo.WithShuffles(int(shuffles)%64 + 1)}
+		switch mode % 4 {
+		case 1:
+			opts = append(opts, cbsgo.WithDirection(cbsgo.GainOnly), cbsgo.WithTieBreak(cbsgo.Midpoint))
+		case 2:
+			opts = append(opts, cbsgo.WithBreakpoints(0.95), cbsgo.WithFit(), cbsgo.WithCalls(cbsgo.DefaultCallThresholds()))
+		case 3:
+			opts = append(opts, cbsgo.WithHaarScreen(3), cbsgo.WithLengthPrior(10), cbsgo.WithAlphaAdjustment(cbsgo.DepthAdjustment))
+		}
+		res, err := cbsgo.Run(x, opts...)
+		if err != nil {
+			return
+		}
+		if err := cbsgo.ValidateSegmentation(res.Segments, len(x)); err != nil {
+			t.Errorf("Invalid segmentation of %v: %v", x, err)
+		}
+	})
+}
+
+func FuzzCBS(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte, shuffles, _ uint8) {
+		x := floats(data)
+		intervals, err := cbsgo.CBS(x, int(shuffles)%64+1, 0.05, 1)
+		if err != nil {
+			return
+		}
+		segments := make([]cbsgo.Segment, len(intervals))
+		for i, iv := range intervals {
+			segments[i] = cbsgo.Segment{Start: iv[0], End: iv[1], BinStart: iv[0], BinEnd: iv[1]}
+		}
+		if err := cbsgo.ValidateSegmentation(segments, len(x)); err != nil {
+			t.Errorf("Invalid segmentation of %v: %v", x, err)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package cbsgo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// VCFWriter writes gains and losses as symbolic structural variant records
+// in VCF 4.2 format. Segments whose mean lies within the threshold of zero
+// are considered copy neutral and skipped.
+type VCFWriter struct {
+	w         *bufio.Writer
+	threshold float64
+	header    bool
+	manifest  *Manifest
+}
+
+// NewVCFWriter returns a VCFWriter writing to w, calling a gain or a loss for
+// segments whose mean, a log2 ratio, exceeds threshold in absolute value.
+func NewVCFWriter(w io.Writer, threshold float64) *VCFWriter {
+	return &VCFWriter{w: bufio.NewWriter(w), threshold: threshold, header: true}
+}
+
+// SetManifest writes m in the header, as a cbsgoManifest line of space
+// separated name=value pairs. Call it before the first Write.
+func (v *VCFWriter) SetManifest(m Manifest) error {
+	v.manifest = &m
+	return nil
+}
+
+// SetCoordinates checks the coordinates of the records written, which are
+// the one-based positions required by VCF.
+func (v *VCFWriter) SetCoordinates(c Coordinates) error {
+	if c.Bins {
+		return errors.New("cbsgo: VCF records need positions, not bin indices")
+	}
+	return c.check("VCF", OneBased)
+}
+
+func (v *VCFWriter) Write(s Segment) error {
+	if err := v.writeHeader(); err != nil {
+		return err
+	}
+	var alt string
+	switch {
+	case s.Mean > v.threshold:
+		alt = "DUP"
+	case s.Mean < -v.threshold:
+		alt = "DEL"
+	default:
+		return nil
+	}
+	// The record starts at the base preceding the event, in one-based
+	// coordinates, unless the event starts at the first base.
+	pos := max(s.Start, 1)
+	svlen := s.Len()
+	if alt == "DEL" {
+		svlen = -svlen
+	}
+	_, err := fmt.Fprintf(v.w, "%s\t%d\t.\tN\t<%s>\t.\tPASS\tSVTYPE=%s;END=%d;SVLEN=%d;LOG2R=%.4g;BINS=%d\n",
+		s.Chrom, pos, alt, alt, s.End, svlen, s.Mean, s.BinEnd-s.BinStart)
+	return err
+}
+
+func (v *VCFWriter) Close() error {
+	if err := v.writeHeader(); err != nil {
+		return err
+	}
+	return v.w.Flush()
+}
+
+// writeHeader writes the header lines if they are still due.
+func (v *VCFWriter) writeHeader() error {
+	if !v.header {
+		return nil
+	}
+	v.header = false
+	if _, err := fmt.Fprintf(v.w, "##fileformat=VCFv4.2\n##source=cbsgo\n"); err != nil {
+		return err
+	}
+	if v.manifest != nil {
+		if _, err := fmt.Fprintf(v.w, "##cbsgoManifest=%s\n", *v.manifest); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(v.w, `##ALT=<ID=DEL,Description="Deletion">
+##ALT=<ID=DUP,Description="Duplication">
+##INFO=<ID=SVTYPE,Number=1,Type=String,Description="Type of structural variant">
+##INFO=<ID=END,Number=1,Type=Integer,Description="End position of the variant">
+##INFO=<ID=SVLEN,Number=1,Type=Integer,Description="Difference in length between REF and ALT alleles">
+##INFO=<ID=LOG2R,Number=1,Type=Float,Description="Mean log2 ratio of the segment">
+##INFO=<ID=BINS,Number=1,Type=Integer,Description="Number of bins in the segment">
+##FILTER=<ID=PASS,Description="All filters passed">
+#CHROM	POS	ID	REF	ALT	QUAL	FILTER	INFO
+`)
+	return err
+}
@@ -0,0 +1,44 @@
+package cbsgo_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestVCFWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := cbsgo.NewVCFWriter(&buf, 0.2)
+	for _, s := range []cbsgo.Segment{
+		{Chrom: "chr1", Start: 0, End: 1000, BinStart: 0, BinEnd: 10, Mean: 0.58},
+		{Chrom: "chr1", Start: 1000, End: 3000, BinStart: 10, BinEnd: 30, Mean: 0.1},
+		{Chrom: "chr1", Start: 3000, End: 3500, BinStart: 30, BinEnd: 35, Mean: -1},
+	} {
+		if err := w.Write(s); err != nil {
+			t.Fatalf("Write returned an unexpected error: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned an unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "##fileformat=VCFv4.2\n") {
+		t.Errorf("Missing VCF header in %q", out)
+	}
+	var records []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			records = append(records, line)
+		}
+	}
+	expected := []string{
+		"chr1\t1\t.\tN\t<DUP>\t.\tPASS\tSVTYPE=DUP;END=1000;SVLEN=1000;LOG2R=0.58;BINS=10",
+		"chr1\t3000\t.\tN\t<DEL>\t.\tPASS\tSVTYPE=DEL;END=3500;SVLEN=-500;LOG2R=-1;BINS=5",
+	}
+	if strings.Join(records, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, records)
+	}
+}
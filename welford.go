@@ -0,0 +1,158 @@
+package cbsgo
+
+import "math"
+
+// RunningStats accumulates the weighted mean and variance of values added
+// one at a time, with the numerically stable updates of Welford's algorithm,
+// so that the statistics of a segment can be kept up to date as its values
+// arrive. The zero value holds no values.
+//
+// Weights are frequency weights, as in gonum's stat.MeanVariance, which
+// computes the same statistics at once: a value of weight 2 counts as two
+// values.
+type RunningStats struct {
+	n    int
+	w    float64
+	mean float64
+	m2   float64
+}
+
+// Add adds the value x of weight 1.
+func (s *RunningStats) Add(x float64) {
+	s.AddWeighted(x, 1)
+}
+
+// AddWeighted adds the value x of weight w, which must be positive.
+func (s *RunningStats) AddWeighted(x, w float64) {
+	if s.n == 0 {
+		// Set the first value rather than update the mean of 0, whose
+		// rounding would be amplified by large values.
+		*s = RunningStats{n: 1, w: w, mean: x}
+		return
+	}
+	s.n++
+	s.w += w
+	d := x - s.mean
+	s.mean += d * w / s.w
+	s.m2 += w * d * (x - s.mean)
+}
+
+// Merge adds the values accumulated by o, as if they had been added to s,
+// with the pairwise update of Chan et al., so that the statistics of
+// adjacent segments or of parallel workers can be combined.
+func (s *RunningStats) Merge(o RunningStats) {
+	if o.n == 0 {
+		return
+	}
+	if s.n == 0 {
+		*s = o
+		return
+	}
+	w := s.w + o.w
+	d := o.mean - s.mean
+	s.mean += d * o.w / w
+	s.m2 += o.m2 + d*d*s.w*o.w/w
+	s.n += o.n
+	s.w = w
+}
+
+// Count returns the number of values added.
+func (s RunningStats) Count() int {
+	return s.n
+}
+
+// Weight returns the total weight of the values added.
+func (s RunningStats) Weight() float64 {
+	return s.w
+}
+
+// Mean returns the weighted mean of the values, NaN if there are none.
+func (s RunningStats) Mean() float64 {
+	if s.n == 0 {
+		return math.NaN()
+	}
+	return s.mean
+}
+
+// Variance returns the unbiased weighted variance of the values, 0 for fewer
+// than two values like the standard deviation of a segment of one bin.
+func (s RunningStats) Variance() float64 {
+	if s.n < 2 || s.w <= 1 {
+		return 0
+	}
+	return max(s.m2/(s.w-1), 0)
+}
+
+// SD returns the standard deviation of the values, the square root of
+// Variance.
+func (s RunningStats) SD() float64 {
+	return math.Sqrt(s.Variance())
+}
+
+// RunningCovariance accumulates the weighted means, variances and covariance
+// of pairs of values added one at a time, like RunningStats. The zero value
+// holds no values.
+type RunningCovariance struct {
+	x, y RunningStats
+	// c is the sum of the weighted products of the deviations from the
+	// means.
+	c float64
+}
+
+// Add adds the pair (x, y) of weight 1.
+func (c *RunningCovariance) Add(x, y float64) {
+	c.AddWeighted(x, y, 1)
+}
+
+// AddWeighted adds the pair (x, y) of weight w, which must be positive.
+func (c *RunningCovariance) AddWeighted(x, y, w float64) {
+	dx := x - c.x.mean
+	c.x.AddWeighted(x, w)
+	c.y.AddWeighted(y, w)
+	c.c += w * dx * (y - c.y.mean)
+}
+
+// Merge adds the pairs accumulated by o, as if they had been added to c.
+func (c *RunningCovariance) Merge(o RunningCovariance) {
+	if o.x.n == 0 {
+		return
+	}
+	if c.x.n == 0 {
+		*c = o
+		return
+	}
+	w := c.x.w + o.x.w
+	dx, dy := o.x.mean-c.x.mean, o.y.mean-c.y.mean
+	c.c += o.c + dx*dy*c.x.w*o.x.w/w
+	c.x.Merge(o.x)
+	c.y.Merge(o.y)
+}
+
+// X returns the statistics of the first values of the pairs.
+func (c RunningCovariance) X() RunningStats {
+	return c.x
+}
+
+// Y returns the statistics of the second values of the pairs.
+func (c RunningCovariance) Y() RunningStats {
+	return c.y
+}
+
+// Covariance returns the unbiased weighted covariance of the pairs, 0 for
+// fewer than two pairs.
+func (c RunningCovariance) Covariance() float64 {
+	if c.x.n < 2 || c.x.w <= 1 {
+		return 0
+	}
+	return c.c / (c.x.w - 1)
+}
+
+// Correlation returns the Pearson correlation of the pairs, NaN if either
+// value is constant.
+func (c RunningCovariance) Correlation() float64 {
+	sx, sy := c.x.SD(), c.y.SD()
+	if sx == 0 || sy == 0 {
+		return math.NaN()
+	}
+	return c.Covariance() / (sx * sy)
+}
@@ -0,0 +1,77 @@
+package cbsgo_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+	"gonum.org/v1/gonum/stat"
+)
+
+func TestRunningStats(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	x, y, w := make([]float64, 1000), make([]float64, 1000), make([]float64, 1000)
+	for i := range x {
+		// Values far from 0 challenge the numerical stability, to the precision
+		// of float64 at 1e9.
+		x[i] = 1e9 + rng.NormFloat64()
+		y[i] = 2*x[i] + rng.NormFloat64()
+		w[i] = 0.5 + rng.Float64()
+	}
+
+	var s, a, b cbsgo.RunningStats
+	var c, ca, cb cbsgo.RunningCovariance
+	for i := range x {
+		s.AddWeighted(x[i], w[i])
+		c.AddWeighted(x[i], y[i], w[i])
+		if i < 300 {
+			a.AddWeighted(x[i], w[i])
+			ca.AddWeighted(x[i], y[i], w[i])
+		} else {
+			b.AddWeighted(x[i], w[i])
+			cb.AddWeighted(x[i], y[i], w[i])
+		}
+	}
+	a.Merge(b)
+	ca.Merge(cb)
+
+	mean, variance := stat.MeanVariance(x, w)
+	cov, corr := stat.Covariance(x, y, w), stat.Correlation(x, y, w)
+	near := func(a, b float64) bool { return math.Abs(a-b) <= 1e-6*math.Max(1, math.Abs(b)) }
+	for name, s := range map[string]cbsgo.RunningStats{"added": s, "merged": a, "x": c.X(), "merged x": ca.X()} {
+		if s.Count() != len(x) || !near(s.Mean(), mean) || !near(s.Variance(), variance) || !near(s.SD(), math.Sqrt(variance)) {
+			t.Errorf("%s: unexpected mean %v and variance %v, expected %v and %v", name, s.Mean(), s.Variance(), mean, variance)
+		}
+	}
+	for name, c := range map[string]cbsgo.RunningCovariance{"added": c, "merged": ca} {
+		if !near(c.Covariance(), cov) || !near(c.Correlation(), corr) || !near(c.Y().Mean(), stat.Mean(y, w)) {
+			t.Errorf("%s: unexpected covariance %v and correlation %v, expected %v and %v", name, c.Covariance(), c.Correlation(), cov, corr)
+		}
+	}
+}
+
+func TestRunningStatsFew(t *testing.T) {
+	var s cbsgo.RunningStats
+	if !math.IsNaN(s.Mean()) || s.Variance() != 0 || s.Weight() != 0 {
+		t.Errorf("Unexpected statistics of no values: %v, %v", s.Mean(), s.Variance())
+	}
+	s.Merge(cbsgo.RunningStats{})
+	s.Add(3)
+	if s.Mean() != 3 || s.SD() != 0 || s.Count() != 1 {
+		t.Errorf("Unexpected statistics of one value: %v, %v", s.Mean(), s.SD())
+	}
+	var o cbsgo.RunningStats
+	o.Merge(s)
+	o.Add(5)
+	if o.Mean() != 4 || o.Variance() != 2 || o.Weight() != 2 {
+		t.Errorf("Unexpected statistics of two values: %v, %v", o.Mean(), o.Variance())
+	}
+
+	var c cbsgo.RunningCovariance
+	c.Add(1, 2)
+	c.Add(1, 3)
+	if c.Covariance() != 0 || !math.IsNaN(c.Correlation()) {
+		t.Errorf("Unexpected covariance %v and correlation %v of a constant", c.Covariance(), c.Correlation())
+	}
+}
@@ -0,0 +1,91 @@
+package cbsgo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ReadWIG reads a fixedStep or variableStep WIG file into one track per
+// chromosome, in order of first appearance. The one-based WIG positions are
+// converted to the zero-based, half-open coordinates used throughout cbsgo.
+func ReadWIG(r io.Reader) ([]*Track, error) {
+	var tb trackBuilder
+	var (
+		chrom           string
+		fixed           bool
+		pos, step, span int
+		declared        bool
+	)
+	dr, err := Decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	defer dr.Close()
+	sc := bufio.NewScanner(dr)
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		fields := strings.Fields(text)
+		if len(fields) > 0 && (fields[0] == "fixedStep" || fields[0] == "variableStep") {
+			fixed = fields[0] == "fixedStep"
+			chrom, pos, step, span = "", 0, 1, 1
+			for _, f := range fields[1:] {
+				key, value, _ := strings.Cut(f, "=")
+				var err error
+				switch key {
+				case "chrom":
+					chrom = value
+				case "start":
+					pos, err = strconv.Atoi(value)
+				case "step":
+					step, err = strconv.Atoi(value)
+				case "span":
+					span, err = strconv.Atoi(value)
+				}
+				if err != nil {
+					return nil, fmt.Errorf("cbsgo: WIG line %d: %w", line, err)
+				}
+			}
+			if chrom == "" || (fixed && pos == 0) {
+				return nil, fmt.Errorf("cbsgo: WIG line %d: incomplete declaration", line)
+			}
+			declared = true
+			continue
+		}
+		if skipHeader(text) {
+			continue
+		}
+		if !declared {
+			return nil, fmt.Errorf("cbsgo: WIG line %d: data before a step declaration", line)
+		}
+
+		start := pos
+		var value float64
+		var err error
+		if fixed {
+			if len(fields) != 1 {
+				return nil, fmt.Errorf("cbsgo: WIG line %d: expected 1 field, got %d", line, len(fields))
+			}
+			value, err = strconv.ParseFloat(fields[0], 64)
+			pos += step
+		} else {
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("cbsgo: WIG line %d: expected 2 fields, got %d", line, len(fields))
+			}
+			var err2 error
+			start, err = strconv.Atoi(fields[0])
+			value, err2 = strconv.ParseFloat(fields[1], 64)
+			err = firstError(err, err2)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cbsgo: WIG line %d: %w", line, err)
+		}
+		tb.add(chrom, start-1, start-1+span, value)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return tb.tracks, nil
+}
@@ -0,0 +1,43 @@
+package cbsgo_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestReadWIG(t *testing.T) {
+	input := `track type=wiggle_0
+fixedStep chrom=chr1 start=1 step=100 span=50
+0.5
+1.5
+variableStep chrom=chr2 span=10
+11 2
+31 -2
+fixedStep chrom=chr1 start=301 step=100
+3
+`
+	tracks, err := cbsgo.ReadWIG(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadWIG returned an unexpected error: %v", err)
+	}
+	expected := []*cbsgo.Track{
+		{Chrom: "chr1", Starts: []int{0, 100, 300}, Ends: []int{50, 150, 301}, Values: []float64{0.5, 1.5, 3}},
+		{Chrom: "chr2", Starts: []int{10, 30}, Ends: []int{20, 40}, Values: []float64{2, -2}},
+	}
+	if !reflect.DeepEqual(tracks, expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, tracks)
+	}
+
+	for name, input := range map[string]string{
+		"no declaration": "1.5\n",
+		"no start":       "fixedStep chrom=chr1 step=10\n1\n",
+		"bad value":      "variableStep chrom=chr1\n10 x\n",
+	} {
+		if _, err := cbsgo.ReadWIG(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
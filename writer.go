@@ -0,0 +1,126 @@
+package cbsgo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// SegmentWriter writes segments one at a time, such as to a file in one of
+// the formats supported by cbsgo. See WithWriter.
+type SegmentWriter interface {
+	Write(Segment) error
+	// Close flushes any buffered output. It does not close the underlying
+	// writer.
+	Close() error
+}
+
+// BEDWriter writes segments in BED format, with the name column holding the
+// copy number state of the segment if called (see WithCalls), and otherwise
+// its mean rounded to four significant digits, as a label for genome browsers.
+// Use BedGraphWriter for the exact means.
+type BEDWriter struct {
+	w      *bufio.Writer
+	coords Coordinates
+}
+
+// SetManifest writes m as a comment line before the segments. Call it before
+// the first Write.
+func (b *BEDWriter) SetManifest(m Manifest) error {
+	_, err := fmt.Fprintf(b.w, "# cbsgo %s\n", m)
+	return err
+}
+
+// SetCoordinates sets the coordinates of the segments written, which are
+// zero-based as required by BED. Call it before the first Write.
+func (b *BEDWriter) SetCoordinates(c Coordinates) error {
+	if err := c.check("BED", ZeroBased); err != nil {
+		return err
+	}
+	b.coords = c
+	return nil
+}
+
+// NewBEDWriter returns a BEDWriter writing to w.
+func NewBEDWriter(w io.Writer) *BEDWriter {
+	return &BEDWriter{w: bufio.NewWriter(w)}
+}
+
+func (b *BEDWriter) Write(s Segment) error {
+	start, end := b.coords.interval(s.Start, s.End, s.BinStart, s.BinEnd, ZeroBased)
+	if s.State != StateUnknown {
+		_, err := fmt.Fprintf(b.w, "%s\t%d\t%d\t%s\n", s.Chrom, start, end, s.State)
+		return err
+	}
+	_, err := fmt.Fprintf(b.w, "%s\t%d\t%d\t%.4g\n", s.Chrom, start, end, s.Mean)
+	return err
+}
+
+func (b *BEDWriter) Close() error {
+	return b.w.Flush()
+}
+
+// SEGWriter writes segments in the SEG format of DNAcopy and IGV, with one-based,
+// closed coordinates.
+type SEGWriter struct {
+	w        *bufio.Writer
+	sample   string
+	header   bool
+	manifest *Manifest
+	coords   Coordinates
+}
+
+// NewSEGWriter returns a SEGWriter writing the segments of sample to w.
+// Several SEGWriters for different samples may share w through a single
+// header by setting header to false for all but the first.
+func NewSEGWriter(w io.Writer, sample string, header bool) *SEGWriter {
+	return &SEGWriter{w: bufio.NewWriter(w), sample: sample, header: header}
+}
+
+// SetManifest writes m as a comment line before the header line, if the
+// writer writes it. Call it before the first Write.
+func (sw *SEGWriter) SetManifest(m Manifest) error {
+	sw.manifest = &m
+	return nil
+}
+
+// SetCoordinates sets the coordinates of the segments written, which are
+// one-based as required by SEG. Call it before the first Write.
+func (sw *SEGWriter) SetCoordinates(c Coordinates) error {
+	if err := c.check("SEG", OneBased); err != nil {
+		return err
+	}
+	sw.coords = c
+	return nil
+}
+
+func (sw *SEGWriter) Write(s Segment) error {
+	if err := sw.writeHeader(); err != nil {
+		return err
+	}
+	start, end := sw.coords.interval(s.Start, s.End, s.BinStart, s.BinEnd, OneBased)
+	_, err := fmt.Fprintf(sw.w, "%s\t%s\t%d\t%d\t%d\t%g\n", sw.sample, s.Chrom, start, end, s.BinEnd-s.BinStart, s.Mean)
+	return err
+}
+
+func (sw *SEGWriter) Close() error {
+	if err := sw.writeHeader(); err != nil {
+		return err
+	}
+	return sw.w.Flush()
+}
+
+// writeHeader writes the header line if it is still due.
+func (sw *SEGWriter) writeHeader() error {
+	if !sw.header {
+		return nil
+	}
+	sw.header = false
+	if sw.manifest != nil {
+		if _, err := fmt.Fprintf(sw.w, "# cbsgo %s\n", *sw.manifest); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(sw.w, "ID\tchrom\tloc.start\tloc.end\tnum.mark\tseg.mean")
+	return err
+}
@@ -0,0 +1,115 @@
+package cbsgo_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+// collector is a SegmentWriter keeping the segments in memory.
+type collector struct {
+	segments []cbsgo.Segment
+	closed   bool
+}
+
+func (c *collector) Write(s cbsgo.Segment) error {
+	c.segments = append(c.segments, s)
+	return nil
+}
+
+func (c *collector) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestWithWriter(t *testing.T) {
+	x := []float64{1, 1, 1, 3, 3, 2, 1, 2, 3, 300, 310, 321, 310, 299, 1, 2, 1, 2, 1, 1}
+	for name, opts := range map[string][]cbsgo.Option{
+		"single":    {cbsgo.WithSeed(42)},
+		"consensus": {cbsgo.WithSeed(42), cbsgo.WithConsensus(5)},
+	} {
+		expected, err := cbsgo.Run(x, opts...)
+		if err != nil {
+			t.Fatalf("%s: Run returned an unexpected error: %v", name, err)
+		}
+
+		var c collector
+		res, err := cbsgo.Run(x, append(opts, cbsgo.WithWriter(&c))...)
+		if err != nil {
+			t.Fatalf("%s: Run returned an unexpected error: %v", name, err)
+		}
+		if len(res.Segments) != 0 || c.closed {
+			t.Errorf("%s: expected the segments to be written only, got %v", name, res.Segments)
+		}
+		if !reflect.DeepEqual(c.segments, expected.Segments) {
+			t.Errorf("%s: unexpected result.\nExpected: %v\nGot: %v", name, expected.Segments, c.segments)
+		}
+	}
+}
+
+func TestTrackWithWriter(t *testing.T) {
+	track := &cbsgo.Track{
+		Chrom:  "chr3",
+		Values: []float64{1, 1, 1, 3, 99, 3, 2, 1, 2, 3, 300, 310, 321, 310, 299},
+		Mask:   []bool{4: true, 14: false},
+	}
+	expected, err := track.Segment(cbsgo.WithSeed(42))
+	if err != nil {
+		t.Fatalf("Segment returned an unexpected error: %v", err)
+	}
+	var c collector
+	if _, err := track.Segment(cbsgo.WithSeed(42), cbsgo.WithWriter(&c)); err != nil {
+		t.Fatalf("Segment returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(c.segments, expected.Segments) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected.Segments, c.segments)
+	}
+}
+
+var writerSegments = []cbsgo.Segment{
+	{Chrom: "chr1", Start: 0, End: 1000, BinStart: 0, BinEnd: 10, Mean: 0.0123456},
+	{Chrom: "chr1", Start: 1000, End: 3000, BinStart: 10, BinEnd: 30, Mean: -1.5},
+}
+
+func TestBEDWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := cbsgo.NewBEDWriter(&buf)
+	for _, s := range writerSegments {
+		if err := w.Write(s); err != nil {
+			t.Fatalf("Write returned an unexpected error: %v", err)
+		}
+	}
+	called := writerSegments[1]
+	called.State = cbsgo.Loss
+	if err := w.Write(called); err != nil {
+		t.Fatalf("Write returned an unexpected error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned an unexpected error: %v", err)
+	}
+	expected := "chr1\t0\t1000\t0.01235\nchr1\t1000\t3000\t-1.5\nchr1\t1000\t3000\tloss\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected result.\nExpected: %q\nGot: %q", expected, buf.String())
+	}
+}
+
+func TestSEGWriter(t *testing.T) {
+	var buf bytes.Buffer
+	for i, sample := range []string{"a", "b"} {
+		w := cbsgo.NewSEGWriter(&buf, sample, i == 0)
+		if err := w.Write(writerSegments[i]); err != nil {
+			t.Fatalf("Write returned an unexpected error: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close returned an unexpected error: %v", err)
+		}
+	}
+	expected := "ID\tchrom\tloc.start\tloc.end\tnum.mark\tseg.mean\n" +
+		"a\tchr1\t1\t1000\t10\t0.0123456\n" +
+		"b\tchr1\t1001\t3000\t20\t-1.5\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected result.\nExpected: %q\nGot: %q", expected, buf.String())
+	}
+}
@@ -0,0 +1,42 @@
+package cbsgo
+
+import (
+	"fmt"
+	"math"
+)
+
+// ZScores returns the z-score of every bin of x relative to its segment,
+// (x - Mean) / SD, to find bins deviating from the segment they belong to,
+// such as focal amplifications too short to be segmented or residual
+// artifacts. The z-score of bins not covered by any segment, or in segments
+// with a zero standard deviation, is NaN.
+func ZScores(segments []Segment, x []float64) ([]float64, error) {
+	z := make([]float64, len(x))
+	for i := range z {
+		z[i] = math.NaN()
+	}
+	for i, s := range segments {
+		if s.BinStart < 0 || s.BinEnd > len(x) || s.BinStart > s.BinEnd {
+			return nil, fmt.Errorf("cbsgo: segment %d spans bins [%d, %d) outside of %d values", i, s.BinStart, s.BinEnd, len(x))
+		}
+		if s.SD == 0 || math.IsNaN(s.SD) {
+			continue
+		}
+		for j := s.BinStart; j < s.BinEnd; j++ {
+			z[j] = (x[j] - s.Mean) / s.SD
+		}
+	}
+	return z, nil
+}
+
+// Outliers returns, in order, the indices of the bins whose z-score exceeds
+// threshold in absolute value.
+func Outliers(z []float64, threshold float64) []int {
+	var res []int
+	for i, v := range z {
+		if math.Abs(v) > threshold {
+			res = append(res, i)
+		}
+	}
+	return res
+}
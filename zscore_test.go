@@ -0,0 +1,39 @@
+package cbsgo_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/mattdsm/cbsgo"
+)
+
+func TestZScores(t *testing.T) {
+	x := []float64{1, 3, 1, 3, 12, 5, 5, 5}
+	segments := []cbsgo.Segment{
+		{BinStart: 0, BinEnd: 5, Mean: 2, SD: 2},
+		{BinStart: 5, BinEnd: 7, Mean: 5, SD: 0},
+	}
+
+	z, err := cbsgo.ZScores(segments, x)
+	if err != nil {
+		t.Fatalf("ZScores returned an unexpected error: %v", err)
+	}
+	expected := []float64{-0.5, 0.5, -0.5, 0.5, 5}
+	if !reflect.DeepEqual(z[:5], expected) {
+		t.Errorf("Unexpected result.\nExpected: %v\nGot: %v", expected, z[:5])
+	}
+	for _, i := range []int{5, 6, 7} {
+		if !math.IsNaN(z[i]) {
+			t.Errorf("Expected a NaN z-score for bin %d, got %v", i, z[i])
+		}
+	}
+
+	if got := cbsgo.Outliers(z, 3); !reflect.DeepEqual(got, []int{4}) {
+		t.Errorf("Unexpected outliers %v", got)
+	}
+
+	if _, err := cbsgo.ZScores([]cbsgo.Segment{{BinStart: 0, BinEnd: 9}}, x); err == nil {
+		t.Errorf("Expected an error for a segment beyond the values")
+	}
+}